@@ -62,8 +62,8 @@ type ExampleOptions struct {
 	ImageContentSources              []hyperv1.ImageContentSource
 	InfraID                          string
 	MachineCIDR                      string
-	ServiceCIDR                      string
-	ClusterCIDR                      string
+	ServiceCIDR                      []string
+	ClusterCIDR                      []string
 	NodeSelector                     map[string]string
 	BaseDomain                       string
 	PublicZoneID                     string
@@ -408,11 +408,11 @@ func (o ExampleOptions) Resources() *ExampleResources {
 		},
 	}
 
-	if o.ClusterCIDR != "" {
-		cluster.Spec.Networking.ClusterNetwork = []hyperv1.ClusterNetworkEntry{{CIDR: *ipnet.MustParseCIDR(o.ClusterCIDR)}}
+	for _, cidr := range o.ClusterCIDR {
+		cluster.Spec.Networking.ClusterNetwork = append(cluster.Spec.Networking.ClusterNetwork, hyperv1.ClusterNetworkEntry{CIDR: *ipnet.MustParseCIDR(cidr)})
 	}
-	if o.ServiceCIDR != "" {
-		cluster.Spec.Networking.ServiceNetwork = []hyperv1.ServiceNetworkEntry{{CIDR: *ipnet.MustParseCIDR(o.ServiceCIDR)}}
+	for _, cidr := range o.ServiceCIDR {
+		cluster.Spec.Networking.ServiceNetwork = append(cluster.Spec.Networking.ServiceNetwork, hyperv1.ServiceNetworkEntry{CIDR: *ipnet.MustParseCIDR(cidr)})
 	}
 	if o.MachineCIDR != "" {
 		cluster.Spec.Networking.MachineNetwork = []hyperv1.MachineNetworkEntry{{CIDR: *ipnet.MustParseCIDR(o.MachineCIDR)}}
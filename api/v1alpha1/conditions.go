@@ -20,6 +20,8 @@ const (
 	KubeAPIServerAvailable ConditionType = "KubeAPIServerAvailable"
 	// EtcdAvailable bubbles up the same condition from HCP.
 	EtcdAvailable ConditionType = "EtcdAvailable"
+	// KonnectivityAvailable bubbles up the same condition from HCP.
+	KonnectivityAvailable ConditionType = "KonnectivityAvailable"
 	// ValidHostedControlPlaneConfiguration bubbles up the same condition from HCP.
 	ValidHostedControlPlaneConfiguration ConditionType = "ValidHostedControlPlaneConfiguration"
 
@@ -67,9 +69,34 @@ const (
 	// ValidReleaseImage indicates if the release image set in the spec is valid
 	// for the HostedCluster. For example, this can be set false if the
 	// HostedCluster itself attempts an unsupported version before 4.9 or an
-	// unsupported upgrade e.g y-stream upgrade before 4.11.
+	// unsupported upgrade e.g y-stream upgrade before 4.11, or if the operator
+	// is configured to verify release signatures and the release image is not
+	// signed by a trusted key.
 	ValidReleaseImage ConditionType = "ValidReleaseImage"
 
+	// ValidIdentityProviderConditionPrefix is the prefix of the conditions that
+	// report the validation result of each OAuth identity provider configured in
+	// spec.configuration.oauth. The condition type is the prefix followed by a
+	// dot and the identity provider name. Names that are not valid in condition
+	// types have the characters that are not allowed replaced by underscores and
+	// a hash of the name appended.
+	// A failure here may require external user intervention to resolve.
+	ValidIdentityProviderConditionPrefix = "ValidIdentityProvider"
+
+	// ValidIdentityProviders indicates whether all OAuth identity providers are
+	// configured in the OAuth server. Identity providers with invalid references,
+	// OpenID discovery documents or duplicate names are left out of the OAuth
+	// server configuration until they are fixed, and are listed in its message.
+	// A failure here may require external user intervention to resolve.
+	ValidIdentityProviders ConditionType = "ValidIdentityProviders"
+
+	// ValidControlPlaneComponentOverrides bubbles up the same condition from HCP. It signals if every override in
+	// spec.controlPlaneComponents references a component and containers that exist in the control plane.
+	// Overrides that reference an unknown component or container have no effect. Resource overrides whose
+	// requests exceed the limits of the component are skipped.
+	// A failure here is unlikely to resolve without the changing user input.
+	ValidControlPlaneComponentOverrides ConditionType = "ValidControlPlaneComponentOverrides"
+
 	// PlatformCredentialsFound indicates that credentials required for the
 	// desired platform are valid.
 	PlatformCredentialsFound ConditionType = "PlatformCredentialsFound"
@@ -97,6 +124,18 @@ const (
 	EtcdWaitingForQuorumReason    = "EtcdWaitingForQuorum"
 	EtcdStatefulSetNotFoundReason = "StatefulSetNotFound"
 
+	KonnectivityProbeFailedReason = "KonnectivityProbeFailed"
+	KonnectivityNoNodesReason     = "NoNodes"
+
+	IdentityProviderReferenceInvalidReason = "InvalidReference"
+	IdentityProviderDiscoveryFailedReason  = "DiscoveryFailed"
+	IdentityProviderUnreachableReason      = "Unreachable"
+	IdentityProviderBindFailedReason       = "BindFailed"
+	IdentityProvidersOmittedReason         = "IdentityProvidersOmitted"
+
+	UnknownControlPlaneComponentReason          = "UnknownComponent"
+	InvalidControlPlaneComponentResourcesReason = "InvalidComponentResources"
+
 	UnmanagedEtcdMisconfiguredReason = "UnmanagedEtcdMisconfigured"
 	UnmanagedEtcdAsExpected          = "UnmanagedEtcdAsExpected"
 
@@ -109,6 +148,8 @@ const (
 	OIDCConfigurationInvalidReason        = "OIDCConfigurationInvalid"
 	PlatformCredentialsNotFoundReason     = "PlatformCredentialsNotFound"
 	InvalidImageReason                    = "InvalidImage"
+	UnverifiedReleaseImageReason          = "UnverifiedReleaseImage"
+	ReleaseVerificationUnavailableReason  = "ReleaseVerificationUnavailable"
 )
 
 // Messages.
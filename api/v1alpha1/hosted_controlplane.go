@@ -186,6 +186,37 @@ type HostedControlPlaneSpec struct {
 	//
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// ControlPlaneEgress configures how outbound connections of control plane
+	// components that tunnel through the konnectivity socks5 proxy are routed.
+	// By default, connections to cloud provider APIs are made directly from the
+	// management cluster and everything else is tunneled into the guest cluster
+	// network through konnectivity.
+	//
+	// +optional
+	ControlPlaneEgress *ControlPlaneEgressSpec `json:"controlPlaneEgress,omitempty"`
+
+	// Authentication configures how users authenticate to the hosted cluster
+	// API server. Certificate authority references of OIDC providers point to
+	// ConfigMaps in the control plane namespace.
+	//
+	// +optional
+	Authentication *ClusterAuthenticationSpec `json:"authentication,omitempty"`
+
+	// ControlPlaneComponents overrides the configuration of individual control
+	// plane components.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ControlPlaneComponents []ControlPlaneComponentOverride `json:"controlPlaneComponents,omitempty"`
+
+	// ControlPlaneSize is the size tier of the control plane. It determines the
+	// resources and replicas of the API servers and etcd. When empty, the
+	// default resources are used.
+	//
+	// +optional
+	ControlPlaneSize ControlPlaneSizeTier `json:"controlPlaneSize,omitempty"`
 }
 
 // AvailabilityPolicy specifies a high level availability policy for components.
@@ -283,6 +314,12 @@ type HostedControlPlaneStatus struct {
 	// Current condition types are: "Available"
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// GuestLoad is the load of the guest cluster as observed from the control
+	// plane.
+	//
+	// +optional
+	GuestLoad *GuestClusterLoad `json:"guestLoad,omitempty"`
 }
 
 type APIEndpoint struct {
@@ -513,6 +513,28 @@ func (in *AzurePlatformSpec) DeepCopy() *AzurePlatformSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAuthenticationSpec) DeepCopyInto(out *ClusterAuthenticationSpec) {
+	*out = *in
+	if in.OIDCProviders != nil {
+		in, out := &in.OIDCProviders, &out.OIDCProviders
+		*out = make([]OIDCProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAuthenticationSpec.
+func (in *ClusterAuthenticationSpec) DeepCopy() *ClusterAuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterAutoscaling) DeepCopyInto(out *ClusterAutoscaling) {
 	*out = *in
@@ -700,6 +722,101 @@ func (in *ClusterVersionStatus) DeepCopy() *ClusterVersionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneComponentOverride) DeepCopyInto(out *ControlPlaneComponentOverride) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ControlPlaneContainerOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneComponentOverride.
+func (in *ControlPlaneComponentOverride) DeepCopy() *ControlPlaneComponentOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneComponentOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneContainerOverride) DeepCopyInto(out *ControlPlaneContainerOverride) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneContainerOverride.
+func (in *ControlPlaneContainerOverride) DeepCopy() *ControlPlaneContainerOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneContainerOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneEgressSpec) DeepCopyInto(out *ControlPlaneEgressSpec) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]EgressRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneEgressSpec.
+func (in *ControlPlaneEgressSpec) DeepCopy() *ControlPlaneEgressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneEgressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneSizeStatus) DeepCopyInto(out *ControlPlaneSizeStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneSizeStatus.
+func (in *ControlPlaneSizeStatus) DeepCopy() *ControlPlaneSizeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneSizeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSSpec) DeepCopyInto(out *DNSSpec) {
 	*out = *in
@@ -715,6 +832,31 @@ func (in *DNSSpec) DeepCopy() *DNSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressRoute) DeepCopyInto(out *EgressRoute) {
+	*out = *in
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressRoute.
+func (in *EgressRoute) DeepCopy() *EgressRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdSpec) DeepCopyInto(out *EtcdSpec) {
 	*out = *in
@@ -776,6 +918,21 @@ func (in *Filter) DeepCopy() *Filter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestClusterLoad) DeepCopyInto(out *GuestClusterLoad) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestClusterLoad.
+func (in *GuestClusterLoad) DeepCopy() *GuestClusterLoad {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestClusterLoad)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostedCluster) DeepCopyInto(out *HostedCluster) {
 	*out = *in
@@ -897,6 +1054,23 @@ func (in *HostedClusterSpec) DeepCopyInto(out *HostedClusterSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ControlPlaneEgress != nil {
+		in, out := &in.ControlPlaneEgress, &out.ControlPlaneEgress
+		*out = new(ControlPlaneEgressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(ClusterAuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControlPlaneComponents != nil {
+		in, out := &in.ControlPlaneComponents, &out.ControlPlaneComponents
+		*out = make([]ControlPlaneComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterSpec.
@@ -935,6 +1109,11 @@ func (in *HostedClusterStatus) DeepCopyInto(out *HostedClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ControlPlaneSize != nil {
+		in, out := &in.ControlPlaneSize, &out.ControlPlaneSize
+		*out = new(ControlPlaneSizeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedClusterStatus.
@@ -1087,6 +1266,23 @@ func (in *HostedControlPlaneSpec) DeepCopyInto(out *HostedControlPlaneSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ControlPlaneEgress != nil {
+		in, out := &in.ControlPlaneEgress, &out.ControlPlaneEgress
+		*out = new(ControlPlaneEgressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = new(ClusterAuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControlPlaneComponents != nil {
+		in, out := &in.ControlPlaneComponents, &out.ControlPlaneComponents
+		*out = make([]ControlPlaneComponentOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedControlPlaneSpec.
@@ -1129,6 +1325,11 @@ func (in *HostedControlPlaneStatus) DeepCopyInto(out *HostedControlPlaneStatus)
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.GuestLoad != nil {
+		in, out := &in.GuestLoad, &out.GuestLoad
+		*out = new(GuestClusterLoad)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostedControlPlaneStatus.
@@ -1823,6 +2024,105 @@ func (in *NodePortPublishingStrategy) DeepCopy() *NodePortPublishingStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCClaimMapping) DeepCopyInto(out *OIDCClaimMapping) {
+	*out = *in
+	if in.Prefix != nil {
+		in, out := &in.Prefix, &out.Prefix
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCClaimMapping.
+func (in *OIDCClaimMapping) DeepCopy() *OIDCClaimMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCClaimMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCClaimMappings) DeepCopyInto(out *OIDCClaimMappings) {
+	*out = *in
+	in.Username.DeepCopyInto(&out.Username)
+	in.Groups.DeepCopyInto(&out.Groups)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCClaimMappings.
+func (in *OIDCClaimMappings) DeepCopy() *OIDCClaimMappings {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCClaimMappings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCIssuer) DeepCopyInto(out *OIDCIssuer) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CertificateAuthority != nil {
+		in, out := &in.CertificateAuthority, &out.CertificateAuthority
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCIssuer.
+func (in *OIDCIssuer) DeepCopy() *OIDCIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCProvider) DeepCopyInto(out *OIDCProvider) {
+	*out = *in
+	in.Issuer.DeepCopyInto(&out.Issuer)
+	in.ClaimMappings.DeepCopyInto(&out.ClaimMappings)
+	if in.RequiredClaims != nil {
+		in, out := &in.RequiredClaims, &out.RequiredClaims
+		*out = make([]OIDCRequiredClaim, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCProvider.
+func (in *OIDCProvider) DeepCopy() *OIDCProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRequiredClaim) DeepCopyInto(out *OIDCRequiredClaim) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRequiredClaim.
+func (in *OIDCRequiredClaim) DeepCopy() *OIDCRequiredClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRequiredClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PersistentVolumeEtcdStorageSpec) DeepCopyInto(out *PersistentVolumeEtcdStorageSpec) {
 	*out = *in
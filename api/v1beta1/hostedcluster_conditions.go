@@ -30,6 +30,11 @@ const (
 	// EtcdAvailable bubbles up the same condition from HCP. It signals if etcd is available.
 	// A failure here often means a software bug or a non-stable cluster.
 	EtcdAvailable ConditionType = "EtcdAvailable"
+	// KonnectivityAvailable bubbles up the same condition from HCP. It signals if connections from the control plane
+	// to the guest cluster can be established through the konnectivity tunnel. When this is false, webhooks, logs,
+	// exec and port-forward requests do not work.
+	// A failure here often means the konnectivity agents on the nodes are not able to connect to the konnectivity server.
+	KonnectivityAvailable ConditionType = "KonnectivityAvailable"
 	// ValidHostedControlPlaneConfiguration bubbles up the same condition from HCP. It signals if the hostedControlPlane input is valid and
 	// supported by the underlying management cluster.
 	// A failure here is unlikely to resolve without the changing user input.
@@ -88,7 +93,9 @@ const (
 	// ValidReleaseImage indicates if the release image set in the spec is valid
 	// for the HostedCluster. For example, this can be set false if the
 	// HostedCluster itself attempts an unsupported version before 4.9 or an
-	// unsupported upgrade e.g y-stream upgrade before 4.11.
+	// unsupported upgrade e.g y-stream upgrade before 4.11, or if the operator
+	// is configured to verify release signatures and the release image is not
+	// signed by a trusted key.
 	// A failure here is unlikely to resolve without the changing user input.
 	ValidReleaseImage ConditionType = "ValidReleaseImage"
 
@@ -113,6 +120,29 @@ const (
 	// A failure here may require external user intervention to resolve.
 	ValidAWSIdentityProvider ConditionType = "ValidAWSIdentityProvider"
 
+	// ValidIdentityProviderConditionPrefix is the prefix of the conditions that
+	// report the validation result of each OAuth identity provider configured in
+	// spec.configuration.oauth. The condition type is the prefix followed by a
+	// dot and the identity provider name. Names that are not valid in condition
+	// types have the characters that are not allowed replaced by underscores and
+	// a hash of the name appended.
+	// A failure here may require external user intervention to resolve.
+	ValidIdentityProviderConditionPrefix = "ValidIdentityProvider"
+
+	// ValidIdentityProviders indicates whether all OAuth identity providers are
+	// configured in the OAuth server. Identity providers with invalid references,
+	// OpenID discovery documents or duplicate names are left out of the OAuth
+	// server configuration until they are fixed, and are listed in its message.
+	// A failure here may require external user intervention to resolve.
+	ValidIdentityProviders ConditionType = "ValidIdentityProviders"
+
+	// ValidControlPlaneComponentOverrides bubbles up the same condition from HCP. It signals if every override in
+	// spec.controlPlaneComponents references a component and containers that exist in the control plane.
+	// Overrides that reference an unknown component or container have no effect. Resource overrides whose
+	// requests exceed the limits of the component are skipped.
+	// A failure here is unlikely to resolve without the changing user input.
+	ValidControlPlaneComponentOverrides ConditionType = "ValidControlPlaneComponentOverrides"
+
 	// PlatformCredentialsFound indicates that credentials required for the
 	// desired platform are valid.
 	// A failure here is unlikely to resolve without the changing user input.
@@ -142,6 +172,18 @@ const (
 	EtcdWaitingForQuorumReason    = "EtcdWaitingForQuorum"
 	EtcdStatefulSetNotFoundReason = "StatefulSetNotFound"
 
+	KonnectivityProbeFailedReason = "KonnectivityProbeFailed"
+	KonnectivityNoNodesReason     = "NoNodes"
+
+	IdentityProviderReferenceInvalidReason = "InvalidReference"
+	IdentityProviderDiscoveryFailedReason  = "DiscoveryFailed"
+	IdentityProviderUnreachableReason      = "Unreachable"
+	IdentityProviderBindFailedReason       = "BindFailed"
+	IdentityProvidersOmittedReason         = "IdentityProvidersOmitted"
+
+	UnknownControlPlaneComponentReason          = "UnknownComponent"
+	InvalidControlPlaneComponentResourcesReason = "InvalidComponentResources"
+
 	UnmanagedEtcdMisconfiguredReason = "UnmanagedEtcdMisconfigured"
 	UnmanagedEtcdAsExpected          = "UnmanagedEtcdAsExpected"
 
@@ -154,6 +196,8 @@ const (
 	OIDCConfigurationInvalidReason        = "OIDCConfigurationInvalid"
 	PlatformCredentialsNotFoundReason     = "PlatformCredentialsNotFound"
 	InvalidImageReason                    = "InvalidImage"
+	UnverifiedReleaseImageReason          = "UnverifiedReleaseImage"
+	ReleaseVerificationUnavailableReason  = "ReleaseVerificationUnavailable"
 	InvalidIdentityProvider               = "InvalidIdentityProvider"
 
 	ReconciliationPausedConditionReason             = "ReconciliationPaused"
@@ -118,11 +118,24 @@ const (
 	// of the cluster will be removed, including image registry storage, ingress dns records, load balancers, and persistent storage.
 	CleanupCloudResourcesAnnotation = "hypershift.openshift.io/cleanup-cloud-resources"
 
+	// IgnitionServerAttestationAnnotation enables the verification of the instance identity of machines by the
+	// ignition server, when set to "true". It is supported on the AWS and Azure platforms. Ignition can't send the
+	// identity of instances, so their boot image must run the ignition server attestation proxy, which NodePool
+	// user data then points Ignition to. On AWS, the certificate instance identity documents of the region are
+	// signed with must be in the ignition-server-attestation-ca ConfigMap of the control plane namespace. On Azure,
+	// the ConfigMap may contain the roots of the attested data certificates, which default to the system roots.
+	IgnitionServerAttestationAnnotation = "hypershift.openshift.io/ignition-server-attestation"
+
+	// IgnitionServerSingleUseTokensAnnotation makes the ignition server serve a payload only to the first instance of
+	// every machine, when set to "true". It requires IgnitionServerAttestationAnnotation.
+	IgnitionServerSingleUseTokensAnnotation = "hypershift.openshift.io/ignition-server-single-use-tokens"
+
 	// ResourceRequestOverrideAnnotationPrefix is a prefix for an annotation to override resource requests for a particular deployment/container
 	// in a hosted control plane. The format of the annotation is:
 	// resource-request-override.hypershift.openshift.io/[deployment-name].[container-name]: [resource-type-1]=[value1],[resource-type-2]=[value2],...
 	// For example, to override the memory and cpu request for the Kubernetes APIServer:
 	// resource-request-override.hypershift.openshift.io/kube-apiserver.kube-apiserver: memory=3Gi,cpu=2000m
+	// Overrides in spec.controlPlaneComponents take precedence over these annotations.
 	ResourceRequestOverrideAnnotationPrefix = "resource-request-override.hypershift.openshift.io"
 
 	// LimitedSupportLabel is a label that can be used by consumers to indicate
@@ -321,6 +334,319 @@ type HostedClusterSpec struct {
 	//
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// ControlPlaneEgress configures how outbound connections of control plane
+	// components that tunnel through the konnectivity socks5 proxy are routed.
+	// By default, connections to cloud provider APIs are made directly from the
+	// management cluster and everything else is tunneled into the guest cluster
+	// network through konnectivity.
+	//
+	// +optional
+	ControlPlaneEgress *ControlPlaneEgressSpec `json:"controlPlaneEgress,omitempty"`
+
+	// Authentication configures how users authenticate to the hosted cluster
+	// API server. By default, the OpenShift OAuth server and OAuth API server are
+	// deployed and users log in through the identity providers configured in
+	// spec.configuration.oauth. This field is immutable.
+	//
+	// +optional
+	// +immutable
+	Authentication *ClusterAuthenticationSpec `json:"authentication,omitempty"`
+
+	// ControlPlaneComponents overrides the configuration of individual control
+	// plane components. Overrides take precedence over the
+	// resource-request-override.hypershift.openshift.io annotations. Overrides
+	// that reference a component or container that does not exist in the control
+	// plane are reported in the ValidControlPlaneComponentOverrides condition.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ControlPlaneComponents []ControlPlaneComponentOverride `json:"controlPlaneComponents,omitempty"`
+}
+
+// ControlPlaneSizeTier is a size tier of a control plane.
+//
+// +kubebuilder:validation:Enum=Small;Medium;Large;XLarge
+type ControlPlaneSizeTier string
+
+const (
+	SmallControlPlaneSize  ControlPlaneSizeTier = "Small"
+	MediumControlPlaneSize ControlPlaneSizeTier = "Medium"
+	LargeControlPlaneSize  ControlPlaneSizeTier = "Large"
+	XLargeControlPlaneSize ControlPlaneSizeTier = "XLarge"
+)
+
+// ControlPlaneSizeStatus is the size tier of a control plane and the load it was
+// derived from.
+type ControlPlaneSizeStatus struct {
+	// Tier is the current size tier of the control plane.
+	Tier ControlPlaneSizeTier `json:"tier"`
+
+	// Nodes is the number of nodes of the guest cluster when the load was last
+	// observed.
+	Nodes int32 `json:"nodes"`
+
+	// APIRequestsPerSecond is the rate of requests served by the kube API
+	// servers when the load was last observed.
+	APIRequestsPerSecond int32 `json:"apiRequestsPerSecond"`
+
+	// LastTransitionTime is the time the tier last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// GuestClusterLoad is the load of a guest cluster.
+type GuestClusterLoad struct {
+	// Nodes is the number of nodes of the guest cluster.
+	Nodes int32 `json:"nodes"`
+
+	// APIRequestsPerSecond is the rate of requests served by all the kube API
+	// servers of the control plane, averaged over the last minute.
+	APIRequestsPerSecond int32 `json:"apiRequestsPerSecond"`
+}
+
+// ControlPlaneComponentOverride overrides the configuration of a control plane
+// component.
+type ControlPlaneComponentOverride struct {
+	// Name is the name of the Deployment or StatefulSet of the component in the
+	// control plane namespace, such as kube-apiserver or etcd.
+	//
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Replicas is the number of replicas of the component. It overrides the
+	// number derived from the controllerAvailabilityPolicy. It is not
+	// supported for etcd.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// PriorityClassName is the priority class of the pods of the component.
+	//
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Tolerations are added to the default tolerations of the pods of the
+	// component.
+	//
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Containers overrides the configuration of individual containers of the
+	// component.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Containers []ControlPlaneContainerOverride `json:"containers,omitempty"`
+}
+
+// ControlPlaneContainerOverride overrides the configuration of a container of a
+// control plane component.
+type ControlPlaneContainerOverride struct {
+	// Name is the name of the container or init container.
+	Name string `json:"name"`
+
+	// Image overrides the image of the container.
+	//
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Resources overrides the requests and limits of the container. Resources
+	// that are not listed keep their default value.
+	//
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Env sets environment variables of the container. Variables with the same
+	// name as a default variable replace it.
+	//
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// ControlPlaneEgressSpec specifies routing rules for outbound connections of
+// control plane components.
+type ControlPlaneEgressSpec struct {
+	// Routes is an ordered list of routing rules. The first route that matches a
+	// destination determines how the connection is established. Destinations
+	// that do not match any route use the default routing.
+	//
+	// +optional
+	Routes []EgressRoute `json:"routes,omitempty"`
+}
+
+// EgressRoute maps a set of destinations to the path used to reach them.
+type EgressRoute struct {
+	// Name identifies the route in logs and metrics.
+	//
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// CIDRs is a list of destination IP ranges in CIDR notation matched by this route.
+	//
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+
+	// Domains is a list of destination host names matched by this route. A
+	// domain starting with "*." matches any subdomain of the remaining name,
+	// any other value must match the host name exactly.
+	//
+	// +optional
+	Domains []string `json:"domains,omitempty"`
+
+	// Target specifies how connections matching this route are established.
+	Target EgressRouteTarget `json:"target"`
+
+	// ProxyURL is the URL of the HTTP proxy that connections are sent through
+	// using CONNECT. It is required when Target is Proxy.
+	//
+	// +optional
+	ProxyURL string `json:"proxyURL,omitempty"`
+}
+
+// EgressRouteTarget specifies how a connection matching an EgressRoute is established.
+//
+// +kubebuilder:validation:Enum=Direct;Konnectivity;Proxy
+type EgressRouteTarget string
+
+const (
+	// DirectEgressRouteTarget connects from the management cluster network,
+	// honoring the proxy configuration of the management cluster.
+	DirectEgressRouteTarget EgressRouteTarget = "Direct"
+
+	// KonnectivityEgressRouteTarget tunnels the connection through konnectivity
+	// into the guest cluster network.
+	KonnectivityEgressRouteTarget EgressRouteTarget = "Konnectivity"
+
+	// ProxyEgressRouteTarget connects through the HTTP proxy given in ProxyURL.
+	ProxyEgressRouteTarget EgressRouteTarget = "Proxy"
+)
+
+// ClusterAuthenticationSpec specifies how users authenticate to the hosted cluster.
+type ClusterAuthenticationSpec struct {
+	// Type is the authentication mode of the hosted cluster.
+	//
+	// +kubebuilder:default=IntegratedOAuth
+	// +unionDiscriminator
+	Type ClusterAuthenticationType `json:"type"`
+
+	// OIDCProviders are the external OIDC issuers whose tokens are trusted by
+	// the API server. It is required when Type is OIDC. The API server
+	// currently supports a single issuer.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=1
+	OIDCProviders []OIDCProvider `json:"oidcProviders,omitempty"`
+}
+
+// ClusterAuthenticationType is the authentication mode of a hosted cluster.
+//
+// +kubebuilder:validation:Enum=IntegratedOAuth;OIDC
+type ClusterAuthenticationType string
+
+const (
+	// IntegratedOAuthAuthenticationType deploys the OpenShift OAuth server and
+	// OAuth API server, which issue and validate tokens for the cluster.
+	IntegratedOAuthAuthenticationType ClusterAuthenticationType = "IntegratedOAuth"
+
+	// OIDCAuthenticationType configures the API server to validate tokens of
+	// external OIDC issuers directly. The OpenShift OAuth server and OAuth API
+	// server are not deployed.
+	OIDCAuthenticationType ClusterAuthenticationType = "OIDC"
+)
+
+// OIDCProvider configures an external OIDC issuer trusted by the API server.
+type OIDCProvider struct {
+	// Name identifies the provider.
+	//
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Issuer describes the issuer of the tokens.
+	Issuer OIDCIssuer `json:"issuer"`
+
+	// ClaimMappings describes how token claims map to the user name and groups.
+	//
+	// +optional
+	ClaimMappings OIDCClaimMappings `json:"claimMappings,omitempty"`
+
+	// RequiredClaims are claims that must be present in a token, with the
+	// given value, for the token to be accepted.
+	//
+	// +optional
+	RequiredClaims []OIDCRequiredClaim `json:"requiredClaims,omitempty"`
+
+	// CLIClientID is the OAuth client ID used by command line clients to
+	// obtain tokens from the issuer. It is used to generate exec plugin based
+	// kubeconfigs and defaults to the first audience of the issuer.
+	//
+	// +optional
+	CLIClientID string `json:"cliClientID,omitempty"`
+}
+
+// OIDCIssuer describes the issuer of OIDC tokens.
+type OIDCIssuer struct {
+	// URL is the issuer URL. It must use the https scheme and match the "iss"
+	// claim of the tokens.
+	//
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
+
+	// Audiences are the accepted values of the "aud" claim of the tokens. The
+	// API server currently accepts a single audience.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1
+	Audiences []string `json:"audiences"`
+
+	// CertificateAuthority references a ConfigMap holding the PEM encoded CA
+	// bundle used to verify the issuer under the "ca-bundle.crt" key. When
+	// unset, the system trust store is used.
+	//
+	// +optional
+	CertificateAuthority *corev1.LocalObjectReference `json:"certificateAuthority,omitempty"`
+}
+
+// OIDCClaimMappings describes how token claims map to user attributes.
+type OIDCClaimMappings struct {
+	// Username is the claim used as the user name. The claim defaults to
+	// "sub". When the prefix is unset, user names of claims other than
+	// "email" are prefixed with the issuer URL followed by "#". An empty
+	// prefix disables prefixing.
+	//
+	// +optional
+	Username OIDCClaimMapping `json:"username,omitempty"`
+
+	// Groups is the claim used as the list of groups of the user. When
+	// unset, groups are not mapped.
+	//
+	// +optional
+	Groups OIDCClaimMapping `json:"groups,omitempty"`
+}
+
+// OIDCClaimMapping maps a token claim to a user attribute.
+type OIDCClaimMapping struct {
+	// Claim is the name of the token claim.
+	//
+	// +optional
+	Claim string `json:"claim,omitempty"`
+
+	// Prefix is prepended to the claim value.
+	//
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+}
+
+// OIDCRequiredClaim is a claim that must be present in a token with a given value.
+type OIDCRequiredClaim struct {
+	// Claim is the name of the required claim.
+	Claim string `json:"claim"`
+
+	// RequiredValue is the value the claim must have.
+	RequiredValue string `json:"requiredValue"`
 }
 
 // OLMCatalogPlacement is an enum specifying the placement of OLM catalog components.
@@ -486,7 +812,8 @@ type ClusterNetworking struct {
 	ClusterNetwork []ClusterNetworkEntry `json:"clusterNetwork"`
 
 	// ServiceNetwork is the list of IP address pools for services.
-	// NOTE: currently only one entry is supported.
+	// At most one IPv4 and one IPv6 entry are supported. When both are set the
+	// cluster is dual-stack and the first entry determines the primary IP family.
 	//
 	// +optional
 	ServiceNetwork []ServiceNetworkEntry `json:"serviceNetwork"`
@@ -537,7 +864,9 @@ type CIDRBlock string
 type APIServerNetworking struct {
 	// AdvertiseAddress is the address that nodes will use to talk to the API
 	// server. This is an address associated with the loopback adapter of each
-	// node. If not specified, 172.20.0.1 is used.
+	// node. It must belong to the primary IP family of the service network. If
+	// not specified, 172.20.0.1 is used for IPv4 primary clusters and fd00::1
+	// for IPv6 primary clusters.
 	AdvertiseAddress *string `json:"advertiseAddress,omitempty"`
 
 	// Port is the port at which the APIServer is exposed inside a node. Other
@@ -1711,6 +2040,13 @@ type HostedClusterStatus struct {
 	// plane's current state.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ControlPlaneSize is the size tier of the control plane, derived from the
+	// load of the guest cluster when control plane sizing is enabled in the
+	// HyperShift operator.
+	//
+	// +optional
+	ControlPlaneSize *ControlPlaneSizeStatus `json:"controlPlaneSize,omitempty"`
 }
 
 // ClusterVersionStatus reports the status of the cluster versioning,
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfigFlagName is the flag pointing to a cluster config file.
+	ConfigFlagName = "config"
+	// DumpConfigFlagName is the flag pointing to where the effective config is written.
+	DumpConfigFlagName = "dump-config"
+
+	// configPlatformKey records the platform subcommand a config file was written for.
+	configPlatformKey = "platform"
+)
+
+// configIgnoredFlags are flags that control the command itself rather than describing the cluster.
+var configIgnoredFlags = map[string]bool{
+	ConfigFlagName:     true,
+	DumpConfigFlagName: true,
+	"help":             true,
+}
+
+// ApplyConfigFile sets the flags of a create cluster command from a YAML or JSON config file.
+// Keys of the file are flag names; flags given on the command line take precedence over the
+// file. Relative paths for flags marked as filenames are resolved against the directory of
+// the config file.
+func ApplyConfigFile(cmd *cobra.Command, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	baseDir := filepath.Dir(path)
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := config[key]
+		if key == configPlatformKey {
+			if platform := fmt.Sprint(value); platform != cmd.Name() {
+				return fmt.Errorf("config file %s is for platform %s, not %s", path, platform, cmd.Name())
+			}
+			continue
+		}
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil || configIgnoredFlags[key] {
+			return fmt.Errorf("unknown key %q in config file %s", key, path)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := setFlagFromConfig(flag, value, baseDir); err != nil {
+			return fmt.Errorf("invalid value for %q in config file %s: %w", key, path, err)
+		}
+		flag.Changed = true
+	}
+	return nil
+}
+
+func setFlagFromConfig(flag *pflag.Flag, value interface{}, baseDir string) error {
+	switch v := value.(type) {
+	case []interface{}:
+		sliceValue, ok := flag.Value.(pflag.SliceValue)
+		if !ok {
+			return fmt.Errorf("expected a %s, got a list", flag.Value.Type())
+		}
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, scalarString(item))
+		}
+		return sliceValue.Replace(items)
+	case map[string]interface{}:
+		if flag.Value.Type() != "stringToString" {
+			return fmt.Errorf("expected a %s, got a map", flag.Value.Type())
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(v))
+		for _, key := range keys {
+			pairs = append(pairs, key+"="+scalarString(v[key]))
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write(pairs); err != nil {
+			return err
+		}
+		w.Flush()
+		return flag.Value.Set(strings.TrimSpace(buf.String()))
+	case nil:
+		return nil
+	default:
+		s := scalarString(v)
+		if _, isFilename := flag.Annotations[cobra.BashCompFilenameExt]; isFilename && s != "" && !filepath.IsAbs(s) {
+			s = filepath.Join(baseDir, s)
+		}
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			return sliceValue.Replace([]string{s})
+		}
+		return flag.Value.Set(s)
+	}
+}
+
+// scalarString formats a YAML scalar the way it would be passed on the command line.
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		// YAML numbers are decoded as float64
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// WriteConfig writes the effective values of all flags of a create cluster command in the
+// format read by ApplyConfigFile.
+func WriteConfig(cmd *cobra.Command, out io.Writer) error {
+	config := map[string]interface{}{
+		configPlatformKey: cmd.Name(),
+	}
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if configIgnoredFlags[flag.Name] || flag.Hidden || err != nil {
+			return
+		}
+		switch flag.Value.Type() {
+		case "bool":
+			config[flag.Name] = flag.Value.String() == "true"
+		case "int", "int32", "int64", "uint", "uint32", "uint64":
+			var n int64
+			n, err = strconv.ParseInt(flag.Value.String(), 10, 64)
+			config[flag.Name] = n
+		case "stringToString":
+			m := map[string]string{}
+			s := strings.TrimSuffix(strings.TrimPrefix(flag.Value.String(), "["), "]")
+			if s != "" {
+				var records []string
+				records, err = csv.NewReader(strings.NewReader(s)).Read()
+				for _, record := range records {
+					kv := strings.SplitN(record, "=", 2)
+					if len(kv) == 2 {
+						m[kv[0]] = kv[1]
+					}
+				}
+			}
+			config[flag.Name] = m
+		default:
+			if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+				items := sliceValue.GetSlice()
+				if items == nil {
+					items = []string{}
+				}
+				config[flag.Name] = items
+				return
+			}
+			config[flag.Name] = flag.Value.String()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize config: %w", err)
+	}
+	_, err = out.Write(raw)
+	return err
+}
+
+// BindConfigFlags adds --config and --dump-config to a create cluster command and its
+// platform subcommands. The config file is applied before the flags are validated, and
+// --dump-config writes the effective config instead of creating the cluster.
+func BindConfigFlags(cmd *cobra.Command) {
+	var configFile, dumpConfig string
+	cmd.PersistentFlags().StringVar(&configFile, ConfigFlagName, configFile, "Path to a YAML or JSON file with flag values for the cluster, keyed by flag name. Flags given on the command line take precedence")
+	cmd.PersistentFlags().StringVar(&dumpConfig, DumpConfigFlagName, dumpConfig, "Write the effective config to this path ('-' for stdout) instead of creating the cluster")
+	cmd.MarkPersistentFlagFilename(ConfigFlagName, "yaml", "yml", "json")
+
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if configFile == "" {
+			return nil
+		}
+		return ApplyConfigFile(cmd, configFile)
+	}
+
+	for _, platformCmd := range cmd.Commands() {
+		run := platformCmd.RunE
+		if run == nil && platformCmd.Run != nil {
+			runNoErr := platformCmd.Run
+			run = func(cmd *cobra.Command, args []string) error {
+				runNoErr(cmd, args)
+				return nil
+			}
+		}
+		if run == nil {
+			continue
+		}
+		platformCmd.RunE = func(cmd *cobra.Command, args []string) error {
+			if dumpConfig == "" {
+				return run(cmd, args)
+			}
+			if dumpConfig == "-" {
+				return WriteConfig(cmd, cmd.OutOrStdout())
+			}
+			var buf bytes.Buffer
+			if err := WriteConfig(cmd, &buf); err != nil {
+				return err
+			}
+			return os.WriteFile(dumpConfig, buf.Bytes(), 0644)
+		}
+	}
+}
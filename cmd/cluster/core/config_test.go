@@ -0,0 +1,174 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type testConfigOptions struct {
+	Name             string
+	NodePoolReplicas int32
+	Annotations      []string
+	Zones            []string
+	NodeSelector     map[string]string
+	NodeDrainTimeout time.Duration
+	FIPS             bool
+	PullSecretFile   string
+	Ran              bool
+}
+
+func newTestConfigCommand(opts *testConfigOptions) *cobra.Command {
+	cmd := &cobra.Command{Use: "cluster"}
+	cmd.PersistentFlags().StringVar(&opts.Name, "name", "example", "")
+	cmd.PersistentFlags().Int32Var(&opts.NodePoolReplicas, "node-pool-replicas", 0, "")
+	cmd.PersistentFlags().StringArrayVar(&opts.Annotations, "annotations", nil, "")
+	cmd.PersistentFlags().StringToStringVar(&opts.NodeSelector, "node-selector", nil, "")
+	cmd.PersistentFlags().DurationVar(&opts.NodeDrainTimeout, "node-drain-timeout", 0, "")
+	cmd.PersistentFlags().BoolVar(&opts.FIPS, "fips", false, "")
+	cmd.PersistentFlags().StringVar(&opts.PullSecretFile, "pull-secret", "", "")
+	cmd.MarkPersistentFlagFilename("pull-secret")
+
+	platformCmd := &cobra.Command{Use: "aws"}
+	platformCmd.Flags().StringSliceVar(&opts.Zones, "zones", nil, "")
+	platformCmd.MarkPersistentFlagRequired("pull-secret")
+	platformCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		opts.Ran = true
+		return nil
+	}
+	cmd.AddCommand(platformCmd)
+	BindConfigFlags(cmd)
+
+	root := &cobra.Command{Use: "create"}
+	root.AddCommand(cmd)
+	return root
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cluster.yaml")
+	config := `
+platform: aws
+name: from-file
+node-pool-replicas: 3
+annotations:
+- a=b
+- c=d,e
+zones: [us-east-1a, us-east-1b]
+node-selector:
+  role: cp
+node-drain-timeout: 10m
+fips: true
+pull-secret: pull-secret.json
+`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	otherPlatformConfigPath := filepath.Join(dir, "azure.yaml")
+	if err := os.WriteFile(otherPlatformConfigPath, []byte("platform: azure\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expectErr bool
+		expected  testConfigOptions
+	}{
+		{
+			name: "When only a config file is given it should apply all of its values",
+			args: []string{"cluster", "aws", "--config", configPath},
+			expected: testConfigOptions{
+				Name:             "from-file",
+				NodePoolReplicas: 3,
+				Annotations:      []string{"a=b", "c=d,e"},
+				Zones:            []string{"us-east-1a", "us-east-1b"},
+				NodeSelector:     map[string]string{"role": "cp"},
+				NodeDrainTimeout: 10 * time.Minute,
+				FIPS:             true,
+				PullSecretFile:   filepath.Join(dir, "pull-secret.json"),
+				Ran:              true,
+			},
+		},
+		{
+			name: "When flags are given it should override the config file",
+			args: []string{"cluster", "aws", "--config", configPath, "--name", "from-flag", "--zones", "us-west-2a", "--pull-secret", "/abs/ps.json"},
+			expected: testConfigOptions{
+				Name:             "from-flag",
+				NodePoolReplicas: 3,
+				Annotations:      []string{"a=b", "c=d,e"},
+				Zones:            []string{"us-west-2a"},
+				NodeSelector:     map[string]string{"role": "cp"},
+				NodeDrainTimeout: 10 * time.Minute,
+				FIPS:             true,
+				PullSecretFile:   "/abs/ps.json",
+				Ran:              true,
+			},
+		},
+		{
+			name:      "When the config file is for another platform it should fail",
+			args:      []string{"cluster", "aws", "--config", otherPlatformConfigPath},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			opts := &testConfigOptions{}
+			cmd := newTestConfigCommand(opts)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(opts.Ran).To(BeFalse())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(*opts).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	opts := &testConfigOptions{}
+	cmd := newTestConfigCommand(opts)
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"cluster", "aws", "--dump-config", "-", "--pull-secret", "/ps.json", "--node-selector", "role=cp", "--annotations", "a=b", "--node-drain-timeout", "5m"})
+	g.Expect(cmd.Execute()).To(Succeed())
+	g.Expect(opts.Ran).To(BeFalse())
+
+	dumped := map[string]interface{}{}
+	g.Expect(yaml.Unmarshal(out.Bytes(), &dumped)).To(Succeed())
+	g.Expect(dumped).To(Equal(map[string]interface{}{
+		"platform":           "aws",
+		"name":               "example",
+		"node-pool-replicas": float64(0),
+		"annotations":        []interface{}{"a=b"},
+		"zones":              []interface{}{},
+		"node-selector":      map[string]interface{}{"role": "cp"},
+		"node-drain-timeout": "5m0s",
+		"fips":               false,
+		"pull-secret":        "/ps.json",
+	}))
+
+	// The dumped config is accepted as a config file and reproduces the same values
+	path := filepath.Join(t.TempDir(), "dumped.yaml")
+	g.Expect(os.WriteFile(path, out.Bytes(), 0644)).To(Succeed())
+	reloaded := &testConfigOptions{}
+	cmd = newTestConfigCommand(reloaded)
+	cmd.SetArgs([]string{"cluster", "aws", "--config", path})
+	g.Expect(cmd.Execute()).To(Succeed())
+	g.Expect(reloaded.NodeSelector).To(Equal(map[string]string{"role": "cp"}))
+	g.Expect(reloaded.NodeDrainTimeout).To(Equal(5 * time.Minute))
+	g.Expect(reloaded.PullSecretFile).To(Equal("/ps.json"))
+}
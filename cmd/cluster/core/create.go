@@ -25,9 +25,11 @@ import (
 	"github.com/go-logr/logr"
 	apifixtures "github.com/openshift/hypershift/api/fixtures"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	preflightcmd "github.com/openshift/hypershift/cmd/preflight"
 	"github.com/openshift/hypershift/cmd/util"
 	"github.com/openshift/hypershift/cmd/version"
 	hyperapi "github.com/openshift/hypershift/support/api"
+	"github.com/openshift/hypershift/support/preflight"
 	"github.com/openshift/hypershift/support/releaseinfo"
 )
 
@@ -57,8 +59,8 @@ type CreateOptions struct {
 	ReleaseImage                     string
 	Render                           bool
 	SSHKeyFile                       string
-	ServiceCIDR                      string
-	ClusterCIDR                      string
+	ServiceCIDR                      []string
+	ClusterCIDR                      []string
 	ExternalDNSDomain                string
 	NodeSelector                     map[string]string
 	NonePlatform                     NonePlatformCreateOptions
@@ -71,6 +73,7 @@ type CreateOptions struct {
 	Timeout                          time.Duration
 	Log                              logr.Logger
 	SkipAPIBudgetVerification        bool
+	SkipPreflight                    bool
 	CredentialSecretName             string
 
 	// BeforeApply is called immediately before resources are applied to the
@@ -420,10 +423,39 @@ func CreateCluster(ctx context.Context, opts *CreateOptions, platformSpecificApp
 		return nil
 	}
 
+	if !opts.SkipPreflight {
+		if err := runPreflight(ctx, opts, exampleOptions.Resources().Cluster); err != nil {
+			return err
+		}
+	}
+
 	// Otherwise, apply the objects
 	return apply(ctx, opts.Log, exampleOptions, opts.Wait, opts.BeforeApply)
 }
 
+// runPreflight checks that the management cluster can host the control plane of hc. Warnings
+// are logged, failures prevent the cluster from being created.
+func runPreflight(ctx context.Context, opts *CreateOptions, hc *hyperv1.HostedCluster) error {
+	results, err := preflightcmd.Run(ctx, preflight.OptionsForHostedCluster(hc, opts.ExternalDNSDomain))
+	if err != nil {
+		return fmt.Errorf("failed to run preflight checks: %w", err)
+	}
+	var failed []string
+	for _, result := range results {
+		switch result.Status {
+		case preflight.StatusWarn:
+			opts.Log.Info("WARNING: preflight check "+result.Name, "message", result.Message, "remediation", result.Remediation)
+		case preflight.StatusFail:
+			opts.Log.Info("ERROR: preflight check "+result.Name, "message", result.Message, "remediation", result.Remediation)
+			failed = append(failed, result.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("preflight checks failed: %s, use --skip-preflight to create the cluster anyway", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
 func defaultNetworkType(ctx context.Context, opts *CreateOptions, releaseProvider releaseinfo.Provider, readFile func(string) ([]byte, error)) error {
 	if opts.NetworkType != "" {
 		return nil
@@ -1,12 +1,11 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -24,17 +23,25 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubeclient "k8s.io/client-go/kubernetes"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
 	capiaws "sigs.k8s.io/cluster-api-provider-aws/v2/api/v1beta2"
 	capikubevirt "sigs.k8s.io/cluster-api-provider-kubevirt/api/v1alpha1"
 	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	hyperapi "github.com/openshift/hypershift/api"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/consolelogs"
 	"github.com/openshift/hypershift/cmd/log"
 	"github.com/openshift/hypershift/cmd/util"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
@@ -53,6 +60,19 @@ type DumpOptions struct {
 
 	DumpGuestCluster bool
 
+	// DisableRedaction keeps Secret data and token-like strings in the dump.
+	DisableRedaction bool
+	// Since limits pod logs to those newer than this duration when set.
+	Since time.Duration
+	// ArchiveOnly leaves only the compressed archive in the artifact directory.
+	ArchiveOnly bool
+	// ConsoleLogs includes the console logs of the machines of the cluster.
+	ConsoleLogs bool
+	// AWSCredentialsFile and AzureCredentialsFile are used to read the console
+	// logs of machines on AWS and Azure.
+	AWSCredentialsFile   string
+	AzureCredentialsFile string
+
 	Log logr.Logger
 }
 
@@ -76,6 +96,12 @@ func NewDumpCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.ArtifactDir, "artifact-dir", opts.ArtifactDir, "Destination directory for dump files")
 	cmd.Flags().StringVar(&opts.AgentNamespace, "agent-namespace", opts.AgentNamespace, "For agent platform, the namespace where the agents are located")
 	cmd.Flags().BoolVar(&opts.DumpGuestCluster, "dump-guest-cluster", opts.DumpGuestCluster, "If the guest cluster contents should also be dumped")
+	cmd.Flags().BoolVar(&opts.DisableRedaction, "no-redact", opts.DisableRedaction, "Keep Secret data and token-like strings in the dump. Only use this for dumps that are not shared")
+	cmd.Flags().DurationVar(&opts.Since, "since", opts.Since, "Only dump pod logs newer than this duration, e.g. 2h. Defaults to all logs")
+	cmd.Flags().BoolVar(&opts.ConsoleLogs, "console-logs", opts.ConsoleLogs, "Include the console logs of the machines of the cluster. Supported on AWS, Azure, KubeVirt and PowerVS")
+	cmd.Flags().StringVar(&opts.AWSCredentialsFile, "aws-creds", opts.AWSCredentialsFile, "Path to an AWS credentials file, used to read console logs on AWS")
+	cmd.Flags().StringVar(&opts.AzureCredentialsFile, "azure-creds", opts.AzureCredentialsFile, "Path to an Azure credentials file, used to read console logs on Azure")
+	cmd.Flags().BoolVar(&opts.ArchiveOnly, "archive-only", opts.ArchiveOnly, "Only write the compressed archive "+DumpArchiveFile+" to the artifact directory")
 
 	cmd.MarkFlagRequired("artifact-dir")
 
@@ -89,12 +115,8 @@ func NewDumpCommand() *cobra.Command {
 	return cmd
 }
 
-func dumpGuestCluster(ctx context.Context, opts *DumpOptions) error {
+func dumpGuestCluster(ctx context.Context, c client.Client, opts *DumpOptions, w *dumpWriter) error {
 	start := time.Now()
-	c, err := util.GetClient()
-	if err != nil {
-		return err
-	}
 	hcluster := &hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{
 		Namespace: opts.Namespace,
 		Name:      opts.Name,
@@ -113,24 +135,14 @@ func dumpGuestCluster(ctx context.Context, opts *DumpOptions) error {
 	if err := c.Get(ctx, client.ObjectKeyFromObject(kubeconfigSecret), kubeconfigSecret); err != nil {
 		return fmt.Errorf("failed to get guest cluster kubeconfig secret: %w", err)
 	}
-	kubeconfigFile, err := os.CreateTemp(os.TempDir(), "kubeconfig-")
+	// The kubeconfig is only kept in memory so it can not end up in the artifact directory
+	guestConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigSecret.Data["kubeconfig"])
 	if err != nil {
-		return fmt.Errorf("failed to create tempfile for kubeconfig: %w", err)
-	}
-	defer func() {
-		if err := kubeconfigFile.Close(); err != nil {
-			opts.Log.Error(err, "Failed to close kubeconfig file")
-		}
-		if err := os.Remove(kubeconfigFile.Name()); err != nil {
-			opts.Log.Error(err, "Failed to cleanup temporary kubeconfig")
-		}
-	}()
-	if _, err := kubeconfigFile.Write(kubeconfigSecret.Data["kubeconfig"]); err != nil {
-		return fmt.Errorf("failed to write kubeconfig data: %w", err)
+		return fmt.Errorf("failed to load guest cluster kubeconfig: %w", err)
 	}
-	target := opts.ArtifactDir + "/hostedcluster-" + opts.Name
-	opts.Log.Info("Dumping guestcluster", "target", target)
-	if err := DumpGuestCluster(ctx, opts.Log, kubeconfigFile.Name(), target); err != nil {
+	target := "hostedcluster-" + opts.Name
+	opts.Log.Info("Dumping guestcluster", "target", w.path(target))
+	if err := dumpGuestClusterResources(ctx, opts.Log, guestConfig, w.withPrefix(target)); err != nil {
 		return fmt.Errorf("failed to dump guest cluster: %w", err)
 	}
 	opts.Log.Info("Successfully dumped guest cluster", "duration", time.Since(start).String())
@@ -139,10 +151,6 @@ func dumpGuestCluster(ctx context.Context, opts *DumpOptions) error {
 }
 
 func DumpCluster(ctx context.Context, opts *DumpOptions) error {
-	ocCommand, err := exec.LookPath("oc")
-	if err != nil || len(ocCommand) == 0 {
-		return fmt.Errorf("cannot find oc command")
-	}
 	cfg, err := util.GetConfig()
 	if err != nil {
 		return err
@@ -151,34 +159,69 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 	if err != nil {
 		return err
 	}
+	kubeClient, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	collectConsoleLogs := func(ctx context.Context, hc *hyperv1.HostedCluster) (map[string][]byte, error) {
+		return consolelogs.Collect(ctx, hc, consolelogs.CollectOptions{
+			AWSCredentialsFile:   opts.AWSCredentialsFile,
+			AzureCredentialsFile: opts.AzureCredentialsFile,
+		})
+	}
+	return dumpCluster(ctx, opts, c, kubeClient, &spdyPodExecutor{config: cfg, client: kubeClient}, collectConsoleLogs)
+}
+
+// consoleLogCollector returns the console logs of the machines of a HostedCluster by machine name.
+type consoleLogCollector func(ctx context.Context, hc *hyperv1.HostedCluster) (map[string][]byte, error)
+
+func dumpCluster(ctx context.Context, opts *DumpOptions, c client.Client, kubeClient kubeclient.Interface, executor podExecutor, collectConsoleLogs consoleLogCollector) error {
+	if err := os.MkdirAll(opts.ArtifactDir, 0755); err != nil {
+		return fmt.Errorf("failed to create artifact dir: %w", err)
+	}
+	dir := opts.ArtifactDir
+	if opts.ArchiveOnly {
+		tmpDir, err := os.MkdirTemp("", "hypershift-dump-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		dir = tmpDir
+	}
+	manifest := &DumpManifest{
+		Namespace: opts.Namespace,
+		Name:      opts.Name,
+		CreatedAt: time.Now().UTC(),
+		Redacted:  !opts.DisableRedaction,
+	}
+	if opts.Since > 0 {
+		manifest.Since = opts.Since.String()
+	}
+	w := newDumpWriter(dir, manifest)
+	dumper := &resourceDumper{client: c, writer: w, log: opts.Log}
+
 	allNodePools := &hyperv1.NodePoolList{}
-	if err = c.List(ctx, allNodePools, client.InNamespace(opts.Namespace)); err != nil {
+	if err := c.List(ctx, allNodePools, client.InNamespace(opts.Namespace)); err != nil {
 		opts.Log.Error(err, "Cannot list nodepools")
 	}
-	nodePools := []*hyperv1.NodePool{}
+	nodePoolNames := map[string]bool{}
 	for i := range allNodePools.Items {
 		if allNodePools.Items[i].Spec.ClusterName == opts.Name {
-			nodePools = append(nodePools, &allNodePools.Items[i])
+			nodePoolNames[allNodePools.Items[i].Name] = true
 		}
 	}
-	cmd := OCAdmInspect{
-		oc:             ocCommand,
-		artifactDir:    opts.ArtifactDir,
-		agentNamespace: opts.AgentNamespace,
-		log:            opts.Log,
-	}
-	objectNames := make([]string, 0, len(nodePools)+1)
-	objectNames = append(objectNames, typedName(&hyperv1.HostedCluster{}, opts.Name))
-	for _, nodePool := range nodePools {
-		objectNames = append(objectNames, typedName(&hyperv1.NodePool{}, nodePool.Name))
-	}
-	cmd.WithNamespace(opts.Namespace).Run(ctx, objectNames...)
+	dumper.dump(ctx, opts.Namespace, gvksOf(&hyperv1.HostedCluster{}), func(obj *unstructured.Unstructured) bool {
+		return obj.GetName() == opts.Name
+	})
+	dumper.dump(ctx, opts.Namespace, gvksOf(&hyperv1.NodePool{}), func(obj *unstructured.Unstructured) bool {
+		return nodePoolNames[obj.GetName()]
+	})
 
-	cmd.Run(ctx, objectType(&corev1.Node{}))
+	dumper.dump(ctx, "", gvksOf(&corev1.Node{}), nil)
 
 	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(opts.Namespace, opts.Name).Name
 
-	resources := []client.Object{
+	resources := gvksOf(
 		&appsv1.DaemonSet{},
 		&appsv1.Deployment{},
 		&appsv1.ReplicaSet{},
@@ -190,6 +233,7 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 		&corev1.PersistentVolumeClaim{},
 		&corev1.Pod{},
 		&corev1.ReplicationController{},
+		&corev1.Secret{},
 		&corev1.Service{},
 		&capiv1.Cluster{},
 		&capiv1.MachineDeployment{},
@@ -209,21 +253,30 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 		&routev1.Route{},
 		&imagev1.ImageStream{},
 		&networkingv1.NetworkPolicy{},
+	)
+	if opts.DisableRedaction {
+		// Secrets are only dumped when their data is redacted
+		resources = removeGVK(resources, corev1.SchemeGroupVersion.WithKind("Secret"))
 	}
-	resourceList := strings.Join(resourceTypes(resources), ",")
 	if opts.AgentNamespace != "" {
 		// Additional Agent platform resources
-		resourceList += ",clusterdeployment.hive.openshift.io,agentclusterinstall.extensions.hive.openshift.io"
-	}
-	cmd.WithNamespace(controlPlaneNamespace).Run(ctx, resourceList)
-	cmd.WithNamespace(opts.Namespace).Run(ctx, resourceList)
-	cmd.WithNamespace("hypershift").Run(ctx, resourceList)
+		resources = append(resources,
+			schema.GroupVersionKind{Group: "hive.openshift.io", Version: "v1", Kind: "ClusterDeployment"},
+			schema.GroupVersionKind{Group: "extensions.hive.openshift.io", Version: "v1beta1", Kind: "AgentClusterInstall"},
+		)
+	}
+	dumper.dump(ctx, controlPlaneNamespace, resources, nil)
+	dumper.dump(ctx, opts.Namespace, resources, nil)
+	dumper.dump(ctx, "hypershift", resources, nil)
 	if opts.AgentNamespace != "" {
-		cmd.WithNamespace(opts.AgentNamespace).Run(ctx, "agent.agent-install.openshift.io,infraenv.agent-install.openshift.io")
+		dumper.dump(ctx, opts.AgentNamespace, []schema.GroupVersionKind{
+			{Group: "agent-install.openshift.io", Version: "v1beta1", Kind: "Agent"},
+			{Group: "agent-install.openshift.io", Version: "v1beta1", Kind: "InfraEnv"},
+		}, nil)
 	}
 
 	podList := &corev1.PodList{}
-	if err = c.List(ctx, podList, client.InNamespace(controlPlaneNamespace)); err != nil {
+	if err := c.List(ctx, podList, client.InNamespace(controlPlaneNamespace)); err != nil {
 		opts.Log.Error(err, "Cannot list pods in controlplane namespace", "namespace", controlPlaneNamespace)
 	}
 	hypershiftNSPodList := &corev1.PodList{}
@@ -231,54 +284,75 @@ func DumpCluster(ctx context.Context, opts *DumpOptions) error {
 		opts.Log.Error(err, "Failed to list pods in hypershift namespace")
 	}
 	podList.Items = append(podList.Items, hypershiftNSPodList.Items...)
-	kubeClient := kubeclient.NewForConfigOrDie(cfg)
-	outputLogs(ctx, opts.Log, kubeClient, opts.ArtifactDir, podList, opts.LogCheckers...)
-	gatherNetworkLogs(ocCommand, controlPlaneNamespace, opts.ArtifactDir, ctx, c, opts.Log)
+	outputLogs(ctx, opts.Log, kubeClient, w, podList, opts.Since, opts.LogCheckers...)
+	gatherNetworkLogs(ctx, executor, controlPlaneNamespace, w, c, opts.Log)
+
+	if opts.ConsoleLogs {
+		dumpConsoleLogs(ctx, c, opts, w, collectConsoleLogs)
+	}
 
 	if opts.DumpGuestCluster {
-		if err = dumpGuestCluster(ctx, opts); err != nil {
+		if err := dumpGuestCluster(ctx, c, opts, w); err != nil {
 			opts.Log.Error(err, "Failed to dump guest cluster")
 		}
 	}
 
-	files, err := os.ReadDir(opts.ArtifactDir)
-	if err != nil {
-		return fmt.Errorf("failed to list artifactDir %s: %w", opts.ArtifactDir, err)
-	}
-	args := []string{"-cvzf", "hypershift-dump.tar.gz"}
-	for _, file := range files {
-		args = append(args, file.Name())
-	}
-
-	tarCMD := exec.CommandContext(ctx, "tar", args...)
-	tarCMD.Dir = opts.ArtifactDir
-
-	opts.Log.Info("Archiving dump", "command", "tar", "args", args)
+	archivePath := filepath.Join(opts.ArtifactDir, DumpArchiveFile)
+	opts.Log.Info("Archiving dump", "archive", archivePath, "files", len(manifest.Files))
 	startArchivingDump := time.Now()
-	if out, err := tarCMD.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to run tar with %v args: got err %w and out \n%s", args, err, string(out))
+	if err := w.finish(archivePath); err != nil {
+		return fmt.Errorf("failed to archive dump: %w", err)
 	}
-	opts.Log.Info("Successfully archied dump", "duration", time.Since(startArchivingDump).String())
+	opts.Log.Info("Successfully archived dump", "duration", time.Since(startArchivingDump).String())
 
 	return nil
 }
 
+// dumpConsoleLogs writes the console log of every machine to machine-console-logs/<machine>.log,
+// the same layout as the console-logs command.
+func dumpConsoleLogs(ctx context.Context, c client.Client, opts *DumpOptions, w *dumpWriter, collect consoleLogCollector) {
+	hcluster := &hyperv1.HostedCluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: opts.Name}, hcluster); err != nil {
+		opts.Log.Error(err, "Cannot get hostedcluster for console logs")
+		return
+	}
+	logs, err := collect(ctx, hcluster)
+	if err != nil {
+		// Some machines may have logs even if others failed
+		opts.Log.Error(err, "Failed to collect console logs")
+	}
+	for name, content := range logs {
+		relPath := filepath.Join("machine-console-logs", name+".log")
+		if err := w.write(relPath, content, DumpFileRef{Type: DumpConsoleLogEntry, Resource: name}); err != nil {
+			opts.Log.Error(err, "Failed to write console log", "machine", name)
+		}
+	}
+}
+
 // DumpGuestCluster dumps resources from a hosted cluster using its apiserver
 // indicated by the provided kubeconfig. This function assumes that pods aren't
 // able to be scheduled and so can only gather information directly accessible
 // through the api server.
 func DumpGuestCluster(ctx context.Context, log logr.Logger, kubeconfig string, destDir string) error {
-	ocCommand, err := exec.LookPath("oc")
-	if err != nil || len(ocCommand) == 0 {
-		return fmt.Errorf("cannot find oc command")
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
-	cmd := OCAdmInspect{
-		oc:          ocCommand,
-		artifactDir: destDir,
-		kubeconfig:  kubeconfig,
-		log:         log,
+	manifest := &DumpManifest{CreatedAt: time.Now().UTC(), Redacted: true}
+	w := newDumpWriter(destDir, manifest)
+	if err := dumpGuestClusterResources(ctx, log, config, w); err != nil {
+		return err
 	}
-	resources := []client.Object{
+	return w.finish(filepath.Join(destDir, DumpArchiveFile))
+}
+
+func dumpGuestClusterResources(ctx context.Context, log logr.Logger, config *restclient.Config, w *dumpWriter) error {
+	c, err := client.New(config, client.Options{Scheme: hyperapi.Scheme})
+	if err != nil {
+		return fmt.Errorf("failed to create guest cluster client: %w", err)
+	}
+	dumper := &resourceDumper{client: c, writer: w, log: log}
+	resources := gvksOf(
 		&apiextensionsv1.CustomResourceDefinition{},
 		&appsv1.ControllerRevision{},
 		&appsv1.DaemonSet{},
@@ -310,97 +384,123 @@ func DumpGuestCluster(ctx context.Context, log logr.Logger, kubeconfig string, d
 		// https://github.com/openshift/api/blob/2bde012f248a5172dcde2f7104caf0726cf6d93a/config/v1/types_cluster_version.go#L266-L270
 		&snapshotv1.VolumeSnapshotClass{},
 		&snapshotv1.VolumeSnapshotContent{},
-	}
-	resourceList := strings.Join(resourceTypes(resources), ",")
-	cmd.Run(ctx, resourceList)
+	)
+	dumper.dump(ctx, "", resources, nil)
 	return nil
 }
 
-type OCAdmInspect struct {
-	oc             string
-	artifactDir    string
-	namespace      string
-	kubeconfig     string
-	agentNamespace string
-	log            logr.Logger
-}
-
-func (i *OCAdmInspect) WithNamespace(namespace string) *OCAdmInspect {
-	withNS := *i
-	withNS.namespace = namespace
-	return &withNS
+// resourceDumper lists resources with a client and writes them as one YAML list per
+// resource type and namespace, following the layout of oc adm inspect.
+type resourceDumper struct {
+	client client.Client
+	writer *dumpWriter
+	log    logr.Logger
 }
 
-func (i *OCAdmInspect) Run(ctx context.Context, cmdArgs ...string) {
-	allArgs := []string{"adm", "inspect", "--dest-dir", i.artifactDir}
-	if len(i.namespace) > 0 {
-		allArgs = append(allArgs, "-n", i.namespace)
-	}
-	if len(i.kubeconfig) > 0 {
-		allArgs = append(allArgs, "--kubeconfig", i.kubeconfig)
-	}
-	allArgs = append(allArgs, cmdArgs...)
-	cmd := exec.CommandContext(ctx, i.oc, allArgs...)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		i.log.Info("oc adm inspect returned an error", "args", allArgs, "error", err.Error(), "output", string(out))
-	}
-}
+// dump writes all objects of the given kinds in namespace, or across all namespaces and
+// cluster-scoped resources if namespace is empty. Objects are skipped if filter returns false.
+// Kinds that are not served by the cluster are skipped.
+func (d *resourceDumper) dump(ctx context.Context, namespace string, gvks []schema.GroupVersionKind, filter func(*unstructured.Unstructured) bool) {
+	for _, gvk := range gvks {
+		// Use the version preferred by the server, e.g. v1alpha1 HostedClusters on older management clusters
+		mapping, err := d.client.RESTMapper().RESTMapping(gvk.GroupKind())
+		if err != nil {
+			if !meta.IsNoMatchError(err) {
+				d.log.Info("Failed to find resource", "kind", gvk.String(), "error", err.Error())
+			}
+			continue
+		}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(mapping.GroupVersionKind.GroupVersion().WithKind(mapping.GroupVersionKind.Kind + "List"))
+		var listOpts []client.ListOption
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != "" {
+			listOpts = append(listOpts, client.InNamespace(namespace))
+		}
+		if err := d.client.List(ctx, list, listOpts...); err != nil {
+			d.log.Info("Failed to list resources", "kind", gvk.String(), "namespace", namespace, "error", err.Error())
+			continue
+		}
+		var items []unstructured.Unstructured
+		for i := range list.Items {
+			if filter != nil && !filter(&list.Items[i]) {
+				continue
+			}
+			if d.writer.redact {
+				RedactObject(&list.Items[i])
+			}
+			items = append(items, list.Items[i])
+		}
+		if len(items) == 0 {
+			continue
+		}
+		list.Items = items
 
-func objectType(obj client.Object) string {
-	var kind, group string
-	gvks, _, err := hyperapi.Scheme.ObjectKinds(obj)
-	if err != nil || len(gvks) == 0 {
-		kind = "unknown"
-		group = "unknown"
-	} else {
-		kind = strings.ToLower(gvks[0].Kind)
-		group = gvks[0].Group
-	}
-	if len(group) > 0 {
-		return fmt.Sprintf("%s.%s", kind, group)
-	} else {
-		return kind
+		group := mapping.Resource.Group
+		if group == "" {
+			group = "core"
+		}
+		resource := mapping.Resource.Resource
+		relPath := filepath.Join("cluster-scoped-resources", group, resource+".yaml")
+		ref := DumpFileRef{Type: DumpResourcesEntry, Resource: resource, Items: len(items)}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != "" {
+			relPath = filepath.Join("namespaces", namespace, group, resource+".yaml")
+			ref.Namespace = namespace
+		}
+		content, err := yaml.Marshal(list.UnstructuredContent())
+		if err != nil {
+			d.log.Error(err, "Failed to serialize resources", "kind", gvk.String())
+			continue
+		}
+		if err := d.writer.write(relPath, content, ref); err != nil {
+			d.log.Error(err, "Failed to write resources", "kind", gvk.String())
+		}
 	}
 }
 
-func resourceTypes(objs []client.Object) []string {
-	result := make([]string, 0, len(objs))
+func gvksOf(objs ...client.Object) []schema.GroupVersionKind {
+	result := make([]schema.GroupVersionKind, 0, len(objs))
 	for _, obj := range objs {
-		result = append(result, objectType(obj))
+		gvks, _, err := hyperapi.Scheme.ObjectKinds(obj)
+		if err != nil || len(gvks) == 0 {
+			continue
+		}
+		result = append(result, gvks[0])
 	}
 	return result
 }
 
-func typedName(obj client.Object, name string) string {
-	return fmt.Sprintf("%s/%s", objectType(obj), name)
+func removeGVK(gvks []schema.GroupVersionKind, remove schema.GroupVersionKind) []schema.GroupVersionKind {
+	result := make([]schema.GroupVersionKind, 0, len(gvks))
+	for _, gvk := range gvks {
+		if gvk != remove {
+			result = append(result, gvk)
+		}
+	}
+	return result
 }
 
 type LogChecker func(filename string, content []byte)
 
-func outputLogs(ctx context.Context, l logr.Logger, c kubeclient.Interface, artifactDir string, podList *corev1.PodList, checker ...LogChecker) {
+func outputLogs(ctx context.Context, l logr.Logger, c kubeclient.Interface, w *dumpWriter, podList *corev1.PodList, since time.Duration, checker ...LogChecker) {
+	var sinceSeconds *int64
+	if since > 0 {
+		seconds := int64(since.Seconds())
+		sinceSeconds = &seconds
+	}
 	for _, pod := range podList.Items {
-		dir := filepath.Join(artifactDir, "namespaces", pod.Namespace, "core", "pods", "logs")
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			l.Error(err, "Cannot create directory", "directory", dir)
-			continue
-		}
-		for _, container := range pod.Spec.InitContainers {
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name}), false, checker...)
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s-previous.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, Previous: true}), true, checker...)
-		}
-		for _, container := range pod.Spec.Containers {
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name}), false, checker...)
-			outputLog(ctx, l, filepath.Join(dir, fmt.Sprintf("%s-%s-previous.log", pod.Name, container.Name)),
-				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, Previous: true}), true, checker...)
+		dir := filepath.Join("namespaces", pod.Namespace, "core", "pods", "logs")
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			ref := DumpFileRef{Type: DumpLogEntry, Namespace: pod.Namespace, Resource: pod.Name, Container: container.Name}
+			outputLog(ctx, l, w, filepath.Join(dir, fmt.Sprintf("%s-%s.log", pod.Name, container.Name)), ref,
+				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, SinceSeconds: sinceSeconds}), false, checker...)
+			outputLog(ctx, l, w, filepath.Join(dir, fmt.Sprintf("%s-%s-previous.log", pod.Name, container.Name)), ref,
+				c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, Previous: true, SinceSeconds: sinceSeconds}), true, checker...)
 		}
 	}
 }
 
-func outputLog(ctx context.Context, l logr.Logger, fileName string, req *restclient.Request, skipLogErr bool, checker ...LogChecker) {
+func outputLog(ctx context.Context, l logr.Logger, w *dumpWriter, relPath string, ref DumpFileRef, req *restclient.Request, skipLogErr bool, checker ...LogChecker) {
 	b, err := req.DoRaw(ctx)
 	if err != nil {
 		if !skipLogErr {
@@ -408,21 +508,52 @@ func outputLog(ctx context.Context, l logr.Logger, fileName string, req *restcli
 		}
 		return
 	}
+	// Checkers look at the raw logs before they are redacted
 	for _, c := range checker {
-		c(fileName, b)
+		c(w.path(relPath), b)
 	}
-	if err := os.WriteFile(fileName, b, 0644); err != nil {
-		l.Error(err, "Failed to write file", "file", fileName)
+	if err := w.write(relPath, b, ref); err != nil {
+		l.Error(err, "Failed to write file", "file", relPath)
 	}
 }
 
-func gatherNetworkLogs(ocCommand, controlPlaneNamespace, artifactDir string, ctx context.Context, c client.Client, l logr.Logger) {
-	// copy ovn dbs and save db cluster status for all ovnkube-master pods
-	dir := filepath.Join(artifactDir, "network_logs")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		l.Error(err, "Cannot create directory", "directory", dir)
-		return
+// podExecutor runs a command in a container and returns its standard output.
+type podExecutor interface {
+	Exec(ctx context.Context, namespace, pod, container string, command ...string) ([]byte, error)
+}
+
+// spdyPodExecutor runs commands through the pods/exec subresource of the API server.
+type spdyPodExecutor struct {
+	config *restclient.Config
+	client kubeclient.Interface
+}
+
+func (e *spdyPodExecutor) Exec(ctx context.Context, namespace, pod, container string, command ...string) ([]byte, error) {
+	req := e.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, kubescheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create executor: %w", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return stdout.Bytes(), fmt.Errorf("%w: %s", err, stderr.String())
 	}
+	return stdout.Bytes(), nil
+}
+
+func gatherNetworkLogs(ctx context.Context, executor podExecutor, controlPlaneNamespace string, w *dumpWriter, c client.Client, l logr.Logger) {
+	// copy ovn dbs and save db cluster status for all ovnkube-master pods
+	dir := "network_logs"
 	podList := &corev1.PodList{}
 	if err := c.List(ctx, podList, &client.ListOptions{
 		Namespace:     controlPlaneNamespace,
@@ -432,12 +563,14 @@ func gatherNetworkLogs(ocCommand, controlPlaneNamespace, artifactDir string, ctx
 	}
 	for _, pod := range podList.Items {
 		for _, dbType := range []string{"n", "s"} {
-			allArgs := []string{"cp", fmt.Sprintf("%s/%s:/etc/ovn/ovn%sb_db.db", controlPlaneNamespace, pod.Name, dbType),
-				"-c", fmt.Sprintf("%sbdb", dbType), filepath.Join(dir, fmt.Sprintf("%s_ovn%sb_db.db", pod.Name, dbType))}
-			cmd := exec.CommandContext(ctx, ocCommand, allArgs...)
-			out, err := cmd.CombinedOutput()
+			container := fmt.Sprintf("%sbdb", dbType)
+			ref := DumpFileRef{Type: DumpNetworkEntry, Namespace: controlPlaneNamespace, Resource: pod.Name, Container: container}
+			dbFile := fmt.Sprintf("/etc/ovn/ovn%sb_db.db", dbType)
+			out, err := executor.Exec(ctx, controlPlaneNamespace, pod.Name, container, "cat", dbFile)
 			if err != nil {
-				l.Info("Copy ovn dbs command returned an error", "args", allArgs, "error", err.Error(), "output", string(out))
+				l.Info("Copy ovn dbs command returned an error", "pod", pod.Name, "file", dbFile, "error", err.Error())
+			} else if err := w.write(filepath.Join(dir, fmt.Sprintf("%s_ovn%sb_db.db", pod.Name, dbType)), out, ref); err != nil {
+				l.Error(err, "Failed to write file")
 			}
 			var dbName string
 			if dbType == "n" {
@@ -445,16 +578,13 @@ func gatherNetworkLogs(ocCommand, controlPlaneNamespace, artifactDir string, ctx
 			} else {
 				dbName = "OVN_Southbound"
 			}
-			allArgs = []string{"exec", "-n", controlPlaneNamespace, pod.Name, "-c", fmt.Sprintf("%sbdb", dbType),
-				"--", "bash", "-c", fmt.Sprintf("ovn-appctl -t /var/run/ovn/ovn%sb_db.ctl cluster/status %s", dbType, dbName)}
-			cmd = exec.CommandContext(ctx, ocCommand, allArgs...)
-			out, err = cmd.CombinedOutput()
+			out, err = executor.Exec(ctx, controlPlaneNamespace, pod.Name, container,
+				"ovn-appctl", "-t", fmt.Sprintf("/var/run/ovn/ovn%sb_db.ctl", dbType), "cluster/status", dbName)
 			if err != nil {
-				l.Info("Get ovn db status command returned an error", "args", allArgs, "error", err.Error(), "output", string(out))
+				l.Info("Get ovn db status command returned an error", "pod", pod.Name, "error", err.Error())
 			}
-			fileName := filepath.Join(dir, fmt.Sprintf("%s_%s_status", pod.Name, dbName))
-			if err := os.WriteFile(fileName, out, 0644); err != nil {
-				l.Error(err, "Failed to write file", "file", fileName)
+			if err := w.write(filepath.Join(dir, fmt.Sprintf("%s_%s_status", pod.Name, dbName)), out, ref); err != nil {
+				l.Error(err, "Failed to write file")
 			}
 		}
 	}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+type fakePodExecutor struct {
+	commands [][]string
+}
+
+func (e *fakePodExecutor) Exec(_ context.Context, _, _, _ string, command ...string) ([]byte, error) {
+	e.commands = append(e.commands, command)
+	return []byte("output of " + strings.Join(command, " ")), nil
+}
+
+func TestDumpCluster(t *testing.T) {
+	controlPlaneNamespace := "clusters-example"
+	objects := []runtime.Object{
+		&hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"}},
+		&hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"}},
+		&hyperv1.NodePool{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-workers"}, Spec: hyperv1.NodePoolSpec{ClusterName: "example"}},
+		&hyperv1.NodePool{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other-workers"}, Spec: hyperv1.NodePoolSpec{ClusterName: "other"}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: controlPlaneNamespace, Name: "pull-secret"},
+			Data:       map[string][]byte{".dockerconfigjson": []byte(`{"auths":{"quay.io":{"auth":"c2VjcmV0"}}}`)},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: controlPlaneNamespace, Name: "ovnkube-master-0", Labels: map[string]string{"app": "ovnkube-master"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nbdb"}}},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		disableRedaction bool
+		archiveOnly      bool
+		since            time.Duration
+		consoleLogs      bool
+	}{
+		{
+			name: "When dumping with defaults it should write redacted files and an archive",
+		},
+		{
+			name:        "When archive only is set it should only write the archive",
+			archiveOnly: true,
+			since:       time.Hour,
+		},
+		{
+			name:             "When redaction is disabled it should skip secrets",
+			disableRedaction: true,
+		},
+		{
+			name:        "When console logs are enabled it should include the logs of every machine",
+			consoleLogs: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{hyperv1.GroupVersion, corev1.SchemeGroupVersion})
+			restMapper.Add(hyperv1.GroupVersion.WithKind("HostedCluster"), meta.RESTScopeNamespace)
+			restMapper.Add(hyperv1.GroupVersion.WithKind("NodePool"), meta.RESTScopeNamespace)
+			restMapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+			restMapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+			restMapper.Add(corev1.SchemeGroupVersion.WithKind("Node"), meta.RESTScopeRoot)
+			c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithRESTMapper(restMapper).WithRuntimeObjects(objects...).Build()
+			kubeClient := kubefake.NewSimpleClientset()
+			executor := &fakePodExecutor{}
+
+			artifactDir := t.TempDir()
+			var checkedLogs []string
+			opts := &DumpOptions{
+				Namespace:        "clusters",
+				Name:             "example",
+				ArtifactDir:      artifactDir,
+				DisableRedaction: tc.disableRedaction,
+				ArchiveOnly:      tc.archiveOnly,
+				Since:            tc.since,
+				ConsoleLogs:      tc.consoleLogs,
+				LogCheckers: []LogChecker{func(filename string, content []byte) {
+					checkedLogs = append(checkedLogs, filename)
+				}},
+				Log: zap.New(zap.UseDevMode(true)),
+			}
+			collectConsoleLogs := func(_ context.Context, hc *hyperv1.HostedCluster) (map[string][]byte, error) {
+				g.Expect(hc.Name).To(Equal("example"))
+				return map[string][]byte{"example-workers-abcde": []byte("Red Hat Enterprise Linux CoreOS")}, errors.New("example-workers-fghij has no console log")
+			}
+			g.Expect(dumpCluster(context.Background(), opts, c, kubeClient, executor, collectConsoleLogs)).To(Succeed())
+
+			entries, err := os.ReadDir(artifactDir)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tc.archiveOnly {
+				g.Expect(entries).To(HaveLen(1))
+				g.Expect(entries[0].Name()).To(Equal(DumpArchiveFile))
+			} else {
+				g.Expect(filepath.Join(artifactDir, DumpManifestFile)).To(BeAnExistingFile())
+			}
+			g.Expect(checkedLogs).ToNot(BeEmpty())
+			g.Expect(executor.commands).To(HaveLen(4))
+
+			files := readArchive(g, filepath.Join(artifactDir, DumpArchiveFile))
+			manifest := &DumpManifest{}
+			g.Expect(json.Unmarshal(files[DumpManifestFile], manifest)).To(Succeed())
+			g.Expect(manifest.Redacted).To(Equal(!tc.disableRedaction))
+			if tc.since > 0 {
+				g.Expect(manifest.Since).To(Equal(tc.since.String()))
+			}
+			for _, file := range manifest.Files {
+				g.Expect(files).To(HaveKey(file.Path))
+				g.Expect(file.Size).To(Equal(len(files[file.Path])))
+			}
+
+			hostedClusters := string(files["namespaces/clusters/hypershift.openshift.io/hostedclusters.yaml"])
+			g.Expect(hostedClusters).To(ContainSubstring("name: example"))
+			g.Expect(hostedClusters).ToNot(ContainSubstring("name: other"))
+			nodePools := string(files["namespaces/clusters/hypershift.openshift.io/nodepools.yaml"])
+			g.Expect(nodePools).To(ContainSubstring("name: example-workers"))
+			g.Expect(nodePools).ToNot(ContainSubstring("name: other-workers"))
+			g.Expect(files).To(HaveKey("network_logs/ovnkube-master-0_ovnnb_db.db"))
+			if tc.consoleLogs {
+				g.Expect(string(files["machine-console-logs/example-workers-abcde.log"])).To(Equal("Red Hat Enterprise Linux CoreOS"))
+			} else {
+				g.Expect(files).ToNot(HaveKey("machine-console-logs/example-workers-abcde.log"))
+			}
+
+			secrets, hasSecrets := files["namespaces/clusters-example/core/secrets.yaml"]
+			if tc.disableRedaction {
+				g.Expect(hasSecrets).To(BeFalse())
+			} else {
+				g.Expect(hasSecrets).To(BeTrue())
+				g.Expect(string(secrets)).To(ContainSubstring(redactedValue))
+				g.Expect(string(secrets)).ToNot(ContainSubstring("c2VjcmV0"))
+			}
+		})
+	}
+}
+
+func readArchive(g Gomega, path string) map[string][]byte {
+	f, err := os.Open(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	g.Expect(err).ToNot(HaveOccurred())
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		content, err := io.ReadAll(tr)
+		g.Expect(err).ToNot(HaveOccurred())
+		files[header.Name] = content
+	}
+	return files
+}
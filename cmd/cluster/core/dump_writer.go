@@ -0,0 +1,166 @@
+package core
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DumpManifestFile is the index of all files of a dump.
+	DumpManifestFile = "manifest.json"
+	// DumpArchiveFile is the compressed archive of a dump.
+	DumpArchiveFile = "hypershift-dump.tar.gz"
+)
+
+// DumpManifestEntryType classifies the files of a dump.
+type DumpManifestEntryType string
+
+const (
+	DumpResourcesEntry  DumpManifestEntryType = "resources"
+	DumpLogEntry        DumpManifestEntryType = "log"
+	DumpNetworkEntry    DumpManifestEntryType = "network"
+	DumpConsoleLogEntry DumpManifestEntryType = "console-log"
+)
+
+// DumpManifest indexes the files of a dump.
+type DumpManifest struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Redacted  bool          `json:"redacted"`
+	Since     string        `json:"since,omitempty"`
+	Files     []DumpFileRef `json:"files"`
+}
+
+// DumpFileRef describes a single file of a dump.
+type DumpFileRef struct {
+	Path      string                `json:"path"`
+	Type      DumpManifestEntryType `json:"type"`
+	Namespace string                `json:"namespace,omitempty"`
+	// Resource is the plural resource name of a resources file or the pod of a log file.
+	Resource string `json:"resource,omitempty"`
+	// Container is the container of a log file.
+	Container string `json:"container,omitempty"`
+	// Items is the number of objects in a resources file.
+	Items  int    `json:"items,omitempty"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// dumpWriter writes the files of a dump below a directory, redacting their content
+// unless disabled, and records them in a manifest.
+type dumpWriter struct {
+	dir      string
+	prefix   string
+	redact   bool
+	manifest *DumpManifest
+}
+
+func newDumpWriter(dir string, manifest *DumpManifest) *dumpWriter {
+	return &dumpWriter{
+		dir:      dir,
+		redact:   manifest.Redacted,
+		manifest: manifest,
+	}
+}
+
+// withPrefix returns a writer that places files below prefix and shares the manifest.
+func (w *dumpWriter) withPrefix(prefix string) *dumpWriter {
+	sub := *w
+	sub.prefix = filepath.Join(w.prefix, prefix)
+	return &sub
+}
+
+// path returns the absolute path of a file of the dump.
+func (w *dumpWriter) path(relPath string) string {
+	return filepath.Join(w.dir, w.prefix, relPath)
+}
+
+// write stores content at relPath and records it with the metadata from ref.
+func (w *dumpWriter) write(relPath string, content []byte, ref DumpFileRef) error {
+	if w.redact {
+		content = RedactText(content)
+	}
+	fileName := w.path(relPath)
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", fileName, err)
+	}
+	if err := os.WriteFile(fileName, content, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", fileName, err)
+	}
+	sum := sha256.Sum256(content)
+	ref.Path = filepath.ToSlash(filepath.Join(w.prefix, relPath))
+	ref.Size = len(content)
+	ref.SHA256 = hex.EncodeToString(sum[:])
+	w.manifest.Files = append(w.manifest.Files, ref)
+	return nil
+}
+
+// finish writes the manifest and a gzipped tar archive with the manifest and all
+// recorded files to archivePath.
+func (w *dumpWriter) finish(archivePath string) error {
+	manifestBytes, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, DumpManifestFile), manifestBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer archive.Close()
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+
+	paths := []string{DumpManifestFile}
+	for _, file := range w.manifest.Files {
+		paths = append(paths, file.Path)
+	}
+	for _, path := range paths {
+		if err := addToArchive(tw, w.dir, path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return archive.Close()
+}
+
+func addToArchive(tw *tar.Writer, dir, path string) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(path)))
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to build archive header for %s: %w", path, err)
+	}
+	header.Name = path
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive header for %s: %w", path, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// redactedValue replaces sensitive values in dumps.
+const redactedValue = "REDACTED"
+
+// tokenPatterns match credentials that may show up in logs and resource dumps. Each
+// pattern has a capture group for the part that is kept, the rest of the match is
+// replaced with redactedValue.
+var tokenPatterns = []*regexp.Regexp{
+	// PEM encoded private keys
+	regexp.MustCompile(`(-----BEGIN [A-Z ]*PRIVATE KEY-----)[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	// JSON web tokens, e.g. service account tokens
+	regexp.MustCompile(`()eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`),
+	// OpenShift OAuth access tokens
+	regexp.MustCompile(`(sha256~)[A-Za-z0-9_-]{20,}`),
+	// Authorization headers
+	regexp.MustCompile(`(?i)((?:bearer|basic)\s+)[A-Za-z0-9._~+/-]{16,}=*`),
+	// AWS access key IDs
+	regexp.MustCompile(`()\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// Credential fields of kubeconfigs, ini files and JSON documents, e.g. docker auths
+	regexp.MustCompile(`(?im)((?:client-key-data|token|password|aws_secret_access_key|aws_session_token|client_secret|clientSecret)"?\s*[:=]\s*"?)[^\s",]{4,}`),
+	regexp.MustCompile(`("auth"\s*:\s*")[^"]+`),
+}
+
+// sensitiveNamePattern matches names of environment variables and similar name/value
+// pairs whose values are redacted.
+var sensitiveNamePattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private_?key|access_?key|api_?key)`)
+
+// RedactText replaces token-like strings in content.
+func RedactText(content []byte) []byte {
+	for _, pattern := range tokenPatterns {
+		content = pattern.ReplaceAll(content, []byte("${1}"+redactedValue))
+	}
+	return content
+}
+
+// RedactObject removes the values of Secrets and of sensitive name/value pairs, such as
+// environment variables, from obj. Keys are kept so dumps still show what is configured.
+func RedactObject(obj *unstructured.Unstructured) {
+	if obj.GetKind() == "Secret" && obj.GroupVersionKind().Group == "" {
+		for _, field := range []string{"data", "stringData"} {
+			values, found, _ := unstructured.NestedMap(obj.Object, field)
+			if !found {
+				continue
+			}
+			for key := range values {
+				values[key] = redactedValue
+			}
+			_ = unstructured.SetNestedMap(obj.Object, values, field)
+		}
+		annotations := obj.GetAnnotations()
+		if _, ok := annotations["kubectl.kubernetes.io/last-applied-configuration"]; ok {
+			annotations["kubectl.kubernetes.io/last-applied-configuration"] = redactedValue
+			obj.SetAnnotations(annotations)
+		}
+	}
+	redactNameValuePairs(obj.Object)
+}
+
+// redactNameValuePairs walks value and redacts the value of every {name, value} map whose
+// name looks sensitive.
+func redactNameValuePairs(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok {
+			if _, hasValue := v["value"].(string); hasValue && sensitiveNamePattern.MatchString(name) {
+				v["value"] = redactedValue
+			}
+		}
+		for _, child := range v {
+			redactNameValuePairs(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			redactNameValuePairs(child)
+		}
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -72,6 +70,15 @@ func (o *ConsoleLogOpts) Run(ctx context.Context) error {
 	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, &hostedCluster); err != nil {
 		return fmt.Errorf("failed to get hostedcluster: %w", err)
 	}
+	logs, err := o.Collect(ctx, &hostedCluster)
+	if writeErr := util.WriteConsoleLogs(o.OutputDir, logs); writeErr != nil {
+		return fmt.Errorf("failed to write console logs: %w", writeErr)
+	}
+	return err
+}
+
+// Collect returns the console output of the running instances of hostedCluster by instance name.
+func (o *ConsoleLogOpts) Collect(ctx context.Context, hostedCluster *hyperv1.HostedCluster) (map[string][]byte, error) {
 	infraID := hostedCluster.Spec.InfraID
 	region := hostedCluster.Spec.Platform.AWS.Region
 	awsSession := awsutil.NewSession("cli-console-logs", o.AWSCredentialsFile, o.AWSKey, o.AWSSecretKey, region)
@@ -81,14 +88,15 @@ func (o *ConsoleLogOpts) Run(ctx context.Context) error {
 	// Fetch any instances belonging to the cluster
 	instances, err := getEC2Instances(ctx, ec2Client, infraID)
 	if err != nil {
-		return fmt.Errorf("failed to get AWS instances: %w", err)
+		return nil, fmt.Errorf("failed to get AWS instances: %w", err)
 	}
 	// get the console output
-	if err := getInstanceConsoleOutput(ctx, ec2Client, instances, o.OutputDir); err != nil {
-		return fmt.Errorf("failed to get instance console output: %w", err)
+	logs, err := getInstanceConsoleOutput(ctx, ec2Client, instances)
+	if err != nil {
+		return logs, fmt.Errorf("failed to get instance console output: %w", err)
 	}
 
-	return nil
+	return logs, nil
 }
 
 func getEC2Instances(ctx context.Context, ec2Client *ec2.EC2, infraID string) (map[string]string, error) {
@@ -124,10 +132,8 @@ func getEC2Instances(ctx context.Context, ec2Client *ec2.EC2, infraID string) (m
 	return instances, nil
 }
 
-func getInstanceConsoleOutput(ctx context.Context, ec2Client *ec2.EC2, instances map[string]string, outputDir string) error {
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return err
-	}
+func getInstanceConsoleOutput(ctx context.Context, ec2Client *ec2.EC2, instances map[string]string) (map[string][]byte, error) {
+	logs := map[string][]byte{}
 	var errs []error
 	for name, instanceID := range instances {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
@@ -144,12 +150,7 @@ func getInstanceConsoleOutput(ctx context.Context, ec2Client *ec2.EC2, instances
 			errs = append(errs, err)
 			continue
 		}
-		if err := os.WriteFile(filepath.Join(outputDir, name+".log"), logOutput, 0644); err != nil {
-			errs = append(errs, err)
-		}
-	}
-	if len(errs) > 0 {
-		return utilerrors.NewAggregate(errs)
+		logs[name] = logOutput
 	}
-	return nil
+	return logs, utilerrors.NewAggregate(errs)
 }
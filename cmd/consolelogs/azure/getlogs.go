@@ -0,0 +1,176 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	capiazure "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	azureinfra "github.com/openshift/hypershift/cmd/infra/azure"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type ConsoleLogOpts struct {
+	Name                 string
+	Namespace            string
+	AzureCredentialsFile string
+	OutputDir            string
+}
+
+func NewCommand() *cobra.Command {
+
+	opts := &ConsoleLogOpts{
+		Namespace: "clusters",
+	}
+
+	cmd := &cobra.Command{
+		Use:          "azure",
+		Short:        "Get Azure virtual machine serial console logs from boot diagnostics",
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "A cluster namespace")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "A cluster name")
+	cmd.Flags().StringVar(&opts.AzureCredentialsFile, "azure-creds", opts.AzureCredentialsFile, "Path to an Azure credentials file (required)")
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", opts.OutputDir, "Directory where to place console logs (required)")
+
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("azure-creds")
+	cmd.MarkFlagRequired("output-dir")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Run(cmd.Context()); err != nil {
+			log.Log.Error(err, "Failed to get console logs")
+			return err
+		}
+		log.Log.Info("Successfully retrieved console logs")
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *ConsoleLogOpts) Run(ctx context.Context) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	var hostedCluster hyperv1.HostedCluster
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, &hostedCluster); err != nil {
+		return fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	logs, err := o.Collect(ctx, &hostedCluster)
+	if writeErr := util.WriteConsoleLogs(o.OutputDir, logs); writeErr != nil {
+		return fmt.Errorf("failed to write console logs: %w", writeErr)
+	}
+	return err
+}
+
+// Collect returns the serial console logs of the virtual machines of hostedCluster by
+// virtual machine name. The logs are read from boot diagnostics, which must be enabled
+// for the virtual machines.
+func (o *ConsoleLogOpts) Collect(ctx context.Context, hostedCluster *hyperv1.HostedCluster) (map[string][]byte, error) {
+	if hostedCluster.Spec.Platform.Azure == nil {
+		return nil, fmt.Errorf("hostedcluster %s/%s is not an Azure cluster", hostedCluster.Namespace, hostedCluster.Name)
+	}
+	creds, err := azureinfra.ReadCredentials(o.AzureCredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the credentials: %w", err)
+	}
+	authorizer, err := auth.ClientCredentialsConfig{
+		TenantID:     creds.TenantID,
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		AADEndpoint:  azure.PublicCloud.ActiveDirectoryEndpoint,
+		Resource:     azure.PublicCloud.ResourceManagerEndpoint,
+	}.Authorizer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get azure authorizer: %w", err)
+	}
+	vmClient := compute.NewVirtualMachinesClient(hostedCluster.Spec.Platform.Azure.SubscriptionID)
+	vmClient.Authorizer = authorizer
+
+	resourceGroup := hostedCluster.Spec.Platform.Azure.ResourceGroupName
+	vmNames, err := getVirtualMachines(ctx, vmClient, resourceGroup, hostedCluster.Spec.InfraID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure virtual machines: %w", err)
+	}
+
+	logs := map[string][]byte{}
+	var errs []error
+	for _, name := range vmNames {
+		content, err := getSerialConsoleLog(ctx, vmClient, resourceGroup, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get serial console log of %s: %w", name, err))
+			continue
+		}
+		logs[name] = content
+	}
+	return logs, utilerrors.NewAggregate(errs)
+}
+
+// getVirtualMachines returns the names of the virtual machines in resourceGroup that are
+// tagged as owned by the cluster with infraID.
+func getVirtualMachines(ctx context.Context, vmClient compute.VirtualMachinesClient, resourceGroup, infraID string) ([]string, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	iter, err := vmClient.ListComplete(ctxWithTimeout, resourceGroup, "")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for ; iter.NotDone(); err = iter.NextWithContext(ctxWithTimeout) {
+		if err != nil {
+			return nil, err
+		}
+		vm := iter.Value()
+		tags := capiazure.Tags{}
+		for key, value := range vm.Tags {
+			if value != nil {
+				tags[key] = *value
+			}
+		}
+		if tags.HasOwned(infraID) && vm.Name != nil {
+			names = append(names, *vm.Name)
+		}
+	}
+	return names, nil
+}
+
+func getSerialConsoleLog(ctx context.Context, vmClient compute.VirtualMachinesClient, resourceGroup, vmName string) ([]byte, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	data, err := vmClient.RetrieveBootDiagnosticsData(ctxWithTimeout, resourceGroup, vmName, nil)
+	if err != nil {
+		return nil, err
+	}
+	if data.SerialConsoleLogBlobURI == nil {
+		return nil, fmt.Errorf("boot diagnostics are not enabled")
+	}
+	// The blob URI contains a SAS token, so it is read without the Azure authorizer
+	req, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodGet, *data.SerialConsoleLogBlobURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s reading the serial console log", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
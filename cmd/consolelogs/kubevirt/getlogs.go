@@ -0,0 +1,169 @@
+package kubevirt
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	kubeclient "k8s.io/client-go/kubernetes"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+)
+
+const (
+	// guestConsoleLogContainer is the virt-launcher container that streams the serial
+	// console of a VirtualMachineInstance to its log.
+	guestConsoleLogContainer = "guest-console-log"
+	// createdByLabel is set on virt-launcher pods to the UID of their VirtualMachineInstance.
+	createdByLabel = "kubevirt.io/created-by"
+)
+
+var virtualMachineInstanceListGVK = schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstanceList"}
+
+type ConsoleLogOpts struct {
+	Name      string
+	Namespace string
+	OutputDir string
+}
+
+func NewCommand() *cobra.Command {
+
+	opts := &ConsoleLogOpts{
+		Namespace: "clusters",
+	}
+
+	cmd := &cobra.Command{
+		Use:          "kubevirt",
+		Short:        "Get KubeVirt virtual machine serial console logs",
+		Long:         "Get the serial console logs of the KubeVirt virtual machines of a cluster. The logs are read from the guest-console-log container of the virt-launcher pods, which requires serial console logging to be enabled in KubeVirt.",
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "A cluster namespace")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "A cluster name")
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", opts.OutputDir, "Directory where to place console logs (required)")
+
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("output-dir")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Run(cmd.Context()); err != nil {
+			log.Log.Error(err, "Failed to get console logs")
+			return err
+		}
+		log.Log.Info("Successfully retrieved console logs")
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *ConsoleLogOpts) Run(ctx context.Context) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	var hostedCluster hyperv1.HostedCluster
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, &hostedCluster); err != nil {
+		return fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	logs, err := o.Collect(ctx, &hostedCluster)
+	if writeErr := util.WriteConsoleLogs(o.OutputDir, logs); writeErr != nil {
+		return fmt.Errorf("failed to write console logs: %w", writeErr)
+	}
+	return err
+}
+
+// Collect returns the serial console logs of the virtual machines of hostedCluster by
+// virtual machine name.
+func (o *ConsoleLogOpts) Collect(ctx context.Context, hostedCluster *hyperv1.HostedCluster) (map[string][]byte, error) {
+	c, err := util.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	config, err := util.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubeclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kube client: %w", err)
+	}
+	return consoleLogs(ctx, c, kubeClient, manifests.HostedControlPlaneNamespace(hostedCluster.Namespace, hostedCluster.Name).Name)
+}
+
+// consoleLogs returns the logs of the guest-console-log container of the virt-launcher pod
+// of every VirtualMachineInstance in namespace.
+func consoleLogs(ctx context.Context, c crclient.Client, kubeClient kubeclient.Interface, namespace string) (map[string][]byte, error) {
+	vmis := &unstructured.UnstructuredList{}
+	vmis.SetGroupVersionKind(virtualMachineInstanceListGVK)
+	if err := c.List(ctx, vmis, crclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list virtual machine instances: %w", err)
+	}
+
+	logs := map[string][]byte{}
+	var errs []error
+	for _, vmi := range vmis.Items {
+		pods := &corev1.PodList{}
+		if err := c.List(ctx, pods, crclient.InNamespace(namespace), crclient.MatchingLabels{createdByLabel: string(vmi.GetUID())}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list virt-launcher pods of %s: %w", vmi.GetName(), err))
+			continue
+		}
+		pod := launcherPod(pods.Items)
+		if pod == nil {
+			errs = append(errs, fmt.Errorf("virtual machine instance %s has no virt-launcher pod", vmi.GetName()))
+			continue
+		}
+		if !hasContainer(pod, guestConsoleLogContainer) {
+			errs = append(errs, fmt.Errorf("virt-launcher pod %s has no %s container, enable serial console logging in KubeVirt or use virtctl console", pod.Name, guestConsoleLogContainer))
+			continue
+		}
+		content, err := kubeClient.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: guestConsoleLogContainer}).DoRaw(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get serial console log of %s: %w", vmi.GetName(), err))
+			continue
+		}
+		logs[vmi.GetName()] = content
+	}
+	return logs, utilerrors.NewAggregate(errs)
+}
+
+// launcherPod returns the running virt-launcher pod, or the most recent one if none is
+// running, e.g. after a migration or a failed start.
+func launcherPod(pods []corev1.Pod) *corev1.Pod {
+	var result *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodRunning {
+			return pod
+		}
+		if result == nil || newer(pod.CreationTimestamp, result.CreationTimestamp) {
+			result = pod
+		}
+	}
+	return result
+}
+
+func newer(a, b metav1.Time) bool {
+	return b.Before(&a)
+}
+
+func hasContainer(pod *corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package kubevirt
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+)
+
+func TestConsoleLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	namespace := "clusters-example"
+	vmi := func(name string, uid types.UID) client.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("kubevirt.io/v1")
+		obj.SetKind("VirtualMachineInstance")
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetUID(uid)
+		return obj
+	}
+	launcher := func(name string, uid types.UID, phase corev1.PodPhase, containers ...string) client.Object {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{createdByLabel: string(uid)}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		for _, container := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+		}
+		return pod
+	}
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(
+		vmi("example-workers-abcde", "uid-1"),
+		launcher("virt-launcher-example-workers-abcde-old", "uid-1", corev1.PodFailed, "compute", guestConsoleLogContainer),
+		launcher("virt-launcher-example-workers-abcde", "uid-1", corev1.PodRunning, "compute", guestConsoleLogContainer),
+		vmi("example-workers-fghij", "uid-2"),
+		launcher("virt-launcher-example-workers-fghij", "uid-2", corev1.PodRunning, "compute"),
+		vmi("example-workers-klmno", "uid-3"),
+	).Build()
+
+	logs, err := consoleLogs(context.Background(), c, kubefake.NewSimpleClientset(), namespace)
+	g.Expect(logs).To(Equal(map[string][]byte{"example-workers-abcde": []byte("fake logs")}))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("virt-launcher-example-workers-fghij has no guest-console-log container"))
+	g.Expect(err.Error()).To(ContainSubstring("example-workers-klmno has no virt-launcher pod"))
+}
@@ -1,9 +1,16 @@
 package consolelogs
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
 
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/cmd/consolelogs/aws"
+	"github.com/openshift/hypershift/cmd/consolelogs/azure"
+	"github.com/openshift/hypershift/cmd/consolelogs/kubevirt"
+	"github.com/openshift/hypershift/cmd/consolelogs/powervs"
 )
 
 func NewCommand() *cobra.Command {
@@ -14,5 +21,40 @@ func NewCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(aws.NewCommand())
+	cmd.AddCommand(azure.NewCommand())
+	cmd.AddCommand(kubevirt.NewCommand())
+	cmd.AddCommand(powervs.NewCommand())
 	return cmd
 }
+
+// CollectOptions hold the credentials of the platforms that require them.
+type CollectOptions struct {
+	AWSCredentialsFile   string
+	AzureCredentialsFile string
+}
+
+// Collect returns the console logs of the machines of hostedCluster by machine name,
+// using the collector of its platform. Logs that were collected are returned even if
+// collecting others failed.
+func Collect(ctx context.Context, hostedCluster *hyperv1.HostedCluster, opts CollectOptions) (map[string][]byte, error) {
+	switch hostedCluster.Spec.Platform.Type {
+	case hyperv1.AWSPlatform:
+		if opts.AWSCredentialsFile == "" {
+			return nil, fmt.Errorf("credentials for AWS are required to collect console logs")
+		}
+		collector := &aws.ConsoleLogOpts{AWSCredentialsFile: opts.AWSCredentialsFile}
+		return collector.Collect(ctx, hostedCluster)
+	case hyperv1.AzurePlatform:
+		if opts.AzureCredentialsFile == "" {
+			return nil, fmt.Errorf("credentials for Azure are required to collect console logs")
+		}
+		collector := &azure.ConsoleLogOpts{AzureCredentialsFile: opts.AzureCredentialsFile}
+		return collector.Collect(ctx, hostedCluster)
+	case hyperv1.KubevirtPlatform:
+		return (&kubevirt.ConsoleLogOpts{}).Collect(ctx, hostedCluster)
+	case hyperv1.PowerVSPlatform:
+		return (&powervs.ConsoleLogOpts{}).Collect(ctx, hostedCluster)
+	default:
+		return nil, fmt.Errorf("console logs are not supported for platform %s", hostedCluster.Spec.Platform.Type)
+	}
+}
@@ -0,0 +1,150 @@
+package powervs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/IBM-Cloud/power-go-client/clients/instance"
+	"github.com/IBM-Cloud/power-go-client/power/models"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	capiibm "sigs.k8s.io/cluster-api-provider-ibmcloud/api/v1beta1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	powervsinfra "github.com/openshift/hypershift/cmd/infra/powervs"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+)
+
+type ConsoleLogOpts struct {
+	Name      string
+	Namespace string
+	OutputDir string
+	Debug     bool
+}
+
+func NewCommand() *cobra.Command {
+
+	opts := &ConsoleLogOpts{
+		Namespace: "clusters",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "powervs",
+		Short: "Get PowerVS instance boot progress",
+		Long: "Get the boot progress of the PowerVS instances of a cluster. Console output is not supported since PowerVS does not provide it through its API, " +
+			"so the log of an instance contains its status, health, fault and system reference code history instead. " +
+			"The IBM Cloud API key is read from the IBMCLOUD_API_KEY or IBMCLOUD_CREDENTIALS environment variables.",
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "A cluster namespace")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "A cluster name")
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", opts.OutputDir, "Directory where to place console logs (required)")
+	cmd.Flags().BoolVar(&opts.Debug, "debug", opts.Debug, "Enable debug logs of the PowerVS client")
+
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("output-dir")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Run(cmd.Context()); err != nil {
+			log.Log.Error(err, "Failed to get console logs")
+			return err
+		}
+		log.Log.Info("Successfully retrieved console logs")
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *ConsoleLogOpts) Run(ctx context.Context) error {
+	c, err := util.GetClient()
+	if err != nil {
+		return err
+	}
+
+	var hostedCluster hyperv1.HostedCluster
+	if err := c.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, &hostedCluster); err != nil {
+		return fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	logs, err := o.Collect(ctx, &hostedCluster)
+	if writeErr := util.WriteConsoleLogs(o.OutputDir, logs); writeErr != nil {
+		return fmt.Errorf("failed to write console logs: %w", writeErr)
+	}
+	return err
+}
+
+// Collect returns the boot progress of the instances of hostedCluster by machine name.
+func (o *ConsoleLogOpts) Collect(ctx context.Context, hostedCluster *hyperv1.HostedCluster) (map[string][]byte, error) {
+	platform := hostedCluster.Spec.Platform.PowerVS
+	if platform == nil {
+		return nil, fmt.Errorf("hostedcluster %s/%s is not a PowerVS cluster", hostedCluster.Namespace, hostedCluster.Name)
+	}
+	c, err := util.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	machines := &capiibm.IBMPowerVSMachineList{}
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(hostedCluster.Namespace, hostedCluster.Name).Name
+	if err := c.List(ctx, machines, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list PowerVS machines: %w", err)
+	}
+
+	session, err := powervsinfra.CreatePowerVSSession(platform.AccountID, platform.Region, platform.Zone, o.Debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PowerVS session: %w", err)
+	}
+	instanceClient := instance.NewIBMPIInstanceClient(ctx, session, platform.ServiceInstanceID)
+
+	logs := map[string][]byte{}
+	var errs []error
+	for _, machine := range machines.Items {
+		if machine.Status.InstanceID == "" {
+			errs = append(errs, fmt.Errorf("machine %s has no instance", machine.Name))
+			continue
+		}
+		pvmInstance, err := instanceClient.Get(machine.Status.InstanceID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get instance %s of machine %s: %w", machine.Status.InstanceID, machine.Name, err))
+			continue
+		}
+		logs[machine.Name] = bootProgress(pvmInstance)
+	}
+	return logs, utilerrors.NewAggregate(errs)
+}
+
+// bootProgress describes the state of an instance and lists its system reference codes,
+// which report the progress of the firmware and the operating system while booting.
+func bootProgress(pvmInstance *models.PVMInstance) []byte {
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "Console output is not available through the PowerVS API, this is the boot progress of the instance.")
+	fmt.Fprintf(out, "Instance: %s (%s)\n", stringValue(pvmInstance.ServerName), stringValue(pvmInstance.PvmInstanceID))
+	fmt.Fprintf(out, "Status: %s\n", stringValue(pvmInstance.Status))
+	if health := pvmInstance.Health; health != nil {
+		fmt.Fprintf(out, "Health: %s %s\n", health.Status, health.Reason)
+	}
+	if fault := pvmInstance.Fault; fault != nil {
+		fmt.Fprintf(out, "Fault: %v %s %s\n", fault.Code, fault.Message, fault.Details)
+	}
+	fmt.Fprintln(out, "System reference codes:")
+	for _, srcs := range pvmInstance.Srcs {
+		for _, src := range srcs {
+			if src != nil {
+				fmt.Fprintf(out, "%s %s\n", src.Timestamp, src.Src)
+			}
+		}
+	}
+	return out.Bytes()
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,300 @@
+package describe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/get"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+)
+
+type ClusterOptions struct {
+	Namespace string
+	Name      string
+}
+
+func NewClusterCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "cluster",
+		Aliases:      []string{"hostedcluster", "hc"},
+		Short:        "Describes a HostedCluster together with its control plane and NodePools",
+		SilenceUsage: true,
+	}
+
+	opts := ClusterOptions{
+		Namespace: "clusters",
+	}
+
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the HostedCluster")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the HostedCluster")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := DescribeCluster(cmd.Context(), c, opts, os.Stdout); err != nil {
+			log.Log.Error(err, "Failed to describe cluster")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// conditionRow is a condition of any of the resources making up a hosted cluster.
+type conditionRow struct {
+	Source             string
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime metav1.Time
+}
+
+// DescribeCluster writes a report of the HostedCluster, its HostedControlPlane, NodePools
+// and failing control plane pods to out.
+func DescribeCluster(ctx context.Context, c crclient.Client, opts ClusterOptions, out io.Writer) error {
+	hcluster := &hyperv1.HostedCluster{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: opts.Namespace, Name: opts.Name}, hcluster); err != nil {
+		return fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name).Name
+
+	hcp := &hyperv1.HostedControlPlane{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: controlPlaneNamespace, Name: hcluster.Name}, hcp); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get hostedcontrolplane: %w", err)
+		}
+		hcp = nil
+	}
+
+	var nodePoolList hyperv1.NodePoolList
+	if err := c.List(ctx, &nodePoolList, crclient.InNamespace(hcluster.Namespace)); err != nil {
+		return fmt.Errorf("failed to list nodepools: %w", err)
+	}
+	var nodePools []hyperv1.NodePool
+	for _, nodePool := range nodePoolList.Items {
+		if nodePool.Spec.ClusterName == hcluster.Name {
+			nodePools = append(nodePools, nodePool)
+		}
+	}
+	sort.Slice(nodePools, func(i, j int) bool { return nodePools[i].Name < nodePools[j].Name })
+
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods, crclient.InNamespace(controlPlaneNamespace)); err != nil {
+		return fmt.Errorf("failed to list control plane pods: %w", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	current, desired := get.NodeCounts(hcluster, nodePools)
+	fmt.Fprintf(w, "Name:\t%s\n", hcluster.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", hcluster.Namespace)
+	fmt.Fprintf(w, "Control Plane Namespace:\t%s\n", controlPlaneNamespace)
+	fmt.Fprintf(w, "Platform:\t%s\n", hcluster.Spec.Platform.Type)
+	fmt.Fprintf(w, "Release:\t%s\n", hcluster.Spec.Release.Image)
+	fmt.Fprintf(w, "Version:\t%s\n", get.ValueOrNone(get.CompletedVersion(hcluster.Status.Version)))
+	fmt.Fprintf(w, "Progress:\t%s\n", get.ValueOrNone(get.Progress(hcluster.Status.Version)))
+	fmt.Fprintf(w, "Endpoint:\t%s\n", get.ValueOrNone(get.Endpoint(hcluster.Status.ControlPlaneEndpoint)))
+	if hcluster.Status.KubeConfig != nil {
+		fmt.Fprintf(w, "KubeConfig:\t%s\n", hcluster.Status.KubeConfig.Name)
+	}
+	fmt.Fprintf(w, "Nodes:\t%d/%d\n", current, desired)
+	fmt.Fprintf(w, "Age:\t%s\n", get.Age(hcluster.CreationTimestamp))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "\nConditions:")
+	conditions := mergeConditions(hcluster, hcp, nodePools)
+	if len(conditions) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	} else {
+		var rows [][]string
+		for _, condition := range conditions {
+			rows = append(rows, []string{condition.Source, condition.Type, condition.Status, get.ValueOrNone(condition.Reason), get.Age(condition.LastTransitionTime), condition.Message})
+		}
+		if err := printIndentedTable(out, []string{"SOURCE", "TYPE", "STATUS", "REASON", "AGE", "MESSAGE"}, rows); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out, "\nVersion History:")
+	if hcluster.Status.Version == nil || len(hcluster.Status.Version.History) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	} else {
+		var rows [][]string
+		for _, entry := range hcluster.Status.Version.History {
+			completed := "<none>"
+			if entry.CompletionTime != nil {
+				completed = entry.CompletionTime.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			rows = append(rows, []string{string(entry.State), get.ValueOrNone(entry.Version), entry.StartedTime.UTC().Format("2006-01-02T15:04:05Z"), completed, entry.Image})
+		}
+		if err := printIndentedTable(out, []string{"STATE", "VERSION", "STARTED", "COMPLETED", "IMAGE"}, rows); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out, "\nNodePools:")
+	if len(nodePools) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	} else {
+		var rows [][]string
+		for _, nodePool := range nodePools {
+			desired := "<none>"
+			if nodePool.Spec.Replicas != nil {
+				desired = fmt.Sprintf("%d", *nodePool.Spec.Replicas)
+			}
+			rows = append(rows, []string{nodePool.Name, desired, fmt.Sprintf("%d", nodePool.Status.Replicas), get.ValueOrNone(nodePool.Status.Version)})
+		}
+		if err := printIndentedTable(out, []string{"NAME", "DESIRED", "CURRENT", "VERSION"}, rows); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out, "\nFailing Control Plane Pods:")
+	failing := failingPods(pods.Items)
+	if len(failing) == 0 {
+		fmt.Fprintln(out, "  <none>")
+		return nil
+	}
+	return printIndentedTable(out, []string{"NAME", "PHASE", "READY", "RESTARTS", "REASON", "MESSAGE"}, failing)
+}
+
+// mergeConditions returns the conditions of the HostedCluster, HostedControlPlane and NodePools.
+// HostedControlPlane conditions that are already reported identically on the HostedCluster are omitted.
+// Conditions that are not True are listed first within each source.
+func mergeConditions(hcluster *hyperv1.HostedCluster, hcp *hyperv1.HostedControlPlane, nodePools []hyperv1.NodePool) []conditionRow {
+	var result []conditionRow
+	appendMetaConditions := func(source string, conditions []metav1.Condition, skip func(metav1.Condition) bool) {
+		var rows []conditionRow
+		for _, condition := range conditions {
+			if skip != nil && skip(condition) {
+				continue
+			}
+			rows = append(rows, conditionRow{
+				Source:             source,
+				Type:               condition.Type,
+				Status:             string(condition.Status),
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+				LastTransitionTime: condition.LastTransitionTime,
+			})
+		}
+		result = append(result, sortConditions(rows)...)
+	}
+
+	appendMetaConditions("HostedCluster", hcluster.Status.Conditions, nil)
+	if hcp != nil {
+		appendMetaConditions("HostedControlPlane", hcp.Status.Conditions, func(condition metav1.Condition) bool {
+			for _, existing := range hcluster.Status.Conditions {
+				if existing.Type == condition.Type && existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	for _, nodePool := range nodePools {
+		var rows []conditionRow
+		for _, condition := range nodePool.Status.Conditions {
+			rows = append(rows, conditionRow{
+				Source:             "NodePool/" + nodePool.Name,
+				Type:               condition.Type,
+				Status:             string(condition.Status),
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+				LastTransitionTime: condition.LastTransitionTime,
+			})
+		}
+		result = append(result, sortConditions(rows)...)
+	}
+	return result
+}
+
+func sortConditions(rows []conditionRow) []conditionRow {
+	sort.SliceStable(rows, func(i, j int) bool {
+		iTrue, jTrue := rows[i].Status == string(metav1.ConditionTrue), rows[j].Status == string(metav1.ConditionTrue)
+		if iTrue != jTrue {
+			return !iTrue
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows
+}
+
+// failingPods returns a row for every pod that is not running with all of its containers ready.
+func failingPods(pods []corev1.Pod) [][]string {
+	var rows [][]string
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		var ready, restarts int
+		reason, message := pod.Status.Reason, pod.Status.Message
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += int(status.RestartCount)
+			if status.Ready {
+				ready++
+				continue
+			}
+			if reason != "" {
+				continue
+			}
+			switch {
+			case status.State.Waiting != nil:
+				reason, message = status.State.Waiting.Reason, status.State.Waiting.Message
+			case status.State.Terminated != nil:
+				reason, message = status.State.Terminated.Reason, status.State.Terminated.Message
+			case status.LastTerminationState.Terminated != nil:
+				reason, message = status.LastTerminationState.Terminated.Reason, status.LastTerminationState.Terminated.Message
+			}
+		}
+		if pod.Status.Phase == corev1.PodRunning && ready == len(pod.Spec.Containers) {
+			continue
+		}
+		if reason == "" {
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+					reason, message = condition.Reason, condition.Message
+				}
+			}
+		}
+		rows = append(rows, []string{
+			pod.Name,
+			string(pod.Status.Phase),
+			fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+			fmt.Sprintf("%d", restarts),
+			get.ValueOrNone(reason),
+			strings.TrimSpace(message),
+		})
+	}
+	return rows
+}
+
+func printIndentedTable(out io.Writer, header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "  "+strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, "  "+strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,80 @@
+package describe
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestDescribeCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	available := metav1.Condition{Type: string(hyperv1.HostedClusterAvailable), Status: metav1.ConditionTrue, Reason: "AsExpected", Message: "available"}
+	objects := []crclient.Object{
+		&hyperv1.HostedCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+			Status: hyperv1.HostedClusterStatus{
+				Conditions: []metav1.Condition{
+					available,
+					{Type: string(hyperv1.EtcdAvailable), Status: metav1.ConditionFalse, Reason: "QuorumLost", Message: "etcd has no quorum"},
+				},
+			},
+		},
+		&hyperv1.HostedControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-example", Name: "example"},
+			Status: hyperv1.HostedControlPlaneStatus{
+				Conditions: []metav1.Condition{
+					available,
+					{Type: "KubeAPIServerAvailable", Status: metav1.ConditionTrue, Reason: "AsExpected", Message: "kas is up"},
+				},
+			},
+		},
+		&hyperv1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-a"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "example"},
+			Status: hyperv1.NodePoolStatus{Conditions: []hyperv1.NodePoolCondition{
+				{Type: hyperv1.NodePoolReadyConditionType, Status: corev1.ConditionFalse, Reason: "WaitingForMachines", Message: "0 of 2 machines ready"},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-example", Name: "etcd-0"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "etcd",
+					RestartCount: 7,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off restarting failed container"}},
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-example", Name: "kube-apiserver-0"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "kube-apiserver"}}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "kube-apiserver", Ready: true}},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(objects...).Build()
+
+	out := &bytes.Buffer{}
+	g.Expect(DescribeCluster(context.Background(), c, ClusterOptions{Namespace: "clusters", Name: "example"}, out)).To(Succeed())
+	report := out.String()
+	g.Expect(report).To(ContainSubstring("Control Plane Namespace:  clusters-example"))
+	g.Expect(report).To(MatchRegexp(`HostedCluster\s+EtcdAvailable\s+False\s+QuorumLost`))
+	g.Expect(report).To(MatchRegexp(`HostedControlPlane\s+KubeAPIServerAvailable\s+True`))
+	g.Expect(report).ToNot(MatchRegexp(`HostedControlPlane\s+Available`))
+	g.Expect(report).To(MatchRegexp(`NodePool/example-a\s+Ready\s+False\s+WaitingForMachines`))
+	g.Expect(report).To(MatchRegexp(`etcd-0\s+Running\s+0/1\s+7\s+CrashLoopBackOff`))
+	g.Expect(report).ToNot(ContainSubstring("kube-apiserver-0"))
+}
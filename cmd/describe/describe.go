@@ -0,0 +1,17 @@
+package describe
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "describe",
+		Short:        "Commands for describing HyperShift resources in detail",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewClusterCommand())
+
+	return cmd
+}
@@ -0,0 +1,172 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+func NewClustersCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "clusters",
+		Aliases:      []string{"cluster", "hostedclusters", "hc"},
+		Short:        "Displays HostedClusters",
+		SilenceUsage: true,
+	}
+
+	opts := defaultOptions()
+	bindOptions(cmd, &opts)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := GetClusters(cmd.Context(), c, opts, os.Stdout); err != nil {
+			log.Log.Error(err, "Failed to get clusters")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// GetClusters writes the HostedClusters selected by opts to out.
+func GetClusters(ctx context.Context, c crclient.Client, opts Options, out io.Writer) error {
+	var clusters hyperv1.HostedClusterList
+	if err := c.List(ctx, &clusters, crclient.InNamespace(opts.listNamespace())); err != nil {
+		return fmt.Errorf("failed to list hostedclusters: %w", err)
+	}
+	if opts.Name != "" {
+		var filtered []hyperv1.HostedCluster
+		for _, cluster := range clusters.Items {
+			if cluster.Name == opts.Name {
+				filtered = append(filtered, cluster)
+			}
+		}
+		if len(filtered) == 0 {
+			return fmt.Errorf("hostedcluster %s/%s not found", opts.Namespace, opts.Name)
+		}
+		clusters.Items = filtered
+	}
+
+	if opts.Output == OutputJSON || opts.Output == OutputYAML {
+		clusters.SetGroupVersionKind(hyperv1.GroupVersion.WithKind("HostedClusterList"))
+		for i := range clusters.Items {
+			clusters.Items[i].SetGroupVersionKind(hyperv1.GroupVersion.WithKind("HostedCluster"))
+		}
+		return printObject(out, opts.Output, &clusters)
+	}
+
+	var nodePools hyperv1.NodePoolList
+	if err := c.List(ctx, &nodePools, crclient.InNamespace(opts.listNamespace())); err != nil {
+		return fmt.Errorf("failed to list nodepools: %w", err)
+	}
+
+	header := []string{"NAME", "VERSION", "PROGRESS", "AVAILABLE", "NODES", "AGE"}
+	if opts.Output == OutputWide {
+		header = append(header, "PLATFORM", "ENDPOINT", "MESSAGE")
+	}
+	if opts.AllNamespaces {
+		header = append([]string{"NAMESPACE"}, header...)
+	}
+	var rows [][]string
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		current, desired := NodeCounts(cluster, nodePools.Items)
+		available := meta.FindStatusCondition(cluster.Status.Conditions, string(hyperv1.HostedClusterAvailable))
+		row := []string{
+			cluster.Name,
+			ValueOrNone(CompletedVersion(cluster.Status.Version)),
+			ValueOrNone(Progress(cluster.Status.Version)),
+			conditionStatus(available),
+			fmt.Sprintf("%d/%d", current, desired),
+			Age(cluster.CreationTimestamp),
+		}
+		if opts.Output == OutputWide {
+			message := ""
+			if available != nil {
+				message = available.Message
+			}
+			row = append(row, string(cluster.Spec.Platform.Type), ValueOrNone(Endpoint(cluster.Status.ControlPlaneEndpoint)), ValueOrNone(message))
+		}
+		if opts.AllNamespaces {
+			row = append([]string{cluster.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No hostedclusters found")
+		return nil
+	}
+	return PrintTable(out, header, rows)
+}
+
+// CompletedVersion returns the most recent version that completed rolling out.
+func CompletedVersion(version *hyperv1.ClusterVersionStatus) string {
+	if version == nil {
+		return ""
+	}
+	for _, entry := range version.History {
+		if entry.State == configv1.CompletedUpdate {
+			return entry.Version
+		}
+	}
+	return ""
+}
+
+// Progress returns the state of the most recent version rollout.
+func Progress(version *hyperv1.ClusterVersionStatus) string {
+	if version == nil || len(version.History) == 0 {
+		return ""
+	}
+	return string(version.History[0].State)
+}
+
+// Endpoint formats the API endpoint as host:port.
+func Endpoint(endpoint hyperv1.APIEndpoint) string {
+	if endpoint.Host == "" {
+		return ""
+	}
+	return endpoint.Host + ":" + strconv.Itoa(int(endpoint.Port))
+}
+
+// NodeCounts returns the current and desired number of nodes across the NodePools of the cluster.
+// Autoscaled NodePools contribute their current size to the desired count.
+func NodeCounts(cluster *hyperv1.HostedCluster, nodePools []hyperv1.NodePool) (current, desired int32) {
+	for _, nodePool := range nodePools {
+		if nodePool.Namespace != cluster.Namespace || nodePool.Spec.ClusterName != cluster.Name {
+			continue
+		}
+		current += nodePool.Status.Replicas
+		if nodePool.Spec.Replicas != nil {
+			desired += *nodePool.Spec.Replicas
+		} else {
+			desired += nodePool.Status.Replicas
+		}
+	}
+	return current, desired
+}
+
+func conditionStatus(condition *metav1.Condition) string {
+	if condition == nil {
+		return "Unknown"
+	}
+	return string(condition.Status)
+}
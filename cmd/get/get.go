@@ -0,0 +1,114 @@
+package get
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	hyperapi "github.com/openshift/hypershift/api"
+)
+
+const (
+	OutputTable = "table"
+	OutputWide  = "wide"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+type Options struct {
+	Namespace     string
+	Name          string
+	AllNamespaces bool
+	Output        string
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "get",
+		Short:        "Commands for displaying HyperShift resources",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewClustersCommand())
+	cmd.AddCommand(NewNodePoolsCommand())
+
+	return cmd
+}
+
+func bindOptions(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the resources")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "Only display the resource with this name")
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces, "Display resources in all namespaces")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "Output format, one of: table, wide, json, yaml")
+}
+
+func defaultOptions() Options {
+	return Options{
+		Namespace: "clusters",
+		Output:    OutputTable,
+	}
+}
+
+func (o *Options) Validate() error {
+	switch o.Output {
+	case OutputTable, OutputWide, OutputJSON, OutputYAML:
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: table, wide, json, yaml", o.Output)
+	}
+	if o.AllNamespaces && o.Name != "" {
+		return fmt.Errorf("--name cannot be used with --all-namespaces")
+	}
+	return nil
+}
+
+func (o *Options) listNamespace() string {
+	if o.AllNamespaces {
+		return ""
+	}
+	return o.Namespace
+}
+
+// printObject writes obj in the requested structured output format.
+func printObject(out io.Writer, format string, obj runtime.Object) error {
+	switch format {
+	case OutputJSON:
+		return hyperapi.JsonSerializer.Encode(obj, out)
+	case OutputYAML:
+		return hyperapi.YamlSerializer.Encode(obj, out)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// PrintTable writes the header and rows as aligned columns.
+func PrintTable(out io.Writer, header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// ValueOrNone returns "<none>" for empty cells so that columns stay aligned.
+func ValueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// Age returns the human readable age of t, as displayed by kubectl.
+func Age(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t.Time))
+}
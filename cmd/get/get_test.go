@@ -0,0 +1,124 @@
+package get
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestGetClusters(t *testing.T) {
+	cluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+		Spec: hyperv1.HostedClusterSpec{
+			Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform},
+		},
+		Status: hyperv1.HostedClusterStatus{
+			Version: &hyperv1.ClusterVersionStatus{
+				History: []configv1.UpdateHistory{
+					{State: configv1.PartialUpdate, Version: "4.12.1"},
+					{State: configv1.CompletedUpdate, Version: "4.12.0"},
+				},
+			},
+			ControlPlaneEndpoint: hyperv1.APIEndpoint{Host: "api.example.com", Port: 6443},
+			Conditions: []metav1.Condition{
+				{Type: string(hyperv1.HostedClusterAvailable), Status: metav1.ConditionTrue, Message: "The hosted control plane is available"},
+			},
+		},
+	}
+	nodePools := []*hyperv1.NodePool{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-a"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "example", Replicas: pointer.Int32(3)},
+			Status:     hyperv1.NodePoolStatus{Replicas: 2},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-b"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "example", AutoScaling: &hyperv1.NodePoolAutoScaling{Min: 1, Max: 5}},
+			Status:     hyperv1.NodePoolStatus{Replicas: 2},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "other", Replicas: pointer.Int32(10)},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(cluster, nodePools[0], nodePools[1], nodePools[2]).Build()
+
+	testCases := []struct {
+		name     string
+		output   string
+		contains []string
+	}{
+		{
+			name:     "When output is table it should print version, progress, availability and node counts",
+			output:   OutputTable,
+			contains: []string{"NAME", "example", "4.12.0", "Partial", "True", "4/5"},
+		},
+		{
+			name:     "When output is wide it should also print platform, endpoint and message",
+			output:   OutputWide,
+			contains: []string{"AWS", "api.example.com:6443", "The hosted control plane is available"},
+		},
+		{
+			name:     "When output is yaml it should print the HostedCluster list",
+			output:   OutputYAML,
+			contains: []string{"kind: HostedClusterList", "kind: HostedCluster\n", "name: example"},
+		},
+		{
+			name:     "When output is json it should print the HostedCluster list",
+			output:   OutputJSON,
+			contains: []string{`"kind": "HostedClusterList"`, `"name": "example"`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			opts := defaultOptions()
+			opts.Output = tc.output
+			g.Expect(opts.Validate()).To(Succeed())
+			out := &bytes.Buffer{}
+			g.Expect(GetClusters(context.Background(), c, opts, out)).To(Succeed())
+			for _, s := range tc.contains {
+				g.Expect(out.String()).To(ContainSubstring(s))
+			}
+		})
+	}
+}
+
+func TestGetNodePools(t *testing.T) {
+	g := NewGomegaWithT(t)
+	nodePools := []*hyperv1.NodePool{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-a"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "example", Replicas: pointer.Int32(3)},
+			Status: hyperv1.NodePoolStatus{Replicas: 2, Version: "4.12.0", Conditions: []hyperv1.NodePoolCondition{
+				{Type: hyperv1.NodePoolReadyConditionType, Status: "True"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "other", AutoScaling: &hyperv1.NodePoolAutoScaling{Min: 1, Max: 5}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(nodePools[0], nodePools[1]).Build()
+
+	opts := NodePoolsOptions{Options: defaultOptions(), ClusterName: "example"}
+	out := &bytes.Buffer{}
+	g.Expect(GetNodePools(context.Background(), c, opts, out)).To(Succeed())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(lines).To(HaveLen(2))
+	g.Expect(strings.Fields(lines[1])).To(Equal([]string{"example-a", "example", "3", "2", "<none>", "4.12.0", "True", "<unknown>"}))
+
+	opts = NodePoolsOptions{Options: defaultOptions()}
+	opts.Name = "missing"
+	g.Expect(GetNodePools(context.Background(), c, opts, &bytes.Buffer{})).ToNot(Succeed())
+}
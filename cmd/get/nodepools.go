@@ -0,0 +1,147 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type NodePoolsOptions struct {
+	Options
+	ClusterName string
+}
+
+func NewNodePoolsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "nodepools",
+		Aliases:      []string{"nodepool", "np"},
+		Short:        "Displays NodePools",
+		SilenceUsage: true,
+	}
+
+	opts := NodePoolsOptions{Options: defaultOptions()}
+	bindOptions(cmd, &opts.Options)
+	cmd.Flags().StringVar(&opts.ClusterName, "cluster-name", opts.ClusterName, "Only display NodePools of this HostedCluster")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := GetNodePools(cmd.Context(), c, opts, os.Stdout); err != nil {
+			log.Log.Error(err, "Failed to get nodepools")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// GetNodePools writes the NodePools selected by opts to out.
+func GetNodePools(ctx context.Context, c crclient.Client, opts NodePoolsOptions, out io.Writer) error {
+	var nodePools hyperv1.NodePoolList
+	if err := c.List(ctx, &nodePools, crclient.InNamespace(opts.listNamespace())); err != nil {
+		return fmt.Errorf("failed to list nodepools: %w", err)
+	}
+	var filtered []hyperv1.NodePool
+	for _, nodePool := range nodePools.Items {
+		if opts.Name != "" && nodePool.Name != opts.Name {
+			continue
+		}
+		if opts.ClusterName != "" && nodePool.Spec.ClusterName != opts.ClusterName {
+			continue
+		}
+		filtered = append(filtered, nodePool)
+	}
+	if opts.Name != "" && len(filtered) == 0 {
+		return fmt.Errorf("nodepool %s/%s not found", opts.Namespace, opts.Name)
+	}
+	nodePools.Items = filtered
+
+	if opts.Output == OutputJSON || opts.Output == OutputYAML {
+		nodePools.SetGroupVersionKind(hyperv1.GroupVersion.WithKind("NodePoolList"))
+		for i := range nodePools.Items {
+			nodePools.Items[i].SetGroupVersionKind(hyperv1.GroupVersion.WithKind("NodePool"))
+		}
+		return printObject(out, opts.Output, &nodePools)
+	}
+
+	header := []string{"NAME", "CLUSTER", "DESIRED", "CURRENT", "AUTOSCALING", "VERSION", "READY", "AGE"}
+	if opts.Output == OutputWide {
+		header = append(header, "PLATFORM", "UPDATINGVERSION", "UPDATINGCONFIG", "MESSAGE")
+	}
+	if opts.AllNamespaces {
+		header = append([]string{"NAMESPACE"}, header...)
+	}
+	var rows [][]string
+	for _, nodePool := range nodePools.Items {
+		desired := "<none>"
+		if nodePool.Spec.Replicas != nil {
+			desired = fmt.Sprintf("%d", *nodePool.Spec.Replicas)
+		}
+		autoscaling := "<none>"
+		if nodePool.Spec.AutoScaling != nil {
+			autoscaling = fmt.Sprintf("%d-%d", nodePool.Spec.AutoScaling.Min, nodePool.Spec.AutoScaling.Max)
+		}
+		ready := findNodePoolCondition(nodePool.Status.Conditions, hyperv1.NodePoolReadyConditionType)
+		row := []string{
+			nodePool.Name,
+			nodePool.Spec.ClusterName,
+			desired,
+			fmt.Sprintf("%d", nodePool.Status.Replicas),
+			autoscaling,
+			ValueOrNone(nodePool.Status.Version),
+			nodePoolConditionStatus(ready),
+			Age(nodePool.CreationTimestamp),
+		}
+		if opts.Output == OutputWide {
+			message := ""
+			if ready != nil {
+				message = ready.Message
+			}
+			row = append(row,
+				string(nodePool.Spec.Platform.Type),
+				nodePoolConditionStatus(findNodePoolCondition(nodePool.Status.Conditions, hyperv1.NodePoolUpdatingVersionConditionType)),
+				nodePoolConditionStatus(findNodePoolCondition(nodePool.Status.Conditions, hyperv1.NodePoolUpdatingConfigConditionType)),
+				ValueOrNone(message),
+			)
+		}
+		if opts.AllNamespaces {
+			row = append([]string{nodePool.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No nodepools found")
+		return nil
+	}
+	return PrintTable(out, header, rows)
+}
+
+func findNodePoolCondition(conditions []hyperv1.NodePoolCondition, conditionType string) *hyperv1.NodePoolCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func nodePoolConditionStatus(condition *hyperv1.NodePoolCondition) string {
+	if condition == nil {
+		return "Unknown"
+	}
+	return string(condition.Status)
+}
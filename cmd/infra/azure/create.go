@@ -80,7 +80,8 @@ func NewCreateCommand() *cobra.Command {
 	return cmd
 }
 
-func readCredentials(path string) (*apifixtures.AzureCreds, error) {
+// ReadCredentials reads Azure service principal credentials from a YAML file.
+func ReadCredentials(path string) (*apifixtures.AzureCreds, error) {
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from %s: %w", path, err)
@@ -117,7 +118,7 @@ func (o *CreateInfraOptions) Run(ctx context.Context, l logr.Logger) (*CreateInf
 	creds := o.Credentials
 	if creds == nil {
 		var err error
-		creds, err = readCredentials(o.CredentialsFile)
+		creds, err = ReadCredentials(o.CredentialsFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read the credentials: %w", err)
 		}
@@ -59,7 +59,7 @@ func (o *DestroyInfraOptions) Run(ctx context.Context) error {
 	creds := o.Credentials
 	if creds == nil {
 		var err error
-		creds, err = readCredentials(o.CredentialsFile)
+		creds, err = ReadCredentials(o.CredentialsFile)
 		if err != nil {
 			return fmt.Errorf("failed to read the credentials: %w", err)
 		}
@@ -350,11 +350,11 @@ func (infra *Infra) SetupInfra(ctx context.Context, options *CreateInfraOptions)
 		return fmt.Errorf("error setup vpc subnet: %w", err)
 	}
 
-	session, err := createPowerVSSession(infra.AccountID, options.Region, options.Zone, options.Debug)
-	infra.AccountID = session.Options.UserAccount
+	session, err := CreatePowerVSSession(infra.AccountID, options.Region, options.Zone, options.Debug)
 	if err != nil {
 		return fmt.Errorf("error creating powervs session: %w", err)
 	}
+	infra.AccountID = session.Options.UserAccount
 
 	if err = infra.setupPowerVSCloudInstance(ctx, options); err != nil {
 		return fmt.Errorf("error setup powervs cloud instance: %w", err)
@@ -744,8 +744,19 @@ func getAccount(ctx context.Context, auth core.Authenticator) (string, error) {
 	return *apiKey.AccountID, nil
 }
 
-// createPowerVSSession creates PowerVSSession of type *ibmpisession.IBMPISession
-func createPowerVSSession(accountID string, powerVSRegion string, powerVSZone string, debug bool) (*ibmpisession.IBMPISession, error) {
+// CreatePowerVSSession creates PowerVSSession of type *ibmpisession.IBMPISession.
+// The API key is read with GetAPIKey unless infra setup or destroy read it before.
+func CreatePowerVSSession(accountID string, powerVSRegion string, powerVSZone string, debug bool) (*ibmpisession.IBMPISession, error) {
+	if cloudApiKey == "" {
+		apiKey, err := GetAPIKey()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving IBM Cloud API Key: %w", err)
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("cloud API Key not set. Set it with IBMCLOUD_API_KEY env var or set file path containing API Key credential in IBMCLOUD_CREDENTIALS")
+		}
+		cloudApiKey = apiKey
+	}
 	auth := getIAMAuth()
 
 	opt := &ibmpisession.IBMPIOptions{Authenticator: auth,
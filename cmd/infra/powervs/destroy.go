@@ -200,7 +200,7 @@ func (options *DestroyInfraOptions) DestroyInfra(ctx context.Context, infra *Inf
 
 	var session *ibmpisession.IBMPISession
 	if !skipPowerVs {
-		session, err = createPowerVSSession(accountID, options.Region, options.Zone, options.Debug)
+		session, err = CreatePowerVSSession(accountID, options.Region, options.Zone, options.Debug)
 		if err != nil {
 			return err
 		}
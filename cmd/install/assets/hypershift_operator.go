@@ -2,6 +2,7 @@ package assets
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
@@ -281,6 +282,10 @@ type HyperShiftOperatorDeployment struct {
 	MetricsSet                     metrics.MetricsSet
 	IncludeVersion                 bool
 	UWMTelemetry                   bool
+	ControlPlaneSizing             bool
+	ReleaseMetadataCache           bool
+	ReleaseSignatureKeysConfigMap  string
+	ReleaseSignatureStores         []string
 	RHOBSMonitoring                bool
 }
 
@@ -317,6 +322,22 @@ func (o HyperShiftOperatorDeployment) Build() *appsv1.Deployment {
 		metrics.MetricsSetToEnv(o.MetricsSet),
 	}
 
+	if o.ReleaseMetadataCache {
+		// Persist release metadata across container restarts, so releases are not
+		// pulled from the registry again.
+		args = append(args, "--release-metadata-cache-dir=/var/cache/release-metadata")
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "release-metadata-cache",
+			MountPath: "/var/cache/release-metadata",
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "release-metadata-cache",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
 	if o.EnableWebhook {
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "serving-cert",
@@ -360,6 +381,29 @@ func (o HyperShiftOperatorDeployment) Build() *appsv1.Deployment {
 		args = append(args, "--enable-uwm-telemetry-remote-write")
 	}
 
+	if o.ControlPlaneSizing {
+		args = append(args, "--enable-control-plane-sizing")
+	}
+
+	if len(o.ReleaseSignatureKeysConfigMap) > 0 {
+		args = append(args,
+			"--release-signature-keys-dir=/etc/release-signature-keys",
+			"--release-signature-stores="+strings.Join(o.ReleaseSignatureStores, ","),
+		)
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "release-signature-keys",
+			MountPath: "/etc/release-signature-keys",
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "release-signature-keys",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: o.ReleaseSignatureKeysConfigMap},
+				},
+			},
+		})
+	}
+
 	image := o.OperatorImage
 
 	if mapImage, ok := o.Images["hypershift-operator"]; ok {
@@ -883,6 +927,11 @@ func (o HyperShiftOperatorClusterRole) Build() *rbacv1.ClusterRole {
 				Resources: []string{"endpointslices"},
 				Verbs:     []string{"list", "watch"},
 			},
+			{ // This allows the preflight checks to verify the storage class of etcd volumes
+				APIGroups: []string{"storage.k8s.io"},
+				Resources: []string{"storageclasses"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
 		},
 	}
 	return role
@@ -47,6 +47,48 @@ func TestHyperShiftOperatorDeployment_Build(t *testing.T) {
 				fmt.Sprintf("--private-platform=%s", string(hyperv1.NonePlatform)),
 			},
 		},
+		"release metadata cache mounts a cache volume": {
+			inputBuildParameters: HyperShiftOperatorDeployment{
+				Namespace: &corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: testNamespace,
+					},
+				},
+				OperatorImage: testOperatorImage,
+				ServiceAccount: &corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "hypershift",
+					},
+				},
+				Replicas:             3,
+				PrivatePlatform:      string(hyperv1.NonePlatform),
+				ReleaseMetadataCache: true,
+			},
+			expectedVolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "release-metadata-cache",
+					MountPath: "/var/cache/release-metadata",
+				},
+			},
+			expectedVolumes: []corev1.Volume{
+				{
+					Name: "release-metadata-cache",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+			expectedArgs: []string{
+				"run",
+				"--namespace=$(MY_NAMESPACE)",
+				"--pod-name=$(MY_NAME)",
+				"--metrics-addr=:9000",
+				fmt.Sprintf("--enable-ocp-cluster-monitoring=%t", false),
+				fmt.Sprintf("--enable-ci-debug-output=%t", false),
+				fmt.Sprintf("--private-platform=%s", string(hyperv1.NonePlatform)),
+				"--release-metadata-cache-dir=/var/cache/release-metadata",
+			},
+		},
 		"additional-trust-bundle parameter mounts ca bundle volume": {
 			inputBuildParameters: HyperShiftOperatorDeployment{
 				Namespace: &corev1.Namespace{
@@ -58,6 +58,7 @@ type Options struct {
 	EnableConversionWebhook                   bool
 	Template                                  bool
 	Format                                    string
+	OutputDir                                 string
 	ExcludeEtcdManifests                      bool
 	PlatformMonitoring                        metrics.PlatformMonitoring
 	EnableCIDebugOutput                       bool
@@ -78,9 +79,16 @@ type Options struct {
 	ExternalDNSTxtOwnerId                     string
 	EnableAdminRBACGeneration                 bool
 	EnableUWMTelemetryRemoteWrite             bool
+	EnableControlPlaneSizing                  bool
+	EnableReleaseMetadataCache                bool
+	ReleaseSignatureKeysConfigMap             string
+	ReleaseSignatureStores                    []string
 	MetricsSet                                metrics.MetricsSet
 	WaitUntilAvailable                        bool
 	RHOBSMonitoring                           bool
+	DryRun                                    bool
+	Diff                                      bool
+	Prune                                     bool
 }
 
 func (o *Options) Validate() error {
@@ -107,6 +115,10 @@ func (o *Options) Validate() error {
 		errs = append(errs, fmt.Errorf("oidc bucket name must not contain dots (.); see the notes on HTTPS at https://docs.aws.amazon.com/AmazonS3/latest/userguide/bucketnamingrules.html"))
 	}
 
+	if len(o.ReleaseSignatureKeysConfigMap) > 0 && len(o.ReleaseSignatureStores) == 0 {
+		errs = append(errs, fmt.Errorf("--release-signature-stores is required with --release-signature-keys-configmap"))
+	}
+
 	if len(o.ExternalDNSProvider) > 0 {
 		if len(o.ExternalDNSCredentials) == 0 && len(o.ExternalDNSCredentialsSecret) == 0 {
 			errs = append(errs, fmt.Errorf("--external-dns-credentials or --external-dns-credentials-secret are required with --external-dns-provider"))
@@ -121,6 +133,9 @@ func (o *Options) Validate() error {
 	if o.HyperShiftImage != version.HyperShiftImage && len(o.ImageRefsFile) > 0 {
 		errs = append(errs, fmt.Errorf("only one of --hypershift-image or --image-refs-file should be specified"))
 	}
+	if o.Diff && !o.DryRun {
+		errs = append(errs, fmt.Errorf("--diff is only supported with --dry-run"))
+	}
 	if o.RHOBSMonitoring && os.Getenv(rhobsmonitoring.EnvironmentVariable) != "1" {
 		errs = append(errs, fmt.Errorf("when invoking this command with the --rhobs-monitoring flag, the RHOBS_MONITORING environment variable must be set to \"1\""))
 	}
@@ -154,6 +169,7 @@ func NewCommand() *cobra.Command {
 	opts.PrivatePlatform = string(hyperv1.NonePlatform)
 	opts.MetricsSet = metrics.DefaultMetricsSet
 	opts.EnableConversionWebhook = true // default to enabling the conversion webhook
+	opts.EnableReleaseMetadataCache = true
 
 	cmd.PersistentFlags().StringVar(&opts.Namespace, "namespace", "hypershift", "The namespace in which to install HyperShift")
 	cmd.PersistentFlags().StringVar(&opts.HyperShiftImage, "hypershift-image", version.HyperShiftImage, "The HyperShift image to deploy")
@@ -182,9 +198,16 @@ func NewCommand() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&opts.ImageRefsFile, "image-refs", opts.ImageRefsFile, "Image references to user in Hypershift installation")
 	cmd.PersistentFlags().StringVar(&opts.AdditionalTrustBundle, "additional-trust-bundle", opts.AdditionalTrustBundle, "Path to a file with user CA bundle")
 	cmd.PersistentFlags().Var(&opts.MetricsSet, "metrics-set", "The set of metrics to produce for each HyperShift control plane. Valid values are: Telemetry, SRE, All")
+	cmd.PersistentFlags().BoolVar(&opts.EnableControlPlaneSizing, "enable-control-plane-sizing", opts.EnableControlPlaneSizing, "If true, HyperShift operator sizes the API servers and etcd of control planes based on the load of their guest cluster")
+	cmd.PersistentFlags().BoolVar(&opts.EnableReleaseMetadataCache, "enable-release-metadata-cache", opts.EnableReleaseMetadataCache, "If true, HyperShift operator persists the metadata of release images in a volume, so it is not pulled from the registry again after container restarts")
+	cmd.PersistentFlags().StringVar(&opts.ReleaseSignatureKeysConfigMap, "release-signature-keys-configmap", opts.ReleaseSignatureKeysConfigMap, "Name of an existing ConfigMap in the operator namespace with the GPG keyrings and cosign public keys trusted to sign release images. If set, HyperShift operator only rolls out HostedClusters to signed release images")
+	cmd.PersistentFlags().StringSliceVar(&opts.ReleaseSignatureStores, "release-signature-stores", opts.ReleaseSignatureStores, "Locations of the release image signatures, either http(s) or file URLs or directories in the operator pod. Required with --release-signature-keys-configmap")
 	cmd.PersistentFlags().BoolVar(&opts.EnableUWMTelemetryRemoteWrite, "enable-uwm-telemetry-remote-write", opts.EnableUWMTelemetryRemoteWrite, "If true, HyperShift operator ensures user workload monitoring is enabled and that it is configured to remote write telemetry metrics from control planes")
 	cmd.Flags().BoolVar(&opts.WaitUntilAvailable, "wait-until-available", opts.WaitUntilAvailable, "If true, pauses installation until hypershift operator has been rolled out and its webhook service is available (if installing the webhook)")
 	cmd.PersistentFlags().BoolVar(&opts.RHOBSMonitoring, "rhobs-monitoring", opts.RHOBSMonitoring, "If true, HyperShift will generate and use the RHOBS version of monitoring resources (ServiceMonitors, PodMonitors, etc)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "If true, only report which objects would be created, updated or pruned without changing the cluster")
+	cmd.Flags().BoolVar(&opts.Diff, "diff", opts.Diff, "If true, print the changes the install would make to each existing object. Requires --dry-run")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", opts.Prune, "If true, delete objects created by a previous install that are no longer rendered. Only objects with the "+ManagedByInstallLabel+" label are pruned, which installs before it was introduced did not set")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		opts.ApplyDefaults()
@@ -198,11 +221,35 @@ func NewCommand() *cobra.Command {
 			return err
 		}
 
+		if opts.DryRun {
+			client, err := util.GetClient()
+			if err != nil {
+				return err
+			}
+			if err := dryRunApply(cmd.Context(), client, objects, opts.Diff, cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			if opts.Prune {
+				return prune(cmd.Context(), client, objects, true, cmd.OutOrStdout())
+			}
+			return nil
+		}
+
 		err = apply(cmd.Context(), objects)
 		if err != nil {
 			return err
 		}
 
+		if opts.Prune {
+			client, err := util.GetClient()
+			if err != nil {
+				return err
+			}
+			if err := prune(cmd.Context(), client, objects, false, cmd.OutOrStdout()); err != nil {
+				return err
+			}
+		}
+
 		if opts.WaitUntilAvailable {
 			if err := waitUntilAvailable(cmd.Context(), opts); err != nil {
 				return err
@@ -546,6 +593,10 @@ func hyperShiftOperatorManifests(opts Options) ([]crclient.Object, error) {
 		MetricsSet:                     opts.MetricsSet,
 		IncludeVersion:                 !opts.Template,
 		UWMTelemetry:                   opts.EnableUWMTelemetryRemoteWrite,
+		ControlPlaneSizing:             opts.EnableControlPlaneSizing,
+		ReleaseMetadataCache:           opts.EnableReleaseMetadataCache,
+		ReleaseSignatureKeysConfigMap:  opts.ReleaseSignatureKeysConfigMap,
+		ReleaseSignatureStores:         opts.ReleaseSignatureStores,
 		RHOBSMonitoring:                opts.RHOBSMonitoring,
 	}.Build()
 	objects = append(objects, operatorDeployment)
@@ -658,6 +709,14 @@ func hyperShiftOperatorManifests(opts Options) ([]crclient.Object, error) {
 		objects[idx].(interface {
 			SetGroupVersionKind(gvk schema.GroupVersionKind)
 		}).SetGroupVersionKind(gvk)
+
+		// The label allows --prune to find objects that a later install no longer renders
+		labels := objects[idx].GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ManagedByInstallLabel] = "true"
+		objects[idx].SetLabels(labels)
 	}
 
 	return objects, nil
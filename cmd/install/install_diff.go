@@ -0,0 +1,210 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperapi "github.com/openshift/hypershift/api"
+)
+
+// ManagedByInstallLabel is set on every object rendered by the install command. Objects
+// created by installs that did not set it yet are not found by --prune, only by uninstall,
+// which also removes every object the current install renders by name.
+const ManagedByInstallLabel = "hypershift.openshift.io/managed-by-install"
+
+// prunableKinds are the kinds that install renders or rendered in the past. They are
+// searched for labeled objects in addition to the kinds of the current manifests so
+// that a kind that is no longer rendered at all, e.g. the validating webhook, is pruned.
+// Namespaces and CustomResourceDefinitions are never pruned, as deleting them deletes
+// everything they contain; uninstall removes them instead.
+var prunableKinds = []schema.GroupVersionKind{
+	{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "", Version: "v1", Kind: "ConfigMap"},
+	{Group: "", Version: "v1", Kind: "Secret"},
+	{Group: "", Version: "v1", Kind: "Service"},
+	{Group: "", Version: "v1", Kind: "ServiceAccount"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+	{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+	{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"},
+	{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"},
+	{Group: "monitoring.rhobs", Version: "v1", Kind: "ServiceMonitor"},
+	{Group: "monitoring.rhobs", Version: "v1", Kind: "PrometheusRule"},
+}
+
+// dryRunApply reports for every object whether applying it would create, update or leave it
+// unchanged. Updates are computed with a server-side dry-run apply, so defaulting and field
+// ownership are the same as for a real install. If showDiff is set, the changes are printed.
+func dryRunApply(ctx context.Context, c crclient.Client, objects []crclient.Object, showDiff bool, out io.Writer) error {
+	for _, object := range objects {
+		desired, err := toUnstructured(object)
+		if err != nil {
+			return err
+		}
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(desired.GroupVersionKind())
+		if err := c.Get(ctx, crclient.ObjectKeyFromObject(desired), live); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				fmt.Fprintf(out, "would create %s\n", objectRef(desired))
+				continue
+			}
+			return fmt.Errorf("failed to get %s: %w", objectRef(desired), err)
+		}
+		if desired.GetKind() == "PriorityClass" {
+			// PriorityClasses are only created, see apply
+			fmt.Fprintf(out, "unchanged %s\n", objectRef(desired))
+			continue
+		}
+
+		var objectBytes bytes.Buffer
+		if err := hyperapi.YamlSerializer.Encode(object, &objectBytes); err != nil {
+			return err
+		}
+		merged := desired.DeepCopy()
+		if err := c.Patch(ctx, merged, crclient.RawPatch(types.ApplyPatchType, objectBytes.Bytes()), crclient.ForceOwnership, crclient.FieldOwner("hypershift"), crclient.DryRunAll); err != nil {
+			return fmt.Errorf("failed to dry-run apply %s: %w", objectRef(desired), err)
+		}
+		diff := objectDiff(live, merged)
+		if diff == "" {
+			fmt.Fprintf(out, "unchanged %s\n", objectRef(desired))
+			continue
+		}
+		fmt.Fprintf(out, "would update %s\n", objectRef(desired))
+		if showDiff {
+			fmt.Fprintln(out, diff)
+		}
+	}
+	return nil
+}
+
+// objectDiff returns the differences between live and desired, ignoring fields that are
+// maintained by the server. Secret values are replaced by their checksum so that the diff
+// shows which keys change without printing credentials.
+func objectDiff(live, desired *unstructured.Unstructured) string {
+	return cmp.Diff(normalizeForDiff(live), normalizeForDiff(desired))
+}
+
+func normalizeForDiff(obj *unstructured.Unstructured) map[string]interface{} {
+	normalized := obj.DeepCopy()
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(normalized.Object, "status")
+	if normalized.GetKind() == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			values, found, _ := unstructured.NestedMap(normalized.Object, field)
+			if !found {
+				continue
+			}
+			for key, value := range values {
+				sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+				values[key] = "sha256:" + hex.EncodeToString(sum[:])[:12]
+			}
+			unstructured.SetNestedMap(normalized.Object, values, field)
+		}
+	}
+	return normalized.Object
+}
+
+// prune deletes all objects labeled by a previous install that are not part of objects.
+// If dryRun is set, the objects are only reported.
+func prune(ctx context.Context, c crclient.Client, objects []crclient.Object, dryRun bool, out io.Writer) error {
+	candidates, err := pruneCandidates(ctx, c, objects)
+	if err != nil {
+		return err
+	}
+	for _, obj := range candidates {
+		if dryRun {
+			fmt.Fprintf(out, "would prune %s\n", objectRef(obj))
+			continue
+		}
+		if err := c.Delete(ctx, obj, crclient.PropagationPolicy("Background")); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to prune %s: %w", objectRef(obj), err)
+		}
+		fmt.Fprintf(out, "pruned %s\n", objectRef(obj))
+	}
+	return nil
+}
+
+// pruneCandidates returns the objects labeled with ManagedByInstallLabel that are not part of objects.
+func pruneCandidates(ctx context.Context, c crclient.Client, objects []crclient.Object) ([]*unstructured.Unstructured, error) {
+	rendered := map[string]bool{}
+	kinds := append([]schema.GroupVersionKind{}, prunableKinds...)
+	for _, object := range objects {
+		gvk := object.GetObjectKind().GroupVersionKind()
+		rendered[objectKey(gvk.GroupKind(), object.GetNamespace(), object.GetName())] = true
+		if gvk.Kind == "Namespace" || gvk.Kind == "CustomResourceDefinition" || containsGroupKind(kinds, gvk.GroupKind()) {
+			continue
+		}
+		kinds = append(kinds, gvk)
+	}
+
+	var candidates []*unstructured.Unstructured
+	for _, gvk := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := c.List(ctx, list, crclient.HasLabels{ManagedByInstallLabel}); err != nil {
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if !rendered[objectKey(gvk.GroupKind(), obj.GetNamespace(), obj.GetName())] {
+				obj.SetGroupVersionKind(gvk)
+				candidates = append(candidates, obj)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+func containsGroupKind(gvks []schema.GroupVersionKind, gk schema.GroupKind) bool {
+	for _, gvk := range gvks {
+		if gvk.GroupKind() == gk {
+			return true
+		}
+	}
+	return false
+}
+
+func objectKey(gk schema.GroupKind, namespace, name string) string {
+	return gk.String() + "/" + namespace + "/" + name
+}
+
+func objectRef(obj crclient.Object) string {
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
+}
+
+func toUnstructured(obj crclient.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy(), nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %T: %w", obj, err)
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	return u, nil
+}
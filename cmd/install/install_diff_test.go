@@ -0,0 +1,98 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/metrics"
+)
+
+func TestObjectDiff(t *testing.T) {
+	secret := func(value, resourceVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "creds", "namespace": "hypershift", "resourceVersion": resourceVersion},
+			"data":       map[string]interface{}{"credentials": value},
+		}}
+	}
+	testCases := []struct {
+		name         string
+		live         *unstructured.Unstructured
+		desired      *unstructured.Unstructured
+		expectChange bool
+	}{
+		{
+			name:    "When only server maintained fields differ it should report no changes",
+			live:    secret("c2VjcmV0", "1"),
+			desired: secret("c2VjcmV0", "2"),
+		},
+		{
+			name:         "When a secret value changes it should report the change without the value",
+			live:         secret("c2VjcmV0", "1"),
+			desired:      secret("bmV3LXNlY3JldA==", "1"),
+			expectChange: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			diff := objectDiff(tc.live, tc.desired)
+			if !tc.expectChange {
+				g.Expect(diff).To(BeEmpty())
+				return
+			}
+			g.Expect(diff).To(ContainSubstring("credentials"))
+			g.Expect(diff).ToNot(ContainSubstring("c2VjcmV0"))
+			g.Expect(diff).ToNot(ContainSubstring("bmV3LXNlY3JldA=="))
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	g := NewGomegaWithT(t)
+	objects, err := hyperShiftOperatorManifests(Options{
+		Namespace:       "hypershift",
+		PrivatePlatform: string(hyperv1.NonePlatform),
+		MetricsSet:      metrics.DefaultMetricsSet,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, object := range objects {
+		g.Expect(object.GetLabels()).To(HaveKeyWithValue(ManagedByInstallLabel, "true"))
+	}
+
+	managed := map[string]string{ManagedByInstallLabel: "true"}
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(
+		// Rendered by the current install
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "hypershift", Name: "operator", Labels: managed}},
+		// Rendered by a previous install with --external-dns-provider
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "hypershift", Name: "external-dns", Labels: managed}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "external-dns", Labels: managed}},
+		// Not created by install
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "hypershift", Name: "user-config"}},
+	).Build()
+
+	out := &bytes.Buffer{}
+	g.Expect(prune(context.Background(), c, objects, true, out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("would prune Deployment hypershift/external-dns\nwould prune ClusterRole external-dns\n"))
+	g.Expect(c.Get(context.Background(), crclient.ObjectKey{Namespace: "hypershift", Name: "external-dns"}, &appsv1.Deployment{})).To(Succeed())
+
+	out.Reset()
+	g.Expect(prune(context.Background(), c, objects, false, out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("pruned Deployment hypershift/external-dns\npruned ClusterRole external-dns\n"))
+	g.Expect(c.Get(context.Background(), crclient.ObjectKey{Namespace: "hypershift", Name: "external-dns"}, &appsv1.Deployment{})).ToNot(Succeed())
+	g.Expect(c.Get(context.Background(), crclient.ObjectKey{Namespace: "hypershift", Name: "operator"}, &appsv1.Deployment{})).To(Succeed())
+	g.Expect(c.Get(context.Background(), crclient.ObjectKey{Namespace: "hypershift", Name: "user-config"}, &corev1.ConfigMap{})).To(Succeed())
+}
@@ -13,8 +13,10 @@ import (
 )
 
 var (
-	RenderFormatYaml = "yaml"
-	RenderFormatJson = "json"
+	RenderFormatYaml      = "yaml"
+	RenderFormatJson      = "json"
+	RenderFormatHelm      = "helm"
+	RenderFormatKustomize = "kustomize"
 
 	TemplateParamHyperShiftImage          = "OPERATOR_IMG"
 	TemplateParamHyperShiftImageTag       = "IMAGE_TAG"
@@ -40,7 +42,8 @@ func NewRenderCommand(opts *Options) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&opts.Template, "template", false, "Render as Openshift template instead of plain manifests")
-	cmd.Flags().StringVar(&opts.Format, "format", RenderFormatYaml, fmt.Sprintf("Output format for the manifests, supports %s and %s", RenderFormatYaml, RenderFormatJson))
+	cmd.Flags().StringVar(&opts.Format, "format", RenderFormatYaml, fmt.Sprintf("Output format for the manifests, supports %s, %s, %s (a chart) and %s (a base with components)", RenderFormatYaml, RenderFormatJson, RenderFormatHelm, RenderFormatKustomize))
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", "", fmt.Sprintf("Directory to write the %s chart or %s tree to", RenderFormatHelm, RenderFormatKustomize))
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		opts.ApplyDefaults()
@@ -50,6 +53,13 @@ func NewRenderCommand(opts *Options) *cobra.Command {
 			return err
 		}
 
+		switch opts.Format {
+		case RenderFormatHelm:
+			return renderHelmChart(*opts, opts.OutputDir)
+		case RenderFormatKustomize:
+			return renderKustomize(*opts, opts.OutputDir)
+		}
+
 		var objects []crclient.Object
 
 		if opts.Template {
@@ -80,8 +90,17 @@ func (o *Options) ValidateRender() error {
 		return err
 	}
 
-	if o.Format != RenderFormatYaml && o.Format != RenderFormatJson {
-		return fmt.Errorf("--format must be %s or %s", RenderFormatYaml, RenderFormatJson)
+	switch o.Format {
+	case RenderFormatYaml, RenderFormatJson:
+	case RenderFormatHelm, RenderFormatKustomize:
+		if o.Template {
+			return fmt.Errorf("--template is not supported with --format=%s", o.Format)
+		}
+		if o.OutputDir == "" {
+			return fmt.Errorf("--output-dir is required with --format=%s", o.Format)
+		}
+	default:
+		return fmt.Errorf("--format must be %s, %s, %s or %s", RenderFormatYaml, RenderFormatJson, RenderFormatHelm, RenderFormatKustomize)
 	}
 
 	return nil
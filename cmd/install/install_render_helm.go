@@ -0,0 +1,359 @@
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/pkg/version"
+	"github.com/openshift/hypershift/support/metrics"
+)
+
+const (
+	helmChartName = "hypershift-operator"
+
+	helmComponentAWSPrivateLink    = ".Values.privatePlatform.aws.enabled"
+	helmComponentOIDC              = ".Values.oidc.enabled"
+	helmComponentExternalDNS       = ".Values.externalDNS.enabled"
+	helmComponentValidatingWebhook = ".Values.validatingWebhook.enabled"
+	helmComponentEtcd              = ".Values.etcd.enabled"
+
+	// helmRawValuePrefix marks string placeholders that have to be rendered without quotes,
+	// e.g. numbers
+	helmRawValuePrefix = "__helm_raw__"
+)
+
+var helmRawValuePattern = regexp.MustCompile(`['"]?` + helmRawValuePrefix + `(\{\{[^}]*\}\})['"]?`)
+
+// helmComponents disable the optional components that are enabled by helmTemplateOptions.
+var helmComponents = []struct {
+	condition string
+	disable   func(o *Options)
+}{
+	{condition: helmComponentAWSPrivateLink, disable: func(o *Options) { o.PrivatePlatform = string(hyperv1.NonePlatform) }},
+	{condition: helmComponentOIDC, disable: func(o *Options) {
+		o.OIDCStorageProviderS3BucketName = ""
+		o.OIDCStorageProviderS3CredentialsSecret = ""
+	}},
+	{condition: helmComponentExternalDNS, disable: func(o *Options) { o.ExternalDNSProvider = "" }},
+	{condition: helmComponentValidatingWebhook, disable: func(o *Options) { o.EnableValidatingWebhook = false }},
+	{condition: helmComponentEtcd, disable: func(o *Options) { o.ExcludeEtcdManifests = true }},
+}
+
+// helmTemplateOptions returns options that enable all optional components and reference
+// chart values instead of the configured settings. Credentials are always referenced by
+// secret name so that the chart does not contain them. The value references have no
+// spaces, as the YAML serializer folds long strings at spaces.
+func helmTemplateOptions(opts Options) Options {
+	o := opts
+	o.HyperShiftImage = "{{.Values.image}}"
+	o.Namespace = "{{.Values.namespace}}"
+	o.MetricsSet = metrics.MetricsSet("{{.Values.metricsSet}}")
+
+	o.PrivatePlatform = string(hyperv1.AWSPlatform)
+	o.AWSPrivateCreds = ""
+	o.AWSPrivateRegion = "{{.Values.privatePlatform.aws.region}}"
+	o.AWSPrivateCredentialsSecret = "{{.Values.privatePlatform.aws.credentialsSecret}}"
+	o.AWSPrivateCredentialsSecretKey = "{{.Values.privatePlatform.aws.credentialsSecretKey}}"
+
+	o.OIDCStorageProviderS3Credentials = ""
+	o.OIDCStorageProviderS3BucketName = "{{.Values.oidc.bucketName}}"
+	o.OIDCStorageProviderS3Region = "{{.Values.oidc.region}}"
+	o.OIDCStorageProviderS3CredentialsSecret = "{{.Values.oidc.credentialsSecret}}"
+	o.OIDCStorageProviderS3CredentialsSecretKey = "{{.Values.oidc.credentialsSecretKey}}"
+
+	if o.ExternalDNSProvider == "" {
+		o.ExternalDNSProvider = "aws"
+	}
+	o.ExternalDNSCredentials = ""
+	o.ExternalDNSCredentialsSecret = "{{.Values.externalDNS.credentialsSecret}}"
+	o.ExternalDNSDomainFilter = "{{.Values.externalDNS.domainFilter}}"
+	o.ExternalDNSTxtOwnerId = "{{.Values.externalDNS.txtOwnerID}}"
+
+	o.EnableValidatingWebhook = true
+	o.ExcludeEtcdManifests = false
+	return o
+}
+
+// helmValues are the chart values, defaulted from the options passed to render.
+type helmValues struct {
+	Image                    string
+	Namespace                string
+	Replicas                 int32
+	MetricsSet               string
+	AWSPrivateLinkEnabled    bool
+	AWSPrivateRegion         string
+	AWSPrivateCredsSecret    string
+	AWSPrivateCredsSecretKey string
+	OIDCEnabled              bool
+	OIDCBucketName           string
+	OIDCRegion               string
+	OIDCCredsSecret          string
+	OIDCCredsSecretKey       string
+	ExternalDNSEnabled       bool
+	ExternalDNSDomainFilter  string
+	ExternalDNSCredsSecret   string
+	ExternalDNSTxtOwnerID    string
+	ValidatingWebhookEnabled bool
+	EtcdEnabled              bool
+	ExternalDNSProvider      string
+}
+
+var helmValuesTemplate = template.Must(template.New("values").Funcs(template.FuncMap{"quote": helmQuote}).Parse(`# Default values for the HyperShift operator chart.
+# Credentials are referenced by the name of existing secrets in the operator namespace.
+
+image: {{ quote .Image }}
+namespace: {{ quote .Namespace }}
+replicas: {{ .Replicas }}
+# The set of metrics to produce for each control plane: Telemetry, SRE or All
+metricsSet: {{ quote .MetricsSet }}
+
+privatePlatform:
+  aws:
+    # Manage private clusters on AWS through PrivateLink
+    enabled: {{ .AWSPrivateLinkEnabled }}
+    region: {{ quote .AWSPrivateRegion }}
+    credentialsSecret: {{ quote .AWSPrivateCredsSecret }}
+    credentialsSecretKey: {{ quote .AWSPrivateCredsSecretKey }}
+
+oidc:
+  # Publish the OIDC discovery documents of AWS clusters to an S3 bucket
+  enabled: {{ .OIDCEnabled }}
+  bucketName: {{ quote .OIDCBucketName }}
+  region: {{ quote .OIDCRegion }}
+  credentialsSecret: {{ quote .OIDCCredsSecret }}
+  credentialsSecretKey: {{ quote .OIDCCredsSecretKey }}
+
+externalDNS:
+  # Manage DNS records for cluster endpoints with external-dns using the {{ .ExternalDNSProvider }} provider
+  enabled: {{ .ExternalDNSEnabled }}
+  domainFilter: {{ quote .ExternalDNSDomainFilter }}
+  credentialsSecret: {{ quote .ExternalDNSCredsSecret }}
+  txtOwnerID: {{ quote .ExternalDNSTxtOwnerID }}
+
+validatingWebhook:
+  enabled: {{ .ValidatingWebhookEnabled }}
+
+etcd:
+  # Install the etcd CRDs
+  enabled: {{ .EtcdEnabled }}
+`))
+
+func helmQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// renderHelmChart writes a chart to dir whose templates are the rendered manifests. The
+// optional components are enabled through values instead of render flags.
+func renderHelmChart(opts Options, dir string) error {
+	templateOpts := helmTemplateOptions(opts)
+	enabled, err := helmManifests(templateOpts)
+	if err != nil {
+		return err
+	}
+	var variants []objectVariant
+	for _, component := range helmComponents {
+		variantOpts := templateOpts
+		component.disable(&variantOpts)
+		objects, err := helmManifests(variantOpts)
+		if err != nil {
+			return err
+		}
+		variants = append(variants, objectVariant{component: component.condition, objects: objects})
+	}
+	objects, err := mergeVariants(enabled, variants)
+	if err != nil {
+		return err
+	}
+
+	templatesDir := filepath.Join(dir, "templates")
+	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		return err
+	}
+	chart := fmt.Sprintf("apiVersion: v2\nname: %s\ndescription: The HyperShift operator\ntype: application\nversion: 0.1.0\nappVersion: %s\n", helmChartName, helmQuote(version.GetRevision()))
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chart), 0644); err != nil {
+		return err
+	}
+
+	txtOwnerID := opts.ExternalDNSTxtOwnerId
+	if txtOwnerID == "" {
+		txtOwnerID = uuid.NewString()
+	}
+	values := &bytes.Buffer{}
+	if err := helmValuesTemplate.Execute(values, helmValues{
+		Image:                    opts.HyperShiftImage,
+		Namespace:                opts.Namespace,
+		Replicas:                 opts.HyperShiftOperatorReplicas,
+		MetricsSet:               string(opts.MetricsSet),
+		AWSPrivateLinkEnabled:    opts.PrivatePlatform == string(hyperv1.AWSPlatform),
+		AWSPrivateRegion:         opts.AWSPrivateRegion,
+		AWSPrivateCredsSecret:    opts.AWSPrivateCredentialsSecret,
+		AWSPrivateCredsSecretKey: opts.AWSPrivateCredentialsSecretKey,
+		OIDCEnabled:              opts.OIDCStorageProviderS3BucketName != "",
+		OIDCBucketName:           opts.OIDCStorageProviderS3BucketName,
+		OIDCRegion:               opts.OIDCStorageProviderS3Region,
+		OIDCCredsSecret:          opts.OIDCStorageProviderS3CredentialsSecret,
+		OIDCCredsSecretKey:       opts.OIDCStorageProviderS3CredentialsSecretKey,
+		ExternalDNSEnabled:       opts.ExternalDNSProvider != "",
+		ExternalDNSProvider:      templateOpts.ExternalDNSProvider,
+		ExternalDNSDomainFilter:  opts.ExternalDNSDomainFilter,
+		ExternalDNSCredsSecret:   opts.ExternalDNSCredentialsSecret,
+		ExternalDNSTxtOwnerID:    txtOwnerID,
+		ValidatingWebhookEnabled: opts.EnableValidatingWebhook,
+		EtcdEnabled:              !opts.ExcludeEtcdManifests,
+	}); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "values.yaml"), values.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	unconditional := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		unconditional = append(unconditional, obj.object)
+	}
+	for i, name := range manifestFileNames(unconditional) {
+		content, err := objects[i].helmTemplate()
+		if err != nil {
+			return fmt.Errorf("failed to render template for %s: %w", unstructuredKey(objects[i].object), err)
+		}
+		if err := os.WriteFile(filepath.Join(templatesDir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func helmManifests(opts Options) ([]*unstructured.Unstructured, error) {
+	objects, err := hyperShiftOperatorManifests(opts)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := applyPatchesToObjects(objects, []ObjectPatch{
+		{Kind: "Deployment", Name: "operator", Path: []string{"spec", "replicas"}, Value: helmRawValuePrefix + "{{.Values.replicas}}"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toUnstructuredList(patched)
+}
+
+// helmTemplate serializes the object, wrapping it and its conditional list items in
+// if blocks on the values of the components they depend on.
+func (c *conditionalObject) helmTemplate() ([]byte, error) {
+	obj := c.object.DeepCopy()
+	markers := map[string]int{}
+	for i, path := range variantListPaths {
+		if len(c.lists[i]) == 0 {
+			continue
+		}
+		marker := fmt.Sprintf("__helm_list_%d__", i)
+		markers[marker] = i
+		setPath(obj.Object, path, marker)
+	}
+	content, err := helmYAML(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	if len(c.conditions) > 0 {
+		fmt.Fprintf(out, "{{- if %s }}\n", helmCondition(c.conditions))
+	}
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		trimmed := strings.TrimRight(line, "\n")
+		idx := strings.Index(trimmed, ": __helm_list_")
+		if idx < 0 {
+			out.WriteString(line)
+			continue
+		}
+		list, ok := markers[trimmed[idx+2:]]
+		if !ok {
+			out.WriteString(line)
+			continue
+		}
+		// List items are indented like their key, which follows an optional "- "
+		keyStart := len(trimmed) - len(strings.TrimLeft(trimmed, " -"))
+		indent := strings.Repeat(" ", keyStart)
+		fmt.Fprintf(out, "%s:\n", trimmed[:idx])
+		// Consecutive items with the same conditions share an if block
+		openCondition := ""
+		for _, item := range c.lists[list] {
+			itemContent, err := helmYAML([]interface{}{item.value})
+			if err != nil {
+				return nil, err
+			}
+			itemCondition := ""
+			if len(item.conditions) > 0 {
+				itemCondition = helmCondition(item.conditions)
+			}
+			if itemCondition != openCondition {
+				if openCondition != "" {
+					fmt.Fprintf(out, "%s{{- end }}\n", indent)
+				}
+				if itemCondition != "" {
+					fmt.Fprintf(out, "%s{{- if %s }}\n", indent, itemCondition)
+				}
+				openCondition = itemCondition
+			}
+			for _, itemLine := range strings.SplitAfter(string(itemContent), "\n") {
+				if itemLine != "" {
+					out.WriteString(indent + itemLine)
+				}
+			}
+		}
+		if openCondition != "" {
+			fmt.Fprintf(out, "%s{{- end }}\n", indent)
+		}
+	}
+	if len(c.conditions) > 0 {
+		out.WriteString("{{- end }}\n")
+	}
+	return helmRawValuePattern.ReplaceAll(out.Bytes(), []byte("$1")), nil
+}
+
+// helmYAML serializes content and escapes all template delimiters in it except for the
+// references to chart values, e.g. in CRD descriptions.
+func helmYAML(content interface{}) ([]byte, error) {
+	b, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.ReplaceAll(b, []byte("{{"), []byte(`{{"{{"}}`))
+	b = bytes.ReplaceAll(b, []byte(`{{"{{"}}.Values.`), []byte("{{.Values."))
+	return b, nil
+}
+
+func helmCondition(conditions []condition) string {
+	terms := make([]string, 0, len(conditions))
+	for _, cond := range conditions {
+		alternatives := make([]string, 0, len(cond.components))
+		for _, component := range cond.components {
+			if cond.negated {
+				alternatives = append(alternatives, fmt.Sprintf("(not %s)", component))
+			} else {
+				alternatives = append(alternatives, component)
+			}
+		}
+		if len(alternatives) == 1 {
+			terms = append(terms, alternatives[0])
+		} else {
+			terms = append(terms, "(or "+strings.Join(alternatives, " ")+")")
+		}
+	}
+	if len(terms) == 1 {
+		return strings.TrimSuffix(strings.TrimPrefix(terms[0], "("), ")")
+	}
+	return "and " + strings.Join(terms, " ")
+}
@@ -0,0 +1,207 @@
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/metrics"
+)
+
+func helmTestOptions() Options {
+	return Options{
+		Namespace:                      "hypershift",
+		HyperShiftImage:                "quay.io/hypershift/hypershift-operator:test",
+		PrivatePlatform:                string(hyperv1.NonePlatform),
+		MetricsSet:                     metrics.DefaultMetricsSet,
+		EnableConversionWebhook:        true,
+		AWSPrivateCredentialsSecretKey: "credentials",
+		OIDCStorageProviderS3CredentialsSecretKey: "credentials",
+		ExternalDNSTxtOwnerId:                     "owner",
+	}
+}
+
+// renderedObjects returns the objects keyed by kind, namespace and name, normalized to their JSON representation.
+func renderedObjects(g Gomega, opts Options) map[string]interface{} {
+	objects, err := hyperShiftOperatorManifests(opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	unstructuredObjects, err := toUnstructuredList(objects)
+	g.Expect(err).ToNot(HaveOccurred())
+	result := map[string]interface{}{}
+	for _, obj := range unstructuredObjects {
+		b, err := json.Marshal(obj.Object)
+		g.Expect(err).ToNot(HaveOccurred())
+		var normalized map[string]interface{}
+		g.Expect(json.Unmarshal(b, &normalized)).To(Succeed())
+		result[unstructuredKey(obj)] = normalized
+	}
+	return result
+}
+
+// executeChart renders the chart templates with values like helm template would.
+func executeChart(g Gomega, chartDir string, values map[string]interface{}) map[string]interface{} {
+	files, err := filepath.Glob(filepath.Join(chartDir, "templates", "*.yaml"))
+	g.Expect(err).ToNot(HaveOccurred())
+	result := map[string]interface{}{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		g.Expect(err).ToNot(HaveOccurred())
+		tmpl, err := template.New(filepath.Base(file)).Option("missingkey=error").Parse(string(content))
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		g.Expect(tmpl.Execute(out, map[string]interface{}{"Values": values})).To(Succeed())
+		if strings.TrimSpace(out.String()) == "" {
+			continue
+		}
+		var obj map[string]interface{}
+		g.Expect(yaml.Unmarshal(out.Bytes(), &obj)).To(Succeed(), "template %s:\n%s", file, out.String())
+		u := &unstructured.Unstructured{Object: obj}
+		result[unstructuredKey(u)] = obj
+	}
+	return result
+}
+
+func chartValues(g Gomega, chartDir string) map[string]interface{} {
+	content, err := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	g.Expect(err).ToNot(HaveOccurred())
+	values := map[string]interface{}{}
+	g.Expect(yaml.Unmarshal(content, &values)).To(Succeed())
+	return values
+}
+
+func TestRenderHelmChart(t *testing.T) {
+	allComponents := helmTestOptions()
+	allComponents.Namespace = "hypershift-operator"
+	allComponents.PrivatePlatform = string(hyperv1.AWSPlatform)
+	allComponents.AWSPrivateRegion = "us-east-1"
+	allComponents.AWSPrivateCredentialsSecret = "private-link-credentials"
+	allComponents.OIDCStorageProviderS3BucketName = "oidc-bucket"
+	allComponents.OIDCStorageProviderS3Region = "us-east-2"
+	allComponents.OIDCStorageProviderS3CredentialsSecret = "oidc-credentials"
+	allComponents.ExternalDNSProvider = "aws"
+	allComponents.ExternalDNSDomainFilter = "example.com"
+	allComponents.ExternalDNSCredentialsSecret = "external-dns-credentials"
+	allComponents.EnableValidatingWebhook = true
+	allComponents.MetricsSet = metrics.MetricsSetAll
+
+	withoutEtcd := helmTestOptions()
+	withoutEtcd.ExcludeEtcdManifests = true
+
+	testCases := []struct {
+		name string
+		// chartOpts are passed to render, valuesOpts define the values the chart is installed with
+		chartOpts  Options
+		valuesOpts Options
+	}{
+		{
+			name:       "When the chart is installed with its default values it should match the rendered manifests",
+			chartOpts:  helmTestOptions(),
+			valuesOpts: helmTestOptions(),
+		},
+		{
+			name:       "When all components are enabled through values it should match the manifests rendered with the equivalent flags",
+			chartOpts:  helmTestOptions(),
+			valuesOpts: allComponents,
+		},
+		{
+			name:       "When all components are disabled through values it should match the manifests rendered with the equivalent flags",
+			chartOpts:  allComponents,
+			valuesOpts: withoutEtcd,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tc.chartOpts.ApplyDefaults()
+			tc.valuesOpts.ApplyDefaults()
+
+			chartDir := filepath.Join(t.TempDir(), "chart")
+			g.Expect(renderHelmChart(tc.chartOpts, chartDir)).To(Succeed())
+			g.Expect(filepath.Join(chartDir, "Chart.yaml")).To(BeAnExistingFile())
+
+			valuesDir := filepath.Join(t.TempDir(), "values")
+			g.Expect(renderHelmChart(tc.valuesOpts, valuesDir)).To(Succeed())
+
+			actual := executeChart(g, chartDir, chartValues(g, valuesDir))
+			expected := renderedObjects(g, tc.valuesOpts)
+			g.Expect(actual).To(HaveLen(len(expected)))
+			for key, obj := range expected {
+				g.Expect(actual).To(HaveKeyWithValue(key, obj))
+			}
+		})
+	}
+}
+
+func TestMergeVariants(t *testing.T) {
+	deployment := func(args ...string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("apps/v1")
+		obj.SetKind("Deployment")
+		obj.SetName("operator")
+		var items []interface{}
+		for _, arg := range args {
+			items = append(items, arg)
+		}
+		g := NewGomegaWithT(t)
+		g.Expect(unstructured.SetNestedField(obj.Object, []interface{}{map[string]interface{}{"name": "operator", "args": items}}, "spec", "template", "spec", "containers")).To(Succeed())
+		return obj
+	}
+
+	testCases := []struct {
+		name          string
+		enabled       *unstructured.Unstructured
+		variants      []objectVariant
+		expectedArgs  []conditionalItem
+		expectedError string
+	}{
+		{
+			name:    "When items are only rendered without either component it should render them while any is disabled",
+			enabled: deployment("run", "--a", "--b"),
+			variants: []objectVariant{
+				{component: "a", objects: []*unstructured.Unstructured{deployment("run", "--none", "--b")}},
+				{component: "b", objects: []*unstructured.Unstructured{deployment("run", "--none", "--a")}},
+			},
+			expectedArgs: []conditionalItem{
+				{value: "run"},
+				{value: "--none", conditions: []condition{{components: []string{"a", "b"}, negated: true}}},
+				{value: "--a", conditions: []condition{{components: []string{"a"}}}},
+				{value: "--b", conditions: []condition{{components: []string{"b"}}}},
+			},
+		},
+		{
+			name:    "When a component reorders items it should fail",
+			enabled: deployment("run", "--a", "--b"),
+			variants: []objectVariant{
+				{component: "a", objects: []*unstructured.Unstructured{deployment("run", "--b", "--a")}},
+			},
+			expectedError: "does not render",
+		},
+		{
+			name:          "When a list has duplicate items it should fail",
+			enabled:       deployment("run", "--a", "--a"),
+			expectedError: "duplicate list item",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			objects, err := mergeVariants([]*unstructured.Unstructured{tc.enabled}, tc.variants)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(HaveLen(1))
+			g.Expect(objects[0].lists[1]).To(Equal(tc.expectedArgs))
+		})
+	}
+}
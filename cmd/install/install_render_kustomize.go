@@ -0,0 +1,271 @@
+package install
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+// kustomizeComponents are the optional parts of the install that are rendered as kustomize
+// components on top of a base without them. Settings that were not passed to render are
+// set to ${VARIABLE} placeholders named like the template parameters, which GitOps tools
+// such as Flux can substitute.
+var kustomizeComponents = []struct {
+	name    string
+	enabled func(o Options) bool
+	enable  func(o *Options)
+}{
+	{
+		name:    "etcd",
+		enabled: func(o Options) bool { return !o.ExcludeEtcdManifests },
+		enable:  func(o *Options) { o.ExcludeEtcdManifests = false },
+	},
+	{
+		name:    "external-dns",
+		enabled: func(o Options) bool { return o.ExternalDNSProvider != "" },
+		enable: func(o *Options) {
+			if o.ExternalDNSProvider == "" {
+				o.ExternalDNSProvider = "aws"
+			}
+			if o.ExternalDNSCredentials == "" && o.ExternalDNSCredentialsSecret == "" {
+				o.ExternalDNSCredentialsSecret = fmt.Sprintf("${%s}", TemplateParamExternalDNSCredsSecret)
+			}
+			if o.ExternalDNSDomainFilter == "" {
+				o.ExternalDNSDomainFilter = fmt.Sprintf("${%s}", TemplateParamExternalDNSDomainFilter)
+			}
+			if o.ExternalDNSTxtOwnerId == "" {
+				o.ExternalDNSTxtOwnerId = fmt.Sprintf("${%s}", TemplateParamExternalDNSTxtOwnerID)
+			}
+		},
+	},
+	{
+		name:    "private-link",
+		enabled: func(o Options) bool { return o.PrivatePlatform == string(hyperv1.AWSPlatform) },
+		enable: func(o *Options) {
+			o.PrivatePlatform = string(hyperv1.AWSPlatform)
+			if o.AWSPrivateCreds == "" && o.AWSPrivateCredentialsSecret == "" {
+				o.AWSPrivateCredentialsSecret = fmt.Sprintf("${%s}", TemplateParamAWSPrivateCredsSecret)
+			}
+			if o.AWSPrivateRegion == "" {
+				o.AWSPrivateRegion = fmt.Sprintf("${%s}", TemplateParamAWSPrivateRegion)
+			}
+		},
+	},
+}
+
+type kustomization struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Resources  []string         `json:"resources,omitempty"`
+	Components []string         `json:"components,omitempty"`
+	Patches    []kustomizePatch `json:"patches,omitempty"`
+}
+
+type kustomizePatch struct {
+	Path   string          `json:"path"`
+	Target kustomizeTarget `json:"target"`
+}
+
+type kustomizeTarget struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// renderKustomize writes a base with the manifests that are always installed to dir/base,
+// one component per optional part to dir/components and an overlay that combines the base
+// with the components enabled by the render flags to dir/overlays/default.
+func renderKustomize(opts Options, dir string) error {
+	baseOpts := opts
+	baseOpts.ExcludeEtcdManifests = true
+	baseOpts.ExternalDNSProvider = ""
+	baseOpts.PrivatePlatform = string(hyperv1.NonePlatform)
+	base, err := kustomizeManifests(baseOpts)
+	if err != nil {
+		return err
+	}
+	baseByKey := map[string]*unstructured.Unstructured{}
+	for _, obj := range base {
+		baseByKey[unstructuredKey(obj)] = obj
+	}
+	if err := writeKustomization(filepath.Join(dir, "base"), kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+	}, base, nil); err != nil {
+		return err
+	}
+
+	overlay := kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{"../../base"},
+	}
+	for _, component := range kustomizeComponents {
+		componentOpts := baseOpts
+		component.enable(&componentOpts)
+		objects, err := kustomizeManifests(componentOpts)
+		if err != nil {
+			return err
+		}
+		var added []*unstructured.Unstructured
+		patches := map[*unstructured.Unstructured][]jsonPatchOperation{}
+		for _, obj := range objects {
+			baseObj, exists := baseByKey[unstructuredKey(obj)]
+			if !exists {
+				added = append(added, obj)
+				continue
+			}
+			ops, err := jsonPatchOperations(baseObj, obj)
+			if err != nil {
+				return fmt.Errorf("component %s: %w", component.name, err)
+			}
+			if len(ops) > 0 {
+				patches[baseObj] = ops
+			}
+		}
+		if err := writeKustomization(filepath.Join(dir, "components", component.name), kustomization{
+			APIVersion: "kustomize.config.k8s.io/v1alpha1",
+			Kind:       "Component",
+		}, added, patches); err != nil {
+			return err
+		}
+		if component.enabled(opts) {
+			overlay.Components = append(overlay.Components, "../../components/"+component.name)
+		}
+	}
+	return writeYAMLFile(filepath.Join(dir, "overlays", "default", "kustomization.yaml"), overlay)
+}
+
+func kustomizeManifests(opts Options) ([]*unstructured.Unstructured, error) {
+	objects, err := hyperShiftOperatorManifests(opts)
+	if err != nil {
+		return nil, err
+	}
+	return toUnstructuredList(objects)
+}
+
+// jsonPatchOperations returns the operations that turn base into desired. Items that are
+// removed are tested first, so that a patch fails instead of removing the wrong item if
+// the base was modified.
+func jsonPatchOperations(base, desired *unstructured.Unstructured) ([]jsonPatchOperation, error) {
+	if !reflect.DeepEqual(withoutVariantLists(base), withoutVariantLists(desired)) {
+		return nil, fmt.Errorf("%s differs outside of the supported lists", unstructuredKey(desired))
+	}
+	var ops []jsonPatchOperation
+	for _, path := range variantListPaths {
+		pointer := "/" + strings.Join(path, "/")
+		baseItems := listAt(base.Object, path)
+		desiredItems := listAt(desired.Object, path)
+		if len(baseItems) == 0 {
+			if len(desiredItems) > 0 {
+				ops = append(ops, jsonPatchOperation{Op: "add", Path: pointer, Value: desiredItems})
+			}
+			continue
+		}
+		if err := checkUniqueItems(baseItems); err != nil {
+			return nil, fmt.Errorf("%s: %w", unstructuredKey(base), err)
+		}
+		if err := checkUniqueItems(desiredItems); err != nil {
+			return nil, fmt.Errorf("%s: %w", unstructuredKey(desired), err)
+		}
+		// Items are matched by value, and those in both lists must keep their order
+		matchedBase, matchedDesired := map[int]bool{}, map[int]bool{}
+		previous := -1
+		for idx, item := range baseItems {
+			desiredIdx := indexOfValue(desiredItems, item)
+			if desiredIdx < 0 {
+				continue
+			}
+			if desiredIdx < previous {
+				return nil, fmt.Errorf("%s reorders the items of %s", unstructuredKey(desired), pointer)
+			}
+			previous = desiredIdx
+			matchedBase[idx] = true
+			matchedDesired[desiredIdx] = true
+		}
+		var removed []int
+		for idx := range baseItems {
+			if !matchedBase[idx] {
+				removed = append(removed, idx)
+			}
+		}
+		// Remove from the end so that the indexes of the remaining items do not change,
+		// then insert the added items at their index in ascending order
+		sort.Sort(sort.Reverse(sort.IntSlice(removed)))
+		for _, idx := range removed {
+			itemPointer := pointer + "/" + strconv.Itoa(idx)
+			ops = append(ops,
+				jsonPatchOperation{Op: "test", Path: itemPointer, Value: baseItems[idx]},
+				jsonPatchOperation{Op: "remove", Path: itemPointer},
+			)
+		}
+		for idx, item := range desiredItems {
+			if !matchedDesired[idx] {
+				ops = append(ops, jsonPatchOperation{Op: "add", Path: pointer + "/" + strconv.Itoa(idx), Value: item})
+			}
+		}
+	}
+	return ops, nil
+}
+
+func writeKustomization(dir string, k kustomization, resources []*unstructured.Unstructured, patches map[*unstructured.Unstructured][]jsonPatchOperation) error {
+	for i, name := range manifestFileNames(resources) {
+		if err := writeYAMLFile(filepath.Join(dir, name), resources[i].Object); err != nil {
+			return err
+		}
+		k.Resources = append(k.Resources, name)
+	}
+
+	var patched []*unstructured.Unstructured
+	for obj := range patches {
+		patched = append(patched, obj)
+	}
+	sort.Slice(patched, func(i, j int) bool { return unstructuredKey(patched[i]) < unstructuredKey(patched[j]) })
+	for i, name := range manifestFileNames(patched) {
+		obj := patched[i]
+		patchName := "patch-" + name
+		if err := writeYAMLFile(filepath.Join(dir, patchName), patches[obj]); err != nil {
+			return err
+		}
+		gvk := obj.GroupVersionKind()
+		k.Patches = append(k.Patches, kustomizePatch{
+			Path: patchName,
+			Target: kustomizeTarget{
+				Group:     gvk.Group,
+				Version:   gvk.Version,
+				Kind:      gvk.Kind,
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		})
+	}
+	return writeYAMLFile(filepath.Join(dir, "kustomization.yaml"), k)
+}
+
+func writeYAMLFile(path string, content interface{}) error {
+	b, err := yaml.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to serialize %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,118 @@
+package install
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+// buildKustomization resolves the resources, components and JSON patches of a kustomization
+// like kustomize build would for the subset of features that render uses.
+func buildKustomization(g Gomega, dir string, objects map[string]map[string]interface{}) {
+	content, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	g.Expect(err).ToNot(HaveOccurred())
+	k := kustomization{}
+	g.Expect(yaml.Unmarshal(content, &k)).To(Succeed())
+
+	for _, resource := range k.Resources {
+		path := filepath.Join(dir, resource)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			buildKustomization(g, path, objects)
+			continue
+		}
+		content, err := os.ReadFile(path)
+		g.Expect(err).ToNot(HaveOccurred())
+		obj := map[string]interface{}{}
+		g.Expect(yaml.Unmarshal(content, &obj)).To(Succeed())
+		objects[unstructuredKey(&unstructured.Unstructured{Object: obj})] = obj
+	}
+	for _, component := range k.Components {
+		buildKustomization(g, filepath.Join(dir, component), objects)
+	}
+	for _, p := range k.Patches {
+		content, err := os.ReadFile(filepath.Join(dir, p.Path))
+		g.Expect(err).ToNot(HaveOccurred())
+		patchJSON, err := yaml.YAMLToJSON(content)
+		g.Expect(err).ToNot(HaveOccurred())
+		patch, err := jsonpatch.DecodePatch(patchJSON)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		target := &unstructured.Unstructured{}
+		target.SetAPIVersion(p.Target.Group + "/" + p.Target.Version)
+		target.SetKind(p.Target.Kind)
+		target.SetNamespace(p.Target.Namespace)
+		target.SetName(p.Target.Name)
+		key := unstructuredKey(target)
+		g.Expect(objects).To(HaveKey(key))
+		targetJSON, err := json.Marshal(objects[key])
+		g.Expect(err).ToNot(HaveOccurred())
+		patched, err := patch.Apply(targetJSON)
+		g.Expect(err).ToNot(HaveOccurred())
+		obj := map[string]interface{}{}
+		g.Expect(json.Unmarshal(patched, &obj)).To(Succeed())
+		objects[key] = obj
+	}
+}
+
+func TestRenderKustomize(t *testing.T) {
+	withPrivateLink := helmTestOptions()
+	withPrivateLink.PrivatePlatform = string(hyperv1.AWSPlatform)
+	withPrivateLink.AWSPrivateRegion = "us-east-1"
+	withPrivateLink.AWSPrivateCredentialsSecret = "private-link-credentials"
+
+	withExternalDNS := helmTestOptions()
+	withExternalDNS.ExternalDNSProvider = "aws"
+	withExternalDNS.ExternalDNSDomainFilter = "example.com"
+	withExternalDNS.ExternalDNSCredentialsSecret = "external-dns-credentials"
+	withExternalDNS.ExcludeEtcdManifests = true
+
+	testCases := []struct {
+		name               string
+		opts               Options
+		expectedComponents []string
+	}{
+		{
+			name:               "When rendering with the private platform it should enable the etcd and private link components",
+			opts:               withPrivateLink,
+			expectedComponents: []string{"../../components/etcd", "../../components/private-link"},
+		},
+		{
+			name:               "When rendering with external-dns and without etcd it should enable the external-dns component",
+			opts:               withExternalDNS,
+			expectedComponents: []string{"../../components/external-dns"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tc.opts.ApplyDefaults()
+			dir := t.TempDir()
+			g.Expect(renderKustomize(tc.opts, dir)).To(Succeed())
+			for _, component := range kustomizeComponents {
+				g.Expect(filepath.Join(dir, "components", component.name, "kustomization.yaml")).To(BeAnExistingFile())
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, "overlays", "default", "kustomization.yaml"))
+			g.Expect(err).ToNot(HaveOccurred())
+			overlay := kustomization{}
+			g.Expect(yaml.Unmarshal(content, &overlay)).To(Succeed())
+			g.Expect(overlay.Components).To(Equal(tc.expectedComponents))
+
+			objects := map[string]map[string]interface{}{}
+			buildKustomization(g, filepath.Join(dir, "overlays", "default"), objects)
+			expected := renderedObjects(g, tc.opts)
+			g.Expect(objects).To(HaveLen(len(expected)))
+			for key, obj := range expected {
+				g.Expect(objects).To(HaveKeyWithValue(key, obj))
+			}
+		})
+	}
+}
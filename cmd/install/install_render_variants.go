@@ -0,0 +1,362 @@
+package install
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// variantListPaths are the lists in which optional components add or replace items of
+// objects that are rendered either way, e.g. the arguments and volumes of the operator
+// deployment. Differences outside of these lists are not supported.
+var variantListPaths = [][]string{
+	{"spec", "template", "spec", "volumes"},
+	{"spec", "template", "spec", "containers", "0", "args"},
+	{"spec", "template", "spec", "containers", "0", "env"},
+	{"spec", "template", "spec", "containers", "0", "volumeMounts"},
+}
+
+// condition enables a part of a rendered manifest while any of its components is
+// enabled, or while any of them is disabled if negated is set.
+type condition struct {
+	components []string
+	negated    bool
+}
+
+func (c condition) holds(enabled func(component string) bool) bool {
+	for _, component := range c.components {
+		if enabled(component) != c.negated {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalItem is a list item that is rendered while all of its conditions hold.
+type conditionalItem struct {
+	value      interface{}
+	conditions []condition
+}
+
+// conditionalObject is a rendered object whose presence and list items may depend on
+// optional components. It is rendered while all of its conditions hold.
+type conditionalObject struct {
+	object     *unstructured.Unstructured
+	conditions []condition
+	// lists holds the items of each variantListPaths entry by index
+	lists map[int][]conditionalItem
+}
+
+// objectVariant is the set of objects rendered with one optional component disabled.
+type objectVariant struct {
+	component string
+	objects   []*unstructured.Unstructured
+}
+
+// mergeVariants combines the objects rendered with all optional components enabled with
+// the objects rendered with each component disabled. Objects are matched by key and list
+// items by value: those rendered with all components enabled depend on each component
+// they are missing without, and those only rendered with components disabled depend on
+// any of these components being disabled. The result is verified to render each of the
+// variants it was merged from.
+func mergeVariants(enabled []*unstructured.Unstructured, variants []objectVariant) ([]*conditionalObject, error) {
+	var result []*conditionalObject
+	byKey := map[string]*conditionalObject{}
+	for _, obj := range enabled {
+		c, err := newConditionalObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+		byKey[unstructuredKey(obj)] = c
+	}
+
+	for _, variant := range variants {
+		present := map[string]bool{}
+		for _, obj := range variant.objects {
+			key := unstructuredKey(obj)
+			present[key] = true
+			c, exists := byKey[key]
+			switch {
+			case !exists:
+				// Only rendered while a component is disabled
+				c, err := newConditionalObject(obj)
+				if err != nil {
+					return nil, err
+				}
+				c.conditions = []condition{{components: []string{variant.component}, negated: true}}
+				result = append(result, c)
+				byKey[key] = c
+			case c.negated():
+				if !reflect.DeepEqual(c.object.Object, obj.Object) {
+					return nil, fmt.Errorf("%s differs between the components it is rendered without", key)
+				}
+				c.conditions[0].components = append(c.conditions[0].components, variant.component)
+			default:
+				if err := c.mergeVariant(obj, variant.component); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for key, c := range byKey {
+			if !present[key] && !c.negated() {
+				c.conditions = append(c.conditions, condition{components: []string{variant.component}})
+			}
+		}
+	}
+
+	if err := verifyVariant(result, "", enabled); err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		if err := verifyVariant(result, variant.component, variant.objects); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func newConditionalObject(obj *unstructured.Unstructured) (*conditionalObject, error) {
+	c := &conditionalObject{object: obj, lists: map[int][]conditionalItem{}}
+	for i, path := range variantListPaths {
+		items := listAt(obj.Object, path)
+		if err := checkUniqueItems(items); err != nil {
+			return nil, fmt.Errorf("%s: %w", unstructuredKey(obj), err)
+		}
+		for _, item := range items {
+			c.lists[i] = append(c.lists[i], conditionalItem{value: item})
+		}
+	}
+	return c, nil
+}
+
+func (c *conditionalObject) negated() bool {
+	return conditionalItem{conditions: c.conditions}.negated()
+}
+
+// mergeVariant conditions the list items that differ between the object and its rendering
+// with component disabled. Items that are only rendered while the component is disabled are
+// inserted after the item they follow in that rendering.
+func (c *conditionalObject) mergeVariant(disabled *unstructured.Unstructured, component string) error {
+	if !reflect.DeepEqual(withoutVariantLists(c.object), withoutVariantLists(disabled)) {
+		return fmt.Errorf("%s differs outside of the supported lists when %s is disabled", unstructuredKey(disabled), component)
+	}
+	for i, path := range variantListPaths {
+		items := c.lists[i]
+		disabledItems := listAt(disabled.Object, path)
+		if err := checkUniqueItems(disabledItems); err != nil {
+			return fmt.Errorf("%s: %w", unstructuredKey(disabled), err)
+		}
+		for idx := range items {
+			if !items[idx].negated() && indexOfValue(disabledItems, items[idx].value) < 0 {
+				items[idx].conditions = append(items[idx].conditions, condition{components: []string{component}})
+			}
+		}
+
+		previous := -1
+		for _, value := range disabledItems {
+			idx := -1
+			for j := range items {
+				if reflect.DeepEqual(items[j].value, value) {
+					idx = j
+					break
+				}
+			}
+			switch {
+			case idx < 0:
+				idx = previous + 1
+				items = append(items[:idx], append([]conditionalItem{{value: value, conditions: []condition{{components: []string{component}, negated: true}}}}, items[idx:]...)...)
+			case items[idx].negated():
+				items[idx].conditions[0].components = append(items[idx].conditions[0].components, component)
+			}
+			previous = idx
+		}
+		if len(items) > 0 {
+			c.lists[i] = items
+		}
+	}
+	return nil
+}
+
+func (i conditionalItem) negated() bool {
+	for _, cond := range i.conditions {
+		if cond.negated {
+			return true
+		}
+	}
+	return false
+}
+
+func conditionsHold(conditions []condition, enabled func(component string) bool) bool {
+	for _, cond := range conditions {
+		if !cond.holds(enabled) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyVariant checks that objects render expected while disabledComponent is disabled
+// and all other components are enabled.
+func verifyVariant(objects []*conditionalObject, disabledComponent string, expected []*unstructured.Unstructured) error {
+	enabled := func(component string) bool { return component != disabledComponent }
+	rendered := map[string]*unstructured.Unstructured{}
+	for _, c := range objects {
+		if !conditionsHold(c.conditions, enabled) {
+			continue
+		}
+		obj := c.object.DeepCopy()
+		for i, path := range variantListPaths {
+			if _, merged := c.lists[i]; !merged {
+				continue
+			}
+			var items []interface{}
+			for _, item := range c.lists[i] {
+				if conditionsHold(item.conditions, enabled) {
+					items = append(items, item.value)
+				}
+			}
+			if len(items) == 0 {
+				setPath(obj.Object, path, nil)
+			} else {
+				setPath(obj.Object, path, items)
+			}
+		}
+		rendered[unstructuredKey(obj)] = obj
+	}
+
+	description := "with all components enabled"
+	if disabledComponent != "" {
+		description = fmt.Sprintf("with %s disabled", disabledComponent)
+	}
+	if len(rendered) != len(expected) {
+		return fmt.Errorf("merging the optional components renders %d objects instead of %d %s", len(rendered), len(expected), description)
+	}
+	for _, obj := range expected {
+		key := unstructuredKey(obj)
+		if !reflect.DeepEqual(rendered[key], obj) {
+			return fmt.Errorf("merging the optional components does not render %s %s", key, description)
+		}
+	}
+	return nil
+}
+
+// checkUniqueItems returns an error if a list has the same item twice, as items are
+// matched by value.
+func checkUniqueItems(items []interface{}) error {
+	for i := range items {
+		if indexOfValue(items[:i], items[i]) >= 0 {
+			return fmt.Errorf("duplicate list item %v", items[i])
+		}
+	}
+	return nil
+}
+
+func indexOfValue(items []interface{}, value interface{}) int {
+	for i := range items {
+		if reflect.DeepEqual(items[i], value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func withoutVariantLists(obj *unstructured.Unstructured) map[string]interface{} {
+	result := obj.DeepCopy().Object
+	for _, path := range variantListPaths {
+		setPath(result, path, nil)
+	}
+	return result
+}
+
+// listAt returns the list at path, where numeric path elements index into lists.
+func listAt(obj map[string]interface{}, path []string) []interface{} {
+	var current interface{} = obj
+	for _, element := range path {
+		switch typed := current.(type) {
+		case map[string]interface{}:
+			current = typed[element]
+		case []interface{}:
+			idx, err := strconv.Atoi(element)
+			if err != nil || idx >= len(typed) {
+				return nil
+			}
+			current = typed[idx]
+		default:
+			return nil
+		}
+	}
+	list, _ := current.([]interface{})
+	return list
+}
+
+// setPath sets the value at path if its parent exists, removing the field if value is nil.
+func setPath(obj map[string]interface{}, path []string, value interface{}) {
+	var current interface{} = obj
+	for _, element := range path[:len(path)-1] {
+		switch typed := current.(type) {
+		case map[string]interface{}:
+			current = typed[element]
+		case []interface{}:
+			idx, err := strconv.Atoi(element)
+			if err != nil || idx >= len(typed) {
+				return
+			}
+			current = typed[idx]
+		default:
+			return
+		}
+	}
+	parent, ok := current.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if value == nil {
+		delete(parent, path[len(path)-1])
+		return
+	}
+	parent[path[len(path)-1]] = value
+}
+
+func unstructuredKey(obj *unstructured.Unstructured) string {
+	return objectKey(obj.GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName())
+}
+
+var placeholderPattern = regexp.MustCompile(`(?:\{\{[^}]*\.|\$\{)([A-Za-z_]+)\}\}?`)
+
+// manifestFileNames returns unique file names for objects based on their kind and name.
+func manifestFileNames(objects []*unstructured.Unstructured) []string {
+	names := make([]string, 0, len(objects))
+	used := map[string]int{}
+	for _, obj := range objects {
+		// Placeholders such as {{.Values.namespace}} are replaced by their last element
+		name := placeholderPattern.ReplaceAllString(obj.GetKind()+"-"+obj.GetName(), "$1")
+		name = strings.ToLower(name)
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, used[name])
+		}
+		names = append(names, name+".yaml")
+	}
+	return names
+}
+
+func toUnstructuredList(objects []crclient.Object) ([]*unstructured.Unstructured, error) {
+	result := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, object := range objects {
+		u, err := toUnstructured(object)
+		if err != nil {
+			return nil, err
+		}
+		// Drop the empty fields that serialization adds for typed objects
+		unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(u.Object, "status")
+		result = append(result, u)
+	}
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/support/metrics"
+)
+
+type UninstallOptions struct {
+	Namespace string
+	// Force removes the operator even if HostedClusters still exist. Their
+	// infrastructure is not cleaned up and has to be removed manually.
+	Force bool
+}
+
+// crdDeletionTimeout is how long uninstall waits with --force for the CRDs to be removed
+// before it removes the operator, which has to remove the finalizers of their objects.
+var crdDeletionTimeout = 10 * time.Minute
+
+func NewUninstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "uninstall",
+		Short:        "Removes the HyperShift operator, its CRDs, webhooks, RBAC and namespace",
+		SilenceUsage: true,
+	}
+
+	opts := UninstallOptions{
+		Namespace: "hypershift",
+	}
+
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace in which HyperShift is installed")
+	cmd.Flags().BoolVar(&opts.Force, "force", opts.Force, "Uninstall even if HostedClusters exist. They are deleted with the CRDs before the operator is removed, but their cloud resources may not be cleaned up")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		client, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := Uninstall(cmd.Context(), client, opts, cmd.OutOrStdout()); err != nil {
+			log.Log.Error(err, "Failed to uninstall HyperShift")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// Uninstall removes everything that install may have created. The webhooks are removed
+// first so that API requests no longer depend on the operator, then the operator so that
+// it stops reconciling, then CRDs, RBAC and the remaining objects and finally the namespace.
+// With Force, the CRDs are removed before the operator instead, so that it removes the
+// finalizers of the remaining objects and the CRDs don't get stuck deleting.
+// The Cluster API CRDs are kept, as they are shared with other Cluster API providers.
+func Uninstall(ctx context.Context, c crclient.Client, opts UninstallOptions, out io.Writer) error {
+	hostedClusters := &hyperv1.HostedClusterList{}
+	if err := c.List(ctx, hostedClusters); err != nil && !meta.IsNoMatchError(err) && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to list hostedclusters: %w", err)
+	}
+	if len(hostedClusters.Items) > 0 {
+		names := make([]string, 0, len(hostedClusters.Items))
+		for _, hc := range hostedClusters.Items {
+			names = append(names, hc.Namespace+"/"+hc.Name)
+		}
+		if !opts.Force {
+			return fmt.Errorf("hostedclusters still exist, destroy them first or use --force: %s", strings.Join(names, ", "))
+		}
+		fmt.Fprintf(out, "warning: removing HyperShift while hostedclusters exist, their resources have to be cleaned up manually: %s\n", strings.Join(names, ", "))
+	}
+
+	objects, err := uninstallObjects(ctx, c, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	isCRD := func(kind string) bool { return kind == "CustomResourceDefinition" }
+	phases := []func(kind string) bool{
+		func(kind string) bool { return strings.HasSuffix(kind, "WebhookConfiguration") },
+		func(kind string) bool { return kind == "Deployment" },
+		isCRD,
+		func(kind string) bool { return kind != "Namespace" },
+		func(kind string) bool { return true },
+	}
+	if opts.Force {
+		phases[1], phases[2] = phases[2], phases[1]
+	}
+	deleted := map[string]bool{}
+	for _, inPhase := range phases {
+		var crds []*unstructured.Unstructured
+		for _, obj := range objects {
+			key := objectKey(obj.GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName())
+			if deleted[key] || !inPhase(obj.GetKind()) {
+				continue
+			}
+			deleted[key] = true
+			if isCRD(obj.GetKind()) && isClusterAPICRD(obj.GetName()) {
+				fmt.Fprintf(out, "kept %s, it is shared with other Cluster API providers\n", objectRef(obj))
+				continue
+			}
+			if err := c.Delete(ctx, obj, crclient.PropagationPolicy("Background")); err != nil {
+				if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+					continue
+				}
+				return fmt.Errorf("failed to delete %s: %w", objectRef(obj), err)
+			}
+			fmt.Fprintf(out, "deleted %s\n", objectRef(obj))
+			if isCRD(obj.GetKind()) {
+				crds = append(crds, obj)
+			}
+		}
+		if opts.Force && len(crds) > 0 {
+			if err := waitForDeletion(ctx, c, crds); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isClusterAPICRD returns true if name is the name of a CRD of a Cluster API group, e.g.
+// cluster.x-k8s.io or infrastructure.cluster.x-k8s.io.
+func isClusterAPICRD(name string) bool {
+	return strings.HasSuffix(name, ".cluster.x-k8s.io")
+}
+
+// waitForDeletion waits until objects are removed.
+func waitForDeletion(ctx context.Context, c crclient.Client, objects []*unstructured.Unstructured) error {
+	ctx, cancel := context.WithTimeout(ctx, crdDeletionTimeout)
+	defer cancel()
+	err := wait.PollImmediateUntilWithContext(ctx, 5*time.Second, func(ctx context.Context) (bool, error) {
+		for _, obj := range objects {
+			live := &unstructured.Unstructured{}
+			live.SetGroupVersionKind(obj.GroupVersionKind())
+			if err := c.Get(ctx, crclient.ObjectKeyFromObject(obj), live); err == nil {
+				return false, nil
+			} else if !apierrors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to get %s: %w", objectRef(obj), err)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for the CRDs to be removed, objects of them may still have finalizers: %w", err)
+	}
+	return nil
+}
+
+// uninstallObjects returns the objects rendered by install with every optional component
+// enabled, together with all objects labeled by a previous install.
+func uninstallObjects(ctx context.Context, c crclient.Client, namespace string) ([]*unstructured.Unstructured, error) {
+	rendered, err := hyperShiftOperatorManifests(Options{
+		Namespace:                    namespace,
+		PrivatePlatform:              string(hyperv1.NonePlatform),
+		EnableValidatingWebhook:      true,
+		EnableConversionWebhook:      true,
+		EnableAdminRBACGeneration:    true,
+		ExternalDNSProvider:          "aws",
+		ExternalDNSCredentialsSecret: "external-dns-credentials",
+		// The bucket name only adds the OIDC configmap in kube-public
+		OIDCStorageProviderS3BucketName: "oidc",
+		MetricsSet:                      metrics.DefaultMetricsSet,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render manifests: %w", err)
+	}
+	var objects []*unstructured.Unstructured
+	for _, object := range rendered {
+		obj, err := toUnstructured(object)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	// Passing no rendered objects returns every labeled object
+	labeled, err := pruneCandidates(ctx, c, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(objects, labeled...), nil
+}
@@ -0,0 +1,93 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestUninstall(t *testing.T) {
+	installed := func() []crclient.Object {
+		return []crclient.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "hypershift"}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "hypershift", Name: "operator"}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "hypershift-operator"}},
+			&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "hostedclusters.hypershift.openshift.io"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "extra", Labels: map[string]string{ManagedByInstallLabel: "true"}}},
+		}
+	}
+	hostedCluster := &hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"}}
+	clusterAPICRD := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "clusters.cluster.x-k8s.io"}}
+
+	testCases := []struct {
+		name          string
+		objects       []crclient.Object
+		force         bool
+		expectError   bool
+		expectRemoved bool
+		expectOrder   string
+	}{
+		{
+			name:          "When no hostedclusters exist it should remove everything",
+			objects:       append(installed(), clusterAPICRD.DeepCopy()),
+			expectRemoved: true,
+			// Webhooks and the operator go first, the namespace last
+			expectOrder: `(?s)deleted Deployment hypershift/operator.*deleted CustomResourceDefinition hostedclusters.*deleted ClusterRole hypershift-operator.*deleted Namespace hypershift\n$`,
+		},
+		{
+			name:        "When hostedclusters exist it should refuse to uninstall",
+			objects:     append(installed(), hostedCluster.DeepCopy()),
+			expectError: true,
+		},
+		{
+			name:          "When hostedclusters exist and force is set it should remove everything",
+			objects:       append(installed(), hostedCluster.DeepCopy(), clusterAPICRD.DeepCopy()),
+			force:         true,
+			expectRemoved: true,
+			// The CRDs go before the operator, so that it can finalize the hostedclusters
+			expectOrder: `(?s)deleted CustomResourceDefinition hostedclusters.*deleted Deployment hypershift/operator.*deleted Namespace hypershift\n$`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(tc.objects...).Build()
+			out := &bytes.Buffer{}
+			err := Uninstall(context.Background(), c, UninstallOptions{Namespace: "hypershift", Force: tc.force}, out)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("clusters/example"))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			for _, obj := range installed() {
+				err := c.Get(context.Background(), crclient.ObjectKeyFromObject(obj), obj)
+				if tc.expectRemoved {
+					g.Expect(err).To(HaveOccurred(), "%T %s should be removed", obj, obj.GetName())
+				} else {
+					g.Expect(err).ToNot(HaveOccurred())
+				}
+			}
+			if tc.expectOrder != "" {
+				g.Expect(out.String()).To(MatchRegexp(tc.expectOrder))
+			}
+			// The Cluster API CRDs are shared with other providers
+			if tc.expectRemoved {
+				g.Expect(c.Get(context.Background(), crclient.ObjectKeyFromObject(clusterAPICRD), &apiextensionsv1.CustomResourceDefinition{})).To(Succeed())
+				g.Expect(out.String()).To(ContainSubstring("kept CustomResourceDefinition clusters.cluster.x-k8s.io"))
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -12,12 +14,15 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	kubejson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/cmd/util"
+	hyperutil "github.com/openshift/hypershift/support/util"
 )
 
 // TODO: NEXT: incorporate into an fzf workflow
@@ -35,11 +40,57 @@ HostedCluster following the pattern:
 
 The kubeconfig for each cluster is based on the secret referenced by the status
 of the HostedCluster itself.
+
+For HostedClusters that authenticate users with an external OIDC provider, the
+rendered kubeconfig obtains tokens from the provider through an exec plugin
+instead of using the admin credentials. By default the plugin is invoked as
+
+    kubectl oidc-login get-token --oidc-issuer-url={issuer-url} --oidc-client-id={client-id}
+
+Use --exec-command and --exec-arg to invoke a different plugin. The {issuer-url}
+and {client-id} placeholders in its arguments are replaced with the issuer URL
+and client ID of the first OIDC provider of the cluster. Use --admin to render
+the admin kubeconfig for those clusters.
+
+Instead of sharing the admin kubeconfig, short-lived credentials can be minted
+for a single cluster:
+
+  * --user (and optionally --group) requests a client certificate from the
+    kube-apiserver-client signer of the cluster through a certificate signing
+    request, which is approved on behalf of the caller.
+  * --service-account namespace/name requests a token for an existing service
+    account of the cluster, bound to the audiences given with --audience.
+
+Both expire after --ttl. The admin kubeconfig is only used to request the
+credentials and is not included in the output. Use --endpoint internal to
+target the kube-apiserver service of the control plane, which is reachable
+from within the management cluster only.
 `
 
 type Options struct {
 	Namespace string
 	Name      string
+	// Admin renders the admin kubeconfig even for clusters using OIDC authentication.
+	Admin bool
+	// ExecCommand is the command of the exec plugin used to obtain OIDC tokens.
+	ExecCommand string
+	// ExecArgs are the arguments of the exec plugin used to obtain OIDC tokens.
+	// The {issuer-url} and {client-id} placeholders are replaced with the values
+	// of the OIDC provider.
+	ExecArgs []string
+	// User is the user name of a client certificate to mint for the cluster.
+	User string
+	// Groups are the groups of the client certificate minted for User.
+	Groups []string
+	// ServiceAccount is the namespace/name of a service account to mint a token for.
+	ServiceAccount string
+	// Audiences are the audiences of the token minted for ServiceAccount.
+	Audiences []string
+	// TTL is the lifetime of the minted credentials.
+	TTL time.Duration
+	// Endpoint is the kube-apiserver endpoint targeted by the minted credentials,
+	// either external or internal.
+	Endpoint string
 }
 
 // NewCreateCommand returns a command which can render kubeconfigs for HostedCluster
@@ -52,15 +103,33 @@ func NewCreateCommand() *cobra.Command {
 		SilenceUsage: true,
 	}
 
-	opts := Options{}
+	opts := Options{
+		ExecCommand: "kubectl",
+		ExecArgs:    defaultExecArgs,
+		TTL:         8 * time.Hour,
+		Endpoint:    EndpointExternal,
+	}
 
 	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "A hostedcluster namespace. Will defalt to 'clusters' if a --name is supplied")
 	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "A hostedcluster name")
+	cmd.Flags().BoolVar(&opts.Admin, "admin", opts.Admin, "Render the admin kubeconfig for hostedclusters that use OIDC authentication")
+	cmd.Flags().StringVar(&opts.ExecCommand, "exec-command", opts.ExecCommand, "The command of the exec plugin that obtains OIDC tokens")
+	cmd.Flags().StringArrayVar(&opts.ExecArgs, "exec-arg", opts.ExecArgs, "An argument passed to the OIDC exec plugin, replacing the default arguments. The "+issuerURLPlaceholder+" and "+clientIDPlaceholder+" placeholders are replaced with the values of the OIDC provider. Can be repeated")
+
+	cmd.Flags().StringVar(&opts.User, "user", opts.User, "Mint a client certificate for this user instead of rendering the cluster kubeconfig. Requires --name")
+	cmd.Flags().StringArrayVar(&opts.Groups, "group", opts.Groups, "A group of the client certificate minted for --user. Can be repeated")
+	cmd.Flags().StringVar(&opts.ServiceAccount, "service-account", opts.ServiceAccount, "Mint a token for this existing service account (namespace/name) instead of rendering the cluster kubeconfig. Requires --name")
+	cmd.Flags().StringArrayVar(&opts.Audiences, "audience", opts.Audiences, "An audience of the token minted for --service-account. Can be repeated, defaults to the audiences of the kube-apiserver")
+	cmd.Flags().DurationVar(&opts.TTL, "ttl", opts.TTL, "The lifetime of the credentials minted for --user or --service-account")
+	cmd.Flags().StringVar(&opts.Endpoint, "endpoint", opts.Endpoint, "The kube-apiserver endpoint of the minted credentials (external, internal)")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if opts.Name != "" && opts.Namespace == "" {
 			opts.Namespace = "clusters"
 		}
+		if err := validateCredentialOptions(opts); err != nil {
+			return err
+		}
 		if err := render(cmd.Context(), opts); err != nil {
 			fmt.Fprintf(os.Stderr, "%s\n", err)
 			return err
@@ -89,7 +158,7 @@ func render(ctx context.Context, opts Options) error {
 	var kubeConfig *clientcmdapiv1.Config
 	switch {
 	case len(opts.Name) == 0:
-		config, err := buildCombinedConfig(ctx, c, opts.Namespace)
+		config, err := buildCombinedConfig(ctx, c, opts)
 		if err != nil {
 			return fmt.Errorf("failed to make kubeconfig: %w", err)
 		}
@@ -116,24 +185,124 @@ func render(ctx context.Context, opts Options) error {
 		if !hasData || len(data) == 0 {
 			return fmt.Errorf("kubeconfig secret has no kubeconfig")
 		}
-		fmt.Print(string(data))
-		return nil
+		if mintsCredentials(opts) {
+			var adminConfig clientcmdapiv1.Config
+			if err := yaml.Unmarshal(data, &adminConfig); err != nil {
+				return fmt.Errorf("failed to load kubeconfig: %w", err)
+			}
+			restConfig, err := clientcmd.RESTConfigFromKubeConfig(data)
+			if err != nil {
+				return fmt.Errorf("failed to load kubeconfig: %w", err)
+			}
+			kubeClient, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kube client: %w", err)
+			}
+			kubeConfig, err := buildCredentialConfig(ctx, kubeClient, &cluster, &adminConfig, opts)
+			if err != nil {
+				return fmt.Errorf("failed to make kubeconfig: %w", err)
+			}
+			return serializer.Encode(kubeConfig, os.Stdout)
+		}
+		if opts.Admin || !hyperutil.IsOIDCAuthenticationHC(&cluster) {
+			fmt.Print(string(data))
+			return nil
+		}
+		var adminConfig clientcmdapiv1.Config
+		if err := yaml.Unmarshal(data, &adminConfig); err != nil {
+			return fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		kubeConfig, err := buildOIDCConfig(&cluster, &adminConfig, opts)
+		if err != nil {
+			return fmt.Errorf("failed to make kubeconfig: %w", err)
+		}
+		return serializer.Encode(kubeConfig, os.Stdout)
+	}
+}
+
+const (
+	// issuerURLPlaceholder is replaced with the issuer URL of the OIDC provider in the exec plugin arguments.
+	issuerURLPlaceholder = "{issuer-url}"
+	// clientIDPlaceholder is replaced with the client ID of the OIDC provider in the exec plugin arguments.
+	clientIDPlaceholder = "{client-id}"
+)
+
+// defaultExecArgs invoke the kubectl oidc-login plugin.
+var defaultExecArgs = []string{
+	"oidc-login",
+	"get-token",
+	"--oidc-issuer-url=" + issuerURLPlaceholder,
+	"--oidc-client-id=" + clientIDPlaceholder,
+}
+
+// buildOIDCConfig renders a kubeconfig for a HostedCluster that uses OIDC authentication.
+// The cluster endpoint and CA are taken from the admin kubeconfig, while the user
+// obtains tokens from the OIDC provider through the configured exec plugin.
+func buildOIDCConfig(cluster *hyperv1.HostedCluster, adminConfig *clientcmdapiv1.Config, opts Options) (*clientcmdapiv1.Config, error) {
+	if len(adminConfig.Clusters) == 0 {
+		return nil, fmt.Errorf("admin kubeconfig has no clusters")
+	}
+	if len(cluster.Spec.Authentication.OIDCProviders) == 0 {
+		return nil, fmt.Errorf("hostedcluster has no OIDC providers")
+	}
+	provider := cluster.Spec.Authentication.OIDCProviders[0]
+	clientID := provider.CLIClientID
+	if clientID == "" && len(provider.Issuer.Audiences) > 0 {
+		clientID = provider.Issuer.Audiences[0]
+	}
+
+	replacer := strings.NewReplacer(issuerURLPlaceholder, provider.Issuer.URL, clientIDPlaceholder, clientID)
+	args := make([]string, 0, len(opts.ExecArgs))
+	for _, arg := range opts.ExecArgs {
+		args = append(args, replacer.Replace(arg))
 	}
+
+	name := cluster.Namespace + "-" + cluster.Name
+	return &clientcmdapiv1.Config{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []clientcmdapiv1.NamedCluster{{
+			Name:    name,
+			Cluster: adminConfig.Clusters[0].Cluster,
+		}},
+		AuthInfos: []clientcmdapiv1.NamedAuthInfo{{
+			Name: "oidc",
+			AuthInfo: clientcmdapiv1.AuthInfo{
+				Exec: &clientcmdapiv1.ExecConfig{
+					APIVersion:      "client.authentication.k8s.io/v1beta1",
+					Command:         opts.ExecCommand,
+					Args:            args,
+					InteractiveMode: clientcmdapiv1.IfAvailableExecInteractiveMode,
+				},
+			},
+		}},
+		Contexts: []clientcmdapiv1.NamedContext{{
+			Name: name,
+			Context: clientcmdapiv1.Context{
+				Cluster:   name,
+				AuthInfo:  "oidc",
+				Namespace: "default",
+			},
+		}},
+		CurrentContext: name,
+	}, nil
 }
 
 // NamedConfig adds a name to a Config.
 type NamedConfig struct {
 	*clientcmdapiv1.Config
 	Name string
+	// User names the kind of credentials of the config, defaults to admin.
+	User string
 }
 
 // buildCombinedConfig finds the kubeconfigs for all HostedClusters which report
 // one and merges them into a single kubeconfig. The generated admin context for
 // each cluster will follow the pattern: {hostedcluster.namespace}-{hostedcluster.name}
-func buildCombinedConfig(ctx context.Context, c client.Client, namespace string) (*clientcmdapiv1.Config, error) {
+func buildCombinedConfig(ctx context.Context, c client.Client, opts Options) (*clientcmdapiv1.Config, error) {
 	// Select clusters.
 	var clusters hyperv1.HostedClusterList
-	if err := c.List(ctx, &clusters, client.InNamespace(namespace)); err != nil {
+	if err := c.List(ctx, &clusters, client.InNamespace(opts.Namespace)); err != nil {
 		return nil, fmt.Errorf("failed to list hostedclusters: %w", err)
 	}
 	var filtered []hyperv1.HostedCluster
@@ -174,6 +343,15 @@ func buildCombinedConfig(ctx context.Context, c client.Client, namespace string)
 			Name:   cluster.Namespace + "-" + cluster.Name,
 			Config: &kubeConfig,
 		}
+		if !opts.Admin && hyperutil.IsOIDCAuthenticationHC(&cluster) {
+			oidcConfig, err := buildOIDCConfig(&cluster, &kubeConfig, opts)
+			if err != nil {
+				log.Printf("failed to make OIDC kubeconfig: %s", err)
+				continue
+			}
+			config.Config = oidcConfig
+			config.User = "oidc"
+		}
 		clusterConfigs = append(clusterConfigs, config)
 		log.Printf("added %s to kubeconfig", config.Name)
 	}
@@ -214,8 +392,12 @@ func mergeClusterKubeConfigs(clusterConfigs []NamedConfig) *clientcmdapiv1.Confi
 			Name:    config.Name,
 			Cluster: configCluster,
 		}
+		user := config.User
+		if user == "" {
+			user = "admin"
+		}
 		authInfo := clientcmdapiv1.NamedAuthInfo{
-			Name:     config.Name + "-admin",
+			Name:     config.Name + "-" + user,
 			AuthInfo: configAuthInfo,
 		}
 		ctx := clientcmdapiv1.NamedContext{
@@ -0,0 +1,66 @@
+package kubeconfig
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestBuildOIDCConfig(t *testing.T) {
+	cluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+		Spec: hyperv1.HostedClusterSpec{
+			Authentication: &hyperv1.ClusterAuthenticationSpec{
+				Type: hyperv1.OIDCAuthenticationType,
+				OIDCProviders: []hyperv1.OIDCProvider{{
+					Name:   "sso",
+					Issuer: hyperv1.OIDCIssuer{URL: "https://sso.example.com", Audiences: []string{"hypershift"}},
+				}},
+			},
+		},
+	}
+	adminConfig := &clientcmdapiv1.Config{
+		Clusters: []clientcmdapiv1.NamedCluster{{
+			Name:    "cluster",
+			Cluster: clientcmdapiv1.Cluster{Server: "https://api.example.com:6443", CertificateAuthorityData: []byte("ca")},
+		}},
+	}
+
+	testCases := []struct {
+		name            string
+		execCommand     string
+		execArgs        []string
+		expectedCommand string
+		expectedArgs    []string
+	}{
+		{
+			name:            "When the default exec plugin is used it should invoke kubectl oidc-login",
+			execCommand:     "kubectl",
+			execArgs:        defaultExecArgs,
+			expectedCommand: "kubectl",
+			expectedArgs:    []string{"oidc-login", "get-token", "--oidc-issuer-url=https://sso.example.com", "--oidc-client-id=hypershift"},
+		},
+		{
+			name:            "When a custom exec plugin is configured it should replace the placeholders in its arguments",
+			execCommand:     "kubelogin",
+			execArgs:        []string{"get-token", "--issuer={issuer-url}", "--client={client-id}", "--scope=email"},
+			expectedCommand: "kubelogin",
+			expectedArgs:    []string{"get-token", "--issuer=https://sso.example.com", "--client=hypershift", "--scope=email"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			config, err := buildOIDCConfig(cluster, adminConfig, Options{ExecCommand: tc.execCommand, ExecArgs: tc.execArgs})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(config.AuthInfos).To(HaveLen(1))
+			g.Expect(config.AuthInfos[0].AuthInfo.Exec.Command).To(Equal(tc.expectedCommand))
+			g.Expect(config.AuthInfos[0].AuthInfo.Exec.Args).To(Equal(tc.expectedArgs))
+			g.Expect(config.Clusters[0].Cluster.Server).To(Equal("https://api.example.com:6443"))
+		})
+	}
+}
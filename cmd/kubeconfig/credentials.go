@@ -0,0 +1,250 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	certutil "k8s.io/client-go/util/cert"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/support/certs"
+	"github.com/openshift/hypershift/support/config"
+	hyperutil "github.com/openshift/hypershift/support/util"
+)
+
+const (
+	// EndpointExternal targets the endpoint of the admin kubeconfig, which is reachable
+	// from outside the management cluster.
+	EndpointExternal = "external"
+	// EndpointInternal targets the kube-apiserver service in the control plane namespace,
+	// which is only reachable from inside the management cluster.
+	EndpointInternal = "internal"
+
+	// minimumTTL is the shortest lifetime accepted for both certificate signing requests
+	// and service account tokens.
+	minimumTTL = 10 * time.Minute
+
+	// kubeAPIServerServiceName is the name of the kube-apiserver service in the control
+	// plane namespace.
+	kubeAPIServerServiceName = "kube-apiserver"
+)
+
+// csrPollInterval is the interval at which an approved certificate signing request is
+// checked for its certificate.
+var csrPollInterval = time.Second
+
+// mintsCredentials returns true if opts request short-lived credentials instead of the
+// kubeconfig of the cluster.
+func mintsCredentials(opts Options) bool {
+	return opts.User != "" || opts.ServiceAccount != ""
+}
+
+// validateCredentialOptions verifies the options used to mint short-lived credentials.
+func validateCredentialOptions(opts Options) error {
+	if !mintsCredentials(opts) {
+		if len(opts.Groups) > 0 || len(opts.Audiences) > 0 {
+			return fmt.Errorf("--group requires --user and --audience requires --service-account")
+		}
+		if opts.Endpoint != EndpointExternal {
+			return fmt.Errorf("--endpoint requires --user or --service-account")
+		}
+		return nil
+	}
+	if opts.Name == "" {
+		return fmt.Errorf("--name is required to mint credentials")
+	}
+	if opts.User != "" && opts.ServiceAccount != "" {
+		return fmt.Errorf("only one of --user or --service-account can be set")
+	}
+	if opts.Admin {
+		return fmt.Errorf("--admin cannot be combined with --user or --service-account")
+	}
+	if len(opts.Groups) > 0 && opts.User == "" {
+		return fmt.Errorf("--group requires --user")
+	}
+	if len(opts.Audiences) > 0 && opts.ServiceAccount == "" {
+		return fmt.Errorf("--audience requires --service-account")
+	}
+	if opts.ServiceAccount != "" {
+		if _, _, err := splitServiceAccount(opts.ServiceAccount); err != nil {
+			return err
+		}
+	}
+	if opts.TTL < minimumTTL {
+		return fmt.Errorf("--ttl must be at least %s", minimumTTL)
+	}
+	switch opts.Endpoint {
+	case EndpointExternal, EndpointInternal:
+	default:
+		return fmt.Errorf("invalid --endpoint %q, must be %s or %s", opts.Endpoint, EndpointExternal, EndpointInternal)
+	}
+	return nil
+}
+
+// buildCredentialConfig renders a kubeconfig for a HostedCluster with short-lived
+// credentials minted through the guest cluster API using the admin kubeconfig. The
+// admin credentials are only used to request the new credentials and never end up in
+// the rendered kubeconfig.
+func buildCredentialConfig(ctx context.Context, kubeClient kubernetes.Interface, cluster *hyperv1.HostedCluster, adminConfig *clientcmdapiv1.Config, opts Options) (*clientcmdapiv1.Config, error) {
+	if len(adminConfig.Clusters) == 0 {
+		return nil, fmt.Errorf("admin kubeconfig has no clusters")
+	}
+	kubeCluster := *adminConfig.Clusters[0].Cluster.DeepCopy()
+	if opts.Endpoint == EndpointInternal {
+		kubeCluster.Server = internalServer(cluster)
+	}
+
+	var authInfo clientcmdapiv1.AuthInfo
+	var user string
+	if opts.User != "" {
+		certPEM, keyPEM, err := issueClientCertificate(ctx, kubeClient, opts.User, opts.Groups, opts.TTL)
+		if err != nil {
+			return nil, err
+		}
+		authInfo.ClientCertificateData = certPEM
+		authInfo.ClientKeyData = keyPEM
+		user = opts.User
+	} else {
+		namespace, name, _ := splitServiceAccount(opts.ServiceAccount)
+		token, err := requestServiceAccountToken(ctx, kubeClient, namespace, name, opts.Audiences, opts.TTL)
+		if err != nil {
+			return nil, err
+		}
+		authInfo.Token = token
+		user = namespace + "-" + name
+	}
+
+	config := mergeClusterKubeConfigs([]NamedConfig{{
+		Name: cluster.Namespace + "-" + cluster.Name,
+		User: user,
+		Config: &clientcmdapiv1.Config{
+			Clusters:  []clientcmdapiv1.NamedCluster{{Cluster: kubeCluster}},
+			AuthInfos: []clientcmdapiv1.NamedAuthInfo{{AuthInfo: authInfo}},
+		},
+	}})
+	config.CurrentContext = config.Contexts[0].Name
+	return config, nil
+}
+
+// internalServer returns the URL of the kube-apiserver service of the control plane
+// of cluster.
+func internalServer(cluster *hyperv1.HostedCluster) string {
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(cluster.Namespace, cluster.Name).Name
+	port := hyperutil.APIPortWithDefaultFromHostedCluster(cluster, config.DefaultAPIServerPort)
+	return fmt.Sprintf("https://%s.%s.svc:%d", kubeAPIServerServiceName, controlPlaneNamespace, port)
+}
+
+// issueClientCertificate requests a client certificate for user and groups from the
+// kube-apiserver-client signer of the guest cluster, approves the request and waits for
+// the certificate. The request is deleted once the certificate is issued.
+func issueClientCertificate(ctx context.Context, kubeClient kubernetes.Interface, user string, groups []string, ttl time.Duration) ([]byte, []byte, error) {
+	key, err := certs.PrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	request, err := certutil.MakeCSR(key, &pkix.Name{CommonName: user, Organization: groups}, nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate signing request: %w", err)
+	}
+	expirationSeconds := int32(ttl.Seconds())
+	csr := &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "hypershift-kubeconfig-",
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:           request,
+			SignerName:        certificatesv1.KubeAPIServerClientSignerName,
+			ExpirationSeconds: &expirationSeconds,
+			Usages: []certificatesv1.KeyUsage{
+				certificatesv1.UsageDigitalSignature,
+				certificatesv1.UsageKeyEncipherment,
+				certificatesv1.UsageClientAuth,
+			},
+		},
+	}
+	csrClient := kubeClient.CertificatesV1().CertificateSigningRequests()
+	csr, err = csrClient.Create(ctx, csr, metav1.CreateOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate signing request: %w", err)
+	}
+	defer func() {
+		if err := csrClient.Delete(ctx, csr.Name, metav1.DeleteOptions{}); err != nil {
+			log.Printf("failed to delete certificate signing request %s: %s", csr.Name, err)
+		}
+	}()
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateApproved,
+		Status:         corev1.ConditionTrue,
+		Reason:         "HyperShiftCreateKubeconfig",
+		Message:        fmt.Sprintf("Approved by hypershift create kubeconfig for user %s", user),
+		LastUpdateTime: metav1.Now(),
+	})
+	if _, err := csrClient.UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return nil, nil, fmt.Errorf("failed to approve certificate signing request %s: %w", csr.Name, err)
+	}
+
+	var certPEM []byte
+	waitCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	err = wait.PollImmediateUntilWithContext(waitCtx, csrPollInterval, func(ctx context.Context) (bool, error) {
+		current, err := csrClient.Get(ctx, csr.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range current.Status.Conditions {
+			if condition.Type == certificatesv1.CertificateDenied || condition.Type == certificatesv1.CertificateFailed {
+				return false, fmt.Errorf("certificate signing request %s: %s: %s", csr.Name, condition.Reason, condition.Message)
+			}
+		}
+		certPEM = current.Status.Certificate
+		return len(certPEM) > 0, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the certificate of certificate signing request %s: %w", csr.Name, err)
+	}
+	log.Printf("issued client certificate for user %s valid for %s", user, ttl)
+	return certPEM, certs.PrivateKeyToPem(key), nil
+}
+
+// requestServiceAccountToken requests a token bound to audiences for the service
+// account namespace/name of the guest cluster.
+func requestServiceAccountToken(ctx context.Context, kubeClient kubernetes.Interface, namespace, name string, audiences []string, ttl time.Duration) (string, error) {
+	expirationSeconds := int64(ttl.Seconds())
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         audiences,
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+	tokenRequest, err := kubeClient.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to request a token for service account %s/%s: %w", namespace, name, err)
+	}
+	if tokenRequest.Status.Token == "" {
+		return "", fmt.Errorf("token request for service account %s/%s returned no token", namespace, name)
+	}
+	log.Printf("issued token for service account %s/%s valid until %s", namespace, name, tokenRequest.Status.ExpirationTimestamp.Format(time.RFC3339))
+	return tokenRequest.Status.Token, nil
+}
+
+// splitServiceAccount splits a service account reference of the form namespace/name.
+func splitServiceAccount(serviceAccount string) (string, string, error) {
+	parts := strings.Split(serviceAccount, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid --service-account %q, must be namespace/name", serviceAccount)
+	}
+	return parts[0], parts[1], nil
+}
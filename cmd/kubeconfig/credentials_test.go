@@ -0,0 +1,166 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestBuildCredentialConfig(t *testing.T) {
+	cluster := &hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"}}
+	adminConfig := &clientcmdapiv1.Config{
+		Clusters: []clientcmdapiv1.NamedCluster{{
+			Name:    "cluster",
+			Cluster: clientcmdapiv1.Cluster{Server: "https://api.example.com:6443", CertificateAuthorityData: []byte("ca")},
+		}},
+		AuthInfos: []clientcmdapiv1.NamedAuthInfo{{
+			Name:     "admin",
+			AuthInfo: clientcmdapiv1.AuthInfo{ClientCertificateData: []byte("admin-cert"), ClientKeyData: []byte("admin-key")},
+		}},
+	}
+
+	testCases := []struct {
+		name     string
+		opts     Options
+		validate func(g *WithT, config *clientcmdapiv1.Config, kubeClient *kubefake.Clientset)
+	}{
+		{
+			name: "When a user is requested it should mint an approved client certificate for the external endpoint",
+			opts: Options{User: "jdoe", Groups: []string{"developers"}, TTL: time.Hour, Endpoint: EndpointExternal},
+			validate: func(g *WithT, config *clientcmdapiv1.Config, kubeClient *kubefake.Clientset) {
+				g.Expect(config.CurrentContext).To(Equal("clusters-example"))
+				g.Expect(config.Clusters[0].Cluster.Server).To(Equal("https://api.example.com:6443"))
+				g.Expect(config.Clusters[0].Cluster.CertificateAuthorityData).To(Equal([]byte("ca")))
+				g.Expect(config.AuthInfos[0].Name).To(Equal("clusters-example-jdoe"))
+				g.Expect(config.AuthInfos[0].AuthInfo.ClientCertificateData).To(Equal([]byte("signed")))
+				g.Expect(config.AuthInfos[0].AuthInfo.ClientKeyData).ToNot(BeEmpty())
+				g.Expect(config.AuthInfos[0].AuthInfo.ClientKeyData).ToNot(Equal([]byte("admin-key")))
+
+				var approved bool
+				for _, action := range kubeClient.Actions() {
+					if action.GetVerb() == "update" && action.GetSubresource() == "approval" {
+						approved = true
+					}
+				}
+				g.Expect(approved).To(BeTrue())
+				csrs, err := kubeClient.CertificatesV1().CertificateSigningRequests().List(context.Background(), metav1.ListOptions{})
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(csrs.Items).To(BeEmpty())
+			},
+		},
+		{
+			name: "When a service account is requested it should mint a token for the internal endpoint",
+			opts: Options{ServiceAccount: "default/deployer", Audiences: []string{"deployer"}, TTL: 2 * time.Hour, Endpoint: EndpointInternal},
+			validate: func(g *WithT, config *clientcmdapiv1.Config, kubeClient *kubefake.Clientset) {
+				g.Expect(config.Clusters[0].Cluster.Server).To(Equal("https://kube-apiserver.clusters-example.svc:6443"))
+				g.Expect(config.AuthInfos[0].Name).To(Equal("clusters-example-default-deployer"))
+				g.Expect(config.AuthInfos[0].AuthInfo.Token).To(Equal("token"))
+				g.Expect(config.AuthInfos[0].AuthInfo.ClientCertificateData).To(BeEmpty())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "certificatesigningrequests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				csr := action.(clienttesting.CreateAction).GetObject().(*certificatesv1.CertificateSigningRequest)
+				block, _ := pem.Decode(csr.Spec.Request)
+				request, err := x509.ParseCertificateRequest(block.Bytes)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(request.Subject.CommonName).To(Equal(tc.opts.User))
+				g.Expect(request.Subject.Organization).To(Equal(tc.opts.Groups))
+				g.Expect(csr.Spec.SignerName).To(Equal(certificatesv1.KubeAPIServerClientSignerName))
+				g.Expect(*csr.Spec.ExpirationSeconds).To(Equal(int32(tc.opts.TTL.Seconds())))
+				csr.Name = "csr"
+				csr.Status.Certificate = []byte("signed")
+				return false, nil, nil
+			})
+			kubeClient.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				g.Expect(action.GetSubresource()).To(Equal("token"))
+				g.Expect(action.GetNamespace()).To(Equal("default"))
+				tokenRequest := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+				g.Expect(tokenRequest.Spec.Audiences).To(Equal(tc.opts.Audiences))
+				g.Expect(*tokenRequest.Spec.ExpirationSeconds).To(Equal(int64(tc.opts.TTL.Seconds())))
+				tokenRequest.Status.Token = "token"
+				return true, tokenRequest, nil
+			})
+
+			config, err := buildCredentialConfig(context.Background(), kubeClient, cluster, adminConfig, tc.opts)
+			g.Expect(err).ToNot(HaveOccurred())
+			tc.validate(g, config, kubeClient)
+		})
+	}
+}
+
+func TestValidateCredentialOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        Options
+		expectError bool
+	}{
+		{
+			name: "When no credentials are requested it should be valid",
+			opts: Options{Endpoint: EndpointExternal},
+		},
+		{
+			name: "When a user is requested for a cluster it should be valid",
+			opts: Options{Name: "example", User: "jdoe", Groups: []string{"developers"}, TTL: time.Hour, Endpoint: EndpointInternal},
+		},
+		{
+			name:        "When credentials are requested without a cluster name it should fail",
+			opts:        Options{User: "jdoe", TTL: time.Hour, Endpoint: EndpointExternal},
+			expectError: true,
+		},
+		{
+			name:        "When both a user and a service account are requested it should fail",
+			opts:        Options{Name: "example", User: "jdoe", ServiceAccount: "default/deployer", TTL: time.Hour, Endpoint: EndpointExternal},
+			expectError: true,
+		},
+		{
+			name:        "When the service account has no namespace it should fail",
+			opts:        Options{Name: "example", ServiceAccount: "deployer", TTL: time.Hour, Endpoint: EndpointExternal},
+			expectError: true,
+		},
+		{
+			name:        "When the TTL is shorter than the minimum it should fail",
+			opts:        Options{Name: "example", User: "jdoe", TTL: time.Minute, Endpoint: EndpointExternal},
+			expectError: true,
+		},
+		{
+			name:        "When groups are set without a user it should fail",
+			opts:        Options{Name: "example", Groups: []string{"developers"}, Endpoint: EndpointExternal},
+			expectError: true,
+		},
+		{
+			name:        "When the endpoint is unknown it should fail",
+			opts:        Options{Name: "example", User: "jdoe", TTL: time.Hour, Endpoint: "public"},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := validateCredentialOptions(tc.opts)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
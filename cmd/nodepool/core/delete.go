@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type DeleteNodePoolOptions struct {
+	Name      string
+	Namespace string
+	// DrainTimeout overrides spec.nodeDrainTimeout before deleting when set.
+	DrainTimeout time.Duration
+	Wait         bool
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+func (o *DeleteNodePoolOptions) RunFunc() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		client, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := o.DeleteNodePool(cmd.Context(), client, cmd.OutOrStdout()); err != nil {
+			log.Log.Error(err, "Failed to delete nodepool")
+			return err
+		}
+		return nil
+	}
+}
+
+// DeleteNodePool deletes the NodePool, optionally overriding how long nodes are drained first.
+func (o *DeleteNodePoolOptions) DeleteNodePool(ctx context.Context, client crclient.Client, out io.Writer) error {
+	nodePool := &hyperv1.NodePool{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, nodePool); err != nil {
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(out, "NodePool %s/%s not found\n", o.Namespace, o.Name)
+			return nil
+		}
+		return fmt.Errorf("failed to get NodePool %s/%s: %w", o.Namespace, o.Name, err)
+	}
+
+	if o.DrainTimeout > 0 {
+		original := nodePool.DeepCopy()
+		nodePool.Spec.NodeDrainTimeout = &metav1.Duration{Duration: o.DrainTimeout}
+		if err := client.Patch(ctx, nodePool, crclient.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to set drain timeout of NodePool %s/%s: %w", o.Namespace, o.Name, err)
+		}
+	}
+
+	if err := client.Delete(ctx, nodePool); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete NodePool %s/%s: %w", o.Namespace, o.Name, err)
+	}
+	fmt.Fprintf(out, "NodePool %s deleted\n", o.Name)
+
+	if !o.Wait {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	err := wait.PollImmediateUntil(o.PollInterval, func() (bool, error) {
+		if err := client.Get(ctx, crclient.ObjectKeyFromObject(nodePool), &hyperv1.NodePool{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			log.Log.Error(err, "Failed to get nodepool")
+		}
+		return false, nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("NodePool %s/%s was not removed: %w", o.Namespace, o.Name, err)
+	}
+	fmt.Fprintf(out, "NodePool %s removed\n", o.Name)
+	return nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+type ScaleNodePoolOptions struct {
+	Name           string
+	Namespace      string
+	Replicas       int32
+	AutoscalingMin int32
+	AutoscalingMax int32
+	Wait           bool
+	Timeout        time.Duration
+	PollInterval   time.Duration
+}
+
+func (o *ScaleNodePoolOptions) Validate(replicasSet, minSet, maxSet bool) error {
+	if replicasSet == (minSet || maxSet) {
+		return fmt.Errorf("exactly one of --replicas or --autoscaling-min/--autoscaling-max is required")
+	}
+	if minSet != maxSet {
+		return fmt.Errorf("--autoscaling-min and --autoscaling-max must be set together")
+	}
+	if replicasSet && o.Replicas < 0 {
+		return fmt.Errorf("--replicas must not be negative")
+	}
+	if minSet && (o.AutoscalingMin < 1 || o.AutoscalingMax < o.AutoscalingMin) {
+		return fmt.Errorf("--autoscaling-min must be at least 1 and not greater than --autoscaling-max")
+	}
+	if o.Wait && minSet {
+		return fmt.Errorf("--wait is only supported with --replicas")
+	}
+	return nil
+}
+
+func (o *ScaleNodePoolOptions) RunFunc() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := o.Validate(cmd.Flags().Changed("replicas"), cmd.Flags().Changed("autoscaling-min"), cmd.Flags().Changed("autoscaling-max")); err != nil {
+			return err
+		}
+		client, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := o.ScaleNodePool(cmd.Context(), client, cmd.OutOrStdout()); err != nil {
+			log.Log.Error(err, "Failed to scale nodepool")
+			return err
+		}
+		return nil
+	}
+}
+
+// ScaleNodePool sets a fixed number of replicas or autoscaling bounds on the NodePool.
+// Autoscaling is used when AutoscalingMax is set.
+func (o *ScaleNodePoolOptions) ScaleNodePool(ctx context.Context, client crclient.Client, out io.Writer) error {
+	nodePool := &hyperv1.NodePool{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, nodePool); err != nil {
+		return fmt.Errorf("failed to get NodePool %s/%s: %w", o.Namespace, o.Name, err)
+	}
+
+	original := nodePool.DeepCopy()
+	if o.AutoscalingMax > 0 {
+		nodePool.Spec.Replicas = nil
+		nodePool.Spec.AutoScaling = &hyperv1.NodePoolAutoScaling{
+			Min: o.AutoscalingMin,
+			Max: o.AutoscalingMax,
+		}
+	} else {
+		replicas := o.Replicas
+		nodePool.Spec.Replicas = &replicas
+		nodePool.Spec.AutoScaling = nil
+	}
+	if err := client.Patch(ctx, nodePool, crclient.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to scale NodePool %s/%s: %w", o.Namespace, o.Name, err)
+	}
+	if o.AutoscalingMax > 0 {
+		fmt.Fprintf(out, "NodePool %s autoscaling set to %d-%d nodes\n", o.Name, o.AutoscalingMin, o.AutoscalingMax)
+		return nil
+	}
+	fmt.Fprintf(out, "NodePool %s scaled to %d nodes\n", o.Name, o.Replicas)
+
+	if !o.Wait {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	var lastReplicas int32 = -1
+	err := wait.PollImmediateUntil(o.PollInterval, func() (bool, error) {
+		current := &hyperv1.NodePool{}
+		if err := client.Get(ctx, crclient.ObjectKeyFromObject(nodePool), current); err != nil {
+			log.Log.Error(err, "Failed to get nodepool")
+			return false, nil
+		}
+		if current.Status.Replicas != lastReplicas {
+			fmt.Fprintf(out, "NodePool %s: %d/%d nodes\n", o.Name, current.Status.Replicas, o.Replicas)
+			lastReplicas = current.Status.Replicas
+		}
+		return current.Status.Replicas == o.Replicas, nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("NodePool %s/%s did not reach %d nodes: %w", o.Namespace, o.Name, o.Replicas, err)
+	}
+	return nil
+}
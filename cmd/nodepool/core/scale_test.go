@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestScaleNodePool(t *testing.T) {
+	testCases := []struct {
+		name                string
+		opts                ScaleNodePoolOptions
+		replicasSet         bool
+		autoscalingSet      bool
+		expectValidationErr bool
+		expectedReplicas    *int32
+		expectedAutoScaling *hyperv1.NodePoolAutoScaling
+	}{
+		{
+			name:             "When replicas are set it should disable autoscaling",
+			opts:             ScaleNodePoolOptions{Replicas: 5},
+			replicasSet:      true,
+			expectedReplicas: pointer.Int32(5),
+		},
+		{
+			name:                "When autoscaling bounds are set it should clear replicas",
+			opts:                ScaleNodePoolOptions{AutoscalingMin: 1, AutoscalingMax: 4},
+			autoscalingSet:      true,
+			expectedAutoScaling: &hyperv1.NodePoolAutoScaling{Min: 1, Max: 4},
+		},
+		{
+			name:                "When both replicas and autoscaling are set it should fail validation",
+			opts:                ScaleNodePoolOptions{Replicas: 5, AutoscalingMin: 1, AutoscalingMax: 4},
+			replicasSet:         true,
+			autoscalingSet:      true,
+			expectValidationErr: true,
+		},
+		{
+			name:                "When autoscaling min is greater than max it should fail validation",
+			opts:                ScaleNodePoolOptions{AutoscalingMin: 5, AutoscalingMax: 4},
+			autoscalingSet:      true,
+			expectValidationErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := tc.opts.Validate(tc.replicasSet, tc.autoscalingSet, tc.autoscalingSet)
+			if tc.expectValidationErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			nodePool := &hyperv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+				Spec:       hyperv1.NodePoolSpec{Replicas: pointer.Int32(2)},
+			}
+			c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(nodePool).Build()
+			tc.opts.Namespace, tc.opts.Name = "clusters", "example"
+			g.Expect(tc.opts.ScaleNodePool(context.Background(), c, &bytes.Buffer{})).To(Succeed())
+
+			g.Expect(c.Get(context.Background(), crclient.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+			g.Expect(nodePool.Spec.Replicas).To(Equal(tc.expectedReplicas))
+			g.Expect(nodePool.Spec.AutoScaling).To(Equal(tc.expectedAutoScaling))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	"github.com/openshift/hypershift/support/supportedversion"
+)
+
+type UpgradeNodePoolOptions struct {
+	Name         string
+	Namespace    string
+	ReleaseImage string
+	Wait         bool
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+func (o *UpgradeNodePoolOptions) RunFunc() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		client, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := o.UpgradeNodePool(cmd.Context(), client, &releaseinfo.RegistryClientProvider{}, cmd.OutOrStdout()); err != nil {
+			log.Log.Error(err, "Failed to upgrade nodepool")
+			return err
+		}
+		return nil
+	}
+}
+
+// UpgradeNodePool updates the release image of the NodePool after checking it against the
+// release of its HostedCluster the same way the NodePool controller does.
+func (o *UpgradeNodePoolOptions) UpgradeNodePool(ctx context.Context, client crclient.Client, provider releaseinfo.Provider, out io.Writer) error {
+	nodePool := &hyperv1.NodePool{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}, nodePool); err != nil {
+		return fmt.Errorf("failed to get NodePool %s/%s: %w", o.Namespace, o.Name, err)
+	}
+	hcluster := &hyperv1.HostedCluster{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: o.Namespace, Name: nodePool.Spec.ClusterName}, hcluster); err != nil {
+		return fmt.Errorf("failed to get HostedCluster %s/%s: %w", o.Namespace, nodePool.Spec.ClusterName, err)
+	}
+	releaseImage := o.ReleaseImage
+	if releaseImage == "" {
+		releaseImage = hcluster.Spec.Release.Image
+	}
+
+	pullSecret := &corev1.Secret{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: hcluster.Namespace, Name: hcluster.Spec.PullSecret.Name}, pullSecret); err != nil {
+		return fmt.Errorf("failed to get pull secret: %w", err)
+	}
+	lookupVersion := func(image string) (*semver.Version, error) {
+		releaseInfo, err := provider.Lookup(ctx, image, pullSecret.Data[corev1.DockerConfigJsonKey])
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup release image %s: %w", image, err)
+		}
+		version, err := semver.Parse(releaseInfo.Version())
+		if err != nil {
+			return nil, err
+		}
+		return &version, nil
+	}
+	targetVersion, err := lookupVersion(releaseImage)
+	if err != nil {
+		return err
+	}
+	currentVersion, err := lookupVersion(nodePool.Spec.Release.Image)
+	if err != nil {
+		return err
+	}
+	hostedClusterVersion, err := lookupVersion(hcluster.Spec.Release.Image)
+	if err != nil {
+		return err
+	}
+	minSupportedVersion := supportedversion.MinSupportedVersion
+	if hcluster.Spec.Platform.Type == hyperv1.IBMCloudPlatform {
+		//IBM Cloud is allowed to manage 4.9 clusters
+		minSupportedVersion = semver.MustParse("4.9.0")
+	}
+	if err := supportedversion.IsValidReleaseVersion(targetVersion, currentVersion, hostedClusterVersion, &minSupportedVersion, hcluster.Spec.Networking.NetworkType, hcluster.Spec.Platform.Type); err != nil {
+		return fmt.Errorf("cannot upgrade NodePool %s from %s to %s: %w", o.Name, currentVersion, targetVersion, err)
+	}
+
+	if nodePool.Spec.Release.Image != releaseImage {
+		original := nodePool.DeepCopy()
+		nodePool.Spec.Release.Image = releaseImage
+		if err := client.Patch(ctx, nodePool, crclient.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to update NodePool %s/%s: %w", o.Namespace, o.Name, err)
+		}
+		fmt.Fprintf(out, "NodePool %s upgrading from %s to %s\n", o.Name, currentVersion, targetVersion)
+	} else {
+		fmt.Fprintf(out, "NodePool %s release image is already %s\n", o.Name, releaseImage)
+	}
+
+	if !o.Wait {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	return WaitForNodePoolVersion(ctx, client, nodePool, targetVersion.String(), o.PollInterval, out)
+}
+
+// WaitForNodePoolVersion waits until all nodes of the NodePool run the target version,
+// printing progress whenever the UpdatingVersion condition changes.
+func WaitForNodePoolVersion(ctx context.Context, client crclient.Client, nodePool *hyperv1.NodePool, targetVersion string, interval time.Duration, out io.Writer) error {
+	var lastProgress string
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		current := &hyperv1.NodePool{}
+		if err := client.Get(ctx, crclient.ObjectKeyFromObject(nodePool), current); err != nil {
+			log.Log.Error(err, "Failed to get nodepool")
+			return false, nil
+		}
+		updating := false
+		progress := "version " + current.Status.Version
+		if current.Status.Version == "" {
+			progress = "version <unknown>"
+		}
+		for _, condition := range current.Status.Conditions {
+			switch {
+			case condition.Type == hyperv1.NodePoolUpdatingVersionConditionType && condition.Status == corev1.ConditionTrue:
+				updating = true
+				progress += ", updating: " + condition.Message
+			case condition.Type == hyperv1.NodePoolValidReleaseImageConditionType && condition.Status == corev1.ConditionFalse && condition.ObservedGeneration == current.Generation:
+				return false, fmt.Errorf("release image is not valid: %s", condition.Message)
+			}
+		}
+		if progress != lastProgress {
+			fmt.Fprintf(out, "NodePool %s/%s: %s\n", current.Namespace, current.Name, progress)
+			lastProgress = progress
+		}
+		return current.Status.Version == targetVersion && !updating, nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("NodePool %s upgrade did not complete: %w", nodePool.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	fakereleaseprovider "github.com/openshift/hypershift/support/releaseinfo/fake"
+)
+
+func TestUpgradeNodePool(t *testing.T) {
+	releaseProvider := &fakereleaseprovider.FakeReleaseProvider{ImageVersion: map[string]string{
+		"release:4.12.0": "4.12.0",
+		"release:4.12.1": "4.12.1",
+		"release:4.13.0": "4.13.0",
+	}}
+	testCases := []struct {
+		name          string
+		releaseImage  string
+		nodePoolState hyperv1.NodePoolStatus
+		expectErr     bool
+	}{
+		{
+			name:          "When no release image is given it should upgrade to the HostedCluster release and wait for completion",
+			nodePoolState: hyperv1.NodePoolStatus{Version: "4.12.1"},
+		},
+		{
+			name:         "When the release is newer than the HostedCluster it should fail",
+			releaseImage: "release:4.13.0",
+			expectErr:    true,
+		},
+		{
+			name: "When the NodePool reports an invalid release image it should stop waiting and fail",
+			nodePoolState: hyperv1.NodePoolStatus{Version: "4.12.0", Conditions: []hyperv1.NodePoolCondition{
+				{Type: hyperv1.NodePoolValidReleaseImageConditionType, Status: corev1.ConditionFalse, Message: "invalid"},
+			}},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			hcluster := &hyperv1.HostedCluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+				Spec: hyperv1.HostedClusterSpec{
+					Release:    hyperv1.Release{Image: "release:4.12.1"},
+					PullSecret: corev1.LocalObjectReference{Name: "pull-secret"},
+					Networking: hyperv1.ClusterNetworking{NetworkType: hyperv1.OVNKubernetes},
+				},
+			}
+			pullSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "pull-secret"},
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+			}
+			nodePool := &hyperv1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+				Spec:       hyperv1.NodePoolSpec{ClusterName: "example", Release: hyperv1.Release{Image: "release:4.12.0"}},
+				Status:     tc.nodePoolState,
+			}
+			c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(hcluster, pullSecret, nodePool).Build()
+			opts := UpgradeNodePoolOptions{
+				Namespace:    "clusters",
+				Name:         "example",
+				ReleaseImage: tc.releaseImage,
+				Wait:         true,
+				Timeout:      5 * time.Second,
+				PollInterval: 10 * time.Millisecond,
+			}
+			err := opts.UpgradeNodePool(context.Background(), c, releaseProvider, &bytes.Buffer{})
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.Get(context.Background(), crclient.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+			g.Expect(nodePool.Spec.Release.Image).To(Equal("release:4.12.1"))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package nodepool
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift/cmd/get"
+	"github.com/openshift/hypershift/cmd/log"
+	"github.com/openshift/hypershift/cmd/nodepool/core"
+	"github.com/openshift/hypershift/cmd/util"
+)
+
+// NewCommand returns the commands managing the lifecycle of existing NodePools.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "nodepool",
+		Short:        "Commands for managing HyperShift NodePools",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(newListCommand())
+	cmd.AddCommand(newScaleCommand())
+	cmd.AddCommand(newUpgradeCommand())
+	cmd.AddCommand(newDeleteCommand())
+
+	return cmd
+}
+
+func newListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "list",
+		Short:        "Lists NodePools",
+		SilenceUsage: true,
+	}
+
+	opts := get.NodePoolsOptions{Options: get.Options{Namespace: "clusters", Output: get.OutputTable}}
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the NodePools")
+	cmd.Flags().StringVar(&opts.ClusterName, "cluster-name", opts.ClusterName, "Only list NodePools of this HostedCluster")
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces, "List NodePools in all namespaces")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "Output format, one of: table, wide, json, yaml")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		client, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := get.GetNodePools(cmd.Context(), client, opts, os.Stdout); err != nil {
+			log.Log.Error(err, "Failed to list nodepools")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+func newScaleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "scale",
+		Short:        "Sets the number of nodes or the autoscaling bounds of a NodePool",
+		SilenceUsage: true,
+	}
+
+	opts := &core.ScaleNodePoolOptions{
+		Namespace:    "clusters",
+		Timeout:      30 * time.Minute,
+		PollInterval: 10 * time.Second,
+	}
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the NodePool")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the NodePool")
+	cmd.Flags().Int32Var(&opts.Replicas, "replicas", opts.Replicas, "The number of nodes, disables autoscaling")
+	cmd.Flags().Int32Var(&opts.AutoscalingMin, "autoscaling-min", opts.AutoscalingMin, "The minimum number of nodes when autoscaling")
+	cmd.Flags().Int32Var(&opts.AutoscalingMax, "autoscaling-max", opts.AutoscalingMax, "The maximum number of nodes when autoscaling")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", opts.Wait, "Wait until the NodePool has the requested number of nodes")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "How long to wait for the NodePool to scale")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = opts.RunFunc()
+
+	return cmd
+}
+
+func newUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "upgrade",
+		Short:        "Upgrades the nodes of a NodePool to a release image",
+		SilenceUsage: true,
+	}
+
+	opts := &core.UpgradeNodePoolOptions{
+		Namespace:    "clusters",
+		Wait:         true,
+		Timeout:      time.Hour,
+		PollInterval: 10 * time.Second,
+	}
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the NodePool")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the NodePool")
+	cmd.Flags().StringVar(&opts.ReleaseImage, "release-image", opts.ReleaseImage, "The release image for nodes. If empty, defaults to the release image of the HostedCluster.")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", opts.Wait, "Follow the upgrade until all nodes run the new release")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "How long to wait for the upgrade to complete")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = opts.RunFunc()
+
+	return cmd
+}
+
+func newDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "delete",
+		Short:        "Deletes a NodePool and its nodes",
+		SilenceUsage: true,
+	}
+
+	opts := &core.DeleteNodePoolOptions{
+		Namespace:    "clusters",
+		Timeout:      30 * time.Minute,
+		PollInterval: 10 * time.Second,
+	}
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the NodePool")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the NodePool")
+	cmd.Flags().DurationVar(&opts.DrainTimeout, "drain-timeout", opts.DrainTimeout, "Override how long each node is drained before it is removed, e.g. 1s to remove nodes without waiting for evictions. If unset the NodePool's nodeDrainTimeout is kept")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", opts.Wait, "Wait until the NodePool and its nodes are removed")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "How long to wait for the NodePool to be removed")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = opts.RunFunc()
+
+	return cmd
+}
@@ -0,0 +1,150 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/support/preflight"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+type Options struct {
+	Stage              string
+	AvailabilityPolicy string
+	PublishingStrategy string
+	EtcdStorageClass   string
+	ExternalDNSDomain  string
+	Output             string
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "preflight",
+		Short:        "Checks that a management cluster can host HyperShift control planes",
+		SilenceUsage: true,
+	}
+
+	opts := Options{
+		Stage:              string(preflight.StageInstall),
+		AvailabilityPolicy: string(hyperv1.SingleReplica),
+		PublishingStrategy: string(hyperv1.LoadBalancer),
+		Output:             OutputTable,
+	}
+
+	cmd.Flags().StringVar(&opts.Stage, "stage", opts.Stage, "When the checks are run, one of: install, create. The create stage also requires HyperShift to be installed")
+	cmd.Flags().StringVar(&opts.AvailabilityPolicy, "availability-policy", opts.AvailabilityPolicy, "The control plane availability policy to check node capacity for. Supported options: SingleReplica, HighlyAvailable")
+	cmd.Flags().StringVar(&opts.PublishingStrategy, "publishing-strategy", opts.PublishingStrategy, "The publishing strategy of the API server. Supported options: LoadBalancer, NodePort, Route")
+	cmd.Flags().StringVar(&opts.EtcdStorageClass, "etcd-storage-class", opts.EtcdStorageClass, "The storage class for etcd data volumes. The default storage class is checked if not set")
+	cmd.Flags().StringVar(&opts.ExternalDNSDomain, "external-dns-domain", opts.ExternalDNSDomain, "The domain managed by external-dns to check for resolvability")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "Output format, one of: table, json")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		results, err := Run(cmd.Context(), opts.checkOptions())
+		if err != nil {
+			return err
+		}
+		if err := printResults(cmd.OutOrStdout(), opts.Output, results); err != nil {
+			return err
+		}
+		if preflight.Failed(results) {
+			return errors.New("preflight checks failed")
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *Options) Validate() error {
+	switch preflight.Stage(o.Stage) {
+	case preflight.StageInstall, preflight.StageCreate:
+	default:
+		return fmt.Errorf("unsupported stage %q, must be one of: install, create", o.Stage)
+	}
+	switch hyperv1.AvailabilityPolicy(o.AvailabilityPolicy) {
+	case hyperv1.SingleReplica, hyperv1.HighlyAvailable:
+	default:
+		return fmt.Errorf("unsupported availability policy %q, must be one of: SingleReplica, HighlyAvailable", o.AvailabilityPolicy)
+	}
+	switch hyperv1.PublishingStrategyType(o.PublishingStrategy) {
+	case hyperv1.LoadBalancer, hyperv1.NodePort, hyperv1.Route:
+	default:
+		return fmt.Errorf("unsupported publishing strategy %q, must be one of: LoadBalancer, NodePort, Route", o.PublishingStrategy)
+	}
+	switch o.Output {
+	case OutputTable, OutputJSON:
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: table, json", o.Output)
+	}
+	return nil
+}
+
+func (o *Options) checkOptions() preflight.Options {
+	return preflight.Options{
+		Stage:              preflight.Stage(o.Stage),
+		AvailabilityPolicy: hyperv1.AvailabilityPolicy(o.AvailabilityPolicy),
+		PublishingStrategy: hyperv1.PublishingStrategyType(o.PublishingStrategy),
+		EtcdStorageClass:   o.EtcdStorageClass,
+		ExternalDNSDomain:  o.ExternalDNSDomain,
+	}
+}
+
+// Run checks the management cluster of the current kubeconfig.
+func Run(ctx context.Context, opts preflight.Options) ([]preflight.Result, error) {
+	config, err := util.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := util.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	checker, err := preflight.NewChecker(client, config)
+	if err != nil {
+		return nil, err
+	}
+	return checker.Run(ctx, opts), nil
+}
+
+func printResults(out io.Writer, format string, results []preflight.Result) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Name, result.Status, result.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	header := "\nRemediation:"
+	for _, result := range results {
+		if result.Remediation == "" {
+			continue
+		}
+		if header != "" {
+			fmt.Fprintln(out, header)
+			header = ""
+		}
+		fmt.Fprintf(out, "  %s: %s\n", result.Name, result.Remediation)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/ignition-server/controllers"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	"github.com/openshift/hypershift/support/util"
+)
+
+const (
+	// renderNamespace is the namespace the control plane manifests are served from
+	// to the ignition provider.
+	renderNamespace = "render"
+
+	mcsConfigMapName       = "machine-config-server"
+	bootstrapKubeconfigKey = "kubeconfig"
+	bootstrapKubeconfig    = "bootstrap-kubeconfig"
+
+	// placeholderKubeconfig is the bootstrap kubeconfig of payloads rendered without
+	// the one of the control plane.
+	placeholderKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: local
+  cluster:
+    server: https://api.render.invalid:6443
+contexts:
+- name: local
+  context:
+    cluster: local
+    user: kubelet
+current-context: local
+users:
+- name: kubelet
+  user: {}
+`
+)
+
+type IgnitionOptions struct {
+	HostedClusterFile         string
+	NodePoolFile              string
+	ConfigFiles               []string
+	ControlPlaneManifestFiles []string
+	ReleaseImage              string
+	PullSecretFile            string
+	CompareReleaseImage       string
+	CompareTo                 string
+	OutputFile                string
+	WorkDir                   string
+	HypershiftOperatorImage   string
+}
+
+func NewIgnitionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ignition",
+		Short: "Renders the ignition payload of a NodePool locally",
+		Long: `Renders the ignition payload of a NodePool locally, the way the ignition server does, without a cluster.
+
+The config of the NodePool is assembled the way the NodePool controller does, including the apiserver haproxy config
+generated by the HyperShift operator. It requires the HostedCluster, and its kubeconfig Secret for clusters with a
+public API server, in --hostedcluster.
+
+The control plane inputs of the payload are read from --control-plane-manifests, which must contain the
+machine-config-server ConfigMap of the control plane namespace. Core ignition ConfigMaps and the bootstrap-kubeconfig
+Secret are used if present; a placeholder kubeconfig is rendered otherwise. These can be captured once with:
+
+  oc get -n <control plane namespace> -o yaml configmap/machine-config-server > control-plane.yaml
+  echo --- >> control-plane.yaml
+  oc get -n <control plane namespace> -o yaml configmap -l hypershift.openshift.io/core-ignition-config >> control-plane.yaml
+
+The machine-config binaries of the release image are run locally, which requires Linux.
+
+The payload is printed unless it is compared to the render of another release image or to a previous render, in which
+case the files and units that changed are listed.`,
+		SilenceUsage: true,
+	}
+
+	opts := IgnitionOptions{}
+	cmd.Flags().StringVar(&opts.HostedClusterFile, "hostedcluster", opts.HostedClusterFile, "File containing the HostedCluster manifest of the NodePool, and its kubeconfig Secret")
+	cmd.Flags().StringVar(&opts.NodePoolFile, "nodepool", opts.NodePoolFile, "File containing the NodePool manifest")
+	cmd.Flags().StringArrayVar(&opts.ConfigFiles, "config", opts.ConfigFiles, "File containing the ConfigMaps referenced by the NodePool config. Can be repeated")
+	cmd.Flags().StringArrayVar(&opts.ControlPlaneManifestFiles, "control-plane-manifests", opts.ControlPlaneManifestFiles, "File containing manifests of the control plane namespace, including the machine-config-server ConfigMap. Can be repeated")
+	cmd.Flags().StringVar(&opts.ReleaseImage, "release-image", opts.ReleaseImage, "The release image to render the payload of (default: the release image of the NodePool)")
+	cmd.Flags().StringVar(&opts.PullSecretFile, "pull-secret", opts.PullSecretFile, "File containing the pull secret of the release image")
+	cmd.Flags().StringVar(&opts.CompareReleaseImage, "compare-release-image", opts.CompareReleaseImage, "Also render the payload of this release image and list the files and units that changed in it")
+	cmd.Flags().StringVar(&opts.CompareTo, "compare-to", opts.CompareTo, "File containing a previously rendered payload to list the files and units that changed from")
+	cmd.Flags().StringVarP(&opts.OutputFile, "output", "o", opts.OutputFile, "File to write the rendered payload to")
+	cmd.Flags().StringVar(&opts.WorkDir, "dir", opts.WorkDir, "Working directory (default: temporary dir)")
+	cmd.Flags().StringVar(&opts.HypershiftOperatorImage, "hypershift-operator-image", opts.HypershiftOperatorImage, "The HyperShift operator image, used as control plane operator image of releases that do not contain one")
+
+	cmd.MarkFlagRequired("hostedcluster")
+	cmd.MarkFlagRequired("nodepool")
+	cmd.MarkFlagRequired("control-plane-manifests")
+	cmd.MarkFlagRequired("pull-secret")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if opts.CompareReleaseImage != "" && opts.CompareTo != "" {
+			return errors.New("only one of --compare-release-image and --compare-to can be set")
+		}
+		ctrl.SetLogger(zap.New(zap.UseDevMode(true), zap.WriteTo(cmd.ErrOrStderr())))
+		return opts.Run(cmd.Context(), cmd.OutOrStdout())
+	}
+
+	return cmd
+}
+
+func (o *IgnitionOptions) Run(ctx context.Context, out io.Writer) error {
+	clusterManifests, err := readManifests(o.HostedClusterFile)
+	if err != nil {
+		return err
+	}
+	var hcluster *hyperv1.HostedCluster
+	for _, obj := range clusterManifests {
+		if hc, ok := obj.(*hyperv1.HostedCluster); ok {
+			hcluster = hc
+			break
+		}
+	}
+	if hcluster == nil {
+		return fmt.Errorf("%s does not contain a HostedCluster", o.HostedClusterFile)
+	}
+	if hcluster.Spec.PullSecret.Name == "" {
+		hcluster.Spec.PullSecret.Name = "pull-secret"
+	}
+	nodePoolManifests, err := readManifests(o.NodePoolFile)
+	if err != nil {
+		return err
+	}
+	var nodePool *hyperv1.NodePool
+	for _, obj := range nodePoolManifests {
+		if np, ok := obj.(*hyperv1.NodePool); ok {
+			nodePool = np
+			break
+		}
+	}
+	if nodePool == nil {
+		return fmt.Errorf("%s does not contain a NodePool", o.NodePoolFile)
+	}
+	configs, err := readManifests(o.ConfigFiles...)
+	if err != nil {
+		return err
+	}
+	controlPlane, err := readManifests(o.ControlPlaneManifestFiles...)
+	if err != nil {
+		return err
+	}
+	pullSecret, err := os.ReadFile(o.PullSecretFile)
+	if err != nil {
+		return fmt.Errorf("failed to read pull secret: %w", err)
+	}
+
+	reconciler := nodePoolConfigReconciler(hcluster, nodePool, clusterManifests, configs, controlPlane, pullSecret)
+	reconciler.HypershiftOperatorImage = o.HypershiftOperatorImage
+	render := func(provider *controllers.LocalIgnitionProvider, image string) ([]byte, error) {
+		releaseImage, err := reconciler.ReleaseProvider.Lookup(ctx, image, pullSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up release image %s: %w", image, err)
+		}
+		config, missing, err := reconciler.Config(ctx, nodePool, hcluster, releaseImage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assemble the NodePool config: %w", err)
+		}
+		if missing {
+			ctrl.Log.Info("Some core configs of the NodePool are missing from the manifests, the payload differs from the one served to nodes")
+		}
+		payload, err := provider.GetPayload(ctx, image, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render payload of %s: %w", image, err)
+		}
+		return payload, nil
+	}
+
+	workDir := o.WorkDir
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "render-ignition")
+		if err != nil {
+			return fmt.Errorf("failed to create working directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+	}
+	provider, err := ignitionProvider(workDir, nodePool.Spec.Platform.Type, controlPlane, pullSecret)
+	if err != nil {
+		return err
+	}
+
+	releaseImage := o.ReleaseImage
+	if releaseImage == "" {
+		releaseImage = nodePool.Spec.Release.Image
+	}
+	payload, err := render(provider, releaseImage)
+	if err != nil {
+		return err
+	}
+	pretty, err := PrettyPayload(payload)
+	if err != nil {
+		return err
+	}
+	if o.OutputFile != "" {
+		if err := os.WriteFile(o.OutputFile, pretty, 0600); err != nil {
+			return fmt.Errorf("failed to write payload: %w", err)
+		}
+	}
+
+	var oldPayload, newPayload []byte
+	switch {
+	case o.CompareReleaseImage != "":
+		oldPayload = payload
+		newPayload, err = render(provider, o.CompareReleaseImage)
+		if err != nil {
+			return err
+		}
+	case o.CompareTo != "":
+		oldPayload, err = os.ReadFile(o.CompareTo)
+		if err != nil {
+			return fmt.Errorf("failed to read payload to compare to: %w", err)
+		}
+		newPayload = payload
+	default:
+		_, err := out.Write(pretty)
+		return err
+	}
+	diff, err := DiffPayloads(oldPayload, newPayload)
+	if err != nil {
+		return err
+	}
+	diff.Write(out)
+	return nil
+}
+
+// ignitionProvider returns a LocalIgnitionProvider serving the control plane
+// manifests from a fake client.
+func ignitionProvider(workDir string, platform hyperv1.PlatformType, controlPlane []client.Object, pullSecret []byte) (*controllers.LocalIgnitionProvider, error) {
+	objects := []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: renderNamespace, Name: "pull-secret"},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: pullSecret},
+		},
+	}
+	hasMCSConfig, hasBootstrapKubeconfig := false, false
+	for _, obj := range controlPlane {
+		switch obj := obj.(type) {
+		case *corev1.ConfigMap:
+			hasMCSConfig = hasMCSConfig || obj.Name == mcsConfigMapName
+		case *corev1.Secret:
+			if obj.Name == "pull-secret" {
+				continue
+			}
+			hasBootstrapKubeconfig = hasBootstrapKubeconfig || obj.Name == bootstrapKubeconfig
+		default:
+			continue
+		}
+		obj.SetNamespace(renderNamespace)
+		obj.SetResourceVersion("")
+		objects = append(objects, obj)
+	}
+	if !hasMCSConfig {
+		return nil, fmt.Errorf("the control plane manifests do not contain the %s ConfigMap", mcsConfigMapName)
+	}
+	if !hasBootstrapKubeconfig {
+		objects = append(objects, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: renderNamespace, Name: bootstrapKubeconfig},
+			Data:       map[string][]byte{bootstrapKubeconfigKey: []byte(placeholderKubeconfig)},
+		})
+	}
+
+	imageFileCache, err := controllers.NewImageFileCache(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create image file cache: %w", err)
+	}
+	return &controllers.LocalIgnitionProvider{
+		Client:          fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(objects...).Build(),
+		ReleaseProvider: &releaseinfo.RegistryClientProvider{},
+		CloudProvider:   platform,
+		Namespace:       renderNamespace,
+		WorkDir:         workDir,
+		ImageFileCache:  imageFileCache,
+	}, nil
+}
+
+// nodePoolConfigReconciler returns a NodePoolReconciler that assembles the config of
+// nodePool like the NodePool controller, from a fake client serving hcluster, its pull
+// secret and the Secrets in clusterManifests, the ConfigMaps in configs and those in
+// the control plane manifests.
+func nodePoolConfigReconciler(hcluster *hyperv1.HostedCluster, nodePool *hyperv1.NodePool, clusterManifests, configs, controlPlane []client.Object, pullSecret []byte) *nodepool.NodePoolReconciler {
+	hcluster = hcluster.DeepCopy()
+	hcluster.ResourceVersion = ""
+	objects := []client.Object{
+		hcluster,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: hcluster.Namespace, Name: hcluster.Spec.PullSecret.Name},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: pullSecret},
+		},
+	}
+	add := func(manifests []client.Object, namespace string, include func(client.Object) bool) {
+		for _, obj := range manifests {
+			if !include(obj) {
+				continue
+			}
+			obj = obj.DeepCopyObject().(client.Object)
+			obj.SetNamespace(namespace)
+			obj.SetResourceVersion("")
+			objects = append(objects, obj)
+		}
+	}
+	add(clusterManifests, hcluster.Namespace, func(obj client.Object) bool {
+		secret, ok := obj.(*corev1.Secret)
+		return ok && secret.Name != hcluster.Spec.PullSecret.Name && (secret.Namespace == "" || secret.Namespace == hcluster.Namespace)
+	})
+	add(configs, nodePool.Namespace, func(obj client.Object) bool {
+		cm, ok := obj.(*corev1.ConfigMap)
+		return ok && (cm.Namespace == "" || cm.Namespace == nodePool.Namespace)
+	})
+	// The control plane manifests are captured from the control plane namespace.
+	add(controlPlane, controlPlaneNamespace(hcluster), func(obj client.Object) bool {
+		_, ok := obj.(*corev1.ConfigMap)
+		return ok
+	})
+
+	return &nodepool.NodePoolReconciler{
+		Client:                fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(objects...).Build(),
+		ReleaseProvider:       releaseinfo.NewCachedProvider(&releaseinfo.RegistryClientProvider{}, releaseinfo.CachedProviderOptions{}),
+		ImageMetadataProvider: &util.RegistryClientImageMetadataProvider{},
+	}
+}
+
+func controlPlaneNamespace(hcluster *hyperv1.HostedCluster) string {
+	return manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name).Name
+}
+
+// readManifests reads the objects of the YAML or JSON manifests in files. Lists are
+// expanded into their items.
+func readManifests(files ...string) ([]client.Object, error) {
+	var objects []client.Object
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifests: %w", err)
+		}
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for {
+			u := &unstructured.Unstructured{}
+			if err := decoder.Decode(&u.Object); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+			}
+			if len(u.Object) == 0 {
+				continue
+			}
+			var items []unstructured.Unstructured
+			if u.IsList() {
+				list, err := u.ToList()
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode list in %s: %w", file, err)
+				}
+				items = list.Items
+			} else {
+				items = []unstructured.Unstructured{*u}
+			}
+			for i := range items {
+				obj, err := typedObject(&items[i])
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+				}
+				objects = append(objects, obj)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// typedObject converts u to its type in the HyperShift scheme, if it has one.
+func typedObject(u *unstructured.Unstructured) (client.Object, error) {
+	runtimeObj, err := hyperapi.Scheme.New(u.GroupVersionKind())
+	if err != nil {
+		return u, nil
+	}
+	obj, ok := runtimeObj.(client.Object)
+	if !ok {
+		return u, nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+	return obj, nil
+}
@@ -0,0 +1,180 @@
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	ignitionapi "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/vincent-petithory/dataurl"
+)
+
+// Change is the change of a file or unit between two ignition payloads.
+type Change string
+
+const (
+	Added    Change = "+"
+	Removed  Change = "-"
+	Modified Change = "~"
+)
+
+// PayloadDiff lists the files and systemd units that differ between two ignition
+// payloads, by path and name.
+type PayloadDiff struct {
+	Files map[string]Change
+	Units map[string]Change
+}
+
+// Empty returns whether the payloads have the same files and units.
+func (d *PayloadDiff) Empty() bool {
+	return len(d.Files) == 0 && len(d.Units) == 0
+}
+
+// Write prints the changes of d, sorted by path and name.
+func (d *PayloadDiff) Write(w io.Writer) {
+	if d.Empty() {
+		fmt.Fprintln(w, "No files or units changed")
+		return
+	}
+	for _, section := range []struct {
+		title   string
+		changes map[string]Change
+	}{
+		{title: "Files", changes: d.Files},
+		{title: "Units", changes: d.Units},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", section.title)
+		var names []string
+		for name := range section.changes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s %s\n", section.changes[name], name)
+		}
+	}
+}
+
+// PrettyPayload returns payload indented for reading.
+func PrettyPayload(payload []byte) ([]byte, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, payload, "", "  "); err != nil {
+		return nil, fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// DiffPayloads compares the files and systemd units of the old and new ignition
+// payloads. Files are compared by their decoded contents, so payloads encoding the
+// same contents differently don't differ.
+func DiffPayloads(oldPayload, newPayload []byte) (*PayloadDiff, error) {
+	oldFiles, oldUnits, err := payloadEntries(oldPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read old payload: %w", err)
+	}
+	newFiles, newUnits, err := payloadEntries(newPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read new payload: %w", err)
+	}
+	return &PayloadDiff{
+		Files: diffEntries(oldFiles, newFiles),
+		Units: diffEntries(oldUnits, newUnits),
+	}, nil
+}
+
+func diffEntries(oldEntries, newEntries map[string]string) map[string]Change {
+	changes := map[string]Change{}
+	for name, oldEntry := range oldEntries {
+		newEntry, exists := newEntries[name]
+		switch {
+		case !exists:
+			changes[name] = Removed
+		case newEntry != oldEntry:
+			changes[name] = Modified
+		}
+	}
+	for name := range newEntries {
+		if _, exists := oldEntries[name]; !exists {
+			changes[name] = Added
+		}
+	}
+	return changes
+}
+
+// payloadEntries returns a comparable representation of the files of payload by
+// path, and of its systemd units by name.
+func payloadEntries(payload []byte) (map[string]string, map[string]string, error) {
+	var config ignitionapi.Config
+	if err := json.Unmarshal(payload, &config); err != nil {
+		return nil, nil, fmt.Errorf("payload is not an ignition config: %w", err)
+	}
+
+	files := map[string]string{}
+	for _, file := range config.Storage.Files {
+		contents, err := resourceContents(file.Contents)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode file %s: %w", file.Path, err)
+		}
+		for _, resource := range file.Append {
+			appended, err := resourceContents(resource)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to decode file %s: %w", file.Path, err)
+			}
+			contents = append(contents, appended...)
+		}
+		// Contents are replaced by their decoded form, so only what is written
+		// to the node is compared.
+		file.Contents, file.Append = ignitionapi.Resource{}, nil
+		attributes, err := json.Marshal(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[file.Path] = string(attributes) + "\n" + string(contents)
+	}
+
+	units := map[string]string{}
+	for _, unit := range config.Systemd.Units {
+		sort.Slice(unit.Dropins, func(i, j int) bool { return unit.Dropins[i].Name < unit.Dropins[j].Name })
+		data, err := json.Marshal(unit)
+		if err != nil {
+			return nil, nil, err
+		}
+		units[unit.Name] = string(data)
+	}
+	return files, units, nil
+}
+
+// resourceContents returns the contents of an inline resource. The sources of
+// remote resources are returned as is, since they can't be fetched.
+func resourceContents(resource ignitionapi.Resource) ([]byte, error) {
+	if resource.Source == nil {
+		return nil, nil
+	}
+	if !strings.HasPrefix(*resource.Source, "data:") {
+		return []byte(*resource.Source), nil
+	}
+	url, err := dataurl.DecodeString(*resource.Source)
+	if err != nil {
+		return nil, err
+	}
+	if resource.Compression == nil || *resource.Compression == "" {
+		return url.Data, nil
+	}
+	if *resource.Compression != "gzip" {
+		return nil, fmt.Errorf("unsupported compression %q", *resource.Compression)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(url.Data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
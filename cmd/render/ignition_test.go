@@ -0,0 +1,265 @@
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/api/image/docker10"
+	imagev1 "github.com/openshift/api/image/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	fakereleaseprovider "github.com/openshift/hypershift/support/releaseinfo/fake"
+	"github.com/openshift/hypershift/support/thirdparty/library-go/pkg/image/dockerv1client"
+	"github.com/openshift/hypershift/support/util/fakeimagemetadataprovider"
+)
+
+const machineConfig = `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: %s
+spec:
+  config:
+    ignition:
+      version: 3.2.0
+`
+
+func configMapManifest(name, labels string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  namespace: clusters
+  labels: {%s}
+data:
+  config: |
+    apiVersion: machineconfiguration.openshift.io/v1
+    kind: MachineConfig
+    metadata:
+      name: %s
+    spec:
+      config:
+        ignition:
+          version: 3.2.0
+`, name, labels, name)
+}
+
+func writeFile(t *testing.T, contents string) string {
+	file := filepath.Join(t.TempDir(), "manifests.yaml")
+	if err := os.WriteFile(file, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReadManifests(t *testing.T) {
+	g := NewGomegaWithT(t)
+	file := writeFile(t, `apiVersion: hypershift.openshift.io/v1beta1
+kind: NodePool
+metadata:
+  name: example
+  namespace: clusters
+spec:
+  clusterName: example
+  config:
+  - name: config-1
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: config-1
+- apiVersion: kubevirt.io/v1
+  kind: VirtualMachineInstance
+  metadata:
+    name: vmi
+`)
+
+	objects, err := readManifests(file)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(objects).To(HaveLen(3))
+	g.Expect(objects[0]).To(BeAssignableToTypeOf(&hyperv1.NodePool{}))
+	g.Expect(objects[0].(*hyperv1.NodePool).Spec.Config).To(Equal([]corev1.LocalObjectReference{{Name: "config-1"}}))
+	g.Expect(objects[1]).To(BeAssignableToTypeOf(&corev1.ConfigMap{}))
+	g.Expect(objects[2].GetName()).To(Equal("vmi"))
+
+	_, err = readManifests(writeFile(t, "not: [valid"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestNodePoolConfig(t *testing.T) {
+	hcluster := &hyperv1.HostedCluster{}
+	hcluster.Namespace = "clusters"
+	hcluster.Name = "example"
+	hcluster.Annotations = map[string]string{hyperv1.ControlPlaneOperatorImageAnnotation: "cpo-image"}
+	hcluster.Spec.PullSecret.Name = "pull-secret"
+	hcluster.Status.KubeConfig = &corev1.LocalObjectReference{Name: "example-admin-kubeconfig"}
+
+	nodePool := &hyperv1.NodePool{}
+	nodePool.Namespace = "clusters"
+	nodePool.Name = "example"
+	nodePool.Spec.Config = []corev1.LocalObjectReference{{Name: "user"}}
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: https://api.example.com:6443
+contexts:
+- name: cluster
+  context:
+    cluster: cluster
+current-context: cluster
+`
+	clusterManifests := fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: example-admin-kubeconfig
+  namespace: clusters
+data:
+  kubeconfig: %s
+`, base64.StdEncoding.EncodeToString([]byte(kubeconfig)))
+	controlPlaneManifests := configMapManifest("core", "hypershift.openshift.io/core-ignition-config: 'true'") + "---\n" +
+		configMapManifest("tuning", "hypershift.openshift.io/nto-generated-machine-config: 'true', "+hyperv1.NodePoolLabel+": example") + "---\n" +
+		configMapManifest("other-tuning", "hypershift.openshift.io/nto-generated-machine-config: 'true', "+hyperv1.NodePoolLabel+": other") + "---\n" +
+		configMapManifest("unrelated", "")
+
+	testCases := []struct {
+		name             string
+		configs          string
+		expectedConfig   []string
+		unexpectedConfig []string
+		expectedError    string
+	}{
+		{
+			name:             "When all configs are found it should join the haproxy, core, tuning and NodePool configs",
+			configs:          configMapManifest("user", "") + "---\n" + configMapManifest("unreferenced", ""),
+			expectedConfig:   []string{"name: 20-apiserver-haproxy", "name: core", "name: tuning", "name: user"},
+			unexpectedConfig: []string{"name: other-tuning", "name: unrelated", "name: unreferenced"},
+		},
+		{
+			name:          "When a config of the NodePool is missing it should fail",
+			configs:       configMapManifest("unreferenced", ""),
+			expectedError: `configmaps "user" not found`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			clusterObjects, err := readManifests(writeFile(t, clusterManifests))
+			g.Expect(err).ToNot(HaveOccurred())
+			configs, err := readManifests(writeFile(t, tc.configs))
+			g.Expect(err).ToNot(HaveOccurred())
+			controlPlane, err := readManifests(writeFile(t, controlPlaneManifests))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			r := nodePoolConfigReconciler(hcluster, nodePool, clusterObjects, configs, controlPlane, []byte("{}"))
+			r.ReleaseProvider = &fakereleaseprovider.FakeReleaseProvider{}
+			r.ImageMetadataProvider = &fakeimagemetadataprovider.FakeImageMetadataProvider{Result: &dockerv1client.DockerImageConfig{Config: &docker10.DockerConfig{
+				Labels: map[string]string{"io.openshift.hypershift.control-plane-operator-skips-haproxy": "true"},
+			}}}
+			releaseImage := &releaseinfo.ReleaseImage{ImageStream: &imagev1.ImageStream{Spec: imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{{
+				Name: "haproxy-router",
+				From: &corev1.ObjectReference{Name: "haproxy-image"},
+			}}}}}
+
+			config, _, err := r.Config(context.Background(), nodePool, hcluster, releaseImage)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, expected := range tc.expectedConfig {
+				g.Expect(config).To(ContainSubstring(expected))
+			}
+			for _, unexpected := range tc.unexpectedConfig {
+				g.Expect(config).ToNot(ContainSubstring(unexpected))
+			}
+		})
+	}
+}
+
+func TestIgnitionProvider(t *testing.T) {
+	g := NewGomegaWithT(t)
+	controlPlane, err := readManifests(writeFile(t, configMapManifest("core", "")))
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = ignitionProvider(t.TempDir(), hyperv1.AWSPlatform, controlPlane, []byte("{}"))
+	g.Expect(err).To(MatchError(ContainSubstring("do not contain the machine-config-server ConfigMap")))
+
+	controlPlane, err = readManifests(writeFile(t, configMapManifest("machine-config-server", "")))
+	g.Expect(err).ToNot(HaveOccurred())
+	provider, err := ignitionProvider(t.TempDir(), hyperv1.AWSPlatform, controlPlane, []byte("{}"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The bootstrap kubeconfig is a placeholder when not in the control plane manifests.
+	secret := &corev1.Secret{}
+	g.Expect(provider.Client.Get(context.Background(), client.ObjectKey{Namespace: renderNamespace, Name: bootstrapKubeconfig}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[bootstrapKubeconfigKey])).To(Equal(placeholderKubeconfig))
+	g.Expect(provider.Client.Get(context.Background(), client.ObjectKey{Namespace: renderNamespace, Name: "pull-secret"}, secret)).To(Succeed())
+	g.Expect(provider.Client.Get(context.Background(), client.ObjectKey{Namespace: renderNamespace, Name: mcsConfigMapName}, &corev1.ConfigMap{})).To(Succeed())
+}
+
+func TestDiffPayloads(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	w.Write([]byte("kubelet config"))
+	w.Close()
+
+	oldPayload := []byte(`{
+  "ignition": {"version": "3.2.0"},
+  "storage": {"files": [
+    {"path": "/etc/kubelet.conf", "contents": {"source": "data:,kubelet%20config"}},
+    {"path": "/etc/removed", "contents": {"source": "data:,removed"}},
+    {"path": "/etc/mode", "mode": 420, "contents": {"source": "data:,mode"}}
+  ]},
+  "systemd": {"units": [
+    {"name": "kubelet.service", "enabled": true, "contents": "[Service]"},
+    {"name": "crio.service", "dropins": [{"name": "a.conf"}, {"name": "b.conf"}]}
+  ]}
+}`)
+	newPayload := []byte(`{
+  "ignition": {"version": "3.2.0"},
+  "storage": {"files": [
+    {"path": "/etc/kubelet.conf", "contents": {"compression": "gzip", "source": "data:;base64,` + base64.StdEncoding.EncodeToString(gzipped.Bytes()) + `"}},
+    {"path": "/etc/added", "contents": {"source": "data:,added"}},
+    {"path": "/etc/mode", "mode": 384, "contents": {"source": "data:,mode"}}
+  ]},
+  "systemd": {"units": [
+    {"name": "kubelet.service", "enabled": false, "contents": "[Service]"},
+    {"name": "crio.service", "dropins": [{"name": "b.conf"}, {"name": "a.conf"}]}
+  ]}
+}`)
+
+	diff, err := DiffPayloads(oldPayload, newPayload)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(diff.Files).To(Equal(map[string]Change{"/etc/removed": Removed, "/etc/added": Added, "/etc/mode": Modified}))
+	g.Expect(diff.Units).To(Equal(map[string]Change{"kubelet.service": Modified}))
+
+	out := &bytes.Buffer{}
+	diff.Write(out)
+	g.Expect(out.String()).To(Equal("Files:\n  + /etc/added\n  ~ /etc/mode\n  - /etc/removed\nUnits:\n  ~ kubelet.service\n"))
+
+	diff, err = DiffPayloads(oldPayload, oldPayload)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(diff.Empty()).To(BeTrue())
+
+	_, err = DiffPayloads(oldPayload, []byte("not json"))
+	g.Expect(err).To(HaveOccurred())
+
+	pretty, err := PrettyPayload([]byte(`{"ignition":{"version":"3.2.0"}}`))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(pretty)).To(Equal("{\n  \"ignition\": {\n    \"version\": \"3.2.0\"\n  }\n}\n"))
+}
@@ -0,0 +1,17 @@
+package render
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "render",
+		Short:        "Commands for rendering HyperShift artifacts locally",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewIgnitionCommand())
+
+	return cmd
+}
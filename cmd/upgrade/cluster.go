@@ -0,0 +1,431 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blang/semver"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/cmd/log"
+	nodepoolcore "github.com/openshift/hypershift/cmd/nodepool/core"
+	"github.com/openshift/hypershift/cmd/util"
+	"github.com/openshift/hypershift/support/releaseinfo"
+	"github.com/openshift/hypershift/support/supportedversion"
+)
+
+// maxNodePoolMinorVersionSkew is how many minor versions the nodes of a NodePool
+// may lag behind the control plane.
+const maxNodePoolMinorVersionSkew = 2
+
+type ClusterOptions struct {
+	Namespace    string
+	Name         string
+	ReleaseImage string
+	Version      string
+	Arch         string
+	NodePools    []string
+	AllNodePools bool
+	Force        bool
+	DryRun       bool
+	Wait         bool
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+func NewClusterCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Upgrades a HostedCluster and optionally its NodePools after running preflight checks",
+		Long: `Upgrades a HostedCluster and optionally its NodePools after running preflight checks.
+
+The target release is resolved from --release-image or --version and checked against
+the supported version range, the ClusterVersionUpgradeable condition, network type
+compatibility and the version skew of the NodePools. NodePools listed in --nodepools
+are upgraded in the given order once the control plane upgrade completes.`,
+		SilenceUsage: true,
+	}
+
+	opts := ClusterOptions{
+		Namespace:    "clusters",
+		Arch:         "x86_64",
+		Wait:         true,
+		Timeout:      2 * time.Hour,
+		PollInterval: 10 * time.Second,
+	}
+
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", opts.Namespace, "The namespace of the HostedCluster")
+	cmd.Flags().StringVar(&opts.Name, "name", opts.Name, "The name of the HostedCluster")
+	cmd.Flags().StringVar(&opts.ReleaseImage, "release-image", opts.ReleaseImage, "The OCP release image to upgrade to")
+	cmd.Flags().StringVar(&opts.Version, "version", opts.Version, "The OCP version to upgrade to, resolved to the official release image for --arch")
+	cmd.Flags().StringVar(&opts.Arch, "arch", opts.Arch, "The architecture of the release image resolved from --version")
+	cmd.Flags().StringSliceVar(&opts.NodePools, "nodepools", opts.NodePools, "NodePools to upgrade after the control plane, in order")
+	cmd.Flags().BoolVar(&opts.AllNodePools, "all-nodepools", opts.AllNodePools, "Upgrade all NodePools of the HostedCluster after the control plane")
+	cmd.Flags().BoolVar(&opts.Force, "force", opts.Force, "Upgrade even if the cluster is not upgradeable or another upgrade is in progress")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "Only run the preflight checks and print the upgrade plan")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", opts.Wait, "Follow the upgrade until it completes")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "How long to wait for the upgrade to complete")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		c, err := util.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := UpgradeCluster(cmd.Context(), c, &releaseinfo.RegistryClientProvider{}, opts, os.Stdout); err != nil {
+			log.Log.Error(err, "Failed to upgrade cluster")
+			return err
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+func (o *ClusterOptions) Validate() error {
+	if (o.ReleaseImage == "") == (o.Version == "") {
+		return fmt.Errorf("exactly one of --release-image or --version is required")
+	}
+	if o.AllNodePools && len(o.NodePools) > 0 {
+		return fmt.Errorf("--nodepools and --all-nodepools are mutually exclusive")
+	}
+	return nil
+}
+
+// targetImage returns the release image to upgrade to.
+func (o *ClusterOptions) targetImage() string {
+	if o.ReleaseImage != "" {
+		return o.ReleaseImage
+	}
+	return fmt.Sprintf("quay.io/openshift-release-dev/ocp-release:%s-%s", strings.TrimPrefix(o.Version, "v"), o.Arch)
+}
+
+// PreflightCheck is the result of a single upgrade precondition.
+type PreflightCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// Plan describes the upgrade of a HostedCluster and its NodePools.
+type Plan struct {
+	Cluster        *hyperv1.HostedCluster
+	CurrentImage   string
+	CurrentVersion string
+	TargetImage    string
+	TargetVersion  string
+	Checks         []PreflightCheck
+	// NodePools are upgraded in this order after the control plane.
+	NodePools []*hyperv1.NodePool
+}
+
+// Failed returns true if any preflight check did not pass.
+func (p *Plan) Failed() bool {
+	for _, check := range p.Checks {
+		if !check.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) controlPlaneNeedsUpgrade() bool {
+	return p.Cluster.Spec.Release.Image != p.TargetImage
+}
+
+func (p *Plan) Print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "PREFLIGHT CHECK\tRESULT\tMESSAGE")
+	for _, check := range p.Checks {
+		result := "PASS"
+		if !check.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Name, result, check.Message)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "\nUpgrade plan:")
+	step := 1
+	if p.controlPlaneNeedsUpgrade() {
+		fmt.Fprintf(out, "  %d. HostedCluster %s/%s: %s -> %s (%s)\n", step, p.Cluster.Namespace, p.Cluster.Name, p.CurrentVersion, p.TargetVersion, p.TargetImage)
+		step++
+	} else {
+		fmt.Fprintf(out, "  -  HostedCluster %s/%s is already at %s\n", p.Cluster.Namespace, p.Cluster.Name, p.TargetVersion)
+	}
+	for _, nodePool := range p.NodePools {
+		fmt.Fprintf(out, "  %d. NodePool %s/%s: %s -> %s\n", step, nodePool.Namespace, nodePool.Name, valueOrUnknown(nodePool.Status.Version), p.TargetVersion)
+		step++
+	}
+}
+
+// BuildPlan resolves the target release and runs the same checks the HyperShift
+// operator applies to release changes of HostedClusters and NodePools.
+func BuildPlan(ctx context.Context, c crclient.Client, provider releaseinfo.Provider, opts ClusterOptions) (*Plan, error) {
+	hcluster := &hyperv1.HostedCluster{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: opts.Namespace, Name: opts.Name}, hcluster); err != nil {
+		return nil, fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	pullSecret := &corev1.Secret{}
+	if err := c.Get(ctx, crclient.ObjectKey{Namespace: hcluster.Namespace, Name: hcluster.Spec.PullSecret.Name}, pullSecret); err != nil {
+		return nil, fmt.Errorf("failed to get pull secret: %w", err)
+	}
+	pullSecretBytes, ok := pullSecret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return nil, fmt.Errorf("expected %s key in pull secret", corev1.DockerConfigJsonKey)
+	}
+
+	plan := &Plan{
+		Cluster:      hcluster,
+		CurrentImage: hcluster.Spec.Release.Image,
+		TargetImage:  opts.targetImage(),
+	}
+	targetVersion, err := lookupVersion(ctx, provider, plan.TargetImage, pullSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	plan.TargetVersion = targetVersion.String()
+	if opts.Version != "" && plan.TargetVersion != strings.TrimPrefix(opts.Version, "v") {
+		return nil, fmt.Errorf("release image %s has version %s, expected %s", plan.TargetImage, plan.TargetVersion, opts.Version)
+	}
+	currentVersion, err := lookupVersion(ctx, provider, plan.CurrentImage, pullSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	plan.CurrentVersion = currentVersion.String()
+
+	minSupportedVersion := supportedversion.MinSupportedVersion
+	if hcluster.Spec.Platform.Type == hyperv1.IBMCloudPlatform {
+		//IBM Cloud is allowed to manage 4.9 clusters
+		minSupportedVersion = semver.MustParse("4.9.0")
+	}
+
+	// Supported version range and network type compatibility
+	check := PreflightCheck{Name: "SupportedVersion", Passed: true, Message: fmt.Sprintf("%s is supported", plan.TargetVersion)}
+	var versionErr error
+	if plan.controlPlaneNeedsUpgrade() {
+		versionErr = supportedversion.IsValidReleaseVersion(targetVersion, currentVersion, &supportedversion.LatestSupportedVersion, &minSupportedVersion, hcluster.Spec.Networking.NetworkType, hcluster.Spec.Platform.Type)
+	} else {
+		versionErr = supportedversion.IsValidReleaseVersion(targetVersion, nil, &supportedversion.LatestSupportedVersion, &minSupportedVersion, hcluster.Spec.Networking.NetworkType, hcluster.Spec.Platform.Type)
+	}
+	if versionErr != nil {
+		check.Passed, check.Message = false, versionErr.Error()
+	}
+	plan.Checks = append(plan.Checks, check)
+
+	// ClusterVersionUpgradeable
+	check = PreflightCheck{Name: "Upgradeable", Passed: true, Message: "cluster version is upgradeable"}
+	if upgradeable := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.ClusterVersionUpgradeable)); upgradeable != nil && upgradeable.Status == metav1.ConditionFalse && plan.controlPlaneNeedsUpgrade() {
+		if opts.Force {
+			check.Message = fmt.Sprintf("cluster version is not upgradeable, upgrade is forced: %s", upgradeable.Message)
+		} else {
+			check.Passed, check.Message = false, fmt.Sprintf("cluster version is not upgradeable: %s", upgradeable.Message)
+		}
+	}
+	plan.Checks = append(plan.Checks, check)
+
+	// No other upgrade in progress
+	check = PreflightCheck{Name: "NoUpgradeInProgress", Passed: true, Message: "no upgrade in progress"}
+	if hcluster.Status.Version != nil && len(hcluster.Status.Version.History) > 0 {
+		latest := hcluster.Status.Version.History[0]
+		if latest.State == configv1.PartialUpdate && latest.Image != plan.TargetImage {
+			if opts.Force {
+				check.Message = fmt.Sprintf("upgrade to %s is in progress and will be superseded", latest.Version)
+			} else {
+				check.Passed, check.Message = false, fmt.Sprintf("upgrade to %s is in progress", latest.Version)
+			}
+		}
+	}
+	plan.Checks = append(plan.Checks, check)
+
+	// NodePool version skew
+	var nodePoolList hyperv1.NodePoolList
+	if err := c.List(ctx, &nodePoolList, crclient.InNamespace(hcluster.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list nodepools: %w", err)
+	}
+	nodePools := map[string]*hyperv1.NodePool{}
+	var nodePoolNames []string
+	for i := range nodePoolList.Items {
+		if nodePoolList.Items[i].Spec.ClusterName != hcluster.Name {
+			continue
+		}
+		nodePools[nodePoolList.Items[i].Name] = &nodePoolList.Items[i]
+		nodePoolNames = append(nodePoolNames, nodePoolList.Items[i].Name)
+	}
+	sort.Strings(nodePoolNames)
+	toUpgrade := opts.NodePools
+	if opts.AllNodePools {
+		toUpgrade = nodePoolNames
+	}
+	upgrading := map[string]bool{}
+	for _, name := range toUpgrade {
+		nodePool, ok := nodePools[name]
+		if !ok {
+			return nil, fmt.Errorf("nodepool %s/%s of hostedcluster %s not found", hcluster.Namespace, name, hcluster.Name)
+		}
+		if upgrading[name] {
+			return nil, fmt.Errorf("nodepool %s is listed more than once", name)
+		}
+		upgrading[name] = true
+		plan.NodePools = append(plan.NodePools, nodePool)
+	}
+	for _, name := range nodePoolNames {
+		nodePool := nodePools[name]
+		check := PreflightCheck{Name: "NodePoolVersionSkew/" + name, Passed: true}
+		nodePoolVersion, err := lookupVersion(ctx, provider, nodePool.Spec.Release.Image, pullSecretBytes)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case upgrading[name]:
+			check.Message = fmt.Sprintf("%s will be upgraded to %s", nodePoolVersion, plan.TargetVersion)
+			if err := supportedversion.IsValidReleaseVersion(targetVersion, nodePoolVersion, targetVersion, &minSupportedVersion, hcluster.Spec.Networking.NetworkType, hcluster.Spec.Platform.Type); err != nil {
+				check.Passed, check.Message = false, err.Error()
+			}
+		case nodePoolVersion.Major != targetVersion.Major || nodePoolVersion.Minor > targetVersion.Minor:
+			check.Passed, check.Message = false, fmt.Sprintf("%s would be newer than the control plane", nodePoolVersion)
+		case targetVersion.Minor-nodePoolVersion.Minor > maxNodePoolMinorVersionSkew:
+			check.Passed, check.Message = false, fmt.Sprintf("%s would be more than %d minor versions behind the control plane, upgrade the NodePool as well", nodePoolVersion, maxNodePoolMinorVersionSkew)
+		default:
+			check.Message = fmt.Sprintf("%s is within the supported skew", nodePoolVersion)
+		}
+		plan.Checks = append(plan.Checks, check)
+	}
+
+	return plan, nil
+}
+
+// UpgradeCluster runs the preflight checks, prints the plan and executes it unless
+// this is a dry run.
+func UpgradeCluster(ctx context.Context, c crclient.Client, provider releaseinfo.Provider, opts ClusterOptions, out io.Writer) error {
+	plan, err := BuildPlan(ctx, c, provider, opts)
+	if err != nil {
+		return err
+	}
+	plan.Print(out)
+	if plan.Failed() {
+		return fmt.Errorf("preflight checks failed")
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if plan.controlPlaneNeedsUpgrade() {
+		hcluster := plan.Cluster
+		original := hcluster.DeepCopy()
+		hcluster.Spec.Release.Image = plan.TargetImage
+		if opts.Force {
+			if hcluster.Annotations == nil {
+				hcluster.Annotations = map[string]string{}
+			}
+			hcluster.Annotations[hyperv1.ForceUpgradeToAnnotation] = plan.TargetImage
+		}
+		if err := c.Patch(ctx, hcluster, crclient.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to update hostedcluster release image: %w", err)
+		}
+		fmt.Fprintf(out, "\nUpdated HostedCluster %s/%s to %s\n", hcluster.Namespace, hcluster.Name, plan.TargetImage)
+	}
+	if opts.Wait {
+		if err := waitForCluster(ctx, c, plan, opts.PollInterval, out); err != nil {
+			return err
+		}
+	}
+
+	for _, nodePool := range plan.NodePools {
+		if nodePool.Spec.Release.Image != plan.TargetImage {
+			original := nodePool.DeepCopy()
+			nodePool.Spec.Release.Image = plan.TargetImage
+			if err := c.Patch(ctx, nodePool, crclient.MergeFrom(original)); err != nil {
+				return fmt.Errorf("failed to update nodepool %s release image: %w", nodePool.Name, err)
+			}
+			fmt.Fprintf(out, "Updated NodePool %s/%s to %s\n", nodePool.Namespace, nodePool.Name, plan.TargetImage)
+		}
+		if opts.Wait {
+			if err := nodepoolcore.WaitForNodePoolVersion(ctx, c, nodePool, plan.TargetVersion, opts.PollInterval, out); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Wait {
+		fmt.Fprintln(out, "Upgrade completed")
+	} else {
+		fmt.Fprintln(out, "Upgrade started, follow its progress with 'hypershift describe cluster'")
+	}
+	return nil
+}
+
+// waitForCluster waits until the control plane reports the target release as completed,
+// printing progress whenever it changes.
+func waitForCluster(ctx context.Context, c crclient.Client, plan *Plan, interval time.Duration, out io.Writer) error {
+	var lastProgress string
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		hcluster := &hyperv1.HostedCluster{}
+		if err := c.Get(ctx, crclient.ObjectKeyFromObject(plan.Cluster), hcluster); err != nil {
+			log.Log.Error(err, "Failed to get hostedcluster")
+			return false, nil
+		}
+		progress := "waiting for the control plane to pick up the new release"
+		done := false
+		if hcluster.Status.Version != nil && len(hcluster.Status.Version.History) > 0 {
+			latest := hcluster.Status.Version.History[0]
+			if latest.Image == plan.TargetImage {
+				progress = fmt.Sprintf("%s %s", latest.State, latest.Version)
+				done = latest.State == configv1.CompletedUpdate
+			}
+		}
+		if condition := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.HostedClusterProgressing)); condition != nil && !done && condition.Message != "" {
+			progress += ": " + condition.Message
+		}
+		if progress != lastProgress {
+			fmt.Fprintf(out, "HostedCluster %s/%s: %s\n", hcluster.Namespace, hcluster.Name, progress)
+			lastProgress = progress
+		}
+		return done, nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("control plane upgrade did not complete: %w", err)
+	}
+	return nil
+}
+
+func lookupVersion(ctx context.Context, provider releaseinfo.Provider, image string, pullSecret []byte) (*semver.Version, error) {
+	releaseImage, err := provider.Lookup(ctx, image, pullSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup release image %s: %w", image, err)
+	}
+	version, err := semver.Parse(releaseImage.Version())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version of release image %s: %w", image, err)
+	}
+	return &version, nil
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "<unknown>"
+	}
+	return s
+}
@@ -0,0 +1,194 @@
+package upgrade
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	fakereleaseprovider "github.com/openshift/hypershift/support/releaseinfo/fake"
+)
+
+const (
+	image4120 = "example.com/release:4.12.0"
+	image4121 = "example.com/release:4.12.1"
+	image4130 = "example.com/release:4.13.0"
+	image4140 = "example.com/release:4.14.0"
+	image4110 = "example.com/release:4.11.0"
+	image4100 = "example.com/release:4.10.0"
+)
+
+var releaseProvider = &fakereleaseprovider.FakeReleaseProvider{ImageVersion: map[string]string{
+	image4100: "4.10.0",
+	image4110: "4.11.0",
+	image4120: "4.12.0",
+	image4121: "4.12.1",
+	image4130: "4.13.0",
+	image4140: "4.14.0",
+}}
+
+func upgradeObjects(mutate func(*hyperv1.HostedCluster, []*hyperv1.NodePool)) []crclient.Object {
+	hcluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+		Spec: hyperv1.HostedClusterSpec{
+			Release:    hyperv1.Release{Image: image4120},
+			PullSecret: corev1.LocalObjectReference{Name: "pull-secret"},
+			Networking: hyperv1.ClusterNetworking{NetworkType: hyperv1.OVNKubernetes},
+			Platform:   hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform},
+		},
+	}
+	nodePools := []*hyperv1.NodePool{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-a"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "example", Release: hyperv1.Release{Image: image4120}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example-b"},
+			Spec:       hyperv1.NodePoolSpec{ClusterName: "example", Release: hyperv1.Release{Image: image4120}},
+		},
+	}
+	if mutate != nil {
+		mutate(hcluster, nodePools)
+	}
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "pull-secret"},
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+	}
+	return []crclient.Object{hcluster, pullSecret, nodePools[0], nodePools[1]}
+}
+
+func TestBuildPlan(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mutate       func(*hyperv1.HostedCluster, []*hyperv1.NodePool)
+		opts         ClusterOptions
+		failedChecks []string
+	}{
+		{
+			name: "When upgrading a z-stream it should pass all checks",
+			opts: ClusterOptions{ReleaseImage: image4121},
+		},
+		{
+			name:         "When upgrading beyond the latest supported version it should fail",
+			opts:         ClusterOptions{ReleaseImage: image4140},
+			failedChecks: []string{"SupportedVersion"},
+		},
+		{
+			name: "When upgrading a y-stream with OpenShiftSDN it should fail",
+			opts: ClusterOptions{ReleaseImage: image4130},
+			mutate: func(hc *hyperv1.HostedCluster, _ []*hyperv1.NodePool) {
+				hc.Spec.Networking.NetworkType = hyperv1.OpenShiftSDN
+			},
+			failedChecks: []string{"SupportedVersion"},
+		},
+		{
+			name: "When the cluster is not upgradeable it should fail",
+			opts: ClusterOptions{ReleaseImage: image4130},
+			mutate: func(hc *hyperv1.HostedCluster, _ []*hyperv1.NodePool) {
+				hc.Status.Conditions = []metav1.Condition{{Type: string(hyperv1.ClusterVersionUpgradeable), Status: metav1.ConditionFalse, Message: "blocked"}}
+			},
+			failedChecks: []string{"Upgradeable"},
+		},
+		{
+			name: "When the cluster is not upgradeable and the upgrade is forced it should pass",
+			opts: ClusterOptions{ReleaseImage: image4130, Force: true},
+			mutate: func(hc *hyperv1.HostedCluster, _ []*hyperv1.NodePool) {
+				hc.Status.Conditions = []metav1.Condition{{Type: string(hyperv1.ClusterVersionUpgradeable), Status: metav1.ConditionFalse, Message: "blocked"}}
+			},
+		},
+		{
+			name: "When another upgrade is in progress it should fail",
+			opts: ClusterOptions{ReleaseImage: image4130},
+			mutate: func(hc *hyperv1.HostedCluster, _ []*hyperv1.NodePool) {
+				hc.Status.Version = &hyperv1.ClusterVersionStatus{History: []configv1.UpdateHistory{{State: configv1.PartialUpdate, Image: image4121, Version: "4.12.1"}}}
+			},
+			failedChecks: []string{"NoUpgradeInProgress"},
+		},
+		{
+			name: "When a NodePool not being upgraded would exceed the version skew it should fail",
+			opts: ClusterOptions{ReleaseImage: image4130, NodePools: []string{"example-b"}},
+			mutate: func(hc *hyperv1.HostedCluster, nodePools []*hyperv1.NodePool) {
+				nodePools[0].Spec.Release.Image = image4100
+			},
+			failedChecks: []string{"NodePoolVersionSkew/example-a"},
+		},
+		{
+			name: "When the lagging NodePool is upgraded as well it should pass",
+			opts: ClusterOptions{ReleaseImage: image4130, AllNodePools: true},
+			mutate: func(hc *hyperv1.HostedCluster, nodePools []*hyperv1.NodePool) {
+				nodePools[0].Spec.Release.Image = image4110
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(upgradeObjects(tc.mutate)...).Build()
+			tc.opts.Namespace, tc.opts.Name = "clusters", "example"
+			plan, err := BuildPlan(context.Background(), c, releaseProvider, tc.opts)
+			g.Expect(err).ToNot(HaveOccurred())
+			var failed []string
+			for _, check := range plan.Checks {
+				if !check.Passed {
+					failed = append(failed, check.Name)
+				}
+			}
+			if len(tc.failedChecks) == 0 {
+				g.Expect(failed).To(BeEmpty())
+				g.Expect(plan.Failed()).To(BeFalse())
+			} else {
+				g.Expect(failed).To(Equal(tc.failedChecks))
+				g.Expect(plan.Failed()).To(BeTrue())
+			}
+		})
+	}
+}
+
+func TestUpgradeCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	objects := upgradeObjects(func(hc *hyperv1.HostedCluster, nodePools []*hyperv1.NodePool) {
+		hc.Status.Version = &hyperv1.ClusterVersionStatus{History: []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Image: image4121, Version: "4.12.1"}}}
+		nodePools[1].Status.Version = "4.12.1"
+	})
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(objects...).Build()
+	opts := ClusterOptions{
+		Namespace:    "clusters",
+		Name:         "example",
+		ReleaseImage: image4121,
+		NodePools:    []string{"example-b"},
+		Force:        true,
+		Wait:         true,
+		Timeout:      5 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+	}
+	out := &bytes.Buffer{}
+	g.Expect(UpgradeCluster(context.Background(), c, releaseProvider, opts, out)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Upgrade completed"))
+
+	hcluster := &hyperv1.HostedCluster{}
+	g.Expect(c.Get(context.Background(), crclient.ObjectKey{Namespace: "clusters", Name: "example"}, hcluster)).To(Succeed())
+	g.Expect(hcluster.Spec.Release.Image).To(Equal(image4121))
+	g.Expect(hcluster.Annotations).To(HaveKeyWithValue(hyperv1.ForceUpgradeToAnnotation, image4121))
+
+	var nodePools hyperv1.NodePoolList
+	g.Expect(c.List(context.Background(), &nodePools)).To(Succeed())
+	for _, nodePool := range nodePools.Items {
+		if nodePool.Name == "example-b" {
+			g.Expect(nodePool.Spec.Release.Image).To(Equal(image4121))
+		} else {
+			g.Expect(nodePool.Spec.Release.Image).To(Equal(image4120))
+		}
+	}
+
+	opts.ReleaseImage = image4140
+	g.Expect(UpgradeCluster(context.Background(), c, releaseProvider, opts, &bytes.Buffer{})).ToNot(Succeed())
+}
@@ -0,0 +1,17 @@
+package upgrade
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "upgrade",
+		Short:        "Commands for upgrading HyperShift resources",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewClusterCommand())
+
+	return cmd
+}
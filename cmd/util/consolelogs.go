@@ -0,0 +1,22 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// WriteConsoleLogs writes the console log of every machine to <outputDir>/<machine>.log.
+func WriteConsoleLogs(outputDir string, logs map[string][]byte) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	var errs []error
+	for name, content := range logs {
+		if err := os.WriteFile(filepath.Join(outputDir, name+".log"), content, 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/openshift/hypershift/support/proxy"
 	"github.com/openshift/hypershift/support/util"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -73,6 +74,17 @@ func ReconcileRole(role *rbacv1.Role, ownerRef config.OwnerRef, platform hyperv1
 				"watch",
 			},
 		},
+		{
+			APIGroups: []string{autoscalingv2.SchemeGroupVersion.Group},
+			Resources: []string{
+				"horizontalpodautoscalers",
+			},
+			Verbs: []string{
+				"get",
+				"list",
+				"watch",
+			},
+		},
 		{
 			APIGroups: []string{hyperv1.GroupVersion.Group},
 			Resources: []string{
@@ -221,6 +233,7 @@ var (
 			hccVolumeKubeconfig().Name:      "/etc/kubernetes/kubeconfig",
 			hccVolumeRootCA().Name:          "/etc/kubernetes/root-ca",
 			hccVolumeClusterSignerCA().Name: "/etc/kubernetes/cluster-signer-ca",
+			hccVolumeReleaseMetadata().Name: config.ReleaseMetadataCacheDir,
 		},
 	}
 	hccLabels = map[string]string{
@@ -250,6 +263,7 @@ func ReconcileDeployment(deployment *appsv1.Deployment, image, hcpName, openShif
 					util.BuildVolume(hccVolumeKubeconfig(), buildHCCVolumeKubeconfig),
 					util.BuildVolume(hccVolumeRootCA(), buildHCCVolumeRootCA),
 					util.BuildVolume(hccVolumeClusterSignerCA(), buildHCCClusterSignerCA),
+					util.BuildVolume(hccVolumeReleaseMetadata(), buildHCCVolumeReleaseMetadata),
 				},
 				ServiceAccountName: manifests.ConfigOperatorServiceAccount("").Name,
 			},
@@ -295,6 +309,12 @@ func hccVolumeClusterSignerCA() *corev1.Volume {
 	}
 }
 
+func hccVolumeReleaseMetadata() *corev1.Volume {
+	return &corev1.Volume{
+		Name: "release-metadata",
+	}
+}
+
 func buildHCCContainerMain(image, hcpName, openShiftVersion, kubeVersion string, enableCIDebugOutput bool, platformType hyperv1.PlatformType, konnectivityAddress string, konnectivityPort int32, oauthAddress string, oauthPort int32, releaseImage string) func(c *corev1.Container) {
 	return func(c *corev1.Container) {
 		c.Image = image
@@ -313,6 +333,7 @@ func buildHCCContainerMain(image, hcpName, openShiftVersion, kubeVersion string,
 			fmt.Sprintf("--konnectivity-port=%d", konnectivityPort),
 			fmt.Sprintf("--oauth-address=%s", oauthAddress),
 			fmt.Sprintf("--oauth-port=%d", oauthPort),
+			fmt.Sprintf("--release-metadata-cache-dir=%s", volumeMounts.Path(c.Name, hccVolumeReleaseMetadata().Name)),
 		}
 		c.Ports = []corev1.ContainerPort{{Name: "metrics", ContainerPort: 8080}}
 		c.Env = []corev1.EnvVar{
@@ -361,3 +382,7 @@ func buildHCCClusterSignerCA(v *corev1.Volume) {
 		DefaultMode: pointer.Int32Ptr(0640),
 	}
 }
+
+func buildHCCVolumeReleaseMetadata(v *corev1.Volume) {
+	v.EmptyDir = &corev1.EmptyDirVolumeSource{}
+}
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -15,6 +17,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/blang/semver"
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
@@ -65,6 +68,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -72,6 +76,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/duration"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -147,6 +152,7 @@ type HostedControlPlaneReconciler struct {
 	DefaultIngressDomain          string
 	MetricsSet                    metrics.MetricsSet
 	reconcileInfrastructureStatus func(ctx context.Context, hcp *hyperv1.HostedControlPlane) (InfrastructureStatus, error)
+	identityProviderValidations   identityProviderValidationCache
 }
 
 func (r *HostedControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager, createOrUpdate upsert.CreateOrUpdateFN) error {
@@ -422,6 +428,16 @@ func (r *HostedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.R
 		meta.SetStatusCondition(&hostedControlPlane.Status.Conditions, condition)
 	}
 
+	// Reconcile control plane component overrides status
+	{
+		condition, err := r.componentOverridesCondition(ctx, hostedControlPlane)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		condition.ObservedGeneration = hostedControlPlane.Generation
+		meta.SetStatusCondition(&hostedControlPlane.Status.Conditions, *condition)
+	}
+
 	// Reconcile infrastructure status
 	{
 		r.Log.Info("Reconciling infrastructure status")
@@ -470,6 +486,13 @@ func (r *HostedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.R
 		meta.SetStatusCondition(&hostedControlPlane.Status.Conditions, newCondition)
 	}
 
+	// Validate the identity providers once the kube apiserver, which runs the konnectivity server
+	// used to reach them, is available.
+	if !util.IsOIDCAuthenticationHCP(hostedControlPlane) && meta.IsStatusConditionTrue(hostedControlPlane.Status.Conditions, string(hyperv1.KubeAPIServerAvailable)) {
+		p := oauth.NewOAuthServerParams(hostedControlPlane, nil, "", 0, r.SetDefaultSecurityContext)
+		oauth.SetIdentityProviderConditions(&hostedControlPlane.Status.Conditions, r.validateIdentityProviders(ctx, hostedControlPlane, p), hostedControlPlane.Generation)
+	}
+
 	// Reconcile hostedcontrolplane availability and Ready flag
 	{
 		infrastructureCondition := meta.FindStatusCondition(hostedControlPlane.Status.Conditions, string(hyperv1.InfrastructureReady))
@@ -777,16 +800,22 @@ func (r *HostedControlPlaneReconciler) reconcile(ctx context.Context, hostedCont
 		return nil
 	}
 
-	// Reconcile openshift oauth apiserver
-	r.Log.Info("Reconciling OpenShift OAuth API Server")
-	if err := r.reconcileOpenShiftOAuthAPIServer(ctx, hostedControlPlane, observedConfig, releaseImage, infraStatus.OauthAPIServerHost, createOrUpdate); err != nil {
-		return fmt.Errorf("failed to reconcile openshift oauth apiserver: %w", err)
-	}
+	// The OAuth stack is not deployed when the API server validates tokens of
+	// external OIDC issuers directly.
+	if util.IsOIDCAuthenticationHCP(hostedControlPlane) {
+		r.Log.Info("Skipping OAuth server and OpenShift OAuth API Server, authentication type is OIDC")
+	} else {
+		// Reconcile openshift oauth apiserver
+		r.Log.Info("Reconciling OpenShift OAuth API Server")
+		if err := r.reconcileOpenShiftOAuthAPIServer(ctx, hostedControlPlane, observedConfig, releaseImage, infraStatus.OauthAPIServerHost, createOrUpdate); err != nil {
+			return fmt.Errorf("failed to reconcile openshift oauth apiserver: %w", err)
+		}
 
-	// Reconcile oauth server
-	r.Log.Info("Reconciling OAuth Server")
-	if err = r.reconcileOAuthServer(ctx, hostedControlPlane, releaseImage, infraStatus.OAuthHost, infraStatus.OAuthPort, createOrUpdate); err != nil {
-		return fmt.Errorf("failed to reconcile openshift oauth apiserver: %w", err)
+		// Reconcile oauth server
+		r.Log.Info("Reconciling OAuth Server")
+		if err = r.reconcileOAuthServer(ctx, hostedControlPlane, releaseImage, infraStatus.OAuthHost, infraStatus.OAuthPort, createOrUpdate); err != nil {
+			return fmt.Errorf("failed to reconcile openshift oauth apiserver: %w", err)
+		}
 	}
 
 	// Reconcile openshift controller manager
@@ -879,7 +908,8 @@ func (r *HostedControlPlaneReconciler) reconcile(ctx context.Context, hostedCont
 
 	// Reconcile kubeadmin password
 	r.Log.Info("Reconciling kubeadmin password secret")
-	explicitOauthConfig := hostedControlPlane.Spec.Configuration != nil && hostedControlPlane.Spec.Configuration.OAuth != nil
+	// There is no kubeadmin login without the OAuth server.
+	explicitOauthConfig := hostedControlPlane.Spec.Configuration != nil && hostedControlPlane.Spec.Configuration.OAuth != nil || util.IsOIDCAuthenticationHCP(hostedControlPlane)
 	if err := r.reconcileKubeadminPassword(ctx, hostedControlPlane, explicitOauthConfig, createOrUpdate); err != nil {
 		return fmt.Errorf("failed to ensure control plane: %w", err)
 	}
@@ -1474,7 +1504,7 @@ func (r *HostedControlPlaneReconciler) reconcilePKI(ctx context.Context, hcp *hy
 	// KAS server secret
 	kasServerSecret := manifests.KASServerCertSecret(hcp.Namespace)
 	if _, err := createOrUpdate(ctx, r, kasServerSecret, func() error {
-		return pki.ReconcileKASServerCertSecret(kasServerSecret, rootCASecret, p.OwnerRef, p.ExternalAPIAddress, p.InternalAPIAddress, p.ServiceCIDR)
+		return pki.ReconcileKASServerCertSecret(kasServerSecret, rootCASecret, p.OwnerRef, p.ExternalAPIAddress, p.InternalAPIAddress, p.ServiceCIDRs)
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile kas server secret: %w", err)
 	}
@@ -1797,6 +1827,12 @@ func (r *HostedControlPlaneReconciler) reconcileKonnectivity(ctx context.Context
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile konnectivity server local service: %w", err)
 	}
+	egressRoutesConfigMap := manifests.KonnectivityEgressRoutesConfigMap(hcp.Namespace)
+	if _, err := createOrUpdate(ctx, r, egressRoutesConfigMap, func() error {
+		return konnectivity.ReconcileEgressRoutesConfigMap(egressRoutesConfigMap, p.OwnerRef, hcp.Spec.ControlPlaneEgress)
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile konnectivity egress routes configmap: %w", err)
+	}
 	agentDeployment := manifests.KonnectivityAgentDeployment(hcp.Namespace)
 	ips := []string{
 		infraStatus.OpenShiftAPIHost,
@@ -1808,6 +1844,24 @@ func (r *HostedControlPlaneReconciler) reconcileKonnectivity(ctx context.Context
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile konnectivity agent deployment: %w", err)
 	}
+	serverPodMonitor := manifests.KonnectivityServerPodMonitor(hcp.Namespace)
+	if _, err := createOrUpdate(ctx, r, serverPodMonitor, func() error {
+		return konnectivity.ReconcileServerPodMonitor(serverPodMonitor, p.OwnerRef, hcp.Spec.ClusterID, r.MetricsSet)
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile konnectivity server pod monitor: %w", err)
+	}
+	agentPodMonitor := manifests.KonnectivityAgentPodMonitor(hcp.Namespace)
+	if _, err := createOrUpdate(ctx, r, agentPodMonitor, func() error {
+		return konnectivity.ReconcileAgentPodMonitor(agentPodMonitor, p.OwnerRef, hcp.Spec.ClusterID, r.MetricsSet)
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile konnectivity agent pod monitor: %w", err)
+	}
+	socks5ProxyPodMonitor := manifests.KonnectivitySocks5ProxyPodMonitor(hcp.Namespace)
+	if _, err := createOrUpdate(ctx, r, socks5ProxyPodMonitor, func() error {
+		return konnectivity.ReconcileSocks5ProxyPodMonitor(socks5ProxyPodMonitor, p.OwnerRef, hcp.Spec.ClusterID, r.MetricsSet)
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile konnectivity socks5 proxy pod monitor: %w", err)
+	}
 	return nil
 }
 
@@ -2264,9 +2318,15 @@ func (r *HostedControlPlaneReconciler) reconcileOAuthServer(ctx context.Context,
 		return fmt.Errorf("cannot get oauth serving cert: %w", err)
 	}
 
+	// Identity providers that cannot be configured are left out of the rollout so
+	// that a single misconfigured identity provider does not lock out every user.
+	identityProviders := oauth.UsableIdentityProviders(p.IdentityProviders(), r.validateIdentityProviders(ctx, hcp, p))
+	configParams := p.ConfigParams(oauthServingCert)
+	configParams.IdentityProviders = identityProviders
+
 	oauthConfig := manifests.OAuthServerConfig(hcp.Namespace)
 	if _, err := createOrUpdate(ctx, r, oauthConfig, func() error {
-		return oauth.ReconcileOAuthServerConfig(ctx, oauthConfig, p.OwnerRef, r.Client, p.ConfigParams(oauthServingCert))
+		return oauth.ReconcileOAuthServerConfig(ctx, oauthConfig, p.OwnerRef, r.Client, configParams)
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile oauth server config: %w", err)
 	}
@@ -2282,13 +2342,84 @@ func (r *HostedControlPlaneReconciler) reconcileOAuthServer(ctx context.Context,
 
 	deployment := manifests.OAuthServerDeployment(hcp.Namespace)
 	if _, err := createOrUpdate(ctx, r, deployment, func() error {
-		return oauth.ReconcileDeployment(ctx, r, deployment, p.OwnerRef, oauthConfig, p.OAuthServerImage, p.DeploymentConfig, p.IdentityProviders(), p.OauthConfigOverrides, p.AvailabilityProberImage, util.APIPort(hcp), p.NamedCertificates(), p.Socks5ProxyImage, p.NoProxy)
+		return oauth.ReconcileDeployment(ctx, r, deployment, p.OwnerRef, oauthConfig, p.OAuthServerImage, p.DeploymentConfig, identityProviders, p.OauthConfigOverrides, p.AvailabilityProberImage, util.APIPort(hcp), p.NamedCertificates(), p.Socks5ProxyImage, p.NoProxy)
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile oauth deployment: %w", err)
 	}
 	return nil
 }
 
+// identityProviderRetryInterval is how often identity providers that failed validation are
+// validated again while their configuration does not change.
+const identityProviderRetryInterval = time.Minute
+
+// identityProviderValidation is the result of validating the identity providers of a hosted control plane.
+type identityProviderValidation struct {
+	identityProviders []configv1.IdentityProvider
+	overrides         map[string]*oauth.ConfigOverride
+	validatedAt       time.Time
+	results           []oauth.IdentityProviderValidation
+}
+
+// identityProviderValidationCache remembers the validation of the identity providers per hosted control
+// plane, so the connectivity checks only run again when the identity provider configuration changes.
+type identityProviderValidationCache struct {
+	lock        sync.Mutex
+	validations map[string]identityProviderValidation
+}
+
+// validateIdentityProviders returns the validation of the configured identity providers. The identity
+// providers are only validated again when their configuration changed, or when some of them failed
+// validation more than identityProviderRetryInterval ago.
+func (r *HostedControlPlaneReconciler) validateIdentityProviders(ctx context.Context, hcp *hyperv1.HostedControlPlane, p *oauth.OAuthServerParams) []oauth.IdentityProviderValidation {
+	key := client.ObjectKeyFromObject(hcp).String()
+	cache := &r.identityProviderValidations
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	identityProviders := p.IdentityProviders()
+	if previous, ok := cache.validations[key]; ok &&
+		equality.Semantic.DeepEqual(previous.identityProviders, identityProviders) &&
+		equality.Semantic.DeepEqual(previous.overrides, p.OauthConfigOverrides) &&
+		(allIdentityProvidersValid(previous.results) || time.Since(previous.validatedAt) < identityProviderRetryInterval) {
+		return previous.results
+	}
+
+	dial := func(ctx context.Context, address string) (net.Conn, error) {
+		clientSecret := manifests.KonnectivityClientSecret(hcp.Namespace)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(clientSecret), clientSecret); err != nil {
+			return nil, fmt.Errorf("failed to get konnectivity client secret: %w", err)
+		}
+		return konnectivity.DialEgress(ctx, hcp.Spec.ControlPlaneEgress, clientSecret, address)
+	}
+	results := oauth.ValidateIdentityProviders(ctx, r.Client, hcp.Namespace, identityProviders, p.OauthConfigOverrides, dial)
+	for _, result := range results {
+		if result.Condition.Status != metav1.ConditionTrue {
+			r.Log.Info("Identity provider is not valid", "name", result.Name, "reason", result.Condition.Reason, "message", result.Condition.Message, "usable", result.Usable)
+		}
+	}
+
+	if cache.validations == nil {
+		cache.validations = map[string]identityProviderValidation{}
+	}
+	cache.validations[key] = identityProviderValidation{
+		identityProviders: identityProviders,
+		overrides:         p.OauthConfigOverrides,
+		validatedAt:       time.Now(),
+		results:           results,
+	}
+	return results
+}
+
+func allIdentityProvidersValid(results []oauth.IdentityProviderValidation) bool {
+	for _, result := range results {
+		if result.Condition.Status != metav1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *HostedControlPlaneReconciler) reconcileOpenShiftControllerManager(ctx context.Context, hcp *hyperv1.HostedControlPlane, observedConfig *globalconfig.ObservedConfig, releaseImage *releaseinfo.ReleaseImage, createOrUpdate upsert.CreateOrUpdateFN) error {
 	p := ocm.NewOpenShiftControllerManagerParams(hcp, observedConfig, releaseImage.ComponentImages(), r.SetDefaultSecurityContext)
 	config := manifests.OpenShiftControllerManagerConfig(hcp.Namespace)
@@ -3069,6 +3200,76 @@ func (r *HostedControlPlaneReconciler) etcdRestoredCondition(ctx context.Context
 	return nil
 }
 
+// componentOverridesCondition returns the ValidControlPlaneComponentOverrides condition, which reports the
+// overrides of spec.controlPlaneComponents that reference a component or container not managed by this operator,
+// and the resource overrides that were skipped because their requests exceed the default limits of the component.
+func (r *HostedControlPlaneReconciler) componentOverridesCondition(ctx context.Context, hcp *hyperv1.HostedControlPlane) (*metav1.Condition, error) {
+	if len(hcp.Spec.ControlPlaneComponents) == 0 {
+		return &metav1.Condition{
+			Type:    string(hyperv1.ValidControlPlaneComponentOverrides),
+			Status:  metav1.ConditionTrue,
+			Reason:  hyperv1.AsExpectedReason,
+			Message: "No control plane component overrides",
+		}, nil
+	}
+
+	managedBy := client.MatchingLabels{config.ManagedByLabel: "control-plane-operator"}
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, managedBy, client.InNamespace(hcp.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list managed deployments in namespace %s: %w", hcp.Namespace, err)
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, managedBy, client.InNamespace(hcp.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list managed statefulsets in namespace %s: %w", hcp.Namespace, err)
+	}
+	if len(deployments.Items) == 0 && len(statefulSets.Items) == 0 {
+		return &metav1.Condition{
+			Type:    string(hyperv1.ValidControlPlaneComponentOverrides),
+			Status:  metav1.ConditionUnknown,
+			Reason:  hyperv1.StatusUnknownReason,
+			Message: "Waiting for control plane components to be created",
+		}, nil
+	}
+
+	components := map[string]sets.String{}
+	var invalidResources []string
+	for _, deployment := range deployments.Items {
+		components[deployment.Name] = config.PodSpecContainers(&deployment.Spec.Template.Spec)
+		if message, exists := deployment.Annotations[config.InvalidComponentOverridesAnnotation]; exists {
+			invalidResources = append(invalidResources, message)
+		}
+	}
+	for _, sts := range statefulSets.Items {
+		components[sts.Name] = config.PodSpecContainers(&sts.Spec.Template.Spec)
+		if message, exists := sts.Annotations[config.InvalidComponentOverridesAnnotation]; exists {
+			invalidResources = append(invalidResources, message)
+		}
+	}
+	if err := config.ValidateComponentOverrides(hcp.Spec.ControlPlaneComponents, components); err != nil {
+		return &metav1.Condition{
+			Type:    string(hyperv1.ValidControlPlaneComponentOverrides),
+			Status:  metav1.ConditionFalse,
+			Reason:  hyperv1.UnknownControlPlaneComponentReason,
+			Message: err.Error(),
+		}, nil
+	}
+	if len(invalidResources) > 0 {
+		sort.Strings(invalidResources)
+		return &metav1.Condition{
+			Type:    string(hyperv1.ValidControlPlaneComponentOverrides),
+			Status:  metav1.ConditionFalse,
+			Reason:  hyperv1.InvalidControlPlaneComponentResourcesReason,
+			Message: fmt.Sprintf("Skipped resource overrides: %s", strings.Join(invalidResources, "; ")),
+		}, nil
+	}
+	return &metav1.Condition{
+		Type:    string(hyperv1.ValidControlPlaneComponentOverrides),
+		Status:  metav1.ConditionTrue,
+		Reason:  hyperv1.AsExpectedReason,
+		Message: "All control plane component overrides are applied",
+	}, nil
+}
+
 func (r *HostedControlPlaneReconciler) etcdStatefulSetCondition(ctx context.Context, sts *appsv1.StatefulSet) (*metav1.Condition, error) {
 	if sts.Status.ReadyReplicas >= *sts.Spec.Replicas/2+1 {
 		return &metav1.Condition{
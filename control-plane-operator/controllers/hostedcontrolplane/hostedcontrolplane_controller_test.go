@@ -17,6 +17,7 @@ import (
 	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/common"
 	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/ingress"
 	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/oauth"
 	fakecapabilities "github.com/openshift/hypershift/support/capabilities/fake"
 	"github.com/openshift/hypershift/support/config"
 	"github.com/openshift/hypershift/support/releaseinfo"
@@ -1231,6 +1232,56 @@ func TestNonReadyInfraTriggersRequeueAfter(t *testing.T) {
 	}
 }
 
+func TestValidateIdentityProvidersOnlyRevalidatesOnChange(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hcp := sampleHCP(t)
+	htpasswd := func(secretName string) []configv1.IdentityProvider {
+		return []configv1.IdentityProvider{{
+			Name: "htpasswd",
+			IdentityProviderConfig: configv1.IdentityProviderConfig{
+				Type:     configv1.IdentityProviderTypeHTPasswd,
+				HTPasswd: &configv1.HTPasswdIdentityProvider{FileData: configv1.SecretNameReference{Name: secretName}},
+			},
+		}}
+	}
+	hcp.Spec.Configuration = &hyperv1.ClusterConfiguration{
+		OAuth: &configv1.OAuthSpec{IdentityProviders: htpasswd("htpasswd")},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: hcp.Namespace, Name: "htpasswd"},
+		Data:       map[string][]byte{configv1.HTPasswdDataKey: []byte("user:password")},
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(secret).Build()
+	r := &HostedControlPlaneReconciler{Client: c, Log: ctrl.LoggerFrom(context.Background())}
+	ctx := context.Background()
+	validate := func() metav1.ConditionStatus {
+		results := r.validateIdentityProviders(ctx, hcp, oauth.NewOAuthServerParams(hcp, nil, "", 0, false))
+		g.Expect(results).To(HaveLen(1))
+		return results[0].Condition.Status
+	}
+
+	g.Expect(validate()).To(Equal(metav1.ConditionTrue))
+
+	// Valid identity providers are not validated again while their configuration does not change.
+	g.Expect(c.Delete(ctx, secret)).To(Succeed())
+	g.Expect(validate()).To(Equal(metav1.ConditionTrue))
+
+	// A configuration change validates them again.
+	hcp.Spec.Configuration.OAuth.IdentityProviders = htpasswd("other")
+	g.Expect(validate()).To(Equal(metav1.ConditionFalse))
+
+	// Invalid identity providers are validated again after the retry interval.
+	other := secret.DeepCopy()
+	other.Name, other.ResourceVersion = "other", ""
+	g.Expect(c.Create(ctx, other)).To(Succeed())
+	g.Expect(validate()).To(Equal(metav1.ConditionFalse))
+	key := client.ObjectKeyFromObject(hcp).String()
+	validation := r.identityProviderValidations.validations[key]
+	validation.validatedAt = validation.validatedAt.Add(-identityProviderRetryInterval)
+	r.identityProviderValidations.validations[key] = validation
+	g.Expect(validate()).To(Equal(metav1.ConditionTrue))
+}
+
 func TestReconcileHCPRouterServices(t *testing.T) {
 	const namespace = "test-ns"
 	publicService := func(m ...func(*corev1.Service)) *corev1.Service {
@@ -1467,3 +1518,84 @@ func TestReconcileRouterServiceStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestComponentOverridesCondition(t *testing.T) {
+	const namespace = "clusters-example"
+	managedBy := map[string]string{config.ManagedByLabel: "control-plane-operator"}
+	kasDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "kube-apiserver", Labels: managedBy},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "kube-apiserver"}},
+		}}},
+	}
+	etcdStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "etcd", Labels: managedBy},
+		Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "etcd"}},
+		}}},
+	}
+
+	testCases := []struct {
+		name            string
+		overrides       []hyperv1.ControlPlaneComponentOverride
+		existingObjects []client.Object
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+	}{
+		{
+			name:           "When there are no overrides it should be true",
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: hyperv1.AsExpectedReason,
+		},
+		{
+			name:           "When the components are not created yet it should be unknown",
+			overrides:      []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver"}},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: hyperv1.StatusUnknownReason,
+		},
+		{
+			name: "When the overrides reference existing deployments and statefulsets it should be true",
+			overrides: []hyperv1.ControlPlaneComponentOverride{
+				{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{Name: "kube-apiserver"}}},
+				{Name: "etcd"},
+			},
+			existingObjects: []client.Object{kasDeployment, etcdStatefulSet},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  hyperv1.AsExpectedReason,
+		},
+		{
+			name:            "When an override references an unknown container it should be false",
+			overrides:       []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{Name: "etcd"}}}},
+			existingObjects: []client.Object{kasDeployment, etcdStatefulSet},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  hyperv1.UnknownControlPlaneComponentReason,
+		},
+		{
+			name:      "When resource overrides of a component were skipped it should be false",
+			overrides: []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{Name: "kube-apiserver"}}}},
+			existingObjects: []client.Object{etcdStatefulSet, func() client.Object {
+				deployment := kasDeployment.DeepCopy()
+				deployment.Annotations = map[string]string{
+					config.InvalidComponentOverridesAnnotation: "component kube-apiserver container kube-apiserver: memory request 8Gi exceeds its limit 4Gi",
+				}
+				return deployment
+			}()},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.InvalidControlPlaneComponentResourcesReason,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			hcp := &hyperv1.HostedControlPlane{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "example"}}
+			hcp.Spec.ControlPlaneComponents = tc.overrides
+			r := &HostedControlPlaneReconciler{
+				Client: fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.existingObjects...).Build(),
+			}
+			condition, err := r.componentOverridesCondition(context.Background(), hcp)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+		})
+	}
+}
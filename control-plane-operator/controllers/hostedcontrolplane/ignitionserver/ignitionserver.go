@@ -6,6 +6,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
 	routev1 "github.com/openshift/api/route/v1"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/controlplaneoperator"
@@ -19,6 +20,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -171,6 +173,22 @@ func ReconcileIgnitionServer(ctx context.Context,
 				Verbs: []string{"*"},
 			},
 		}
+		if attestationEnabled(hcp) {
+			role.Rules = append(role.Rules,
+				// This is needed to verify that instances are machines of the cluster.
+				rbacv1.PolicyRule{
+					APIGroups: []string{"infrastructure.cluster.x-k8s.io"},
+					Resources: []string{"awsmachines", "azuremachines"},
+					Verbs:     []string{"get", "list"},
+				},
+				// This is needed to serve payloads once per machine with single use tokens.
+				rbacv1.PolicyRule{
+					APIGroups: []string{"cluster.x-k8s.io"},
+					Resources: []string{"machines"},
+					Verbs:     []string{"get", "update"},
+				},
+			)
+		}
 		return nil
 	}); err != nil {
 		return fmt.Errorf("failed to reconcile ignition role: %w", err)
@@ -223,6 +241,18 @@ func ReconcileIgnitionServer(ctx context.Context,
 		}
 	}
 
+	if attestationEnabled(hcp) && hcp.Spec.Platform.Type == hyperv1.AWSPlatform {
+		// AWS signs instance identity documents with a certificate per region, which
+		// the ignition server can't verify documents without.
+		caConfigMap := ignitionserver.AttestationCAConfigMap(controlPlaneNamespace)
+		if err := c.Get(ctx, client.ObjectKeyFromObject(caConfigMap), caConfigMap); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("ignition server attestation requires the AWS instance identity certificate of region %s in the %s ConfigMap", hcp.Spec.Platform.AWS.Region, caConfigMap.Name)
+			}
+			return fmt.Errorf("failed to get ignition server attestation ConfigMap: %w", err)
+		}
+	}
+
 	// Reconcile deployment
 	ignitionServerDeployment := ignitionserver.Deployment(controlPlaneNamespace)
 	if result, err := createOrUpdate(ctx, c, ignitionServerDeployment, func() error {
@@ -266,6 +296,12 @@ func ReconcileIgnitionServer(ctx context.Context,
 								EmptyDir: &corev1.EmptyDirVolumeSource{},
 							},
 						},
+						{
+							Name: "release-metadata",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
 					},
 					Containers: []corev1.Container{
 						{
@@ -289,6 +325,10 @@ func ReconcileIgnitionServer(ctx context.Context,
 								"--key-file", "/var/run/secrets/ignition/serving-cert/tls.key",
 								"--registry-overrides", convertRegistryOverridesToCommandLineFlag(registryOverrides),
 								"--platform", string(hcp.Spec.Platform.Type),
+								// Share payloads between replicas and across restarts, so nodes booting
+								// during a control plane rollout are served right away.
+								"--payload-store", "secret",
+								"--release-metadata-cache-dir", config.ReleaseMetadataCacheDir,
 							},
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler:        probeHandler,
@@ -331,6 +371,10 @@ func ReconcileIgnitionServer(ctx context.Context,
 									Name:      "payloads",
 									MountPath: "/payloads",
 								},
+								{
+									Name:      "release-metadata",
+									MountPath: config.ReleaseMetadataCacheDir,
+								},
 							},
 						},
 					},
@@ -339,6 +383,10 @@ func ReconcileIgnitionServer(ctx context.Context,
 		}
 		proxy.SetEnvVars(&ignitionServerDeployment.Spec.Template.Spec.Containers[0].Env)
 
+		if attestationEnabled(hcp) {
+			applyAttestation(&ignitionServerDeployment.Spec.Template.Spec, hcp)
+		}
+
 		if hcp.Spec.AdditionalTrustBundle != nil {
 			// Add trusted-ca mount with optional configmap
 			util.DeploymentAddTrustBundleVolume(hcp.Spec.AdditionalTrustBundle, ignitionServerDeployment)
@@ -428,6 +476,66 @@ func reconcileInternalRoute(route *routev1.Route, ownerRef config.OwnerRef) erro
 	return util.ReconcileInternalRoute(route, ownerRef.Reference.Name, ignitionserver.Service(route.Namespace).Name)
 }
 
+// attestationEnabled returns whether the ignition server verifies the instance
+// identity of machines. Attestation is only supported on AWS and Azure.
+func attestationEnabled(hcp *hyperv1.HostedControlPlane) bool {
+	if hcp.Annotations[hyperv1.IgnitionServerAttestationAnnotation] != "true" {
+		return false
+	}
+	return (hcp.Spec.Platform.Type == hyperv1.AWSPlatform && hcp.Spec.Platform.AWS != nil) ||
+		(hcp.Spec.Platform.Type == hyperv1.AzurePlatform && hcp.Spec.Platform.Azure != nil)
+}
+
+// applyAttestation configures the ignition server to verify the instance identity
+// of machines before serving them a payload.
+func applyAttestation(podSpec *corev1.PodSpec, hcp *hyperv1.HostedControlPlane) {
+	const caDir = "/var/run/secrets/ignition/attestation-ca"
+	container := &podSpec.Containers[0]
+	container.Command = append(container.Command,
+		"--attestation", string(hcp.Spec.Platform.Type),
+		"--infra-id", hcp.Spec.InfraID,
+	)
+	switch hcp.Spec.Platform.Type {
+	case hyperv1.AWSPlatform:
+		container.Command = append(container.Command, "--attestation-region", hcp.Spec.Platform.AWS.Region)
+		// Instances are created in the account of the role CAPI uses to manage them.
+		if roleARN, err := arn.Parse(hcp.Spec.Platform.AWS.RolesRef.NodePoolManagementARN); err == nil {
+			container.Command = append(container.Command, "--attestation-account-id", roleARN.AccountID)
+		}
+	case hyperv1.AzurePlatform:
+		container.Command = append(container.Command, "--attestation-subscription-id", hcp.Spec.Platform.Azure.SubscriptionID)
+		// The credentials are needed to get the VM IDs of machines.
+		for _, key := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET"} {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name: key,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: hcp.Spec.Platform.Azure.Credentials,
+					Key:                  key,
+				}},
+			})
+		}
+	}
+	// The ConfigMap is required on AWS, and optional on Azure where the ignition
+	// server falls back to the system roots.
+	container.Command = append(container.Command, "--attestation-certificates-file", caDir+"/ca.crt")
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "attestation-ca",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ignitionserver.AttestationCAConfigMap("").Name},
+				Optional:             utilpointer.Bool(true),
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "attestation-ca",
+		MountPath: caDir,
+	})
+	if hcp.Annotations[hyperv1.IgnitionServerSingleUseTokensAnnotation] == "true" {
+		container.Command = append(container.Command, "--single-use-tokens")
+	}
+}
+
 func convertRegistryOverridesToCommandLineFlag(registryOverrides map[string]string) string {
 	commandLineFlagArray := []string{}
 	for registrySource, registryReplacement := range registryOverrides {
@@ -7,6 +7,7 @@ import (
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/ignitionserver"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -140,3 +141,56 @@ func TestReconcileIgnitionServerServiceRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyAttestation(t *testing.T) {
+	tests := []struct {
+		name         string
+		hcp          *hyperv1.HostedControlPlane
+		expectedArgs []string
+		expectedEnv  []string
+	}{
+		{
+			name: "When attestation is enabled on AWS it should verify instance identity documents of the region and account",
+			hcp: &hyperv1.HostedControlPlane{Spec: hyperv1.HostedControlPlaneSpec{
+				InfraID: "example-abcde",
+				Platform: hyperv1.PlatformSpec{Type: hyperv1.AWSPlatform, AWS: &hyperv1.AWSPlatformSpec{
+					Region:   "us-east-1",
+					RolesRef: hyperv1.AWSRolesRef{NodePoolManagementARN: "arn:aws:iam::123456789012:role/example-node-pool"},
+				}},
+			}},
+			expectedArgs: []string{"--attestation", "AWS", "--infra-id", "example-abcde", "--attestation-region", "us-east-1", "--attestation-account-id", "123456789012", "--attestation-certificates-file", "/var/run/secrets/ignition/attestation-ca/ca.crt"},
+		},
+		{
+			name: "When attestation is enabled on Azure with single use tokens it should get VM IDs with the cluster credentials",
+			hcp: &hyperv1.HostedControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{hyperv1.IgnitionServerSingleUseTokensAnnotation: "true"}},
+				Spec: hyperv1.HostedControlPlaneSpec{
+					InfraID: "example-abcde",
+					Platform: hyperv1.PlatformSpec{Type: hyperv1.AzurePlatform, Azure: &hyperv1.AzurePlatformSpec{
+						SubscriptionID: "subscription",
+						Credentials:    corev1.LocalObjectReference{Name: "azure-credentials"},
+					}},
+				},
+			},
+			expectedArgs: []string{"--attestation", "Azure", "--infra-id", "example-abcde", "--attestation-subscription-id", "subscription", "--attestation-certificates-file", "/var/run/secrets/ignition/attestation-ca/ca.crt", "--single-use-tokens"},
+			expectedEnv:  []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Command: []string{"ignition-server"}}}}
+			applyAttestation(podSpec, test.hcp)
+			g.Expect(podSpec.Containers[0].Command).To(Equal(append([]string{"ignition-server"}, test.expectedArgs...)))
+			var env []string
+			for _, e := range podSpec.Containers[0].Env {
+				env = append(env, e.Name)
+			}
+			g.Expect(env).To(Equal(test.expectedEnv))
+			g.Expect(podSpec.Volumes).To(HaveLen(1))
+			g.Expect(podSpec.Volumes[0].ConfigMap.Name).To(Equal(ignitionserver.AttestationCAConfigMap("").Name))
+			g.Expect(*podSpec.Volumes[0].ConfigMap.Optional).To(BeTrue())
+			g.Expect(podSpec.Containers[0].VolumeMounts).To(HaveLen(1))
+		})
+	}
+}
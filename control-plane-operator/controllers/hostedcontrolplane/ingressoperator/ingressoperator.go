@@ -156,6 +156,7 @@ func ReconcileDeployment(dep *appsv1.Deployment, params Params, apiPort *int32)
 		{Name: "ingress-operator-kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: manifests.IngressOperatorKubeconfig("").Name, DefaultMode: utilpointer.Int32Ptr(0640)}}},
 		{Name: "admin-kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "service-network-admin-kubeconfig", DefaultMode: utilpointer.Int32Ptr(0640)}}},
 		{Name: "konnectivity-proxy-cert", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: manifests.KonnectivityClientSecret("").Name, DefaultMode: utilpointer.Int32Ptr(0640)}}},
+		konnectivity.Socks5ProxyEgressRoutesVolume(),
 	}
 
 	if params.Platform == hyperv1.AWSPlatform {
@@ -207,12 +208,13 @@ func ingressOperatorSocks5ProxyContainer(socks5ProxyImage string) corev1.Contain
 		Name:    socks5ProxyContainerName,
 		Image:   socks5ProxyImage,
 		Command: []string{"/usr/bin/control-plane-operator", "konnectivity-socks5-proxy", "--resolve-from-guest-cluster-dns=true"},
-		Args: []string{
+		Args: append([]string{
 			"run",
 			// Do not route cloud provider traffic through konnektivity and thus nodes to speed
 			// up cluster creation. Requires proxy env vars to be set.
 			"--connect-directly-to-cloud-apis=true",
-		},
+		}, konnectivity.Socks5ProxyEgressArgs()...),
+		Ports: []corev1.ContainerPort{konnectivity.Socks5ProxyMetricsContainerPort()},
 		Env: []corev1.EnvVar{{
 			Name:  "KUBECONFIG",
 			Value: "/etc/kubernetes/kubeconfig",
@@ -226,6 +228,7 @@ func ingressOperatorSocks5ProxyContainer(socks5ProxyImage string) corev1.Contain
 		VolumeMounts: []corev1.VolumeMount{
 			{Name: "admin-kubeconfig", MountPath: "/etc/kubernetes"},
 			{Name: "konnectivity-proxy-cert", MountPath: "/etc/konnectivity-proxy-tls"},
+			konnectivity.Socks5ProxyEgressRoutesVolumeMount(),
 		},
 	}
 	proxy.SetEnvVars(&c.Env)
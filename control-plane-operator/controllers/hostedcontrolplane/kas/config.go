@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path"
+	"strings"
 
 	"github.com/blang/semver"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
@@ -30,6 +31,7 @@ const (
 	AuditLogFile            = "audit.log"
 	EgressSelectorConfigKey = "config.yaml"
 	DefaultEtcdPort         = 2379
+	OIDCCABundleKey         = "ca-bundle.crt"
 )
 
 func ReconcileConfig(config *corev1.ConfigMap,
@@ -122,14 +124,17 @@ func generateConfig(p KubeAPIServerConfigParams, version semver.Version) *kcpv1.
 			},
 			CORSAllowedOrigins: corsAllowedOrigins(p.AdditionalCORSAllowedOrigins),
 		},
-		AuthConfig: kcpv1.MasterAuthConfig{
-			OAuthMetadataFile: cpath(kasVolumeOauthMetadata().Name, OauthMetadataConfigKey),
-		},
 		ConsolePublicURL:             p.ConsolePublicURL,
 		ImagePolicyConfig:            imagePolicyConfig(p.InternalRegistryHostName, p.ExternalRegistryHostNames),
 		ProjectConfig:                projectConfig(p.DefaultNodeSelector),
 		ServiceAccountPublicKeyFiles: []string{cpath(kasVolumeServiceAccountKey().Name, pki.ServiceSignerPublicKey)},
-		ServicesSubnet:               p.ServiceNetwork[0],
+		ServicesSubnet:               strings.Join(p.ServiceNetwork, ","),
+	}
+	oidcAuthentication := p.Authentication != nil && p.Authentication.Type == hyperv1.OIDCAuthenticationType
+	if !oidcAuthentication {
+		config.AuthConfig = kcpv1.MasterAuthConfig{
+			OAuthMetadataFile: cpath(kasVolumeOauthMetadata().Name, OauthMetadataConfigKey),
+		}
 	}
 	args := kubeAPIServerArgs{}
 	args.Set("advertise-address", p.AdvertiseAddress)
@@ -141,8 +146,12 @@ func generateConfig(p KubeAPIServerConfigParams, version semver.Version) *kcpv1.
 	args.Set("audit-log-maxsize", "100")
 	args.Set("audit-log-path", cpath(kasVolumeWorkLogs().Name, AuditLogFile))
 	args.Set("audit-policy-file", cpath(kasVolumeAuditConfig().Name, AuditPolicyConfigMapKey))
-	args.Set("authentication-token-webhook-config-file", cpath(kasVolumeAuthTokenWebhookConfig().Name, KubeconfigKey))
-	args.Set("authentication-token-webhook-version", "v1")
+	if oidcAuthentication {
+		applyOIDCArgs(args, p.Authentication.OIDCProviders)
+	} else {
+		args.Set("authentication-token-webhook-config-file", cpath(kasVolumeAuthTokenWebhookConfig().Name, KubeconfigKey))
+		args.Set("authentication-token-webhook-version", "v1")
+	}
 	args.Set("authorization-mode", "Scope", "SystemMasters", "RBAC", "Node")
 	args.Set("client-ca-file", cpath(common.VolumeTotalClientCA().Name, certs.CASignerCertMapKey))
 	if p.CloudProviderConfigRef != nil {
@@ -210,6 +219,48 @@ func generateConfig(p KubeAPIServerConfigParams, version semver.Version) *kcpv1.
 	return config
 }
 
+// applyOIDCArgs configures the API server to validate tokens of the external OIDC provider.
+// The --oidc-* flags support a single issuer, which is enforced by the API.
+func applyOIDCArgs(args kubeAPIServerArgs, providers []hyperv1.OIDCProvider) {
+	if len(providers) == 0 {
+		return
+	}
+	provider := providers[0]
+	args.Set("oidc-issuer-url", provider.Issuer.URL)
+	if len(provider.Issuer.Audiences) > 0 {
+		args.Set("oidc-client-id", provider.Issuer.Audiences[0])
+	}
+	if provider.Issuer.CertificateAuthority != nil {
+		args.Set("oidc-ca-file", path.Join(oidcCAVolumeMount.Path(kasContainerMain().Name, kasVolumeOIDCCA().Name), OIDCCABundleKey))
+	}
+	if username := provider.ClaimMappings.Username; username.Claim != "" || username.Prefix != nil {
+		if username.Claim != "" {
+			args.Set("oidc-username-claim", username.Claim)
+		}
+		if username.Prefix != nil {
+			prefix := *username.Prefix
+			if prefix == "" {
+				// "-" disables the default issuer URL prefix
+				prefix = "-"
+			}
+			args.Set("oidc-username-prefix", prefix)
+		}
+	}
+	if groups := provider.ClaimMappings.Groups; groups.Claim != "" {
+		args.Set("oidc-groups-claim", groups.Claim)
+		if groups.Prefix != nil && *groups.Prefix != "" {
+			args.Set("oidc-groups-prefix", *groups.Prefix)
+		}
+	}
+	if len(provider.RequiredClaims) > 0 {
+		var requiredClaims []string
+		for _, claim := range provider.RequiredClaims {
+			requiredClaims = append(requiredClaims, fmt.Sprintf("%s=%s", claim.Claim, claim.RequiredValue))
+		}
+		args.Set("oidc-required-claim", requiredClaims...)
+	}
+}
+
 func cloudProviderConfig(cloudProviderConfigName, cloudProvider string) string {
 	if cloudProviderConfigName != "" {
 		cfgDir := cloudProviderConfigVolumeMount.Path(kasContainerMain().Name, kasVolumeCloudConfig().Name)
@@ -0,0 +1,66 @@
+package kas
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	kcpv1 "github.com/openshift/api/kubecontrolplane/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestApplyOIDCArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider hyperv1.OIDCProvider
+		expected kubeAPIServerArgs
+	}{
+		{
+			name: "When only the issuer is set it should configure issuer and client id",
+			provider: hyperv1.OIDCProvider{
+				Name:   "sso",
+				Issuer: hyperv1.OIDCIssuer{URL: "https://sso.example.com", Audiences: []string{"openshift"}},
+			},
+			expected: kubeAPIServerArgs{
+				"oidc-issuer-url": kcpv1.Arguments{"https://sso.example.com"},
+				"oidc-client-id":  kcpv1.Arguments{"openshift"},
+			},
+		},
+		{
+			name: "When claim mappings, CA and required claims are set it should configure all of them",
+			provider: hyperv1.OIDCProvider{
+				Name: "sso",
+				Issuer: hyperv1.OIDCIssuer{
+					URL:                  "https://sso.example.com",
+					Audiences:            []string{"openshift"},
+					CertificateAuthority: &corev1.LocalObjectReference{Name: "sso-ca"},
+				},
+				ClaimMappings: hyperv1.OIDCClaimMappings{
+					Username: hyperv1.OIDCClaimMapping{Claim: "email", Prefix: pointer.String("")},
+					Groups:   hyperv1.OIDCClaimMapping{Claim: "groups", Prefix: pointer.String("sso:")},
+				},
+				RequiredClaims: []hyperv1.OIDCRequiredClaim{{Claim: "tenant", RequiredValue: "a"}},
+			},
+			expected: kubeAPIServerArgs{
+				"oidc-issuer-url":      kcpv1.Arguments{"https://sso.example.com"},
+				"oidc-client-id":       kcpv1.Arguments{"openshift"},
+				"oidc-ca-file":         kcpv1.Arguments{"/etc/kubernetes/certs/oidc-ca/ca-bundle.crt"},
+				"oidc-username-claim":  kcpv1.Arguments{"email"},
+				"oidc-username-prefix": kcpv1.Arguments{"-"},
+				"oidc-groups-claim":    kcpv1.Arguments{"groups"},
+				"oidc-groups-prefix":   kcpv1.Arguments{"sso:"},
+				"oidc-required-claim":  kcpv1.Arguments{"tenant=a"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			args := kubeAPIServerArgs{}
+			applyOIDCArgs(args, []hyperv1.OIDCProvider{tc.provider})
+			g.Expect(args).To(Equal(tc.expected))
+		})
+	}
+}
@@ -59,6 +59,12 @@ var (
 		},
 	}
 
+	oidcCAVolumeMount = util.PodVolumeMounts{
+		kasContainerMain().Name: {
+			kasVolumeOIDCCA().Name: "/etc/kubernetes/certs/oidc-ca",
+		},
+	}
+
 	cloudProviderConfigVolumeMount = util.PodVolumeMounts{
 		kasContainerMain().Name: {
 			kasVolumeCloudConfig().Name: "/etc/kubernetes/cloud",
@@ -207,6 +213,7 @@ func ReconcileKubeAPIServerDeployment(deployment *appsv1.Deployment,
 	}
 	applyNamedCertificateMounts(namedCertificates, &deployment.Spec.Template.Spec)
 	applyCloudConfigVolumeMount(cloudProviderConfigRef, &deployment.Spec.Template.Spec, cloudProviderName)
+	applyOIDCCAVolumeMount(hcp.Spec.Authentication, &deployment.Spec.Template.Spec)
 	util.ApplyCloudProviderCreds(&deployment.Spec.Template.Spec, cloudProviderName, cloudProviderCreds, images.TokenMinterImage, kasContainerMain().Name)
 
 	if cloudProviderName == aws.Provider {
@@ -662,6 +669,37 @@ func applyCloudConfigVolumeMount(configRef *corev1.LocalObjectReference, podSpec
 	}
 }
 
+func kasVolumeOIDCCA() *corev1.Volume {
+	return &corev1.Volume{
+		Name: "oidc-ca",
+	}
+}
+
+func buildKASVolumeOIDCCA(configMapName string) func(v *corev1.Volume) {
+	return func(v *corev1.Volume) {
+		v.ConfigMap = &corev1.ConfigMapVolumeSource{}
+		v.ConfigMap.Name = configMapName
+		v.ConfigMap.DefaultMode = pointer.Int32Ptr(420)
+	}
+}
+
+// applyOIDCCAVolumeMount mounts the CA bundle of the external OIDC provider, if any.
+func applyOIDCCAVolumeMount(authentication *hyperv1.ClusterAuthenticationSpec, podSpec *corev1.PodSpec) {
+	if authentication == nil || authentication.Type != hyperv1.OIDCAuthenticationType || len(authentication.OIDCProviders) == 0 {
+		return
+	}
+	ca := authentication.OIDCProviders[0].Issuer.CertificateAuthority
+	if ca == nil {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, util.BuildVolume(kasVolumeOIDCCA(), buildKASVolumeOIDCCA(ca.Name)))
+	container := util.FindContainer(kasContainerMain().Name, podSpec.Containers)
+	if container == nil {
+		panic("main kube apiserver container not found in spec")
+	}
+	container.VolumeMounts = append(container.VolumeMounts, oidcCAVolumeMount.ContainerMounts(kasContainerMain().Name)...)
+}
+
 func invokeBootstrapRenderScript(workDir string) string {
 	var script = `#!/bin/sh
 cd /tmp
@@ -39,22 +39,23 @@ type KubeAPIServerParams struct {
 	CloudProviderConfig *corev1.LocalObjectReference `json:"cloudProviderConfig"`
 	CloudProviderCreds  *corev1.LocalObjectReference `json:"cloudProviderCreds"`
 
-	ServiceAccountIssuer string                       `json:"serviceAccountIssuer"`
-	ServiceCIDRs         []string                     `json:"serviceCIDRs"`
-	ClusterCIDRs         []string                     `json:"clusterCIDRs"`
-	AdvertiseAddress     string                       `json:"advertiseAddress"`
-	ExternalAddress      string                       `json:"externalAddress"`
-	ExternalPort         int32                        `json:"externalPort"`
-	InternalAddress      string                       `json:"internalAddress"`
-	InternalPort         int32                        `json:"internalPort"`
-	ExternalOAuthAddress string                       `json:"externalOAuthAddress"`
-	ExternalOAuthPort    int32                        `json:"externalOAuthPort"`
-	EtcdURL              string                       `json:"etcdAddress"`
-	APIServerPort        int32                        `json:"apiServerPort"`
-	KubeConfigRef        *hyperv1.KubeconfigSecretRef `json:"kubeConfigRef"`
-	AuditWebhookRef      *corev1.LocalObjectReference `json:"auditWebhookRef"`
-	ConsolePublicURL     string                       `json:"consolePublicURL"`
-	DisableProfiling     bool                         `json:"disableProfiling"`
+	ServiceAccountIssuer string                             `json:"serviceAccountIssuer"`
+	ServiceCIDRs         []string                           `json:"serviceCIDRs"`
+	ClusterCIDRs         []string                           `json:"clusterCIDRs"`
+	AdvertiseAddress     string                             `json:"advertiseAddress"`
+	ExternalAddress      string                             `json:"externalAddress"`
+	ExternalPort         int32                              `json:"externalPort"`
+	InternalAddress      string                             `json:"internalAddress"`
+	InternalPort         int32                              `json:"internalPort"`
+	ExternalOAuthAddress string                             `json:"externalOAuthAddress"`
+	ExternalOAuthPort    int32                              `json:"externalOAuthPort"`
+	EtcdURL              string                             `json:"etcdAddress"`
+	APIServerPort        int32                              `json:"apiServerPort"`
+	KubeConfigRef        *hyperv1.KubeconfigSecretRef       `json:"kubeConfigRef"`
+	AuditWebhookRef      *corev1.LocalObjectReference       `json:"auditWebhookRef"`
+	ConsolePublicURL     string                             `json:"consolePublicURL"`
+	DisableProfiling     bool                               `json:"disableProfiling"`
+	Authentication       *hyperv1.ClusterAuthenticationSpec `json:"authentication"`
 	config.DeploymentConfig
 	config.OwnerRef
 
@@ -103,7 +104,7 @@ func NewKubeAPIServerParams(ctx context.Context, hcp *hyperv1.HostedControlPlane
 		params.Image = hcp.Spec.Configuration.Image
 		params.Scheduler = hcp.Spec.Configuration.Scheduler
 	}
-	params.AdvertiseAddress = util.AdvertiseAddressWithDefault(hcp, config.DefaultAdvertiseAddress)
+	params.AdvertiseAddress = util.GetAdvertiseAddress(hcp, config.DefaultAdvertiseIPv4Address, config.DefaultAdvertiseIPv6Address)
 	params.APIServerPort = util.APIPortWithDefault(hcp, config.DefaultAPIServerPort)
 	params.InternalPort = util.APIPortWithDefault(hcp, config.DefaultAPIServerPort)
 	if _, ok := hcp.Annotations[hyperv1.PortierisImageAnnotation]; ok {
@@ -296,6 +297,7 @@ func NewKubeAPIServerParams(ctx context.Context, hcp *hyperv1.HostedControlPlane
 	}
 
 	params.KubeConfigRef = hcp.Spec.KubeConfig
+	params.Authentication = hcp.Spec.Authentication
 	params.OwnerRef = config.OwnerRefFrom(hcp)
 
 	params.DeploymentConfig.SetRestartAnnotation(hcp.ObjectMeta)
@@ -375,6 +377,7 @@ func (p *KubeAPIServerParams) ConfigParams() KubeAPIServerConfigParams {
 		NodePortRange:                p.ServiceNodePortRange(),
 		AuditWebhookEnabled:          p.AuditWebhookRef != nil,
 		ConsolePublicURL:             p.ConsolePublicURL,
+		Authentication:               p.Authentication,
 	}
 }
 
@@ -398,6 +401,7 @@ type KubeAPIServerConfigParams struct {
 	NodePortRange                string
 	AuditWebhookEnabled          bool
 	ConsolePublicURL             string
+	Authentication               *hyperv1.ClusterAuthenticationSpec
 	DisableProfiling             bool
 }
 
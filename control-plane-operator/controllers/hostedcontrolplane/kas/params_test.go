@@ -8,6 +8,7 @@ import (
 
 	"k8s.io/utils/pointer"
 
+	"github.com/openshift/hypershift/api/util/ipnet"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/support/config"
 )
@@ -18,12 +19,13 @@ func TestNewAPIServerParamsAPIAdvertiseAddressAndPort(t *testing.T) {
 		name             string
 		advertiseAddress *string
 		port             *int32
+		serviceNetwork   []hyperv1.ServiceNetworkEntry
 		expectedAddress  string
 		expectedPort     int32
 	}{
 		{
 			name:            "not specified",
-			expectedAddress: config.DefaultAdvertiseAddress,
+			expectedAddress: config.DefaultAdvertiseIPv4Address,
 			expectedPort:    config.DefaultAPIServerPort,
 		},
 		{
@@ -35,15 +37,34 @@ func TestNewAPIServerParamsAPIAdvertiseAddressAndPort(t *testing.T) {
 		{
 			name:            "port set",
 			port:            pointer.Int32Ptr(6789),
-			expectedAddress: config.DefaultAdvertiseAddress,
+			expectedAddress: config.DefaultAdvertiseIPv4Address,
 			expectedPort:    6789,
 		},
+		{
+			name: "ipv6 primary service network",
+			serviceNetwork: []hyperv1.ServiceNetworkEntry{
+				{CIDR: *ipnet.MustParseCIDR("fd02::/112")},
+				{CIDR: *ipnet.MustParseCIDR("172.31.0.0/16")},
+			},
+			expectedAddress: config.DefaultAdvertiseIPv6Address,
+			expectedPort:    config.DefaultAPIServerPort,
+		},
+		{
+			name: "ipv4 primary dual-stack service network",
+			serviceNetwork: []hyperv1.ServiceNetworkEntry{
+				{CIDR: *ipnet.MustParseCIDR("172.31.0.0/16")},
+				{CIDR: *ipnet.MustParseCIDR("fd02::/112")},
+			},
+			expectedAddress: config.DefaultAdvertiseIPv4Address,
+			expectedPort:    config.DefaultAPIServerPort,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			hcp := &hyperv1.HostedControlPlane{}
 			hcp.Spec.Networking.APIServer = &hyperv1.APIServerNetworking{Port: test.port, AdvertiseAddress: test.advertiseAddress}
+			hcp.Spec.Networking.ServiceNetwork = test.serviceNetwork
 			p := NewKubeAPIServerParams(context.Background(), hcp, map[string]string{}, "", 0, "", 0, false)
 			g := NewGomegaWithT(t)
 			g.Expect(p.AdvertiseAddress).To(Equal(test.expectedAddress))
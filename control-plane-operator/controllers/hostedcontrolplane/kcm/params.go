@@ -2,6 +2,7 @@ package kcm
 
 import (
 	"context"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -46,8 +47,8 @@ func NewKubeControllerManagerParams(ctx context.Context, hcp *hyperv1.HostedCont
 		HyperkubeImage:          images["hyperkube"],
 		TokenMinterImage:        images["token-minter"],
 		Port:                    DefaultPort,
-		ServiceCIDR:             util.FirstServiceCIDR(hcp.Spec.Networking.ServiceNetwork),
-		ClusterCIDR:             util.FirstClusterCIDR(hcp.Spec.Networking.ClusterNetwork),
+		ServiceCIDR:             strings.Join(util.ServiceCIDRs(hcp.Spec.Networking.ServiceNetwork), ","),
+		ClusterCIDR:             strings.Join(util.ClusterCIDRs(hcp.Spec.Networking.ClusterNetwork), ","),
 		AvailabilityProberImage: images[util.AvailabilityProberImageName],
 	}
 	if hcp.Spec.Configuration != nil {
@@ -0,0 +1,161 @@
+package konnectivity
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/certs"
+)
+
+// DialThroughServer establishes a connection to target through the local konnectivity
+// server using HTTP CONNECT, the same way the konnectivity socks5 proxies of the control plane
+// components do. A successful CONNECT means that an agent was able to reach the target.
+func DialThroughServer(ctx context.Context, clientSecret *corev1.Secret, target string) (net.Conn, error) {
+	serverName := manifests.KonnectivityServerLocalService("").Name
+	tlsConfig, err := clientTLSConfig(clientSecret, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	serverAddress := net.JoinHostPort(serverName, strconv.Itoa(KonnectivityServerLocalPort))
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", serverAddress)
+	if err != nil {
+		return nil, fmt.Errorf("dialing konnectivity server %s failed: %w", serverAddress, err)
+	}
+	connected, err := connect(ctx, conn, target, "127.0.0.1")
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("konnectivity server: %w", err)
+	}
+	return connected, nil
+}
+
+// DialEgress establishes a connection to target the same way the konnectivity socks5
+// proxies do for the given egress configuration: through the first matching route, or
+// through the konnectivity server if no route matches.
+func DialEgress(ctx context.Context, egress *hyperv1.ControlPlaneEgressSpec, clientSecret *corev1.Secret, target string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	route := EgressRouteFor(egress, host)
+	if route == nil || route.Target == hyperv1.KonnectivityEgressRouteTarget {
+		return DialThroughServer(ctx, clientSecret, target)
+	}
+
+	var dialer net.Dialer
+	if route.Target == hyperv1.DirectEgressRouteTarget {
+		return dialer.DialContext(ctx, "tcp", target)
+	}
+
+	proxyURL, err := url.Parse(route.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL of egress route %s: %w", route.Name, err)
+	}
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("dialing proxy %s of egress route %s failed: %w", proxyAddress, route.Name, err)
+	}
+	connected, err := connect(ctx, conn, target, target)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s of egress route %s: %w", proxyAddress, route.Name, err)
+	}
+	return connected, nil
+}
+
+// EgressRouteFor returns the first route of egress that matches host, which is either an
+// IP address or a host name, or nil if there is none.
+func EgressRouteFor(egress *hyperv1.ControlPlaneEgressSpec, host string) *hyperv1.EgressRoute {
+	if egress == nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for i, route := range egress.Routes {
+		if ip != nil {
+			for _, cidr := range route.CIDRs {
+				if _, ipNet, err := net.ParseCIDR(cidr); err == nil && ipNet.Contains(ip) {
+					return &egress.Routes[i]
+				}
+			}
+			continue
+		}
+		for _, domain := range route.Domains {
+			domain = strings.ToLower(domain)
+			if host == domain || (strings.HasPrefix(domain, "*.") && strings.HasSuffix(host, domain[1:])) {
+				return &egress.Routes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// connect sends a CONNECT request for target over conn and returns a connection that
+// can be used to talk to target once the request succeeded.
+func connect(ctx context.Context, conn net.Conn, target, hostHeader string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, hostHeader); err != nil {
+		return nil, fmt.Errorf("sending CONNECT request failed: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading CONNECT response failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT to %s returned %s", target, res.Status)
+	}
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn is a net.Conn that first returns the data that was already buffered
+// while reading the CONNECT response.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func clientTLSConfig(secret *corev1.Secret, serverName string) (*tls.Config, error) {
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(secret.Data[certs.CASignerCertMapKey]) {
+		return nil, fmt.Errorf("secret %s has no valid CA certificate", secret.Name)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate from secret %s: %w", secret.Name, err)
+	}
+	return &tls.Config{
+		RootCAs:      certPool,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,42 @@
+package konnectivity
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestEgressRouteFor(t *testing.T) {
+	egress := &hyperv1.ControlPlaneEgressSpec{
+		Routes: []hyperv1.EgressRoute{
+			{Name: "corp-ldap", Domains: []string{"ldap.corp.example.com"}, Target: hyperv1.DirectEgressRouteTarget},
+			{Name: "corp", Domains: []string{"*.corp.example.com"}, CIDRs: []string{"10.10.0.0/16"}, Target: hyperv1.ProxyEgressRouteTarget, ProxyURL: "http://proxy:3128"},
+		},
+	}
+	testCases := []struct {
+		name     string
+		egress   *hyperv1.ControlPlaneEgressSpec
+		host     string
+		expected string
+	}{
+		{name: "When there is no egress configuration it should not match", host: "ldap.corp.example.com"},
+		{name: "When an exact domain matches it should return the first route", egress: egress, host: "LDAP.corp.example.com.", expected: "corp-ldap"},
+		{name: "When a wildcard domain matches it should return that route", egress: egress, host: "sso.corp.example.com", expected: "corp"},
+		{name: "When an IP is in a CIDR it should return that route", egress: egress, host: "10.10.1.1", expected: "corp"},
+		{name: "When nothing matches it should not return a route", egress: egress, host: "corp.example.com"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			route := EgressRouteFor(tc.egress, tc.host)
+			if tc.expected == "" {
+				g.Expect(route).To(BeNil())
+				return
+			}
+			g.Expect(route).ToNot(BeNil())
+			g.Expect(route.Name).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package konnectivity
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/config"
+)
+
+const (
+	// EgressRoutesConfigMapKey is the key of the egress routes ConfigMap that
+	// holds the serialized ControlPlaneEgressSpec.
+	EgressRoutesConfigMapKey = "routes.json"
+
+	// Socks5ProxyMetricsPort is the port on which the konnectivity socks5 proxy
+	// sidecars expose their metrics.
+	Socks5ProxyMetricsPort = 8095
+
+	// Socks5ProxyMetricsPortName is the name of the metrics port of the
+	// konnectivity socks5 proxy sidecars.
+	Socks5ProxyMetricsPortName = "socks5-metrics"
+
+	egressRoutesVolumeName = "konnectivity-egress-routes"
+	egressRoutesMountPath  = "/etc/konnectivity-egress-routes"
+)
+
+// ReconcileEgressRoutesConfigMap serializes the control plane egress configuration
+// of the HostedControlPlane so it can be consumed by the konnectivity socks5 proxies.
+func ReconcileEgressRoutesConfigMap(cm *corev1.ConfigMap, ownerRef config.OwnerRef, egress *hyperv1.ControlPlaneEgressSpec) error {
+	ownerRef.ApplyTo(cm)
+	if egress == nil {
+		egress = &hyperv1.ControlPlaneEgressSpec{}
+	}
+	serialized, err := json.Marshal(egress)
+	if err != nil {
+		return fmt.Errorf("failed to serialize control plane egress routes: %w", err)
+	}
+	cm.Data = map[string]string{
+		EgressRoutesConfigMapKey: string(serialized),
+	}
+	return nil
+}
+
+// Socks5ProxyEgressArgs returns the konnectivity-socks5-proxy arguments that
+// enable the egress routing table and connection metrics.
+func Socks5ProxyEgressArgs() []string {
+	return []string{
+		fmt.Sprintf("--egress-routes-file=%s", path.Join(egressRoutesMountPath, EgressRoutesConfigMapKey)),
+		fmt.Sprintf("--metrics-addr=:%d", Socks5ProxyMetricsPort),
+	}
+}
+
+// Socks5ProxyMetricsContainerPort returns the metrics port of the konnectivity
+// socks5 proxy sidecars, which is scraped by the socks5 proxy PodMonitor.
+func Socks5ProxyMetricsContainerPort() corev1.ContainerPort {
+	return corev1.ContainerPort{
+		Name:          Socks5ProxyMetricsPortName,
+		ContainerPort: Socks5ProxyMetricsPort,
+		Protocol:      corev1.ProtocolTCP,
+	}
+}
+
+// Socks5ProxyEgressRoutesVolume returns the volume holding the egress routes.
+// It is optional so that proxies keep working before the ConfigMap exists.
+func Socks5ProxyEgressRoutesVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: egressRoutesVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: manifests.KonnectivityEgressRoutesConfigMap("").Name},
+				Optional:             pointer.Bool(true),
+			},
+		},
+	}
+}
+
+// Socks5ProxyEgressRoutesVolumeMount returns the mount for Socks5ProxyEgressRoutesVolume.
+func Socks5ProxyEgressRoutesVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      egressRoutesVolumeName,
+		MountPath: egressRoutesMountPath,
+	}
+}
@@ -0,0 +1,52 @@
+package konnectivity
+
+import (
+	prometheusoperatorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+	"github.com/openshift/hypershift/support/config"
+	"github.com/openshift/hypershift/support/metrics"
+	"github.com/openshift/hypershift/support/util"
+)
+
+const healthPortName = "health"
+
+// ReconcileServerPodMonitor scrapes the connection metrics that the konnectivity
+// server exposes on its health port.
+func ReconcileServerPodMonitor(pm *prometheusoperatorv1.PodMonitor, ownerRef config.OwnerRef, clusterID string, metricsSet metrics.MetricsSet) error {
+	reconcilePodMonitor(pm, ownerRef, konnectivityServerLabels(), clusterID, metricsSet)
+	return nil
+}
+
+// ReconcileAgentPodMonitor scrapes the connection metrics that the control plane
+// konnectivity agents expose on their health port.
+func ReconcileAgentPodMonitor(pm *prometheusoperatorv1.PodMonitor, ownerRef config.OwnerRef, clusterID string, metricsSet metrics.MetricsSet) error {
+	reconcilePodMonitor(pm, ownerRef, konnectivityAgentLabels(), clusterID, metricsSet)
+	return nil
+}
+
+// ReconcileSocks5ProxyPodMonitor scrapes the egress connection metrics of the
+// konnectivity socks5 proxy sidecars. The sidecars run in the pods of several
+// components, so all pods of the namespace are selected and only those exposing
+// the socks5 metrics port are scraped.
+func ReconcileSocks5ProxyPodMonitor(pm *prometheusoperatorv1.PodMonitor, ownerRef config.OwnerRef, clusterID string, metricsSet metrics.MetricsSet) error {
+	reconcilePodMonitor(pm, ownerRef, nil, clusterID, metricsSet)
+	pm.Spec.PodMetricsEndpoints[0].Port = Socks5ProxyMetricsPortName
+	return nil
+}
+
+func reconcilePodMonitor(pm *prometheusoperatorv1.PodMonitor, ownerRef config.OwnerRef, labels map[string]string, clusterID string, metricsSet metrics.MetricsSet) {
+	ownerRef.ApplyTo(pm)
+	pm.Spec.Selector.MatchLabels = labels
+	pm.Spec.NamespaceSelector = prometheusoperatorv1.NamespaceSelector{
+		MatchNames: []string{pm.Namespace},
+	}
+	pm.Spec.PodMetricsEndpoints = []prometheusoperatorv1.PodMetricsEndpoint{
+		{
+			Interval:             "30s",
+			Port:                 healthPortName,
+			Path:                 "/metrics",
+			MetricRelabelConfigs: metrics.KonnectivityRelabelConfigs(metricsSet),
+		},
+	}
+	util.ApplyClusterIDLabelToPodMonitor(&pm.Spec.PodMetricsEndpoints[0], clusterID)
+}
@@ -0,0 +1,26 @@
+package konnectivity
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/config"
+	"github.com/openshift/hypershift/support/metrics"
+)
+
+func TestReconcileSocks5ProxyPodMonitor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pm := manifests.KonnectivitySocks5ProxyPodMonitor("test")
+	g.Expect(ReconcileSocks5ProxyPodMonitor(pm, config.OwnerRef{}, "cluster-id", metrics.MetricsSetAll)).To(Succeed())
+
+	// The sidecars run in the pods of several components, so every pod of the
+	// namespace is selected and the metrics port restricts what is scraped.
+	g.Expect(pm.Spec.Selector.MatchLabels).To(BeEmpty())
+	g.Expect(pm.Spec.Selector.MatchExpressions).To(BeEmpty())
+	g.Expect(pm.Spec.NamespaceSelector.MatchNames).To(ConsistOf("test"))
+	g.Expect(pm.Spec.PodMetricsEndpoints).To(HaveLen(1))
+	g.Expect(pm.Spec.PodMetricsEndpoints[0].Port).To(Equal(Socks5ProxyMetricsContainerPort().Name))
+	g.Expect(pm.Spec.PodMetricsEndpoints[0].Path).To(Equal("/metrics"))
+}
@@ -125,6 +125,13 @@ func buildKonnectivityServerContainer(image string) func(c *corev1.Container) {
 			"--frontend-keepalive-time",
 			"30s",
 		}
+		c.Ports = []corev1.ContainerPort{
+			{
+				Name:          healthPortName,
+				ContainerPort: healthPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		}
 		c.VolumeMounts = volumeMounts.ContainerMounts(c.Name)
 	}
 }
@@ -295,8 +302,36 @@ func buildKonnectivityVolumeAgentCerts(v *corev1.Volume) {
 	}
 }
 
+const (
+	// agentNodesPerReplica is the number of guest cluster nodes served per
+	// control plane konnectivity agent replica.
+	agentNodesPerReplica = 50
+
+	// MaxAgentReplicas is the maximum number of control plane konnectivity agents.
+	MaxAgentReplicas = 5
+)
+
+// AgentReplicas returns the number of control plane konnectivity agent replicas
+// for a guest cluster with the given number of nodes. It never returns less than
+// minReplicas, which is derived from the availability policy of the control plane.
+func AgentReplicas(nodeCount int, minReplicas int32) int32 {
+	replicas := int32((nodeCount + agentNodesPerReplica - 1) / agentNodesPerReplica)
+	if replicas > MaxAgentReplicas {
+		replicas = MaxAgentReplicas
+	}
+	if replicas < minReplicas {
+		replicas = minReplicas
+	}
+	return replicas
+}
+
+// ReconcileAgentDeployment reconciles the control plane konnectivity agents. The
+// replica count is only a lower bound: the konnectivity health controller of the
+// hosted cluster config operator scales the agents up to MaxAgentReplicas based
+// on the number of guest cluster nodes, and those replicas are preserved.
 func ReconcileAgentDeployment(deployment *appsv1.Deployment, ownerRef config.OwnerRef, deploymentConfig config.DeploymentConfig, image string, ips []string) error {
 	ownerRef.ApplyTo(deployment)
+	scaledReplicas := deployment.Spec.Replicas
 	deployment.Spec = appsv1.DeploymentSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: konnectivityAgentLabels(),
@@ -317,6 +352,9 @@ func ReconcileAgentDeployment(deployment *appsv1.Deployment, ownerRef config.Own
 		},
 	}
 	deploymentConfig.ApplyTo(deployment)
+	if scaledReplicas != nil && *deployment.Spec.Replicas > 0 && *scaledReplicas > *deployment.Spec.Replicas && *scaledReplicas <= MaxAgentReplicas {
+		deployment.Spec.Replicas = scaledReplicas
+	}
 	return nil
 }
 
@@ -327,7 +365,11 @@ func buildKonnectivityAgentContainer(image string, ips []string) func(c *corev1.
 	var agentIDs bytes.Buffer
 	seperator := ""
 	for i, ip := range ips {
-		agentIDs.WriteString(fmt.Sprintf("%sipv4=%s", seperator, ip))
+		family := "ipv4"
+		if util.IsIPv6String(ip) {
+			family = "ipv6"
+		}
+		agentIDs.WriteString(fmt.Sprintf("%s%s=%s", seperator, family, ip))
 		if i == 0 {
 			seperator = "&"
 		}
@@ -365,6 +407,13 @@ func buildKonnectivityAgentContainer(image string, ips []string) func(c *corev1.
 			"--v",
 			"3",
 		}
+		c.Ports = []corev1.ContainerPort{
+			{
+				Name:          healthPortName,
+				ContainerPort: healthPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		}
 		c.VolumeMounts = volumeMounts.ContainerMounts(c.Name)
 	}
 }
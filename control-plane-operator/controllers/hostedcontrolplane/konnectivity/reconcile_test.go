@@ -0,0 +1,56 @@
+package konnectivity
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/config"
+)
+
+func TestAgentReplicas(t *testing.T) {
+	testCases := []struct {
+		name        string
+		nodeCount   int
+		minReplicas int32
+		expected    int32
+	}{
+		{name: "no nodes", nodeCount: 0, minReplicas: 1, expected: 1},
+		{name: "small cluster", nodeCount: 50, minReplicas: 1, expected: 1},
+		{name: "one replica per 50 nodes", nodeCount: 51, minReplicas: 1, expected: 2},
+		{name: "HA minimum", nodeCount: 10, minReplicas: 3, expected: 3},
+		{name: "capped at maximum", nodeCount: 1000, minReplicas: 3, expected: MaxAgentReplicas},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(AgentReplicas(tc.nodeCount, tc.minReplicas)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestReconcileAgentDeploymentReplicas(t *testing.T) {
+	testCases := []struct {
+		name             string
+		currentReplicas  *int32
+		configReplicas   int
+		expectedReplicas int32
+	}{
+		{name: "new deployment", configReplicas: 1, expectedReplicas: 1},
+		{name: "scaled up replicas are preserved", currentReplicas: pointer.Int32(3), configReplicas: 1, expectedReplicas: 3},
+		{name: "replicas below the minimum are raised", currentReplicas: pointer.Int32(1), configReplicas: 3, expectedReplicas: 3},
+		{name: "replicas above the maximum are reset", currentReplicas: pointer.Int32(MaxAgentReplicas + 1), configReplicas: 1, expectedReplicas: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			deployment := manifests.KonnectivityAgentDeployment("test")
+			deployment.Spec.Replicas = tc.currentReplicas
+			deploymentConfig := config.DeploymentConfig{Replicas: tc.configReplicas}
+			g.Expect(ReconcileAgentDeployment(deployment, config.OwnerRef{}, deploymentConfig, "image", []string{"10.0.0.1"})).To(Succeed())
+			g.Expect(*deployment.Spec.Replicas).To(Equal(tc.expectedReplicas))
+		})
+	}
+}
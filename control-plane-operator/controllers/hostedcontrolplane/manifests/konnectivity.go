@@ -1,6 +1,7 @@
 package manifests
 
 import (
+	prometheusoperatorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,3 +33,39 @@ func KonnectivityAgentDeployment(ns string) *appsv1.Deployment {
 		},
 	}
 }
+
+func KonnectivityEgressRoutesConfigMap(ns string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "konnectivity-egress-routes",
+			Namespace: ns,
+		},
+	}
+}
+
+func KonnectivityServerPodMonitor(ns string) *prometheusoperatorv1.PodMonitor {
+	return &prometheusoperatorv1.PodMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "konnectivity-server",
+			Namespace: ns,
+		},
+	}
+}
+
+func KonnectivitySocks5ProxyPodMonitor(ns string) *prometheusoperatorv1.PodMonitor {
+	return &prometheusoperatorv1.PodMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "konnectivity-socks5-proxy",
+			Namespace: ns,
+		},
+	}
+}
+
+func KonnectivityAgentPodMonitor(ns string) *prometheusoperatorv1.PodMonitor {
+	return &prometheusoperatorv1.PodMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "konnectivity-agent",
+			Namespace: ns,
+		},
+	}
+}
@@ -121,6 +121,7 @@ func ReconcileDeployment(deployment *appsv1.Deployment, ownerRef config.OwnerRef
 			util.BuildVolume(oasVolumeServingCert(), buildOASVolumeServingCert),
 			util.BuildVolume(oasVolumeEtcdClientCert(), buildOASVolumeEtcdClientCert),
 			util.BuildVolume(oasVolumeKonnectivityProxyCert(), buildOASVolumeKonnectivityProxyCert),
+			konnectivity.Socks5ProxyEgressRoutesVolume(),
 			util.BuildVolume(oasTrustAnchorVolume(), func(v *corev1.Volume) { v.EmptyDir = &corev1.EmptyDirVolumeSource{} }),
 			util.BuildVolume(serviceCASignerVolume(), func(v *corev1.Volume) {
 				v.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: manifests.ServiceServingCA(deployment.Namespace).Name}}
@@ -181,7 +182,8 @@ func buildOASSocks5ProxyContainer(socks5ProxyImage string) func(c *corev1.Contai
 	return func(c *corev1.Container) {
 		c.Image = socks5ProxyImage
 		c.Command = []string{"/usr/bin/control-plane-operator", "konnectivity-socks5-proxy"}
-		c.Args = []string{"run"}
+		c.Args = append([]string{"run"}, konnectivity.Socks5ProxyEgressArgs()...)
+		c.Ports = []corev1.ContainerPort{konnectivity.Socks5ProxyMetricsContainerPort()}
 		c.Resources.Requests = corev1.ResourceList{
 			corev1.ResourceCPU:    resource.MustParse("10m"),
 			corev1.ResourceMemory: resource.MustParse("10Mi"),
@@ -190,7 +192,7 @@ func buildOASSocks5ProxyContainer(socks5ProxyImage string) func(c *corev1.Contai
 			Name:  "KUBECONFIG",
 			Value: "/etc/kubernetes/secrets/kubeconfig/kubeconfig",
 		}}
-		c.VolumeMounts = volumeMounts.ContainerMounts(c.Name)
+		c.VolumeMounts = append(volumeMounts.ContainerMounts(c.Name), konnectivity.Socks5ProxyEgressRoutesVolumeMount())
 	}
 }
 
@@ -103,6 +103,7 @@ func ReconcileDeployment(ctx context.Context, client client.Client, deployment *
 			util.BuildVolume(oauthVolumeWorkLogs(), buildOAuthVolumeWorkLogs),
 			{Name: "admin-kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "service-network-admin-kubeconfig", DefaultMode: utilpointer.Int32Ptr(0640)}}},
 			{Name: "konnectivity-proxy-cert", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: manifests.KonnectivityClientSecret("").Name, DefaultMode: utilpointer.Int32Ptr(0640)}}},
+			konnectivity.Socks5ProxyEgressRoutesVolume(),
 		},
 	}
 	deploymentConfig.ApplyTo(deployment)
@@ -258,7 +259,8 @@ func socks5ProxyContainer(socks5ProxyImage string) corev1.Container {
 		Name:    socks5ProxyContainerName,
 		Image:   socks5ProxyImage,
 		Command: []string{"/usr/bin/control-plane-operator", "konnectivity-socks5-proxy", "--resolve-from-guest-cluster-dns=true"},
-		Args:    []string{"run"},
+		Args:    append([]string{"run"}, konnectivity.Socks5ProxyEgressArgs()...),
+		Ports:   []corev1.ContainerPort{konnectivity.Socks5ProxyMetricsContainerPort()},
 		Env: []corev1.EnvVar{{
 			Name:  "KUBECONFIG",
 			Value: "/etc/kubernetes/kubeconfig",
@@ -272,6 +274,7 @@ func socks5ProxyContainer(socks5ProxyImage string) corev1.Container {
 		VolumeMounts: []corev1.VolumeMount{
 			{Name: "admin-kubeconfig", MountPath: "/etc/kubernetes"},
 			{Name: "konnectivity-proxy-cert", MountPath: "/etc/konnectivity-proxy-tls"},
+			konnectivity.Socks5ProxyEgressRoutesVolumeMount(),
 		},
 	}
 
@@ -0,0 +1,381 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+var (
+	ldapBindTimeout = 10 * time.Second
+
+	// connectivityCheckCache holds the results of LDAP binds and OpenID discoveries, so
+	// that identity providers are not contacted on every reconcile.
+	connectivityCheckCache = cache.NewExpiring()
+	connectivityCheckTTL   = 5 * time.Minute
+
+	invalidConditionTypeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+// maxConditionTypeLength is the maximum length of metav1.Condition types.
+const maxConditionTypeLength = 316
+
+// DialFunc establishes a connection to address (host:port) the same way the oauth
+// server reaches its identity providers.
+type DialFunc func(ctx context.Context, address string) (net.Conn, error)
+
+// IdentityProviderValidation is the result of validating a single identity provider.
+type IdentityProviderValidation struct {
+	// Name is the name of the identity provider.
+	Name string
+	// Usable is false when the identity provider cannot be configured in the oauth
+	// server, e.g. because it references a secret that does not exist. Failing
+	// connectivity checks do not make an identity provider unusable, as the oauth
+	// server retries them on every login.
+	Usable bool
+	// Condition reports the result of the validation.
+	Condition metav1.Condition
+}
+
+// IdentityProviderConditionType returns the condition type that reports the validation
+// result of the identity provider with the given name. Names that are not valid in condition
+// types are sanitized and get a suffix with the hash of the name, so that names that only
+// differ in sanitized characters, like "My LDAP" and "My_LDAP", get distinct condition types.
+func IdentityProviderConditionType(name string) string {
+	prefix := hyperv1.ValidIdentityProviderConditionPrefix + "."
+	sanitized := strings.TrimRight(invalidConditionTypeChars.ReplaceAllString(name, "_"), "_.-")
+	if sanitized == name && name != "" && len(prefix)+len(name) <= maxConditionTypeLength {
+		return prefix + name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	if max := maxConditionTypeLength - len(prefix) - len(suffix); len(sanitized) > max {
+		sanitized = sanitized[:max]
+	}
+	return prefix + sanitized + suffix
+}
+
+// ValidateIdentityProviders checks every identity provider before it is rolled out:
+// referenced secrets and config maps must exist and contain the expected keys, OpenID
+// discovery documents must resolve and LDAP servers must accept a bind with the
+// configured credentials when dialed through dial.
+// Identity providers whose condition type is already used by a previous one, i.e. that have
+// the same name, are not usable, as the oauth server requires unique names.
+func ValidateIdentityProviders(ctx context.Context, kclient crclient.Client, namespace string, identityProviders []configv1.IdentityProvider, providerOverrides map[string]*ConfigOverride, dial DialFunc) []IdentityProviderValidation {
+	results := make([]IdentityProviderValidation, 0, len(identityProviders))
+	conditionTypes := map[string]string{}
+	for _, idp := range identityProviders {
+		result := IdentityProviderValidation{
+			Name:   idp.Name,
+			Usable: true,
+			Condition: metav1.Condition{
+				Type:    IdentityProviderConditionType(idp.Name),
+				Status:  metav1.ConditionTrue,
+				Reason:  hyperv1.AsExpectedReason,
+				Message: hyperv1.AllIsWellMessage,
+			},
+		}
+		if previous, duplicate := conditionTypes[result.Condition.Type]; duplicate {
+			result.Usable = false
+			result.Condition.Status = metav1.ConditionFalse
+			result.Condition.Reason = hyperv1.InvalidConfigurationReason
+			result.Condition.Message = fmt.Sprintf("identity provider name %q is already used by identity provider %q", idp.Name, previous)
+		} else if reason, err := validateIdentityProvider(ctx, kclient, namespace, &idp.IdentityProviderConfig, providerOverrides[idp.Name], dial); err != nil {
+			result.Usable = reason == hyperv1.IdentityProviderUnreachableReason || reason == hyperv1.IdentityProviderBindFailedReason
+			result.Condition.Status = metav1.ConditionFalse
+			result.Condition.Reason = reason
+			result.Condition.Message = err.Error()
+		}
+		if !result.Usable {
+			result.Condition.Message += "; the identity provider is left out of the OAuth server configuration until this is fixed"
+		}
+		conditionTypes[result.Condition.Type] = idp.Name
+		results = append(results, result)
+	}
+	return results
+}
+
+// UsableIdentityProviders returns the identity providers that can be configured in the oauth
+// server. results are the results of ValidateIdentityProviders for identityProviders.
+func UsableIdentityProviders(identityProviders []configv1.IdentityProvider, results []IdentityProviderValidation) []configv1.IdentityProvider {
+	usable := make([]configv1.IdentityProvider, 0, len(identityProviders))
+	for i, idp := range identityProviders {
+		if i < len(results) && results[i].Usable {
+			usable = append(usable, idp)
+		}
+	}
+	return usable
+}
+
+// SetIdentityProviderConditions sets the conditions of results and removes the conditions
+// of identity providers that are no longer configured. It also sets the ValidIdentityProviders
+// condition, which lists the identity providers that are left out of the oauth server configuration.
+func SetIdentityProviderConditions(conditions *[]metav1.Condition, results []IdentityProviderValidation, observedGeneration int64) {
+	var unusable []string
+	for _, result := range results {
+		if !result.Usable {
+			unusable = append(unusable, result.Name)
+		}
+	}
+	aggregate := metav1.Condition{
+		Type:               string(hyperv1.ValidIdentityProviders),
+		Status:             metav1.ConditionTrue,
+		Reason:             hyperv1.AsExpectedReason,
+		Message:            hyperv1.AllIsWellMessage,
+		ObservedGeneration: observedGeneration,
+	}
+	if len(unusable) > 0 {
+		aggregate.Status = metav1.ConditionFalse
+		aggregate.Reason = hyperv1.IdentityProvidersOmittedReason
+		aggregate.Message = fmt.Sprintf("identity providers left out of the OAuth server configuration: %s", strings.Join(unusable, ", "))
+	}
+	meta.SetStatusCondition(conditions, aggregate)
+
+	current := map[string]bool{}
+	for _, result := range results {
+		condition := result.Condition
+		condition.ObservedGeneration = observedGeneration
+		meta.SetStatusCondition(conditions, condition)
+		current[condition.Type] = true
+	}
+	for _, condition := range append([]metav1.Condition(nil), *conditions...) {
+		if strings.HasPrefix(condition.Type, hyperv1.ValidIdentityProviderConditionPrefix+".") && !current[condition.Type] {
+			meta.RemoveStatusCondition(conditions, condition.Type)
+		}
+	}
+}
+
+// validateIdentityProvider returns the condition reason and an error if the identity provider is not valid.
+func validateIdentityProvider(ctx context.Context, kclient crclient.Client, namespace string, providerConfig *configv1.IdentityProviderConfig, configOverride *ConfigOverride, dial DialFunc) (string, error) {
+	const missingProviderFmt string = "type %s was specified, but its configuration is missing"
+
+	refs := &referenceChecker{ctx: ctx, kclient: kclient, namespace: namespace}
+	switch providerConfig.Type {
+	case configv1.IdentityProviderTypeBasicAuth:
+		if providerConfig.BasicAuth == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.remoteConnectionInfo(&providerConfig.BasicAuth.OAuthRemoteConnectionInfo)
+	case configv1.IdentityProviderTypeGitHub:
+		if providerConfig.GitHub == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.secretKey("clientSecret", providerConfig.GitHub.ClientSecret.Name, configv1.ClientSecretKey)
+		refs.caConfigMap("ca", providerConfig.GitHub.CA.Name)
+	case configv1.IdentityProviderTypeGitLab:
+		if providerConfig.GitLab == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.secretKey("clientSecret", providerConfig.GitLab.ClientSecret.Name, configv1.ClientSecretKey)
+		refs.caConfigMap("ca", providerConfig.GitLab.CA.Name)
+	case configv1.IdentityProviderTypeGoogle:
+		if providerConfig.Google == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.secretKey("clientSecret", providerConfig.Google.ClientSecret.Name, configv1.ClientSecretKey)
+	case configv1.IdentityProviderTypeHTPasswd:
+		if providerConfig.HTPasswd == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.secretKey("fileData", providerConfig.HTPasswd.FileData.Name, configv1.HTPasswdDataKey)
+	case configv1.IdentityProviderTypeKeystone:
+		if providerConfig.Keystone == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.remoteConnectionInfo(&providerConfig.Keystone.OAuthRemoteConnectionInfo)
+	case configv1.IdentityProviderTypeLDAP:
+		ldapConfig := providerConfig.LDAP
+		if ldapConfig == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		var bindPassword *corev1.Secret
+		if ldapConfig.BindPassword.Name != "" {
+			bindPassword = refs.secretKey("bindPassword", ldapConfig.BindPassword.Name, configv1.BindPasswordKey)
+		}
+		ca := refs.caConfigMap("ca", ldapConfig.CA.Name)
+		if refs.err != nil {
+			return hyperv1.IdentityProviderReferenceInvalidReason, refs.err
+		}
+		return checkLDAPBind(ctx, ldapConfig, bindPassword, ca, dial)
+	case configv1.IdentityProviderTypeOpenID:
+		openIDConfig := providerConfig.OpenID
+		if openIDConfig == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		refs.secretKey("clientSecret", openIDConfig.ClientSecret.Name, configv1.ClientSecretKey)
+		ca := refs.caConfigMap("ca", openIDConfig.CA.Name)
+		if refs.err != nil {
+			return hyperv1.IdentityProviderReferenceInvalidReason, refs.err
+		}
+		if configOverride == nil {
+			return checkOpenIDDiscovery(ctx, kclient, namespace, openIDConfig, ca)
+		}
+	case configv1.IdentityProviderTypeRequestHeader:
+		if providerConfig.RequestHeader == nil {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf(missingProviderFmt, providerConfig.Type)
+		}
+		if providerConfig.RequestHeader.ClientCA.Name == "" {
+			return hyperv1.InvalidConfigurationReason, fmt.Errorf("clientCA is required")
+		}
+		refs.caConfigMap("clientCA", providerConfig.RequestHeader.ClientCA.Name)
+	default:
+		return hyperv1.InvalidConfigurationReason, fmt.Errorf("the identity provider type '%s' is not supported", providerConfig.Type)
+	}
+	if refs.err != nil {
+		return hyperv1.IdentityProviderReferenceInvalidReason, refs.err
+	}
+	return "", nil
+}
+
+// referenceChecker verifies secret and config map references, recording the first error.
+type referenceChecker struct {
+	ctx       context.Context
+	kclient   crclient.Client
+	namespace string
+	err       error
+}
+
+func (c *referenceChecker) remoteConnectionInfo(info *configv1.OAuthRemoteConnectionInfo) {
+	c.caConfigMap("ca", info.CA.Name)
+	if info.TLSClientCert.Name != "" {
+		c.secretKey("tlsClientCert", info.TLSClientCert.Name, corev1.TLSCertKey)
+	}
+	if info.TLSClientKey.Name != "" {
+		c.secretKey("tlsClientKey", info.TLSClientKey.Name, corev1.TLSPrivateKeyKey)
+	}
+}
+
+// secretKey checks that the secret referenced by field exists and has a non-empty key.
+func (c *referenceChecker) secretKey(field, name, key string) *corev1.Secret {
+	if c.err != nil {
+		return nil
+	}
+	if name == "" {
+		c.err = fmt.Errorf("%s: secret name is required", field)
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := c.kclient.Get(c.ctx, crclient.ObjectKey{Namespace: c.namespace, Name: name}, secret); err != nil {
+		c.err = referenceError(field, "secret", name, err)
+		return nil
+	}
+	if len(secret.Data[key]) == 0 {
+		c.err = fmt.Errorf("%s: secret %s has no value for key %q", field, name, key)
+		return nil
+	}
+	return secret
+}
+
+// caConfigMap checks that the config map referenced by field, if any, holds a valid CA bundle.
+func (c *referenceChecker) caConfigMap(field, name string) *corev1.ConfigMap {
+	if c.err != nil || name == "" {
+		return nil
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.kclient.Get(c.ctx, crclient.ObjectKey{Namespace: c.namespace, Name: name}, cm); err != nil {
+		c.err = referenceError(field, "config map", name, err)
+		return nil
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(caBundle(cm)) {
+		c.err = fmt.Errorf("%s: config map %s has no valid PEM encoded certificates at key %q", field, name, corev1.ServiceAccountRootCAKey)
+		return nil
+	}
+	return cm
+}
+
+func referenceError(field, kind, name string, err error) error {
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s: %s %s not found", field, kind, name)
+	}
+	return fmt.Errorf("%s: failed to get %s %s: %w", field, kind, name, err)
+}
+
+func caBundle(cm *corev1.ConfigMap) []byte {
+	if data := cm.Data[corev1.ServiceAccountRootCAKey]; len(data) > 0 {
+		return []byte(data)
+	}
+	return cm.BinaryData[corev1.ServiceAccountRootCAKey]
+}
+
+// checkLDAPBind binds to the LDAP server with the configured credentials, using StartTLS
+// for ldap:// URLs unless the identity provider is marked insecure, like the oauth server does.
+// Results are cached until the referenced secret or config map change.
+func checkLDAPBind(ctx context.Context, ldapConfig *configv1.LDAPIdentityProvider, bindPassword *corev1.Secret, ca *corev1.ConfigMap, dial DialFunc) (string, error) {
+	address, useTLS, err := ldapAddress(ldapConfig.URL)
+	if err != nil {
+		return hyperv1.InvalidConfigurationReason, fmt.Errorf("invalid LDAP URL %q: %w", ldapConfig.URL, err)
+	}
+	if useTLS && ldapConfig.Insecure {
+		return hyperv1.InvalidConfigurationReason, fmt.Errorf("insecure must be false when using an ldaps URL")
+	}
+
+	cacheKey := fmt.Sprintf("%s|%s|%t", ldapConfig.URL, ldapConfig.BindDN, ldapConfig.Insecure)
+	var password string
+	if bindPassword != nil {
+		password = string(bindPassword.Data[configv1.BindPasswordKey])
+		cacheKey += "|" + string(bindPassword.UID) + "/" + bindPassword.ResourceVersion
+	}
+	var tlsConfig *tls.Config
+	if !ldapConfig.Insecure {
+		host, _, _ := net.SplitHostPort(address)
+		tlsConfig = &tls.Config{ServerName: host}
+		if ca != nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			tlsConfig.RootCAs.AppendCertsFromPEM(caBundle(ca))
+			cacheKey += "|" + string(ca.UID) + "/" + ca.ResourceVersion
+		}
+	}
+	if cached, ok := connectivityCheckCache.Get(cacheKey); ok {
+		result := cached.(connectivityCheckResult)
+		return result.reason, result.err
+	}
+
+	reason, err := ldapBind(ctx, address, useTLS, tlsConfig, ldapConfig.BindDN, password, dial)
+	connectivityCheckCache.Set(cacheKey, connectivityCheckResult{reason: reason, err: err}, connectivityCheckTTL)
+	return reason, err
+}
+
+// checkOpenIDDiscovery resolves the OpenID discovery document of the issuer. Results are
+// cached until the referenced config map changes.
+func checkOpenIDDiscovery(ctx context.Context, kclient crclient.Client, namespace string, openIDConfig *configv1.OpenIDIdentityProvider, ca *corev1.ConfigMap) (string, error) {
+	cacheKey := "openid|" + openIDConfig.Issuer
+	if ca != nil {
+		cacheKey += "|" + string(ca.UID) + "/" + ca.ResourceVersion
+	}
+	if cached, ok := connectivityCheckCache.Get(cacheKey); ok {
+		result := cached.(connectivityCheckResult)
+		return result.reason, result.err
+	}
+
+	var result connectivityCheckResult
+	if _, err := discoverOpenIDURLs(ctx, kclient, openIDConfig.Issuer, corev1.ServiceAccountRootCAKey, namespace, openIDConfig.CA); err != nil {
+		result = connectivityCheckResult{
+			reason: hyperv1.IdentityProviderDiscoveryFailedReason,
+			err:    fmt.Errorf("failed to discover OpenID configuration of issuer %s: %w", openIDConfig.Issuer, err),
+		}
+	}
+	connectivityCheckCache.Set(cacheKey, result, connectivityCheckTTL)
+	return result.reason, result.err
+}
+
+type connectivityCheckResult struct {
+	reason string
+	err    error
+}
@@ -0,0 +1,291 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+// fakeLDAPServer answers every request on conn with a response of the expected type and resultCode.
+func fakeLDAPServer(conn net.Conn, resultCode int) {
+	defer conn.Close()
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		responseTag := ber.Tag(ldap.ApplicationBindResponse)
+		if request.Children[1].Tag == ldap.ApplicationExtendedRequest {
+			responseTag = ldap.ApplicationExtendedResponse
+		}
+		response := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, ""))
+		result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, responseTag, nil, "")
+		result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, ""))
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "diagnostic", ""))
+		response.AppendChild(result)
+		if _, err := conn.Write(response.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func TestValidateIdentityProviders(t *testing.T) {
+	const namespace = "hcp-ns"
+	secret := func(name, key string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Data:       map[string][]byte{key: []byte("value")},
+		}
+	}
+	ldapIDP := func(url string) configv1.IdentityProvider {
+		return configv1.IdentityProvider{
+			Name: "ldap",
+			IdentityProviderConfig: configv1.IdentityProviderConfig{
+				Type: configv1.IdentityProviderTypeLDAP,
+				LDAP: &configv1.LDAPIdentityProvider{
+					URL:          url,
+					BindDN:       "cn=admin,dc=example,dc=com",
+					BindPassword: configv1.SecretNameReference{Name: "bind-password"},
+					Insecure:     true,
+				},
+			},
+		}
+	}
+	ldapDialer := func(resultCode int) DialFunc {
+		return func(ctx context.Context, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go fakeLDAPServer(server, resultCode)
+			return client, nil
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		idp            configv1.IdentityProvider
+		dial           DialFunc
+		expectedUsable bool
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name: "When the htpasswd secret exists it should be valid",
+			idp: configv1.IdentityProvider{
+				Name: "htpasswd",
+				IdentityProviderConfig: configv1.IdentityProviderConfig{
+					Type:     configv1.IdentityProviderTypeHTPasswd,
+					HTPasswd: &configv1.HTPasswdIdentityProvider{FileData: configv1.SecretNameReference{Name: "htpasswd"}},
+				},
+			},
+			expectedUsable: true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: hyperv1.AsExpectedReason,
+		},
+		{
+			name: "When the client secret does not exist it should not be usable",
+			idp: configv1.IdentityProvider{
+				Name: "github",
+				IdentityProviderConfig: configv1.IdentityProviderConfig{
+					Type:   configv1.IdentityProviderTypeGitHub,
+					GitHub: &configv1.GitHubIdentityProvider{ClientSecret: configv1.SecretNameReference{Name: "missing"}},
+				},
+			},
+			expectedUsable: false,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.IdentityProviderReferenceInvalidReason,
+		},
+		{
+			name: "When the secret lacks the expected key it should not be usable",
+			idp: configv1.IdentityProvider{
+				Name: "google",
+				IdentityProviderConfig: configv1.IdentityProviderConfig{
+					Type:   configv1.IdentityProviderTypeGoogle,
+					Google: &configv1.GoogleIdentityProvider{ClientSecret: configv1.SecretNameReference{Name: "htpasswd"}},
+				},
+			},
+			expectedUsable: false,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.IdentityProviderReferenceInvalidReason,
+		},
+		{
+			name: "When the CA config map has no certificates it should not be usable",
+			idp: configv1.IdentityProvider{
+				Name: "gitlab",
+				IdentityProviderConfig: configv1.IdentityProviderConfig{
+					Type: configv1.IdentityProviderTypeGitLab,
+					GitLab: &configv1.GitLabIdentityProvider{
+						ClientSecret: configv1.SecretNameReference{Name: "client-secret"},
+						CA:           configv1.ConfigMapNameReference{Name: "invalid-ca"},
+					},
+				},
+			},
+			expectedUsable: false,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.IdentityProviderReferenceInvalidReason,
+		},
+		{
+			name:           "When the LDAP bind succeeds it should be valid",
+			idp:            ldapIDP("ldap://ldap-success.example.com/dc=example,dc=com?uid"),
+			dial:           ldapDialer(0),
+			expectedUsable: true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: hyperv1.AsExpectedReason,
+		},
+		{
+			name:           "When the LDAP bind is rejected it should report the bind failure but stay usable",
+			idp:            ldapIDP("ldap://ldap-rejected.example.com/dc=example,dc=com?uid"),
+			dial:           ldapDialer(49),
+			expectedUsable: true,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.IdentityProviderBindFailedReason,
+		},
+		{
+			name: "When the LDAP server is unreachable it should report it but stay usable",
+			idp:  ldapIDP("ldap://ldap-unreachable.example.com/dc=example,dc=com?uid"),
+			dial: func(ctx context.Context, address string) (net.Conn, error) {
+				return nil, errors.New("no route to host")
+			},
+			expectedUsable: true,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.IdentityProviderUnreachableReason,
+		},
+		{
+			name:           "When the LDAP URL is invalid it should not be usable",
+			idp:            ldapIDP("https://ldap.example.com"),
+			expectedUsable: false,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: hyperv1.InvalidConfigurationReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			client := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(
+				secret("htpasswd", configv1.HTPasswdDataKey),
+				secret("client-secret", configv1.ClientSecretKey),
+				secret("bind-password", configv1.BindPasswordKey),
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "invalid-ca"},
+					Data:       map[string]string{corev1.ServiceAccountRootCAKey: "not a certificate"},
+				},
+			).Build()
+
+			results := ValidateIdentityProviders(context.Background(), client, namespace, []configv1.IdentityProvider{tc.idp}, nil, tc.dial)
+			g.Expect(results).To(HaveLen(1))
+			g.Expect(results[0].Name).To(Equal(tc.idp.Name))
+			g.Expect(results[0].Usable).To(Equal(tc.expectedUsable))
+			g.Expect(results[0].Condition.Type).To(Equal(IdentityProviderConditionType(tc.idp.Name)))
+			g.Expect(results[0].Condition.Status).To(Equal(tc.expectedStatus), results[0].Condition.Message)
+			g.Expect(results[0].Condition.Reason).To(Equal(tc.expectedReason))
+
+			usable := UsableIdentityProviders([]configv1.IdentityProvider{tc.idp}, results)
+			if tc.expectedUsable {
+				g.Expect(usable).To(HaveLen(1))
+			} else {
+				g.Expect(usable).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestIdentityProviderConditionType(t *testing.T) {
+	// The condition type pattern and maximum length of metav1.Condition.
+	conditionType := `^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])$`
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "htpasswd", expected: "ValidIdentityProvider.htpasswd"},
+		{name: "My_LDAP", expected: "ValidIdentityProvider.My_LDAP"},
+		{name: "My LDAP", expected: "ValidIdentityProvider.My_LDAP-"},
+		{name: "corp/sso:", expected: "ValidIdentityProvider.corp_sso-"},
+		{name: "", expected: "ValidIdentityProvider.-"},
+		{name: strings.Repeat("a", 400), expected: "ValidIdentityProvider.aaa"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			actual := IdentityProviderConditionType(tc.name)
+			g.Expect(actual).To(HavePrefix(tc.expected))
+			g.Expect(actual).To(MatchRegexp(conditionType))
+			g.Expect(len(actual)).To(BeNumerically("<=", maxConditionTypeLength))
+		})
+	}
+	g := NewGomegaWithT(t)
+	g.Expect(IdentityProviderConditionType("My LDAP")).ToNot(Equal(IdentityProviderConditionType("My:LDAP")))
+}
+
+func TestValidateIdentityProvidersDuplicateNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+	htpasswd := configv1.IdentityProvider{
+		Name: "htpasswd",
+		IdentityProviderConfig: configv1.IdentityProviderConfig{
+			Type:     configv1.IdentityProviderTypeHTPasswd,
+			HTPasswd: &configv1.HTPasswdIdentityProvider{FileData: configv1.SecretNameReference{Name: "htpasswd"}},
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "hcp-ns", Name: "htpasswd"},
+		Data:       map[string][]byte{configv1.HTPasswdDataKey: []byte("value")},
+	}).Build()
+	identityProviders := []configv1.IdentityProvider{htpasswd, htpasswd}
+
+	results := ValidateIdentityProviders(context.Background(), client, "hcp-ns", identityProviders, nil, nil)
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results[0].Usable).To(BeTrue())
+	g.Expect(results[1].Usable).To(BeFalse())
+	g.Expect(results[1].Condition.Reason).To(Equal(hyperv1.InvalidConfigurationReason))
+	g.Expect(UsableIdentityProviders(identityProviders, results)).To(Equal([]configv1.IdentityProvider{htpasswd}))
+
+	var conditions []metav1.Condition
+	SetIdentityProviderConditions(&conditions, results, 1)
+	g.Expect(conditions).To(HaveLen(2))
+	g.Expect(conditions[0].Type).To(Equal(string(hyperv1.ValidIdentityProviders)))
+	g.Expect(conditions[0].Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(conditions[0].Message).To(ContainSubstring("htpasswd"))
+	g.Expect(conditions[1].Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(conditions[1].Message).To(ContainSubstring("already used"))
+}
+
+func TestSetIdentityProviderConditions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	conditions := []metav1.Condition{
+		{Type: string(hyperv1.EtcdAvailable), Status: metav1.ConditionTrue, Reason: hyperv1.AsExpectedReason},
+		{Type: IdentityProviderConditionType("removed"), Status: metav1.ConditionFalse, Reason: hyperv1.IdentityProviderUnreachableReason},
+	}
+	results := []IdentityProviderValidation{
+		{
+			Name:   "kept",
+			Usable: true,
+			Condition: metav1.Condition{
+				Type:   IdentityProviderConditionType("kept"),
+				Status: metav1.ConditionTrue,
+				Reason: hyperv1.AsExpectedReason,
+			},
+		},
+	}
+
+	SetIdentityProviderConditions(&conditions, results, 3)
+
+	g.Expect(conditions).To(HaveLen(3))
+	g.Expect(conditions[0].Type).To(Equal(string(hyperv1.EtcdAvailable)))
+	g.Expect(conditions[1].Type).To(Equal(string(hyperv1.ValidIdentityProviders)))
+	g.Expect(conditions[1].Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(conditions[2].Type).To(Equal(IdentityProviderConditionType("kept")))
+	g.Expect(conditions[2].ObservedGeneration).To(Equal(int64(3)))
+}
@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/go-ldap/ldap/v3"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+// ldapAddress returns the host:port address and whether TLS is used from the start
+// for an LDAP URL of the form scheme://host:port/basedn?attribute?scope?filter.
+func ldapAddress(rawURL string) (string, bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false, err
+	}
+	var useTLS bool
+	var defaultPort string
+	switch u.Scheme {
+	case "ldap":
+		defaultPort = "389"
+	case "ldaps":
+		useTLS, defaultPort = true, "636"
+	default:
+		return "", false, fmt.Errorf("unsupported scheme %q, must be ldap or ldaps", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", false, fmt.Errorf("no host in URL")
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// ldapBind dials address and performs a simple bind, upgrading the connection with StartTLS
+// first if tlsConfig is set and TLS is not used from the start. An empty password performs
+// an unauthenticated bind, like the oauth server does.
+func ldapBind(ctx context.Context, address string, useTLS bool, tlsConfig *tls.Config, bindDN, password string, dial DialFunc) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ldapBindTimeout)
+	defer cancel()
+
+	conn, err := dial(ctx, address)
+	if err != nil {
+		return hyperv1.IdentityProviderUnreachableReason, fmt.Errorf("failed to connect to LDAP server %s: %w", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return hyperv1.IdentityProviderUnreachableReason, err
+		}
+	}
+	if useTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client := ldap.NewConn(conn, useTLS)
+	client.SetTimeout(ldapBindTimeout)
+	client.Start()
+	defer client.Close()
+
+	if !useTLS && tlsConfig != nil {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return hyperv1.IdentityProviderUnreachableReason, fmt.Errorf("LDAP server %s: StartTLS failed: %w", address, err)
+		}
+	}
+	if _, err := client.SimpleBind(&ldap.SimpleBindRequest{Username: bindDN, Password: password, AllowEmptyPassword: true}); err != nil {
+		// Result codes from ErrorNetwork on are reported by the client, not the server.
+		var ldapErr *ldap.Error
+		if errors.As(err, &ldapErr) && ldapErr.ResultCode < ldap.ErrorNetwork {
+			return hyperv1.IdentityProviderBindFailedReason, fmt.Errorf("LDAP server %s rejected bind as %q: %w", address, bindDN, err)
+		}
+		return hyperv1.IdentityProviderUnreachableReason, fmt.Errorf("LDAP bind to %s failed: %w", address, err)
+	}
+	return "", nil
+}
@@ -23,13 +23,16 @@ const (
 	ServiceSignerPublicKey  = "service-account.pub"
 )
 
-func ReconcileKASServerCertSecret(secret, ca *corev1.Secret, ownerRef config.OwnerRef, externalAPIAddress, internalAPIAddress, serviceCIDR string) error {
+func ReconcileKASServerCertSecret(secret, ca *corev1.Secret, ownerRef config.OwnerRef, externalAPIAddress, internalAPIAddress string, serviceCIDRs []string) error {
 	svc := manifests.KubeAPIServerService(secret.Namespace)
-	_, serviceIPNet, err := net.ParseCIDR(serviceCIDR)
-	if err != nil {
-		return fmt.Errorf("cannot parse service CIDR: %w", err)
+	var serviceIPs []string
+	for _, serviceCIDR := range serviceCIDRs {
+		_, serviceIPNet, err := net.ParseCIDR(serviceCIDR)
+		if err != nil {
+			return fmt.Errorf("cannot parse service CIDR: %w", err)
+		}
+		serviceIPs = append(serviceIPs, firstIP(serviceIPNet).String())
 	}
-	serviceIP := firstIP(serviceIPNet)
 	dnsNames := []string{
 		"localhost",
 		"kubernetes",
@@ -41,10 +44,7 @@ func ReconcileKASServerCertSecret(secret, ca *corev1.Secret, ownerRef config.Own
 		fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace),
 		fmt.Sprintf("%s.%s.svc.cluster.local", svc.Name, svc.Namespace),
 	}
-	apiServerIPs := []string{
-		"127.0.0.1",
-		serviceIP.String(),
-	}
+	apiServerIPs := append([]string{"127.0.0.1"}, serviceIPs...)
 	if isNumericIP(externalAPIAddress) {
 		apiServerIPs = append(apiServerIPs, externalAPIAddress)
 	} else {
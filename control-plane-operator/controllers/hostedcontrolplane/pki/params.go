@@ -10,13 +10,13 @@ import (
 )
 
 type PKIParams struct {
-	// ServiceCIDR
-	// Subnet for cluster services
-	ServiceCIDR string `json:"serviceCIDR"`
+	// ServiceCIDRs
+	// Subnets for cluster services, primary IP family first
+	ServiceCIDRs []string `json:"serviceCIDRs"`
 
-	// ClusterCIDR
-	// Subnet for pods
-	ClusterCIDR string `json:"clusterCIDR"`
+	// ClusterCIDRs
+	// Subnets for pods, primary IP family first
+	ClusterCIDRs []string `json:"clusterCIDRs"`
 
 	// ExternalAPIAddress
 	// An externally accessible DNS name or IP for the API server. Currently obtained from the load balancer DNS name.
@@ -31,7 +31,7 @@ type PKIParams struct {
 	ExternalKconnectivityAddress string `json:"externalKconnectivityAddress"`
 
 	// NodeInternalAPIServerIP
-	// A fixed IP that pods on worker nodes will use to communicate with the API server - 172.20.0.1
+	// A fixed IP that pods on worker nodes will use to communicate with the API server - 172.20.0.1 or fd00::1
 	NodeInternalAPIServerIP string `json:"nodeInternalAPIServerIP"`
 
 	// ExternalOauthAddress
@@ -54,8 +54,8 @@ func NewPKIParams(hcp *hyperv1.HostedControlPlane,
 	oauthExternalAddress,
 	konnectivityExternalAddress string) *PKIParams {
 	p := &PKIParams{
-		ServiceCIDR:                  util.FirstServiceCIDR(hcp.Spec.Networking.ServiceNetwork),
-		ClusterCIDR:                  util.FirstClusterCIDR(hcp.Spec.Networking.ClusterNetwork),
+		ServiceCIDRs:                 util.ServiceCIDRs(hcp.Spec.Networking.ServiceNetwork),
+		ClusterCIDRs:                 util.ClusterCIDRs(hcp.Spec.Networking.ClusterNetwork),
 		Namespace:                    hcp.Namespace,
 		ExternalAPIAddress:           apiExternalAddress,
 		InternalAPIAddress:           fmt.Sprintf("api.%s.hypershift.local", hcp.Name),
@@ -64,6 +64,6 @@ func NewPKIParams(hcp *hyperv1.HostedControlPlane,
 		IngressSubdomain:             config.IngressSubdomain(hcp),
 		OwnerRef:                     config.OwnerRefFrom(hcp),
 	}
-	p.NodeInternalAPIServerIP = util.AdvertiseAddressWithDefault(hcp, config.DefaultAdvertiseAddress)
+	p.NodeInternalAPIServerIP = util.GetAdvertiseAddress(hcp, config.DefaultAdvertiseIPv4Address, config.DefaultAdvertiseIPv6Address)
 	return p
 }
@@ -23,8 +23,10 @@ import (
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/configmetrics"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/cmca"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/drainer"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/guestload"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/hcpstatus"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/inplaceupgrader"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/konnectivityhealth"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/node"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/controllers/resources"
 	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/operator"
@@ -50,12 +52,14 @@ func NewCommand() *cobra.Command {
 }
 
 var controllerFuncs = map[string]operator.ControllerSetupFunc{
-	"controller-manager-ca":  cmca.Setup,
-	resources.ControllerName: resources.Setup,
-	"inplaceupgrader":        inplaceupgrader.Setup,
-	"node":                   node.Setup,
-	"drainer":                drainer.Setup,
-	hcpstatus.ControllerName: hcpstatus.Setup,
+	"controller-manager-ca":           cmca.Setup,
+	resources.ControllerName:          resources.Setup,
+	"inplaceupgrader":                 inplaceupgrader.Setup,
+	"node":                            node.Setup,
+	"drainer":                         drainer.Setup,
+	hcpstatus.ControllerName:          hcpstatus.Setup,
+	konnectivityhealth.ControllerName: konnectivityhealth.Setup,
+	guestload.ControllerName:          guestload.Setup,
 }
 
 type HostedClusterConfigOperator struct {
@@ -95,6 +99,10 @@ type HostedClusterConfigOperator struct {
 	// OAuthPort is the external port of the oauth server
 	OAuthPort int32
 
+	// ReleaseMetadataCacheDir is the directory the metadata of release images is
+	// persisted in. It is only cached in memory if empty.
+	ReleaseMetadataCacheDir string
+
 	initialCA []byte
 
 	platformType string
@@ -133,6 +141,7 @@ func newHostedClusterConfigOperatorCommand() *cobra.Command {
 	flags.Int32Var(&cpo.KonnectivityPort, "konnectivity-port", cpo.KonnectivityPort, "Port of external konnectivity endpoint")
 	flags.StringVar(&cpo.OAuthAddress, "oauth-address", cpo.KonnectivityAddress, "Address of external oauth endpoint")
 	flags.Int32Var(&cpo.OAuthPort, "oauth-port", cpo.KonnectivityPort, "Port of external oauth endpoint")
+	flags.StringVar(&cpo.ReleaseMetadataCacheDir, "release-metadata-cache-dir", cpo.ReleaseMetadataCacheDir, "Directory in which to persist the metadata of release images, so it is not pulled again after restarts. Metadata is only cached in memory if empty")
 	return cmd
 }
 
@@ -211,10 +220,7 @@ func (o *HostedClusterConfigOperator) Run(ctx context.Context) error {
 		return fmt.Errorf("cannot add CPCluster to manager: %v", err)
 	}
 	releaseProvider := &releaseinfo.StaticProviderDecorator{
-		Delegate: &releaseinfo.CachedProvider{
-			Inner: &releaseinfo.RegistryClientProvider{},
-			Cache: map[string]*releaseinfo.ReleaseImage{},
-		},
+		Delegate: releaseinfo.NewCachedProvider(&releaseinfo.RegistryClientProvider{}, releaseinfo.CachedProviderOptions{Dir: o.ReleaseMetadataCacheDir}),
 		ComponentImages: map[string]string{
 			"konnectivity-agent": konnectivityAgentImage,
 		},
@@ -0,0 +1,234 @@
+package guestload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/operator"
+)
+
+const (
+	ControllerName = "guestload"
+
+	// sampleInterval is the interval in which the load of the guest cluster is sampled.
+	sampleInterval = time.Minute
+
+	// scrapeTimeout is the time scraping the metrics of a single kube API server may take.
+	scrapeTimeout = 10 * time.Second
+
+	// requestsMetric is the counter of the requests served by a kube API server.
+	requestsMetric = "apiserver_request_total"
+)
+
+// scrapeFunc returns the number of requests served by the kube API server pod since it started.
+type scrapeFunc func(ctx context.Context, pod *corev1.Pod) (float64, error)
+
+func Setup(opts *operator.HostedClusterConfigOperatorConfig) error {
+	r := &reconciler{
+		mgtClusterClient:    opts.CPCluster.GetClient(),
+		hostedClusterClient: opts.Manager.GetClient(),
+		scrape:              newScraper(opts.TargetConfig),
+		samples:             map[types.UID]sample{},
+		now:                 time.Now,
+	}
+	c, err := controller.New(ControllerName, opts.Manager, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	if err := c.Watch(source.NewKindWithCache(&hyperv1.HostedControlPlane{}, opts.CPCluster.GetCache()), &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("failed to watch HCP: %w", err)
+	}
+
+	// Only nodes joining or leaving the cluster change the load.
+	nodeMapper := func(crclient.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: opts.Namespace, Name: opts.HCPName}}}
+	}
+	nodePredicate := predicate.Funcs{
+		UpdateFunc: func(event.UpdateEvent) bool { return false },
+	}
+	if err := c.Watch(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(nodeMapper), nodePredicate); err != nil {
+		return fmt.Errorf("failed to watch nodes: %w", err)
+	}
+
+	return nil
+}
+
+// sample is the number of requests a kube API server pod had served at a point in time.
+type sample struct {
+	requests float64
+	time     time.Time
+}
+
+// reconciler reports the load of the guest cluster in the GuestLoad status of the
+// HostedControlPlane: the number of nodes and the rate of requests served by the
+// kube API servers, sampled from their metrics.
+type reconciler struct {
+	mgtClusterClient    crclient.Client
+	hostedClusterClient crclient.Client
+	scrape              scrapeFunc
+	now                 func() time.Time
+
+	// samples are the last samples of the kube API server pods by UID.
+	samples map[types.UID]sample
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	hcp := &hyperv1.HostedControlPlane{}
+	if err := r.mgtClusterClient.Get(ctx, req.NamespacedName, hcp); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get hcp %s: %w", req, err)
+	}
+	if !hcp.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.hostedClusterClient.List(ctx, nodes); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	requestRate, err := r.requestRate(ctx, hcp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	originalHCP := hcp.DeepCopy()
+	hcp.Status.GuestLoad = &hyperv1.GuestClusterLoad{
+		Nodes:                int32(len(nodes.Items)),
+		APIRequestsPerSecond: requestRate,
+	}
+	if !reflect.DeepEqual(hcp.Status, originalHCP.Status) {
+		if err := r.mgtClusterClient.Status().Update(ctx, hcp); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to update hcp: %w", err)
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: sampleInterval}, nil
+}
+
+// requestRate returns the rate of requests served by all the kube API server pods since
+// their last samples. The last reported rate is kept until there are two samples of a pod.
+func (r *reconciler) requestRate(ctx context.Context, hcp *hyperv1.HostedControlPlane) (int32, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	pods := &corev1.PodList{}
+	if err := r.mgtClusterClient.List(ctx, pods, crclient.InNamespace(hcp.Namespace), crclient.MatchingLabels{"app": "kube-apiserver"}); err != nil {
+		return 0, fmt.Errorf("failed to list kube-apiserver pods: %w", err)
+	}
+
+	var rate float64
+	rated := false
+	current := map[types.UID]sample{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		scrapeCtx, cancel := context.WithTimeout(ctx, scrapeTimeout)
+		requests, err := r.scrape(scrapeCtx, pod)
+		cancel()
+		if err != nil {
+			log.Info("Failed to scrape kube-apiserver metrics", "pod", pod.Name, "error", err.Error())
+			if previous, exists := r.samples[pod.UID]; exists {
+				current[pod.UID] = previous
+			}
+			continue
+		}
+		now := r.now()
+		current[pod.UID] = sample{requests: requests, time: now}
+
+		previous, exists := r.samples[pod.UID]
+		// The counter is reset when the container restarts.
+		if !exists || requests < previous.requests || !now.After(previous.time) {
+			continue
+		}
+		rate += (requests - previous.requests) / now.Sub(previous.time).Seconds()
+		rated = true
+	}
+	r.samples = current
+
+	if !rated {
+		if hcp.Status.GuestLoad != nil {
+			return hcp.Status.GuestLoad.APIRequestsPerSecond, nil
+		}
+		return 0, nil
+	}
+	return int32(math.Round(rate)), nil
+}
+
+// newScraper returns a scrapeFunc that reads the metrics of kube API server pods with
+// the credentials of config, which targets the kube API server service.
+func newScraper(config *rest.Config) scrapeFunc {
+	return func(ctx context.Context, pod *corev1.Pod) (float64, error) {
+		port := int32(0)
+		for _, container := range pod.Spec.Containers {
+			for _, containerPort := range container.Ports {
+				if containerPort.Name == "client" {
+					port = containerPort.ContainerPort
+				}
+			}
+		}
+		if port == 0 {
+			return 0, fmt.Errorf("pod %s has no client port", pod.Name)
+		}
+
+		podConfig := rest.CopyConfig(config)
+		// The serving certificate of the kube API server is valid for the service name,
+		// not for the pod IP.
+		if u, err := url.Parse(config.Host); err == nil && podConfig.TLSClientConfig.ServerName == "" {
+			podConfig.TLSClientConfig.ServerName = u.Hostname()
+		}
+		podConfig.Host = "https://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port)))
+		client, err := kubernetes.NewForConfig(podConfig)
+		if err != nil {
+			return 0, err
+		}
+		raw, err := client.Discovery().RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return parseRequestCount(raw)
+	}
+}
+
+// parseRequestCount returns the sum of the requestsMetric counters in the metrics text.
+func parseRequestCount(raw []byte) (float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(raw))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+	family, exists := families[requestsMetric]
+	if !exists {
+		return 0, fmt.Errorf("metric %s not found", requestsMetric)
+	}
+	var total float64
+	for _, metric := range family.GetMetric() {
+		total += metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
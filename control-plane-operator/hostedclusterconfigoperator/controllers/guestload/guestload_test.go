@@ -0,0 +1,102 @@
+package guestload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestReconcile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	const namespace = "hcp-ns"
+	hcp := &hyperv1.HostedControlPlane{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "hcp"}}
+	kasPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(name), Labels: map[string]string{"app": "kube-apiserver"}},
+			Status:     corev1.PodStatus{Phase: phase, PodIP: "10.0.0.1"},
+		}
+	}
+	mgtClusterClient := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(
+		hcp,
+		kasPod("kas-a", corev1.PodRunning),
+		kasPod("kas-b", corev1.PodRunning),
+		kasPod("kas-pending", corev1.PodPending),
+	).Build()
+	var nodes []crclient.Object
+	for i := 0; i < 5; i++ {
+		nodes = append(nodes, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+	hostedClusterClient := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(nodes...).Build()
+
+	now := time.Now()
+	requests := map[string]float64{"kas-a": 1000, "kas-b": 2000}
+	scrapeErrs := map[string]error{}
+	r := &reconciler{
+		mgtClusterClient:    mgtClusterClient,
+		hostedClusterClient: hostedClusterClient,
+		scrape: func(_ context.Context, pod *corev1.Pod) (float64, error) {
+			return requests[pod.Name], scrapeErrs[pod.Name]
+		},
+		samples: map[types.UID]sample{},
+		now:     func() time.Time { return now },
+	}
+	reconcileLoad := func() *hyperv1.GuestClusterLoad {
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: crclient.ObjectKeyFromObject(hcp)})
+		g.Expect(err).ToNot(HaveOccurred())
+		result := &hyperv1.HostedControlPlane{}
+		g.Expect(mgtClusterClient.Get(context.Background(), crclient.ObjectKeyFromObject(hcp), result)).To(Succeed())
+		return result.Status.GuestLoad
+	}
+
+	// The first samples don't give a rate yet.
+	g.Expect(reconcileLoad()).To(Equal(&hyperv1.GuestClusterLoad{Nodes: 5}))
+
+	// The rates of every pod are summed.
+	now = now.Add(time.Minute)
+	requests["kas-a"] += 60 * 10
+	requests["kas-b"] += 60 * 20
+	g.Expect(reconcileLoad()).To(Equal(&hyperv1.GuestClusterLoad{Nodes: 5, APIRequestsPerSecond: 30}))
+
+	// Pods that restarted or fail to be scraped are skipped until they have two samples.
+	now = now.Add(time.Minute)
+	requests["kas-a"] = 10
+	requests["kas-b"] += 60 * 5
+	g.Expect(reconcileLoad()).To(Equal(&hyperv1.GuestClusterLoad{Nodes: 5, APIRequestsPerSecond: 5}))
+
+	// The last rate is kept when no pod can be rated.
+	now = now.Add(time.Minute)
+	scrapeErrs["kas-a"] = errors.New("connection refused")
+	scrapeErrs["kas-b"] = errors.New("connection refused")
+	g.Expect(reconcileLoad()).To(Equal(&hyperv1.GuestClusterLoad{Nodes: 5, APIRequestsPerSecond: 5}))
+	g.Expect(r.samples).To(HaveLen(2))
+}
+
+func TestParseRequestCount(t *testing.T) {
+	g := NewGomegaWithT(t)
+	count, err := parseRequestCount([]byte(`# HELP apiserver_request_total Counter of apiserver requests.
+# TYPE apiserver_request_total counter
+apiserver_request_total{code="200",verb="GET"} 100
+apiserver_request_total{code="201",verb="POST"} 20.5
+# HELP apiserver_current_inflight_requests Maximal number of currently used inflight request limit.
+# TYPE apiserver_current_inflight_requests gauge
+apiserver_current_inflight_requests{request_kind="readOnly"} 3
+`))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(count).To(Equal(120.5))
+
+	_, err = parseRequestCount([]byte("# TYPE other counter\nother 1\n"))
+	g.Expect(err).To(MatchError(ContainSubstring("not found")))
+}
@@ -0,0 +1,231 @@
+package konnectivityhealth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/konnectivity"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/control-plane-operator/hostedclusterconfigoperator/operator"
+)
+
+const (
+	ControllerName = "konnectivityhealth"
+
+	// probeInterval is the interval in which the konnectivity tunnel is probed.
+	probeInterval = time.Minute
+
+	// probeTimeout is the time a single probe may take before the tunnel is considered unavailable.
+	probeTimeout = 10 * time.Second
+)
+
+var probeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "hypershift_konnectivity_probe_duration_seconds",
+	Help:    "Time taken to establish a connection to the guest cluster through the konnectivity tunnel, by result.",
+	Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{"result"})
+
+func init() {
+	metrics.Registry.MustRegister(probeDuration)
+}
+
+// probeFunc establishes a connection to target through the konnectivity tunnel,
+// authenticating with the konnectivity client certificate in clientSecret.
+type probeFunc func(ctx context.Context, clientSecret *corev1.Secret, target string) error
+
+func Setup(opts *operator.HostedClusterConfigOperatorConfig) error {
+	r := &reconciler{
+		mgtClusterClient:    opts.CPCluster.GetClient(),
+		hostedClusterClient: opts.Manager.GetClient(),
+		probe:               dialThroughKonnectivity,
+	}
+	c, err := controller.New(ControllerName, opts.Manager, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	if err := c.Watch(source.NewKindWithCache(&hyperv1.HostedControlPlane{}, opts.CPCluster.GetCache()), &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("failed to watch HCP: %w", err)
+	}
+
+	// Nodes joining or leaving the cluster change the number of guest cluster agents and
+	// the desired number of control plane agents, node updates are not relevant.
+	nodeMapper := func(crclient.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: opts.Namespace, Name: opts.HCPName}}}
+	}
+	nodePredicate := predicate.Funcs{
+		UpdateFunc: func(event.UpdateEvent) bool { return false },
+	}
+	if err := c.Watch(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(nodeMapper), nodePredicate); err != nil {
+		return fmt.Errorf("failed to watch nodes: %w", err)
+	}
+
+	return nil
+}
+
+// reconciler probes the konnectivity tunnel by connecting to the kubernetes service of the
+// guest cluster through it. It reports the result in the KonnectivityAvailable condition
+// of the HostedControlPlane and scales the control plane konnectivity agents with the
+// number of guest cluster nodes. The guest cluster konnectivity agents are not scaled
+// here: they run as a DaemonSet with one agent per node.
+type reconciler struct {
+	mgtClusterClient    crclient.Client
+	hostedClusterClient crclient.Client
+	probe               probeFunc
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	hcp := &hyperv1.HostedControlPlane{}
+	if err := r.mgtClusterClient.Get(ctx, req.NamespacedName, hcp); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get hcp %s: %w", req, err)
+	}
+	if !hcp.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.hostedClusterClient.List(ctx, nodes); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	originalHCP := hcp.DeepCopy()
+	condition := r.probeCondition(ctx, hcp, len(nodes.Items))
+	meta.SetStatusCondition(&hcp.Status.Conditions, condition)
+	if !reflect.DeepEqual(hcp.Status, originalHCP.Status) {
+		log.Info("Konnectivity availability changed", "status", condition.Status, "reason", condition.Reason, "message", condition.Message)
+		if err := r.mgtClusterClient.Status().Patch(ctx, hcp, crclient.MergeFrom(originalHCP)); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to patch hcp status: %w", err)
+		}
+	}
+
+	if err := r.reconcileAgentReplicas(ctx, hcp, len(nodes.Items)); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: probeInterval}, nil
+}
+
+func (r *reconciler) probeCondition(ctx context.Context, hcp *hyperv1.HostedControlPlane, nodeCount int) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               string(hyperv1.KonnectivityAvailable),
+		ObservedGeneration: hcp.Generation,
+	}
+	if nodeCount == 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hyperv1.KonnectivityNoNodesReason
+		condition.Message = "The guest cluster has no nodes to run konnectivity agents"
+		return condition
+	}
+
+	if err := r.probeTunnel(ctx, hcp); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hyperv1.KonnectivityProbeFailedReason
+		condition.Message = err.Error()
+		return condition
+	}
+
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = hyperv1.AsExpectedReason
+	condition.Message = hyperv1.AllIsWellMessage
+	return condition
+}
+
+func (r *reconciler) probeTunnel(ctx context.Context, hcp *hyperv1.HostedControlPlane) error {
+	kubernetesService := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubernetes"}}
+	if err := r.hostedClusterClient.Get(ctx, crclient.ObjectKeyFromObject(kubernetesService), kubernetesService); err != nil {
+		return fmt.Errorf("failed to get kubernetes service from guest cluster: %w", err)
+	}
+	clientSecret := manifests.KonnectivityClientSecret(hcp.Namespace)
+	if err := r.mgtClusterClient.Get(ctx, crclient.ObjectKeyFromObject(clientSecret), clientSecret); err != nil {
+		return fmt.Errorf("failed to get konnectivity client secret: %w", err)
+	}
+
+	target := net.JoinHostPort(kubernetesService.Spec.ClusterIP, "443")
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	start := time.Now()
+	err := r.probe(probeCtx, clientSecret, target)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	probeDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s through konnectivity: %w", target, err)
+	}
+	return nil
+}
+
+// reconcileAgentReplicas scales the control plane konnectivity agents with the number
+// of guest cluster nodes. Deployments scaled to zero or by a HorizontalPodAutoscaler
+// are left untouched.
+func (r *reconciler) reconcileAgentReplicas(ctx context.Context, hcp *hyperv1.HostedControlPlane, nodeCount int) error {
+	deployment := manifests.KonnectivityAgentDeployment(hcp.Namespace)
+	if err := r.mgtClusterClient.Get(ctx, crclient.ObjectKeyFromObject(deployment), deployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get konnectivity agent deployment: %w", err)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+		return nil
+	}
+	if autoscaled, err := r.autoscaled(ctx, deployment); err != nil || autoscaled {
+		return err
+	}
+
+	minReplicas := int32(konnectivity.NewKonnectivityParams(hcp, map[string]string{}, "", 0, false).AgentDeploymentConfig.Replicas)
+	desired := konnectivity.AgentReplicas(nodeCount, minReplicas)
+	if *deployment.Spec.Replicas == desired {
+		return nil
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Scaling konnectivity agents", "nodes", nodeCount, "from", *deployment.Spec.Replicas, "to", desired)
+	original := deployment.DeepCopy()
+	deployment.Spec.Replicas = &desired
+	if err := r.mgtClusterClient.Patch(ctx, deployment, crclient.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to scale konnectivity agent deployment: %w", err)
+	}
+	return nil
+}
+
+// autoscaled returns whether a HorizontalPodAutoscaler scales deployment.
+func (r *reconciler) autoscaled(ctx context.Context, deployment *appsv1.Deployment) (bool, error) {
+	autoscalers := &autoscalingv2.HorizontalPodAutoscalerList{}
+	if err := r.mgtClusterClient.List(ctx, autoscalers, crclient.InNamespace(deployment.Namespace)); err != nil {
+		return false, fmt.Errorf("failed to list horizontal pod autoscalers: %w", err)
+	}
+	for _, autoscaler := range autoscalers.Items {
+		target := autoscaler.Spec.ScaleTargetRef
+		if target.Kind == "Deployment" && target.Name == deployment.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
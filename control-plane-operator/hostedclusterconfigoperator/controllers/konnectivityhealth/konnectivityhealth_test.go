@@ -0,0 +1,164 @@
+package konnectivityhealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+)
+
+func TestReconcile(t *testing.T) {
+	const namespace = "hcp-ns"
+
+	testCases := []struct {
+		name             string
+		nodeCount        int
+		availability     hyperv1.AvailabilityPolicy
+		agentReplicas    int32
+		autoscaled       bool
+		probeErr         error
+		expectedStatus   metav1.ConditionStatus
+		expectedReason   string
+		expectedReplicas int32
+	}{
+		{
+			name:             "tunnel available",
+			nodeCount:        3,
+			availability:     hyperv1.SingleReplica,
+			agentReplicas:    1,
+			expectedStatus:   metav1.ConditionTrue,
+			expectedReason:   hyperv1.AsExpectedReason,
+			expectedReplicas: 1,
+		},
+		{
+			name:             "probe fails",
+			nodeCount:        3,
+			availability:     hyperv1.SingleReplica,
+			agentReplicas:    1,
+			probeErr:         errors.New("503 Service Unavailable"),
+			expectedStatus:   metav1.ConditionFalse,
+			expectedReason:   hyperv1.KonnectivityProbeFailedReason,
+			expectedReplicas: 1,
+		},
+		{
+			name:             "no nodes",
+			availability:     hyperv1.SingleReplica,
+			agentReplicas:    1,
+			expectedStatus:   metav1.ConditionFalse,
+			expectedReason:   hyperv1.KonnectivityNoNodesReason,
+			expectedReplicas: 1,
+		},
+		{
+			name:             "agents are scaled up with node count",
+			nodeCount:        120,
+			availability:     hyperv1.SingleReplica,
+			agentReplicas:    1,
+			expectedStatus:   metav1.ConditionTrue,
+			expectedReason:   hyperv1.AsExpectedReason,
+			expectedReplicas: 3,
+		},
+		{
+			name:             "agents are scaled down to the availability policy minimum",
+			nodeCount:        10,
+			availability:     hyperv1.HighlyAvailable,
+			agentReplicas:    5,
+			expectedStatus:   metav1.ConditionTrue,
+			expectedReason:   hyperv1.AsExpectedReason,
+			expectedReplicas: 3,
+		},
+		{
+			name:             "agents scaled by an autoscaler are left untouched",
+			nodeCount:        120,
+			availability:     hyperv1.SingleReplica,
+			agentReplicas:    2,
+			autoscaled:       true,
+			expectedStatus:   metav1.ConditionTrue,
+			expectedReason:   hyperv1.AsExpectedReason,
+			expectedReplicas: 2,
+		},
+		{
+			name:             "agents scaled to zero are left untouched",
+			nodeCount:        120,
+			availability:     hyperv1.SingleReplica,
+			agentReplicas:    0,
+			expectedStatus:   metav1.ConditionTrue,
+			expectedReason:   hyperv1.AsExpectedReason,
+			expectedReplicas: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			hcp := &hyperv1.HostedControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "hcp"},
+				Spec:       hyperv1.HostedControlPlaneSpec{ControllerAvailabilityPolicy: tc.availability},
+			}
+			agentDeployment := manifests.KonnectivityAgentDeployment(namespace)
+			agentDeployment.Spec.Replicas = pointer.Int32(tc.agentReplicas)
+			clientSecret := manifests.KonnectivityClientSecret(namespace)
+			mgtObjects := []crclient.Object{hcp, agentDeployment, clientSecret}
+			if tc.autoscaled {
+				mgtObjects = append(mgtObjects, &autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "konnectivity-agent"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: agentDeployment.Name},
+					},
+				})
+			}
+
+			guestObjects := []crclient.Object{
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubernetes"},
+					Spec:       corev1.ServiceSpec{ClusterIP: "172.30.0.1"},
+				},
+			}
+			for i := 0; i < tc.nodeCount; i++ {
+				guestObjects = append(guestObjects, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+			}
+
+			var probedTarget string
+			r := &reconciler{
+				mgtClusterClient:    fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(mgtObjects...).Build(),
+				hostedClusterClient: fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(guestObjects...).Build(),
+				probe: func(_ context.Context, _ *corev1.Secret, target string) error {
+					probedTarget = target
+					return tc.probeErr
+				},
+			}
+
+			result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: crclient.ObjectKeyFromObject(hcp)})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result.RequeueAfter).To(Equal(probeInterval))
+			if tc.nodeCount > 0 {
+				g.Expect(probedTarget).To(Equal("172.30.0.1:443"))
+			}
+
+			g.Expect(r.mgtClusterClient.Get(context.Background(), crclient.ObjectKeyFromObject(hcp), hcp)).To(Succeed())
+			condition := meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.KonnectivityAvailable))
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+
+			deployment := &appsv1.Deployment{}
+			g.Expect(r.mgtClusterClient.Get(context.Background(), crclient.ObjectKeyFromObject(agentDeployment), deployment)).To(Succeed())
+			g.Expect(*deployment.Spec.Replicas).To(Equal(tc.expectedReplicas))
+		})
+	}
+}
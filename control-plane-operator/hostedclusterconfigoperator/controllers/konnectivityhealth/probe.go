@@ -0,0 +1,72 @@
+package konnectivityhealth
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/konnectivity"
+	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
+	"github.com/openshift/hypershift/support/certs"
+)
+
+// dialThroughKonnectivity establishes a connection to target through the local konnectivity
+// server using HTTP CONNECT, the same way the konnectivity socks5 proxies of the control plane
+// components do. A successful CONNECT means that an agent was able to reach the target.
+func dialThroughKonnectivity(ctx context.Context, clientSecret *corev1.Secret, target string) error {
+	serverName := manifests.KonnectivityServerLocalService("").Name
+	tlsConfig, err := clientTLSConfig(clientSecret, serverName)
+	if err != nil {
+		return err
+	}
+
+	serverAddress := net.JoinHostPort(serverName, strconv.Itoa(konnectivity.KonnectivityServerLocalPort))
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", serverAddress)
+	if err != nil {
+		return fmt.Errorf("dialing konnectivity server %s failed: %w", serverAddress, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, "127.0.0.1"); err != nil {
+		return fmt.Errorf("sending CONNECT request failed: %w", err)
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return fmt.Errorf("reading CONNECT response failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("konnectivity server returned %s", res.Status)
+	}
+	return nil
+}
+
+func clientTLSConfig(secret *corev1.Secret, serverName string) (*tls.Config, error) {
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(secret.Data[certs.CASignerCertMapKey]) {
+		return nil, fmt.Errorf("secret %s has no valid CA certificate", secret.Name)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate from secret %s: %w", secret.Name, err)
+	}
+	return &tls.Config{
+		RootCAs:      certPool,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -29,6 +29,9 @@ var (
 	}
 )
 
+// ReconcileAgentDaemonSet reconciles the guest cluster konnectivity agents. They run on
+// every node, so their number follows the node count and, unlike the control plane
+// agents, they are not scaled by the konnectivity health controller.
 func ReconcileAgentDaemonSet(daemonset *appsv1.DaemonSet, deploymentConfig config.DeploymentConfig, image string, host string, port int32, platform hyperv1.PlatformType, proxy configv1.ProxyStatus) {
 	var labels map[string]string
 	if daemonset.Spec.Selector != nil && daemonset.Spec.Selector.MatchLabels != nil {
@@ -358,23 +358,27 @@ func (r *reconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result
 		errs = append(errs, fmt.Errorf("failed to reconcile openshift apiserver endpoints: %w", err))
 	}
 
-	log.Info("reconciling openshift oauth apiserver apiservices")
-	if err := r.reconcileOpenshiftOAuthAPIServerAPIServices(ctx, hcp); err != nil {
-		errs = append(errs, fmt.Errorf("failed to reconcile openshift apiserver service: %w", err))
-	}
+	// The OAuth API server is not deployed when the API server validates tokens
+	// of external OIDC issuers directly.
+	if !util.IsOIDCAuthenticationHCP(hcp) {
+		log.Info("reconciling openshift oauth apiserver apiservices")
+		if err := r.reconcileOpenshiftOAuthAPIServerAPIServices(ctx, hcp); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile openshift apiserver service: %w", err))
+		}
 
-	log.Info("reconciling openshift oauth apiserver service")
-	openshiftOAuthAPIServerService := manifests.OpenShiftOAuthAPIServerClusterService()
-	if _, err := r.CreateOrUpdate(ctx, r.client, openshiftOAuthAPIServerService, func() error {
-		oapi.ReconcileClusterService(openshiftOAuthAPIServerService)
-		return nil
-	}); err != nil {
-		errs = append(errs, fmt.Errorf("failed to reconcile openshift oauth apiserver service: %w", err))
-	}
+		log.Info("reconciling openshift oauth apiserver service")
+		openshiftOAuthAPIServerService := manifests.OpenShiftOAuthAPIServerClusterService()
+		if _, err := r.CreateOrUpdate(ctx, r.client, openshiftOAuthAPIServerService, func() error {
+			oapi.ReconcileClusterService(openshiftOAuthAPIServerService)
+			return nil
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile openshift oauth apiserver service: %w", err))
+		}
 
-	log.Info("reconciling openshift oauth apiserver endpoints")
-	if err := r.reconcileOpenshiftOAuthAPIServerEndpoints(ctx, hcp); err != nil {
-		errs = append(errs, fmt.Errorf("failed to reconcile openshift apiserver endpoints: %w", err))
+		log.Info("reconciling openshift oauth apiserver endpoints")
+		if err := r.reconcileOpenshiftOAuthAPIServerEndpoints(ctx, hcp); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile openshift apiserver endpoints: %w", err))
+		}
 	}
 
 	log.Info("reconciling kube apiserver service monitor")
@@ -421,20 +425,22 @@ func (r *reconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result
 		errs = append(errs, fmt.Errorf("failed to reconcile user cert CA bundle: %w", err))
 	}
 
-	log.Info("reconciling oauth browser client")
-	oauthBrowserClient := manifests.OAuthServerBrowserClient()
-	if _, err := r.CreateOrUpdate(ctx, r.client, oauthBrowserClient, func() error {
-		return oauth.ReconcileBrowserClient(oauthBrowserClient, r.oauthAddress, r.oauthPort)
-	}); err != nil {
-		errs = append(errs, fmt.Errorf("failed to reconcile oauth browser client: %w", err))
-	}
+	if !util.IsOIDCAuthenticationHCP(hcp) {
+		log.Info("reconciling oauth browser client")
+		oauthBrowserClient := manifests.OAuthServerBrowserClient()
+		if _, err := r.CreateOrUpdate(ctx, r.client, oauthBrowserClient, func() error {
+			return oauth.ReconcileBrowserClient(oauthBrowserClient, r.oauthAddress, r.oauthPort)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile oauth browser client: %w", err))
+		}
 
-	log.Info("reconciling oauth challenging client")
-	oauthChallengingClient := manifests.OAuthServerChallengingClient()
-	if _, err := r.CreateOrUpdate(ctx, r.client, oauthChallengingClient, func() error {
-		return oauth.ReconcileChallengingClient(oauthChallengingClient, r.oauthAddress, r.oauthPort)
-	}); err != nil {
-		errs = append(errs, fmt.Errorf("failed to reconcile oauth challenging client: %w", err))
+		log.Info("reconciling oauth challenging client")
+		oauthChallengingClient := manifests.OAuthServerChallengingClient()
+		if _, err := r.CreateOrUpdate(ctx, r.client, oauthChallengingClient, func() error {
+			return oauth.ReconcileChallengingClient(oauthChallengingClient, r.oauthAddress, r.oauthPort)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reconcile oauth challenging client: %w", err))
+		}
 	}
 
 	log.Info("reconciling oauth serving cert rbac")
@@ -160,6 +160,7 @@ func NewStartCommand() *cobra.Command {
 		enableCIDebugOutput              bool
 		registryOverrides                map[string]string
 		imageOverrides                   map[string]string
+		releaseMetadataCacheDir          string
 	)
 
 	cmd.Flags().StringVar(&namespace, "namespace", os.Getenv("MY_NAMESPACE"), "The namespace this operator lives in (required)")
@@ -175,6 +176,7 @@ func NewStartCommand() *cobra.Command {
 		"to avoid assuming access to the service network)")
 	cmd.Flags().BoolVar(&enableCIDebugOutput, "enable-ci-debug-output", false, "If extra CI debug output should be enabled")
 	cmd.Flags().StringToStringVar(&registryOverrides, "registry-overrides", map[string]string{}, "registry-overrides contains the source registry string as a key and the destination registry string as value. Images before being applied are scanned for the source registry string and if found the string is replaced with the destination registry string. Format is: sr1=dr1,sr2=dr2")
+	cmd.Flags().StringVar(&releaseMetadataCacheDir, "release-metadata-cache-dir", os.Getenv(config.ReleaseMetadataCacheDirEnvVar), "Directory in which to persist the metadata of release images, so it is not pulled again after restarts. Metadata is only cached in memory if empty")
 	cmd.Flags().StringToStringVar(&imageOverrides, "image-overrides", map[string]string{},
 		"List of images that should be used for a hosted cluster control plane instead of images from OpenShift release specified in HostedCluster. "+
 			"Format is: name1=image1,name2=image2. \"nameX\" is name of an image in OpenShift release (e.g. \"cluster-network-operator\"). "+
@@ -344,10 +346,7 @@ func NewStartCommand() *cobra.Command {
 
 		releaseProvider := &releaseinfo.RegistryMirrorProviderDecorator{
 			Delegate: &releaseinfo.StaticProviderDecorator{
-				Delegate: &releaseinfo.CachedProvider{
-					Inner: &releaseinfo.RegistryClientProvider{},
-					Cache: map[string]*releaseinfo.ReleaseImage{},
-				},
+				Delegate:        releaseinfo.NewCachedProvider(&releaseinfo.RegistryClientProvider{}, releaseinfo.CachedProviderOptions{Dir: releaseMetadataCacheDir, RegistryOverrides: registryOverrides}),
 				ComponentImages: componentImages,
 			},
 			RegistryOverrides: registryOverrides,
@@ -0,0 +1,226 @@
+package controlplanesizing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/controlplaneoperator"
+	"github.com/openshift/hypershift/support/util"
+)
+
+const (
+	ControllerName = "control-plane-sizing"
+
+	// scaleDownMargin is the fraction of the thresholds of a smaller tier the load must
+	// be under before the control plane is moved down to it, so a load close to a
+	// threshold does not flap between two tiers.
+	scaleDownMargin = 0.8
+
+	// scaleDownDelay is the minimum time a tier is kept before the control plane is moved
+	// down to a smaller tier. Control planes are moved up to a larger tier immediately.
+	scaleDownDelay = 30 * time.Minute
+)
+
+// tierThreshold is the maximum load of a size tier.
+type tierThreshold struct {
+	tier                 hyperv1.ControlPlaneSizeTier
+	nodes                int32
+	apiRequestsPerSecond int32
+}
+
+// tierThresholds are the size tiers ordered from the smallest to the largest.
+var tierThresholds = []tierThreshold{
+	{tier: hyperv1.SmallControlPlaneSize, nodes: 10, apiRequestsPerSecond: 100},
+	{tier: hyperv1.MediumControlPlaneSize, nodes: 50, apiRequestsPerSecond: 500},
+	{tier: hyperv1.LargeControlPlaneSize, nodes: 150, apiRequestsPerSecond: 1500},
+	{tier: hyperv1.XLargeControlPlaneSize, nodes: math.MaxInt32, apiRequestsPerSecond: math.MaxInt32},
+}
+
+// load is the load of a guest cluster.
+type load struct {
+	nodes                int32
+	apiRequestsPerSecond int32
+}
+
+// rank returns the position of tier in tierThresholds, or -1 if it is unknown.
+func rank(tier hyperv1.ControlPlaneSizeTier) int {
+	for i, threshold := range tierThresholds {
+		if threshold.tier == tier {
+			return i
+		}
+	}
+	return -1
+}
+
+// tierFor returns the smallest tier whose thresholds, scaled by factor, are not exceeded by l.
+func tierFor(l load, factor float64) hyperv1.ControlPlaneSizeTier {
+	for _, threshold := range tierThresholds {
+		if float64(l.nodes) <= float64(threshold.nodes)*factor && float64(l.apiRequestsPerSecond) <= float64(threshold.apiRequestsPerSecond)*factor {
+			return threshold.tier
+		}
+	}
+	return tierThresholds[len(tierThresholds)-1].tier
+}
+
+// nextTier returns the tier of a control plane with load l, whose current tier is current.
+// It also returns the time after which the control plane may be moved down to a smaller
+// tier, if the load calls for it but the current tier was set too recently.
+func nextTier(current *hyperv1.ControlPlaneSizeStatus, l load, now time.Time) (hyperv1.ControlPlaneSizeTier, time.Duration) {
+	larger := tierFor(l, 1)
+	if current == nil || rank(current.Tier) < 0 || rank(larger) > rank(current.Tier) {
+		return larger, 0
+	}
+	smaller := tierFor(l, scaleDownMargin)
+	if rank(smaller) >= rank(current.Tier) {
+		return current.Tier, 0
+	}
+	if wait := current.LastTransitionTime.Add(scaleDownDelay).Sub(now); wait > 0 {
+		return current.Tier, wait
+	}
+	return smaller, 0
+}
+
+// Reconciler classifies HostedClusters into size tiers from the load of their guest
+// cluster, reported by the control plane in the GuestLoad status of the
+// HostedControlPlane, and the replicas of their NodePools. The tier is reported in the
+// ControlPlaneSize status of the HostedCluster, which the HostedCluster controller
+// propagates to the HostedControlPlane.
+type Reconciler struct {
+	client.Client
+	record.EventRecorder
+	now func() time.Time
+}
+
+func New(c client.Client) *Reconciler {
+	return &Reconciler{
+		Client: c,
+		now:    time.Now,
+	}
+}
+
+func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
+	r.EventRecorder = mgr.GetEventRecorderFor(ControllerName)
+	err := ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&hyperv1.HostedCluster{}).
+		Watches(&source.Kind{Type: &hyperv1.HostedControlPlane{}}, handler.EnqueueRequestsFromMapFunc(enqueueHostedClusterOfControlPlane)).
+		Watches(&source.Kind{Type: &hyperv1.NodePool{}}, handler.EnqueueRequestsFromMapFunc(enqueueHostedClusterOfNodePool)).
+		Complete(r)
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	return nil
+}
+
+func enqueueHostedClusterOfControlPlane(obj client.Object) []reconcile.Request {
+	hostedClusterName := obj.GetAnnotations()[hostedcluster.HostedClusterAnnotation]
+	if hostedClusterName == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: util.ParseNamespacedName(hostedClusterName)}}
+}
+
+func enqueueHostedClusterOfNodePool(obj client.Object) []reconcile.Request {
+	nodePool, ok := obj.(*hyperv1.NodePool)
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: nodePool.Namespace, Name: nodePool.Spec.ClusterName}}}
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	hcluster := &hyperv1.HostedCluster{}
+	if err := r.Get(ctx, req.NamespacedName, hcluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get hostedcluster: %w", err)
+	}
+	if !hcluster.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	l, err := r.load(ctx, hcluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := r.now()
+	tier, wait := nextTier(hcluster.Status.ControlPlaneSize, l, now)
+	original := hcluster.DeepCopy()
+	size := &hyperv1.ControlPlaneSizeStatus{
+		Tier:                 tier,
+		Nodes:                l.nodes,
+		APIRequestsPerSecond: l.apiRequestsPerSecond,
+		LastTransitionTime:   metav1.NewTime(now),
+	}
+	if current := hcluster.Status.ControlPlaneSize; current != nil && current.Tier == tier {
+		size.LastTransitionTime = current.LastTransitionTime
+	} else {
+		log.Info("Control plane size changed", "tier", tier, "nodes", l.nodes, "apiRequestsPerSecond", l.apiRequestsPerSecond)
+		r.Eventf(hcluster, "Normal", "ControlPlaneSizeChanged", "Control plane size changed to %s for %d nodes and %d API requests per second", tier, l.nodes, l.apiRequestsPerSecond)
+	}
+	hcluster.Status.ControlPlaneSize = size
+	if !equality.Semantic.DeepEqual(original.Status, hcluster.Status) {
+		if err := r.Status().Patch(ctx, hcluster, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to update control plane size: %w", err)
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: wait}, nil
+}
+
+// load returns the load of the guest cluster of hcluster. The number of nodes is the
+// larger of the nodes reported by the control plane and the replicas of the NodePools,
+// so control planes are sized up before new nodes join.
+func (r *Reconciler) load(ctx context.Context, hcluster *hyperv1.HostedCluster) (load, error) {
+	var l load
+	hcp := controlplaneoperator.HostedControlPlane(manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name).Name, hcluster.Name)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(hcp), hcp); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return l, fmt.Errorf("failed to get hostedcontrolplane: %w", err)
+		}
+	} else if hcp.Status.GuestLoad != nil {
+		l.nodes = hcp.Status.GuestLoad.Nodes
+		l.apiRequestsPerSecond = hcp.Status.GuestLoad.APIRequestsPerSecond
+	}
+
+	nodePools := &hyperv1.NodePoolList{}
+	if err := r.List(ctx, nodePools, client.InNamespace(hcluster.Namespace)); err != nil {
+		return l, fmt.Errorf("failed to list nodepools: %w", err)
+	}
+	var replicas int32
+	for _, nodePool := range nodePools.Items {
+		if nodePool.Spec.ClusterName != hcluster.Name {
+			continue
+		}
+		nodePoolReplicas := nodePool.Status.Replicas
+		if nodePool.Spec.Replicas != nil && *nodePool.Spec.Replicas > nodePoolReplicas {
+			nodePoolReplicas = *nodePool.Spec.Replicas
+		}
+		replicas += nodePoolReplicas
+	}
+	if replicas > l.nodes {
+		l.nodes = replicas
+	}
+	return l, nil
+}
@@ -0,0 +1,104 @@
+package controlplanesizing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/hypershift/api"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+func TestNextTier(t *testing.T) {
+	now := time.Now()
+	size := func(tier hyperv1.ControlPlaneSizeTier, age time.Duration) *hyperv1.ControlPlaneSizeStatus {
+		return &hyperv1.ControlPlaneSizeStatus{Tier: tier, LastTransitionTime: metav1.NewTime(now.Add(-age))}
+	}
+	testCases := []struct {
+		name         string
+		current      *hyperv1.ControlPlaneSizeStatus
+		load         load
+		expectedTier hyperv1.ControlPlaneSizeTier
+		expectedWait time.Duration
+	}{
+		{
+			name:         "When the cluster has no tier yet it should pick the smallest tier that fits",
+			load:         load{nodes: 20, apiRequestsPerSecond: 50},
+			expectedTier: hyperv1.MediumControlPlaneSize,
+		},
+		{
+			name:         "When the requests exceed the tier it should scale up immediately",
+			current:      size(hyperv1.SmallControlPlaneSize, time.Minute),
+			load:         load{nodes: 3, apiRequestsPerSecond: 2000},
+			expectedTier: hyperv1.XLargeControlPlaneSize,
+		},
+		{
+			name:         "When the load is within the margin of a smaller tier it should keep the tier",
+			current:      size(hyperv1.MediumControlPlaneSize, time.Hour),
+			load:         load{nodes: 9, apiRequestsPerSecond: 50},
+			expectedTier: hyperv1.MediumControlPlaneSize,
+		},
+		{
+			name:         "When the load fits a smaller tier but the tier is recent it should wait",
+			current:      size(hyperv1.LargeControlPlaneSize, 10*time.Minute),
+			load:         load{nodes: 5, apiRequestsPerSecond: 10},
+			expectedTier: hyperv1.LargeControlPlaneSize,
+			expectedWait: 20 * time.Minute,
+		},
+		{
+			name:         "When the load fits a smaller tier and the tier is old it should scale down",
+			current:      size(hyperv1.LargeControlPlaneSize, time.Hour),
+			load:         load{nodes: 5, apiRequestsPerSecond: 10},
+			expectedTier: hyperv1.SmallControlPlaneSize,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tier, wait := nextTier(tc.current, tc.load, now)
+			g.Expect(tier).To(Equal(tc.expectedTier))
+			g.Expect(wait).To(Equal(tc.expectedWait))
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hcluster := &hyperv1.HostedCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"}}
+	hcp := &hyperv1.HostedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-example", Name: "example"},
+		Status:     hyperv1.HostedControlPlaneStatus{GuestLoad: &hyperv1.GuestClusterLoad{Nodes: 4, APIRequestsPerSecond: 120}},
+	}
+	nodePool := &hyperv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "workers"},
+		Spec:       hyperv1.NodePoolSpec{ClusterName: "example", Replicas: pointer.Int32(6)},
+	}
+	otherNodePool := &hyperv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "other"},
+		Spec:       hyperv1.NodePoolSpec{ClusterName: "other", Replicas: pointer.Int32(100)},
+	}
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(hcluster, hcp, nodePool, otherNodePool).Build()
+
+	now := time.Now().Truncate(time.Second)
+	r := New(c)
+	r.EventRecorder = record.NewFakeRecorder(10)
+	r.now = func() time.Time { return now }
+
+	result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(hcluster)})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(BeZero())
+
+	g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(hcluster), hcluster)).To(Succeed())
+	g.Expect(hcluster.Status.ControlPlaneSize).ToNot(BeNil())
+	g.Expect(hcluster.Status.ControlPlaneSize.Tier).To(Equal(hyperv1.MediumControlPlaneSize))
+	g.Expect(hcluster.Status.ControlPlaneSize.Nodes).To(Equal(int32(6)))
+	g.Expect(hcluster.Status.ControlPlaneSize.APIRequestsPerSecond).To(Equal(int32(120)))
+}
@@ -63,6 +63,7 @@ import (
 	"github.com/openshift/hypershift/support/metrics"
 	"github.com/openshift/hypershift/support/proxy"
 	"github.com/openshift/hypershift/support/releaseinfo"
+	"github.com/openshift/hypershift/support/releaseinfo/verify"
 	"github.com/openshift/hypershift/support/rhobsmonitoring"
 	"github.com/openshift/hypershift/support/supportedversion"
 	"github.com/openshift/hypershift/support/upsert"
@@ -135,6 +136,10 @@ type HostedClusterReconciler struct {
 	// ReleaseProvider looks up the OCP version for the release images in HostedClusters
 	ReleaseProvider releaseinfo.ProviderWithRegistryOverrides
 
+	// ReleaseVerifier verifies the signatures of the release images in HostedClusters
+	// before they are rolled out. Signatures are not verified if nil.
+	ReleaseVerifier verify.Verifier
+
 	// SetDefaultSecurityContext is used to configure Security Context for containers
 	SetDefaultSecurityContext bool
 
@@ -577,6 +582,63 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		meta.SetStatusCondition(&hcluster.Status.Conditions, *condition)
 	}
 
+	// Copy the KonnectivityAvailable condition on the hostedcontrolplane.
+	{
+		condition := &metav1.Condition{
+			Type:               string(hyperv1.KonnectivityAvailable),
+			Status:             metav1.ConditionUnknown,
+			Reason:             hyperv1.StatusUnknownReason,
+			Message:            "The hosted control plane is not found",
+			ObservedGeneration: hcluster.Generation,
+		}
+		if hcp != nil {
+			konnectivityCondition := meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.KonnectivityAvailable))
+			if konnectivityCondition != nil {
+				condition = konnectivityCondition
+			}
+		}
+		condition.ObservedGeneration = hcluster.Generation
+		meta.SetStatusCondition(&hcluster.Status.Conditions, *condition)
+	}
+
+	// Copy the ValidControlPlaneComponentOverrides condition on the hostedcontrolplane.
+	{
+		condition := &metav1.Condition{
+			Type:               string(hyperv1.ValidControlPlaneComponentOverrides),
+			Status:             metav1.ConditionUnknown,
+			Reason:             hyperv1.StatusUnknownReason,
+			Message:            "The hosted control plane is not found",
+			ObservedGeneration: hcluster.Generation,
+		}
+		if hcp != nil {
+			overridesCondition := meta.FindStatusCondition(hcp.Status.Conditions, string(hyperv1.ValidControlPlaneComponentOverrides))
+			if overridesCondition != nil {
+				condition = overridesCondition
+			}
+		}
+		condition.ObservedGeneration = hcluster.Generation
+		meta.SetStatusCondition(&hcluster.Status.Conditions, *condition)
+	}
+
+	// Copy the ValidIdentityProviders and per identity provider ValidIdentityProvider conditions on the hostedcontrolplane.
+	if hcp != nil {
+		idpConditionPrefix := hyperv1.ValidIdentityProviderConditionPrefix + "."
+		hcpConditionTypes := sets.NewString()
+		for _, condition := range hcp.Status.Conditions {
+			if !strings.HasPrefix(condition.Type, idpConditionPrefix) && condition.Type != string(hyperv1.ValidIdentityProviders) {
+				continue
+			}
+			condition.ObservedGeneration = hcluster.Generation
+			meta.SetStatusCondition(&hcluster.Status.Conditions, condition)
+			hcpConditionTypes.Insert(condition.Type)
+		}
+		for _, condition := range append([]metav1.Condition(nil), hcluster.Status.Conditions...) {
+			if strings.HasPrefix(condition.Type, idpConditionPrefix) && !hcpConditionTypes.Has(condition.Type) {
+				meta.RemoveStatusCondition(&hcluster.Status.Conditions, condition.Type)
+			}
+		}
+	}
+
 	// Copy the InfrastructureReady condition on the hostedcontrolplane.
 	{
 		condition := &metav1.Condition{
@@ -784,24 +846,40 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 	meta.SetStatusCondition(&hcluster.Status.Conditions, hyperutil.GenerateReconciliationActiveCondition(hcluster.Spec.PausedUntil, hcluster.Generation))
 
 	// Set ValidReleaseImage condition
+	// When release images are verified, the control plane is rolled out from the release
+	// image pinned to the digest verified, which is kept in the HostedControlPlane.
+	releaseImage := hcluster.Spec.Release.Image
+	if r.ReleaseVerifier != nil {
+		releaseImage = ""
+		if hcp != nil && verify.IsPinnedImage(hcp.Spec.ReleaseImage, hcluster.Spec.Release.Image) {
+			releaseImage = hcp.Spec.ReleaseImage
+		}
+	}
 	{
 		condition := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.ValidReleaseImage))
 
 		// This check can be expensive looking up release image versions
 		// (hopefully they are cached).  Skip if we have already observed for
-		// this generation.
-		if condition == nil || condition.ObservedGeneration != hcluster.Generation || condition.Status != metav1.ConditionTrue {
+		// this generation, unless the verified release image isn't known.
+		if condition == nil || condition.ObservedGeneration != hcluster.Generation || condition.Status != metav1.ConditionTrue || releaseImage == "" {
 			condition := metav1.Condition{
 				Type:               string(hyperv1.ValidReleaseImage),
 				ObservedGeneration: hcluster.Generation,
 			}
-			err := r.validateReleaseImage(ctx, hcluster)
+			validatedImage, err := r.validateReleaseImage(ctx, hcluster)
+			if err == nil {
+				releaseImage = validatedImage
+			}
 			if err != nil {
 				condition.Status = metav1.ConditionFalse
 				condition.Message = err.Error()
 
 				if apierrors.IsNotFound(err) {
 					condition.Reason = hyperv1.SecretNotFoundReason
+				} else if verify.IsUnverified(err) {
+					condition.Reason = hyperv1.UnverifiedReleaseImageReason
+				} else if verify.IsTransient(err) {
+					condition.Reason = hyperv1.ReleaseVerificationUnavailableReason
 				} else {
 					condition.Reason = hyperv1.InvalidImageReason
 				}
@@ -894,7 +972,7 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 		validReleaseImage := meta.FindStatusCondition(hcluster.Status.Conditions, string(hyperv1.ValidReleaseImage))
 		if validReleaseImage != nil && validReleaseImage.Status == metav1.ConditionFalse {
-			if validReleaseImage.Reason == hyperv1.SecretNotFoundReason {
+			if validReleaseImage.Reason == hyperv1.SecretNotFoundReason || validReleaseImage.Reason == hyperv1.ReleaseVerificationUnavailableReason {
 				return ctrl.Result{}, fmt.Errorf(validReleaseImage.Message)
 			}
 			log.Error(fmt.Errorf("release image is invalid"), "reconciliation is blocked", "message", validReleaseImage.Message)
@@ -1183,6 +1261,11 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Reconcile the CA bundles of the external OIDC providers
+	if err := r.reconcileOIDCProviderCABundles(ctx, hcluster, controlPlaneNamespace.Name, createOrUpdate); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile OIDC provider CA bundles: %w", err)
+	}
+
 	// Reconcile the service account signing key if set
 	if hcluster.Spec.ServiceAccountSigningKey != nil {
 		if err := r.reconcileServiceAccountSigningKey(ctx, hcluster, controlPlaneNamespace.Name, createOrUpdate); err != nil {
@@ -1270,7 +1353,7 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 	// Reconcile the HostedControlPlane
 	hcp = controlplaneoperator.HostedControlPlane(controlPlaneNamespace.Name, hcluster.Name)
 	_, err = createOrUpdate(ctx, r.Client, hcp, func() error {
-		return reconcileHostedControlPlane(hcp, hcluster)
+		return reconcileHostedControlPlane(hcp, hcluster, releaseImage)
 	})
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile hostedcontrolplane: %w", err)
@@ -1401,7 +1484,7 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 	// Get release image version, if needed
 	var releaseImageVersion semver.Version
 	if !controlPlaneOperatorManagesMachineAutoscaler || !controlPlaneOperatorManagesMachineApprover || !controlplaneOperatorManagesIgnitionServer {
-		releaseInfo, err := r.ReleaseProvider.Lookup(ctx, hcluster.Spec.Release.Image, pullSecretBytes)
+		releaseInfo, err := r.ReleaseProvider.Lookup(ctx, releaseImage, pullSecretBytes)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to lookup release image: %w", err)
 		}
@@ -1500,7 +1583,9 @@ func (r *HostedClusterReconciler) reconcile(ctx context.Context, req ctrl.Reques
 
 // reconcileHostedControlPlane reconciles the given HostedControlPlane, which
 // will be mutated.
-func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hyperv1.HostedCluster) error {
+// reconcileHostedControlPlane reconciles hcp from hcluster. releaseImage is the release
+// image of hcluster, pinned to its digest when it was verified.
+func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hyperv1.HostedCluster, releaseImage string) error {
 	hcp.Annotations = map[string]string{
 		HostedClusterAnnotation: client.ObjectKeyFromObject(hcluster).String(),
 	}
@@ -1519,6 +1604,8 @@ func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hype
 		hyperv1.DisableProfilingAnnotation,
 		hyperv1.PrivateIngressControllerAnnotation,
 		hyperv1.CleanupCloudResourcesAnnotation,
+		hyperv1.IgnitionServerAttestationAnnotation,
+		hyperv1.IgnitionServerSingleUseTokensAnnotation,
 	}
 	for _, key := range mirroredAnnotations {
 		val, hasVal := hcluster.Annotations[key]
@@ -1535,7 +1622,7 @@ func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hype
 		}
 	}
 
-	hcp.Spec.ReleaseImage = hcluster.Spec.Release.Image
+	hcp.Spec.ReleaseImage = releaseImage
 
 	hcp.Spec.PullSecret = corev1.LocalObjectReference{Name: controlplaneoperator.PullSecret(hcp.Namespace).Name}
 	if len(hcluster.Spec.SSHKey.Name) > 0 {
@@ -1573,11 +1660,28 @@ func reconcileHostedControlPlane(hcp *hyperv1.HostedControlPlane, hcluster *hype
 	if hcluster.Spec.SecretEncryption != nil {
 		hcp.Spec.SecretEncryption = hcluster.Spec.SecretEncryption.DeepCopy()
 	}
+	hcp.Spec.ControlPlaneEgress = hcluster.Spec.ControlPlaneEgress.DeepCopy()
+	hcp.Spec.Authentication = hcluster.Spec.Authentication.DeepCopy()
+	if hcp.Spec.Authentication != nil {
+		for i, provider := range hcp.Spec.Authentication.OIDCProviders {
+			if provider.Issuer.CertificateAuthority != nil {
+				hcp.Spec.Authentication.OIDCProviders[i].Issuer.CertificateAuthority = &corev1.LocalObjectReference{Name: controlplaneoperator.OIDCProviderCABundle(hcp.Namespace, provider.Name).Name}
+			}
+		}
+	}
 
 	hcp.Spec.PausedUntil = hcluster.Spec.PausedUntil
 	hcp.Spec.OLMCatalogPlacement = hcluster.Spec.OLMCatalogPlacement
 	hcp.Spec.Autoscaling = hcluster.Spec.Autoscaling
 	hcp.Spec.NodeSelector = hcluster.Spec.NodeSelector
+	hcp.Spec.ControlPlaneSize = ""
+	if hcluster.Status.ControlPlaneSize != nil {
+		hcp.Spec.ControlPlaneSize = hcluster.Status.ControlPlaneSize.Tier
+	}
+	hcp.Spec.ControlPlaneComponents = nil
+	for _, override := range hcluster.Spec.ControlPlaneComponents {
+		hcp.Spec.ControlPlaneComponents = append(hcp.Spec.ControlPlaneComponents, *override.DeepCopy())
+	}
 
 	// Pass through Platform spec.
 	hcp.Spec.Platform = *hcluster.Spec.Platform.DeepCopy()
@@ -2065,7 +2169,13 @@ func reconcileControlPlaneOperatorDeployment(deployment *appsv1.Deployment, hc *
 							},
 							{
 								Name:  "OPERATE_ON_RELEASE_IMAGE",
-								Value: hc.Spec.Release.Image,
+								Value: hcp.Spec.ReleaseImage,
+							},
+							{
+								// Set through the environment, as control plane operators of
+								// older releases don't have the flag.
+								Name:  config.ReleaseMetadataCacheDirEnvVar,
+								Value: config.ReleaseMetadataCacheDir,
 							},
 							metrics.MetricsSetToEnv(metricsSet),
 						},
@@ -2101,6 +2211,20 @@ func reconcileControlPlaneOperatorDeployment(deployment *appsv1.Deployment, hc *
 							TimeoutSeconds:      5,
 						},
 						Resources: cpoResources,
+						VolumeMounts: []corev1.VolumeMount{
+							{
+								Name:      "release-metadata",
+								MountPath: config.ReleaseMetadataCacheDir,
+							},
+						},
+					},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name: "release-metadata",
+						VolumeSource: corev1.VolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
 					},
 				},
 			},
@@ -2809,7 +2933,7 @@ func computeClusterVersionStatus(clock clock.WithTickerAndDelayedExecution, hclu
 	// quite right because the intent here is to identify a terminal rollout
 	// state. For now it assumes when status.releaseImage matches, that rollout
 	// is definitely done.
-	hcpRolloutComplete := (hcp.Spec.ReleaseImage == hcp.Status.ReleaseImage) && (version.Desired.Image == hcp.Status.ReleaseImage)
+	hcpRolloutComplete := (hcp.Spec.ReleaseImage == hcp.Status.ReleaseImage) && (version.Desired.Image == hcp.Status.ReleaseImage || verify.IsPinnedImage(hcp.Status.ReleaseImage, version.Desired.Image))
 	if !hcpRolloutComplete {
 		return version
 	}
@@ -3329,34 +3453,47 @@ func (r *HostedClusterReconciler) validateConfigAndClusterCapabilities(ctx conte
 	return utilerrors.NewAggregate(errs)
 }
 
-func (r *HostedClusterReconciler) validateReleaseImage(ctx context.Context, hc *hyperv1.HostedCluster) error {
+// validateReleaseImage validates the release image of hc and returns it, pinned to the
+// digest verified if release images are verified.
+func (r *HostedClusterReconciler) validateReleaseImage(ctx context.Context, hc *hyperv1.HostedCluster) (string, error) {
 	var pullSecret corev1.Secret
 	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: hc.Namespace, Name: hc.Spec.PullSecret.Name}, &pullSecret); err != nil {
-		return fmt.Errorf("failed to get pull secret: %w", err)
+		return "", fmt.Errorf("failed to get pull secret: %w", err)
 	}
 	pullSecretBytes, ok := pullSecret.Data[corev1.DockerConfigJsonKey]
 	if !ok {
-		return fmt.Errorf("expected %s key in pull secret", corev1.DockerConfigJsonKey)
+		return "", fmt.Errorf("expected %s key in pull secret", corev1.DockerConfigJsonKey)
 	}
 
-	releaseInfo, err := r.ReleaseProvider.Lookup(ctx, hc.Spec.Release.Image, pullSecretBytes)
+	// The release image currently rolled out was verified before, so only the
+	// release image in the spec is verified.
+	releaseImage := hc.Spec.Release.Image
+	if r.ReleaseVerifier != nil {
+		d, err := r.ReleaseVerifier.Verify(ctx, hc.Spec.Release.Image, pullSecretBytes, verify.MirrorsFromImageContentSources(hc.Spec.ImageContentSources))
+		if err != nil {
+			return "", err
+		}
+		releaseImage = verify.PinnedImage(releaseImage, d)
+	}
+
+	releaseInfo, err := r.ReleaseProvider.Lookup(ctx, releaseImage, pullSecretBytes)
 	if err != nil {
-		return fmt.Errorf("failed to lookup release image: %w", err)
+		return "", fmt.Errorf("failed to lookup release image: %w", err)
 	}
 	version, err := semver.Parse(releaseInfo.Version())
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var currentVersion *semver.Version
 	if hc.Status.Version != nil && hc.Status.Version.Desired.Image != hc.Spec.Release.Image {
 		releaseInfo, err := r.ReleaseProvider.Lookup(ctx, hc.Status.Version.Desired.Image, pullSecretBytes)
 		if err != nil {
-			return fmt.Errorf("failed to lookup release image: %w", err)
+			return "", fmt.Errorf("failed to lookup release image: %w", err)
 		}
 		version, err := semver.Parse(releaseInfo.Version())
 		if err != nil {
-			return err
+			return "", err
 		}
 		currentVersion = &version
 	}
@@ -3366,7 +3503,10 @@ func (r *HostedClusterReconciler) validateReleaseImage(ctx context.Context, hc *
 		minSupportedVersion = semver.MustParse("4.9.0")
 	}
 
-	return supportedversion.IsValidReleaseVersion(&version, currentVersion, &supportedversion.LatestSupportedVersion, &minSupportedVersion, hc.Spec.Networking.NetworkType, hc.Spec.Platform.Type)
+	if err := supportedversion.IsValidReleaseVersion(&version, currentVersion, &supportedversion.LatestSupportedVersion, &minSupportedVersion, hc.Spec.Networking.NetworkType, hc.Spec.Platform.Type); err != nil {
+		return "", err
+	}
+	return releaseImage, nil
 }
 
 func isProgressing(ctx context.Context, hc *hyperv1.HostedCluster) (bool, error) {
@@ -4196,6 +4336,57 @@ func (r *HostedClusterReconciler) reconcileServiceAccountSigningKey(ctx context.
 	return err
 }
 
+// reconcileOIDCProviderCABundles syncs the CA ConfigMaps referenced by the external OIDC providers
+// into the control plane namespace and deletes the copies that are no longer referenced.
+func (r *HostedClusterReconciler) reconcileOIDCProviderCABundles(ctx context.Context, hc *hyperv1.HostedCluster, targetNamespace string, createOrUpdate upsert.CreateOrUpdateFN) error {
+	referenced := sets.NewString()
+	if hc.Spec.Authentication != nil {
+		for _, provider := range hc.Spec.Authentication.OIDCProviders {
+			if provider.Issuer.CertificateAuthority == nil {
+				continue
+			}
+			var src corev1.ConfigMap
+			if err := r.Client.Get(ctx, client.ObjectKey{Namespace: hc.Namespace, Name: provider.Issuer.CertificateAuthority.Name}, &src); err != nil {
+				return fmt.Errorf("failed to get OIDC provider %s CA ConfigMap %s: %w", provider.Name, provider.Issuer.CertificateAuthority.Name, err)
+			}
+			dest := controlplaneoperator.OIDCProviderCABundle(targetNamespace, provider.Name)
+			referenced.Insert(dest.Name)
+			_, err := createOrUpdate(ctx, r.Client, dest, func() error {
+				srcData, srcHasData := src.Data["ca-bundle.crt"]
+				if !srcHasData {
+					return fmt.Errorf("hostedcluster configmap %q must have a ca-bundle.crt key", src.Name)
+				}
+				if dest.Labels == nil {
+					dest.Labels = map[string]string{}
+				}
+				dest.Labels[controlplaneoperator.OIDCProviderCABundleLabel] = "true"
+				if dest.Data == nil {
+					dest.Data = map[string]string{}
+				}
+				dest.Data["ca-bundle.crt"] = srcData
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to reconcile OIDC provider %s CA bundle: %w", provider.Name, err)
+			}
+		}
+	}
+
+	var bundles corev1.ConfigMapList
+	if err := r.Client.List(ctx, &bundles, client.InNamespace(targetNamespace), client.HasLabels{controlplaneoperator.OIDCProviderCABundleLabel}); err != nil {
+		return fmt.Errorf("failed to list OIDC provider CA bundles: %w", err)
+	}
+	for i := range bundles.Items {
+		if referenced.Has(bundles.Items[i].Name) {
+			continue
+		}
+		if _, err := hyperutil.DeleteIfNeeded(ctx, r.Client, &bundles.Items[i]); err != nil {
+			return fmt.Errorf("failed to delete OIDC provider CA bundle %s: %w", bundles.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
 func (r *HostedClusterReconciler) validateServiceAccountSigningKey(ctx context.Context, hc *hyperv1.HostedCluster) error {
 	// Skip if service account signing key is not set
 	if hc.Spec.ServiceAccountSigningKey == nil || hc.Spec.ServiceAccountSigningKey.Name == "" {
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/hypershift/api"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
@@ -20,6 +22,7 @@ import (
 	"github.com/openshift/hypershift/support/capabilities"
 	fakecapabilities "github.com/openshift/hypershift/support/capabilities/fake"
 	fakereleaseprovider "github.com/openshift/hypershift/support/releaseinfo/fake"
+	"github.com/openshift/hypershift/support/releaseinfo/verify"
 	"github.com/openshift/hypershift/support/thirdparty/library-go/pkg/image/dockerv1client"
 	"github.com/openshift/hypershift/support/upsert"
 	"github.com/openshift/hypershift/support/util/fakeimagemetadataprovider"
@@ -131,7 +134,7 @@ func TestReconcileHostedControlPlaneUpgrades(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			updated := test.ControlPlane.DeepCopy()
-			err := reconcileHostedControlPlane(updated, &test.Cluster)
+			err := reconcileHostedControlPlane(updated, &test.Cluster, test.Cluster.Spec.Release.Image)
 			if err != nil {
 				t.Error(err)
 			}
@@ -213,6 +216,29 @@ func TestComputeClusterVersionStatus(t *testing.T) {
 				},
 			},
 		},
+		"completed rollout of the verified release image updates history": {
+			Cluster: hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{Release: hyperv1.Release{Image: "registry.example.com/ocp-release:4.12.0"}},
+				Status: hyperv1.HostedClusterStatus{
+					Version: &hyperv1.ClusterVersionStatus{
+						Desired: hyperv1.Release{Image: "registry.example.com/ocp-release:4.12.0"},
+						History: []configv1.UpdateHistory{
+							{Image: "registry.example.com/ocp-release:4.12.0", State: configv1.PartialUpdate, StartedTime: Now},
+						},
+					},
+				},
+			},
+			ControlPlane: hyperv1.HostedControlPlane{
+				Spec:   hyperv1.HostedControlPlaneSpec{ReleaseImage: "registry.example.com/ocp-release:4.12.0@sha256:" + strings.Repeat("0", 64)},
+				Status: hyperv1.HostedControlPlaneStatus{ReleaseImage: "registry.example.com/ocp-release:4.12.0@sha256:" + strings.Repeat("0", 64), Version: "4.12.0", LastReleaseImageTransitionTime: &Later},
+			},
+			ExpectedStatus: hyperv1.ClusterVersionStatus{
+				Desired: hyperv1.Release{Image: "registry.example.com/ocp-release:4.12.0"},
+				History: []configv1.UpdateHistory{
+					{Image: "registry.example.com/ocp-release:4.12.0", Version: "4.12.0", State: configv1.CompletedUpdate, StartedTime: Now, CompletionTime: &Later},
+				},
+			},
+		},
 		"new rollout happens after existing rollout completes": {
 			Cluster: hyperv1.HostedCluster{
 				Spec: hyperv1.HostedClusterSpec{Release: hyperv1.Release{Image: "b"}},
@@ -389,7 +415,7 @@ func TestReconcileHostedControlPlaneAPINetwork(t *testing.T) {
 			hostedCluster := &hyperv1.HostedCluster{}
 			hostedCluster.Spec.Networking.APIServer = test.networking
 			hostedControlPlane := &hyperv1.HostedControlPlane{}
-			err := reconcileHostedControlPlane(hostedControlPlane, hostedCluster)
+			err := reconcileHostedControlPlane(hostedControlPlane, hostedCluster, hostedCluster.Spec.Release.Image)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -447,7 +473,7 @@ func TestReconcileHostedControlPlaneConfiguration(t *testing.T) {
 			hostedControlPlane := &hyperv1.HostedControlPlane{}
 			g := NewGomegaWithT(t)
 
-			err := reconcileHostedControlPlane(hostedControlPlane, hostedCluster)
+			err := reconcileHostedControlPlane(hostedControlPlane, hostedCluster, hostedCluster.Spec.Release.Image)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			// DeepEqual to check that all ClusterConfiguration fields are deep copied to HostedControlPlane
@@ -1131,12 +1157,25 @@ func TestValidateConfigAndClusterCapabilities(t *testing.T) {
 	}
 }
 
+type fakeReleaseVerifier struct {
+	unverified sets.String
+}
+
+func (v fakeReleaseVerifier) Verify(_ context.Context, image string, _ []byte, _ verify.Mirrors) (digest.Digest, error) {
+	if v.unverified.Has(image) {
+		return "", &verify.UnverifiedError{Image: image, Digest: "sha256:abc"}
+	}
+	return "sha256:abc", nil
+}
+
 func TestValidateReleaseImage(t *testing.T) {
 	testCases := []struct {
 		name                  string
 		other                 []crclient.Object
 		hostedCluster         *hyperv1.HostedCluster
+		releaseVerifier       verify.Verifier
 		expectedResult        error
+		expectedImage         string
 		expectedNotFoundError bool
 	}{
 		{
@@ -1441,12 +1480,66 @@ func TestValidateReleaseImage(t *testing.T) {
 			},
 			expectedResult: nil,
 		},
+		{
+			name: "unverified release image, error",
+			other: []crclient.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "pull-secret"},
+					Data: map[string][]byte{
+						corev1.DockerConfigJsonKey: nil,
+					},
+				},
+			},
+			hostedCluster: &hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{
+					Networking: hyperv1.ClusterNetworking{
+						NetworkType: hyperv1.OVNKubernetes,
+					},
+					PullSecret: corev1.LocalObjectReference{
+						Name: "pull-secret",
+					},
+					Release: hyperv1.Release{
+						Image: "image-4.12.0",
+					},
+				},
+			},
+			releaseVerifier: fakeReleaseVerifier{unverified: sets.NewString("image-4.12.0")},
+			expectedResult:  &verify.UnverifiedError{Image: "image-4.12.0", Digest: "sha256:abc"},
+		},
+		{
+			name: "verified release image, success",
+			other: []crclient.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "pull-secret"},
+					Data: map[string][]byte{
+						corev1.DockerConfigJsonKey: nil,
+					},
+				},
+			},
+			hostedCluster: &hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{
+					Networking: hyperv1.ClusterNetworking{
+						NetworkType: hyperv1.OVNKubernetes,
+					},
+					PullSecret: corev1.LocalObjectReference{
+						Name: "pull-secret",
+					},
+					Release: hyperv1.Release{
+						Image: "image-4.12.0",
+					},
+				},
+			},
+			releaseVerifier: fakeReleaseVerifier{unverified: sets.NewString("image-4.11.0")},
+			expectedResult:  nil,
+			expectedImage:   "image-4.12.0@sha256:abc",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			r := &HostedClusterReconciler{
-				Client: fake.NewClientBuilder().WithObjects(tc.other...).Build(),
+				Client:          fake.NewClientBuilder().WithObjects(tc.other...).Build(),
+				ReleaseVerifier: tc.releaseVerifier,
 				ReleaseProvider: &fakereleaseprovider.FakeReleaseProvider{
 					ImageVersion: map[string]string{
 						"image-4.7.0":  "4.7.0",
@@ -1456,12 +1549,17 @@ func TestValidateReleaseImage(t *testing.T) {
 						"image-4.11.1": "4.11.1",
 						"image-4.12.0": "4.12.0",
 						"image-4.13.0": "4.13.0",
+						// Verified release images are looked up by digest.
+						"image-4.12.0@sha256:abc": "4.12.0",
 					},
 				},
 			}
 
 			ctx := context.Background()
-			actual := r.validateReleaseImage(ctx, tc.hostedCluster)
+			image, actual := r.validateReleaseImage(ctx, tc.hostedCluster)
+			if tc.expectedImage != "" && image != tc.expectedImage {
+				t.Errorf("expected release image %s, got %s", tc.expectedImage, image)
+			}
 			if diff := cmp.Diff(actual, tc.expectedResult, equateErrorMessage); diff != "" {
 				t.Errorf("actual validation result differs from expected: %s", diff)
 			}
@@ -1542,6 +1640,45 @@ func TestPauseHostedControlPlane(t *testing.T) {
 	}
 }
 
+func TestReconcileOIDCProviderCABundles(t *testing.T) {
+	hcluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "hc"},
+		Spec: hyperv1.HostedClusterSpec{
+			Authentication: &hyperv1.ClusterAuthenticationSpec{
+				Type: hyperv1.OIDCAuthenticationType,
+				OIDCProviders: []hyperv1.OIDCProvider{{
+					Name: "current",
+					Issuer: hyperv1.OIDCIssuer{
+						CertificateAuthority: &corev1.LocalObjectReference{Name: "current-ca"},
+					},
+				}},
+			},
+		},
+	}
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "current-ca"},
+		Data:       map[string]string{"ca-bundle.crt": "current"},
+	}
+	stale := controlplaneoperator.OIDCProviderCABundle("clusters-hc", "removed")
+	stale.Labels = map[string]string{controlplaneoperator.OIDCProviderCABundleLabel: "true"}
+	unrelated := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-hc", Name: "unrelated"}}
+
+	g := NewGomegaWithT(t)
+	c := fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(source, stale, unrelated).Build()
+	r := &HostedClusterReconciler{Client: c}
+	err := r.reconcileOIDCProviderCABundles(context.Background(), hcluster, "clusters-hc", upsert.New(false).CreateOrUpdate)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	current := controlplaneoperator.OIDCProviderCABundle("clusters-hc", "current")
+	g.Expect(c.Get(context.Background(), crclient.ObjectKeyFromObject(current), current)).To(Succeed())
+	g.Expect(current.Data).To(HaveKeyWithValue("ca-bundle.crt", "current"))
+	g.Expect(current.Labels).To(HaveKey(controlplaneoperator.OIDCProviderCABundleLabel))
+
+	err = c.Get(context.Background(), crclient.ObjectKeyFromObject(stale), &corev1.ConfigMap{})
+	g.Expect(errors2.IsNotFound(err)).To(BeTrue())
+	g.Expect(c.Get(context.Background(), crclient.ObjectKeyFromObject(unrelated), &corev1.ConfigMap{})).To(Succeed())
+}
+
 func TestDefaultClusterIDsIfNeeded(t *testing.T) {
 	testHC := func(infraID, clusterID string) *hyperv1.HostedCluster {
 		return &hyperv1.HostedCluster{
@@ -5,13 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
 	"strings"
 
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/config"
+	"github.com/openshift/hypershift/support/util"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -107,6 +112,198 @@ func validateSliceNetworkCIDRs(hc *hyperv1.HostedCluster) field.ErrorList {
 	return compareCIDREntries(cidrEntries)
 }
 
+// ipFamily returns the IP family of the given address as used in error messages.
+func ipFamily(ip net.IP) string {
+	if util.IsIPv6(ip) {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
+// validateNetworkStack validates that the cluster networks describe a valid single-stack or
+// dual-stack configuration. In a dual-stack cluster the first service and cluster network entries
+// determine the primary IP family, which must agree with each other and with the API server
+// advertise address.
+func validateNetworkStack(hc *hyperv1.HostedCluster) field.ErrorList {
+	var errs field.ErrorList
+	networking := hc.Spec.Networking
+	path := field.NewPath("spec.networking")
+
+	serviceFamilies := sets.NewString()
+	for i, entry := range networking.ServiceNetwork {
+		family := ipFamily(entry.CIDR.IP)
+		if serviceFamilies.Has(family) {
+			errs = append(errs, field.Invalid(path.Child("serviceNetwork").Index(i), entry.CIDR.String(), fmt.Sprintf("only one %s service network is supported", family)))
+		}
+		serviceFamilies.Insert(family)
+	}
+	if len(networking.ServiceNetwork) > 2 {
+		errs = append(errs, field.TooMany(path.Child("serviceNetwork"), len(networking.ServiceNetwork), 2))
+	}
+
+	clusterFamilies := sets.NewString()
+	for _, entry := range networking.ClusterNetwork {
+		clusterFamilies.Insert(ipFamily(entry.CIDR.IP))
+	}
+
+	if len(networking.ServiceNetwork) > 0 && len(networking.ClusterNetwork) > 0 {
+		servicePrimary := ipFamily(networking.ServiceNetwork[0].CIDR.IP)
+		clusterPrimary := ipFamily(networking.ClusterNetwork[0].CIDR.IP)
+		if servicePrimary != clusterPrimary {
+			errs = append(errs, field.Invalid(path.Child("clusterNetwork").Index(0), networking.ClusterNetwork[0].CIDR.String(), fmt.Sprintf("primary cluster network is %s but primary service network is %s", clusterPrimary, servicePrimary)))
+		}
+		if !serviceFamilies.Equal(clusterFamilies) {
+			errs = append(errs, field.Invalid(path.Child("clusterNetwork"), clusterFamilies.List(), fmt.Sprintf("cluster network IP families must match service network IP families %v", serviceFamilies.List())))
+		}
+	}
+
+	if networking.APIServer != nil && networking.APIServer.AdvertiseAddress != nil {
+		advertiseAddressPath := path.Child("apiServer", "advertiseAddress")
+		advertiseAddress := net.ParseIP(*networking.APIServer.AdvertiseAddress)
+		switch {
+		case advertiseAddress == nil:
+			errs = append(errs, field.Invalid(advertiseAddressPath, *networking.APIServer.AdvertiseAddress, "must be a valid IP address"))
+		case len(networking.ServiceNetwork) > 0 && ipFamily(advertiseAddress) != ipFamily(networking.ServiceNetwork[0].CIDR.IP):
+			errs = append(errs, field.Invalid(advertiseAddressPath, *networking.APIServer.AdvertiseAddress, fmt.Sprintf("must be an %s address to match the primary service network", ipFamily(networking.ServiceNetwork[0].CIDR.IP))))
+		}
+	}
+
+	return errs
+}
+
+// validateControlPlaneEgress validates the control plane egress routes. Every route must match
+// at least one destination, CIDRs must be parseable and routes targeting a proxy must specify
+// an absolute proxy URL.
+func validateControlPlaneEgress(egress *hyperv1.ControlPlaneEgressSpec) field.ErrorList {
+	var errs field.ErrorList
+	if egress == nil {
+		return errs
+	}
+	path := field.NewPath("spec.controlPlaneEgress.routes")
+	names := sets.NewString()
+	for i, route := range egress.Routes {
+		routePath := path.Index(i)
+		if names.Has(route.Name) {
+			errs = append(errs, field.Duplicate(routePath.Child("name"), route.Name))
+		}
+		names.Insert(route.Name)
+		if len(route.CIDRs) == 0 && len(route.Domains) == 0 {
+			errs = append(errs, field.Required(routePath, "at least one of cidrs or domains must be specified"))
+		}
+		for j, cidr := range route.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				errs = append(errs, field.Invalid(routePath.Child("cidrs").Index(j), cidr, err.Error()))
+			}
+		}
+		for j, domain := range route.Domains {
+			if strings.TrimPrefix(domain, "*.") == "" || strings.Contains(strings.TrimPrefix(domain, "*."), "*") {
+				errs = append(errs, field.Invalid(routePath.Child("domains").Index(j), domain, "must be a host name, optionally prefixed with \"*.\""))
+			}
+		}
+		switch route.Target {
+		case hyperv1.ProxyEgressRouteTarget:
+			if route.ProxyURL == "" {
+				errs = append(errs, field.Required(routePath.Child("proxyURL"), "proxyURL is required when target is Proxy"))
+			} else if u, err := url.Parse(route.ProxyURL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, field.Invalid(routePath.Child("proxyURL"), route.ProxyURL, "must be an absolute URL"))
+			}
+		default:
+			if route.ProxyURL != "" {
+				errs = append(errs, field.Forbidden(routePath.Child("proxyURL"), "proxyURL may only be set when target is Proxy"))
+			}
+		}
+	}
+	return errs
+}
+
+// validateAuthentication validates the authentication mode. OIDC providers are required in
+// OIDC mode and forbidden otherwise, and their issuers must be absolute https URLs.
+func validateAuthentication(authentication *hyperv1.ClusterAuthenticationSpec) field.ErrorList {
+	var errs field.ErrorList
+	if authentication == nil {
+		return errs
+	}
+	path := field.NewPath("spec.authentication")
+	switch authentication.Type {
+	case hyperv1.OIDCAuthenticationType:
+		if len(authentication.OIDCProviders) == 0 {
+			errs = append(errs, field.Required(path.Child("oidcProviders"), "at least one OIDC provider is required when type is OIDC"))
+		}
+	default:
+		if len(authentication.OIDCProviders) > 0 {
+			errs = append(errs, field.Forbidden(path.Child("oidcProviders"), "oidcProviders may only be set when type is OIDC"))
+		}
+	}
+	names := sets.NewString()
+	for i, provider := range authentication.OIDCProviders {
+		providerPath := path.Child("oidcProviders").Index(i)
+		if names.Has(provider.Name) {
+			errs = append(errs, field.Duplicate(providerPath.Child("name"), provider.Name))
+		}
+		names.Insert(provider.Name)
+		if u, err := url.Parse(provider.Issuer.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, field.Invalid(providerPath.Child("issuer", "url"), provider.Issuer.URL, "must be an absolute https URL"))
+		}
+		for j, audience := range provider.Issuer.Audiences {
+			if audience == "" {
+				errs = append(errs, field.Required(providerPath.Child("issuer", "audiences").Index(j), "audience must not be empty"))
+			}
+		}
+		for j, claim := range provider.RequiredClaims {
+			if claim.Claim == "" || strings.Contains(claim.Claim, "=") {
+				errs = append(errs, field.Invalid(providerPath.Child("requiredClaims").Index(j).Child("claim"), claim.Claim, "must be a non-empty claim name without \"=\""))
+			}
+		}
+	}
+	return errs
+}
+
+// validateControlPlaneComponents validates the control plane component overrides. Limits may not be
+// lower than the requests they override and etcd replicas can't be overridden. Requests and limits
+// of the components sized by the control plane size tier are validated against the resources of the
+// tier by validateControlPlaneComponentResources. The control plane operator skips resource overrides
+// that exceed the limits of any other component and reports them in the
+// ValidControlPlaneComponentOverrides condition.
+func validateControlPlaneComponents(overrides []hyperv1.ControlPlaneComponentOverride) field.ErrorList {
+	var errs field.ErrorList
+	path := field.NewPath("spec.controlPlaneComponents")
+	for i, override := range overrides {
+		overridePath := path.Index(i)
+		if override.Name == "etcd" && override.Replicas != nil {
+			errs = append(errs, field.Forbidden(overridePath.Child("replicas"), "etcd replicas can't be overridden"))
+		}
+		for j, container := range override.Containers {
+			resourcesPath := overridePath.Child("containers").Index(j).Child("resources")
+			for name, limit := range container.Resources.Limits {
+				if request, exists := container.Resources.Requests[name]; exists && request.Cmp(limit) > 0 {
+					errs = append(errs, field.Invalid(resourcesPath.Child("requests").Key(string(name)), request.String(), fmt.Sprintf("must be less than or equal to the %s limit", name)))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateControlPlaneComponentResources validates the resource overrides of the components sized by
+// the size tier of the HostedCluster against the resources of the tier, including the resource request
+// override annotations.
+func validateControlPlaneComponentResources(hc *hyperv1.HostedCluster) field.ErrorList {
+	var errs field.ErrorList
+	if hc.Status.ControlPlaneSize == nil || len(hc.Spec.ControlPlaneComponents) == 0 {
+		return errs
+	}
+	hcp := &hyperv1.HostedControlPlane{ObjectMeta: metav1.ObjectMeta{Annotations: hc.Annotations}}
+	hcp.Spec.ControlPlaneSize = hc.Status.ControlPlaneSize.Tier
+	podSpecs := config.SizedComponentPodSpecs(hcp)
+	path := field.NewPath("spec.controlPlaneComponents")
+	for i, override := range hc.Spec.ControlPlaneComponents {
+		if err := config.ValidateComponentOverrideResources([]hyperv1.ControlPlaneComponentOverride{override}, podSpecs); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), override.Name, fmt.Sprintf("exceeds the resources of the %s control plane size: %v", hcp.Spec.ControlPlaneSize, err)))
+		}
+	}
+	return errs
+}
+
 func validateKubevirtBaseDomainPassthroughCreate(hc *hyperv1.HostedCluster) *field.Error {
 
 	// It is invalid for someone to enable the BaseDomainPassthrough feature
@@ -129,6 +326,11 @@ func validateKubevirtBaseDomainPassthroughCreate(hc *hyperv1.HostedCluster) *fie
 
 func validateHostedClusterCreate(hc *hyperv1.HostedCluster) error {
 	errs := validateSliceNetworkCIDRs(hc)
+	errs = append(errs, validateNetworkStack(hc)...)
+	errs = append(errs, validateControlPlaneEgress(hc.Spec.ControlPlaneEgress)...)
+	errs = append(errs, validateAuthentication(hc.Spec.Authentication)...)
+	errs = append(errs, validateControlPlaneComponents(hc.Spec.ControlPlaneComponents)...)
+	errs = append(errs, validateControlPlaneComponentResources(hc)...)
 
 	if err := validateKubevirtBaseDomainPassthroughCreate(hc); err != nil {
 		errs = append(errs, err)
@@ -165,6 +367,8 @@ func filterMutableHostedClusterSpecFields(spec *hyperv1.HostedClusterSpec) {
 	spec.AdditionalTrustBundle = nil
 	spec.SecretEncryption = nil
 	spec.PausedUntil = nil
+	spec.ControlPlaneEgress = nil
+	spec.ControlPlaneComponents = nil
 	for i, svc := range spec.Services {
 		if svc.Type == hyperv1.NodePort && svc.NodePort != nil {
 			spec.Services[i].NodePort.Address = ""
@@ -265,6 +469,27 @@ func validateStructEqual(x any, y any, path *field.Path) field.ErrorList {
 }
 
 func validateHostedClusterUpdate(new *hyperv1.HostedCluster, old *hyperv1.HostedCluster) error {
+	if errs := validateControlPlaneEgress(new.Spec.ControlPlaneEgress); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	if errs := validateControlPlaneComponents(new.Spec.ControlPlaneComponents); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	// The size tier changes with the load of the cluster, so overrides are only validated against
+	// it when they change to not block unrelated updates.
+	if !equality.Semantic.DeepEqual(new.Spec.ControlPlaneComponents, old.Spec.ControlPlaneComponents) {
+		if errs := validateControlPlaneComponentResources(new); len(errs) > 0 {
+			return errs.ToAggregate()
+		}
+	}
+	// Authentication is only validated when it changes so clusters created before the validation
+	// existed can still be updated.
+	if !equality.Semantic.DeepEqual(new.Spec.Authentication, old.Spec.Authentication) {
+		if errs := validateAuthentication(new.Spec.Authentication); len(errs) > 0 {
+			return errs.ToAggregate()
+		}
+	}
+
 	filterMutableHostedClusterSpecFields(&new.Spec)
 	filterMutableHostedClusterSpecFields(&old.Spec)
 
@@ -8,6 +8,7 @@ import (
 	"github.com/go-logr/zapr"
 	configv1 "github.com/openshift/api/config/v1"
 	hyperapi "github.com/openshift/hypershift/api"
+	"github.com/openshift/hypershift/api/util/ipnet"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	version "github.com/openshift/hypershift/cmd/version"
 	fakecapabilities "github.com/openshift/hypershift/support/capabilities/fake"
@@ -17,6 +18,7 @@ import (
 	"go.uber.org/zap/zaptest"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
@@ -284,6 +286,31 @@ func TestValidateHostedClusterUpdate(t *testing.T) {
 			expectError:         true,
 			expectedErrorString: "[HostedCluster.spec.dns.baseDomain: Invalid value: \"hypershift2\": Attempted to change an immutable field, HostedCluster.spec.networking.apiServer.port: Invalid value: 8443: Attempted to change an immutable field]",
 		},
+		{
+			name: "When authentication is changed to an invalid value it should be rejected",
+			old:  &hyperv1.HostedCluster{},
+			new: &hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{
+					Authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType},
+				},
+			},
+			expectError:         true,
+			expectedErrorString: "spec.authentication.oidcProviders: Required value: at least one OIDC provider is required when type is OIDC",
+		},
+		{
+			name: "When invalid authentication is not changed it should be allowed",
+			old: &hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{
+					Authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType},
+				},
+			},
+			new: &hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{
+					Authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType},
+				},
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -578,3 +605,363 @@ func Test_validateEndpointAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateNetworkStack(t *testing.T) {
+	t.Parallel()
+	serviceNetwork := func(cidrs ...string) []hyperv1.ServiceNetworkEntry {
+		var entries []hyperv1.ServiceNetworkEntry
+		for _, cidr := range cidrs {
+			entries = append(entries, hyperv1.ServiceNetworkEntry{CIDR: *ipnet.MustParseCIDR(cidr)})
+		}
+		return entries
+	}
+	clusterNetwork := func(cidrs ...string) []hyperv1.ClusterNetworkEntry {
+		var entries []hyperv1.ClusterNetworkEntry
+		for _, cidr := range cidrs {
+			entries = append(entries, hyperv1.ClusterNetworkEntry{CIDR: *ipnet.MustParseCIDR(cidr)})
+		}
+		return entries
+	}
+	testCases := []struct {
+		name       string
+		networking hyperv1.ClusterNetworking
+		expectErr  bool
+	}{
+		{
+			name: "IPv4 single-stack, allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("172.31.0.0/16"),
+				ClusterNetwork: clusterNetwork("10.132.0.0/14"),
+			},
+		},
+		{
+			name: "IPv6 single-stack with IPv6 advertise address, allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("fd02::/112"),
+				ClusterNetwork: clusterNetwork("fd01::/48"),
+				APIServer:      &hyperv1.APIServerNetworking{AdvertiseAddress: utilpointer.String("fd00::1")},
+			},
+		},
+		{
+			name: "IPv4-primary dual-stack, allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("172.31.0.0/16", "fd02::/112"),
+				ClusterNetwork: clusterNetwork("10.132.0.0/14", "fd01::/48"),
+			},
+		},
+		{
+			name: "IPv6-primary dual-stack, allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("fd02::/112", "172.31.0.0/16"),
+				ClusterNetwork: clusterNetwork("fd01::/48", "10.132.0.0/14"),
+			},
+		},
+		{
+			name: "two IPv4 service networks, not allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("172.31.0.0/16", "172.30.0.0/16"),
+				ClusterNetwork: clusterNetwork("10.132.0.0/14"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "mismatched primary families, not allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("fd02::/112", "172.31.0.0/16"),
+				ClusterNetwork: clusterNetwork("10.132.0.0/14", "fd01::/48"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "dual-stack service network with single-stack cluster network, not allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("172.31.0.0/16", "fd02::/112"),
+				ClusterNetwork: clusterNetwork("10.132.0.0/14"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "IPv4 advertise address on IPv6-primary cluster, not allowed",
+			networking: hyperv1.ClusterNetworking{
+				ServiceNetwork: serviceNetwork("fd02::/112", "172.31.0.0/16"),
+				ClusterNetwork: clusterNetwork("fd01::/48", "10.132.0.0/14"),
+				APIServer:      &hyperv1.APIServerNetworking{AdvertiseAddress: utilpointer.String("172.20.0.1")},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid advertise address, not allowed",
+			networking: hyperv1.ClusterNetworking{
+				APIServer: &hyperv1.APIServerNetworking{AdvertiseAddress: utilpointer.String("api.example.com")},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hc := &hyperv1.HostedCluster{
+				Spec: hyperv1.HostedClusterSpec{
+					Platform:   hyperv1.PlatformSpec{Type: hyperv1.NonePlatform},
+					Networking: tc.networking,
+				},
+			}
+			errs := validateNetworkStack(hc)
+			if (len(errs) > 0) != tc.expectErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectErr, errs.ToAggregate())
+			}
+		})
+	}
+}
+
+func TestValidateControlPlaneEgress(t *testing.T) {
+	testCases := []struct {
+		name      string
+		egress    *hyperv1.ControlPlaneEgressSpec
+		expectErr bool
+	}{
+		{
+			name: "no egress configuration, allowed",
+		},
+		{
+			name: "valid routes, allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "registry", Domains: []string{"*.quay.io", "registry.example.com"}, Target: hyperv1.DirectEgressRouteTarget},
+				{Name: "corp", CIDRs: []string{"10.10.0.0/16", "fd03::/64"}, Target: hyperv1.ProxyEgressRouteTarget, ProxyURL: "http://proxy.example.com:3128"},
+				{Name: "guest", CIDRs: []string{"192.168.0.0/24"}, Target: hyperv1.KonnectivityEgressRouteTarget},
+			}},
+		},
+		{
+			name: "route without destinations, not allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "empty", Target: hyperv1.DirectEgressRouteTarget},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalid CIDR, not allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "bad", CIDRs: []string{"10.10.0.0"}, Target: hyperv1.DirectEgressRouteTarget},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalid wildcard domain, not allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "bad", Domains: []string{"foo.*.example.com"}, Target: hyperv1.DirectEgressRouteTarget},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "proxy target without proxy URL, not allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "corp", CIDRs: []string{"10.10.0.0/16"}, Target: hyperv1.ProxyEgressRouteTarget},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "proxy URL on direct target, not allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "corp", CIDRs: []string{"10.10.0.0/16"}, Target: hyperv1.DirectEgressRouteTarget, ProxyURL: "http://proxy.example.com:3128"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "duplicate route names, not allowed",
+			egress: &hyperv1.ControlPlaneEgressSpec{Routes: []hyperv1.EgressRoute{
+				{Name: "corp", CIDRs: []string{"10.10.0.0/16"}, Target: hyperv1.DirectEgressRouteTarget},
+				{Name: "corp", CIDRs: []string{"10.11.0.0/16"}, Target: hyperv1.DirectEgressRouteTarget},
+			}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateControlPlaneEgress(tc.egress)
+			if (len(errs) > 0) != tc.expectErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectErr, errs.ToAggregate())
+			}
+		})
+	}
+}
+
+func TestValidateAuthentication(t *testing.T) {
+	provider := hyperv1.OIDCProvider{
+		Name: "sso",
+		Issuer: hyperv1.OIDCIssuer{
+			URL:       "https://sso.example.com/realms/openshift",
+			Audiences: []string{"openshift"},
+		},
+		RequiredClaims: []hyperv1.OIDCRequiredClaim{{Claim: "tenant", RequiredValue: "a"}},
+	}
+	withProvider := func(mutate func(*hyperv1.OIDCProvider)) []hyperv1.OIDCProvider {
+		p := *provider.DeepCopy()
+		mutate(&p)
+		return []hyperv1.OIDCProvider{p}
+	}
+	testCases := []struct {
+		name           string
+		authentication *hyperv1.ClusterAuthenticationSpec
+		expectErr      bool
+	}{
+		{
+			name: "no authentication configuration, allowed",
+		},
+		{
+			name:           "integrated OAuth, allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.IntegratedOAuthAuthenticationType},
+		},
+		{
+			name:           "valid OIDC provider, allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType, OIDCProviders: []hyperv1.OIDCProvider{provider}},
+		},
+		{
+			name:           "OIDC without providers, not allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType},
+			expectErr:      true,
+		},
+		{
+			name:           "providers with integrated OAuth, not allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.IntegratedOAuthAuthenticationType, OIDCProviders: []hyperv1.OIDCProvider{provider}},
+			expectErr:      true,
+		},
+		{
+			name: "non https issuer, not allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType, OIDCProviders: withProvider(func(p *hyperv1.OIDCProvider) {
+				p.Issuer.URL = "http://sso.example.com"
+			})},
+			expectErr: true,
+		},
+		{
+			name: "empty audience, not allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType, OIDCProviders: withProvider(func(p *hyperv1.OIDCProvider) {
+				p.Issuer.Audiences = []string{""}
+			})},
+			expectErr: true,
+		},
+		{
+			name: "required claim containing '=', not allowed",
+			authentication: &hyperv1.ClusterAuthenticationSpec{Type: hyperv1.OIDCAuthenticationType, OIDCProviders: withProvider(func(p *hyperv1.OIDCProvider) {
+				p.RequiredClaims = []hyperv1.OIDCRequiredClaim{{Claim: "tenant=a", RequiredValue: "b"}}
+			})},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateAuthentication(tc.authentication)
+			if (len(errs) > 0) != tc.expectErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectErr, errs.ToAggregate())
+			}
+		})
+	}
+}
+
+func TestValidateControlPlaneComponents(t *testing.T) {
+	withResources := func(requests, limits corev1.ResourceList) []hyperv1.ControlPlaneComponentOverride {
+		return []hyperv1.ControlPlaneComponentOverride{{
+			Name: "kube-apiserver",
+			Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name:      "kube-apiserver",
+				Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits},
+			}},
+		}}
+	}
+	testCases := []struct {
+		name      string
+		overrides []hyperv1.ControlPlaneComponentOverride
+		expectErr bool
+	}{
+		{
+			name: "no overrides, allowed",
+		},
+		{
+			name:      "requests lower than limits, allowed",
+			overrides: withResources(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}),
+		},
+		{
+			name:      "requests without limits, allowed",
+			overrides: withResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}, nil),
+		},
+		{
+			name:      "requests higher than limits, not allowed",
+			overrides: withResources(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}),
+			expectErr: true,
+		},
+		{
+			name:      "kube-apiserver replicas, allowed",
+			overrides: []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver", Replicas: utilpointer.Int32(2)}},
+		},
+		{
+			name:      "etcd replicas, not allowed",
+			overrides: []hyperv1.ControlPlaneComponentOverride{{Name: "etcd", Replicas: utilpointer.Int32(5)}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateControlPlaneComponents(tc.overrides)
+			if (len(errs) > 0) != tc.expectErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectErr, errs.ToAggregate())
+			}
+		})
+	}
+}
+
+func TestValidateControlPlaneComponentResources(t *testing.T) {
+	withRequest := func(memory string) []hyperv1.ControlPlaneComponentOverride {
+		return []hyperv1.ControlPlaneComponentOverride{{
+			Name: "kube-apiserver",
+			Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name:      "kube-apiserver",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)}},
+			}},
+		}}
+	}
+	testCases := []struct {
+		name        string
+		size        *hyperv1.ControlPlaneSizeStatus
+		annotations map[string]string
+		overrides   []hyperv1.ControlPlaneComponentOverride
+		expectErr   bool
+	}{
+		{
+			name:      "When the cluster has no size tier it should be allowed",
+			overrides: withRequest("64Gi"),
+		},
+		{
+			name:      "When the request is below the limit of the size tier it should be allowed",
+			size:      &hyperv1.ControlPlaneSizeStatus{Tier: hyperv1.SmallControlPlaneSize},
+			overrides: withRequest("2Gi"),
+		},
+		{
+			name:      "When the request exceeds the limit of the size tier it should be rejected",
+			size:      &hyperv1.ControlPlaneSizeStatus{Tier: hyperv1.SmallControlPlaneSize},
+			overrides: withRequest("8Gi"),
+			expectErr: true,
+		},
+		{
+			name: "When a resource request override annotation raised the limit of the size tier it should be allowed",
+			size: &hyperv1.ControlPlaneSizeStatus{Tier: hyperv1.SmallControlPlaneSize},
+			annotations: map[string]string{
+				hyperv1.ResourceRequestOverrideAnnotationPrefix + "/kube-apiserver.kube-apiserver": "memory=10Gi",
+			},
+			overrides: withRequest("8Gi"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hc := &hyperv1.HostedCluster{}
+			hc.Annotations = tc.annotations
+			hc.Spec.ControlPlaneComponents = tc.overrides
+			hc.Status.ControlPlaneSize = tc.size
+			errs := validateControlPlaneComponentResources(hc)
+			if (len(errs) > 0) != tc.expectErr {
+				t.Errorf("expected error to be %t, got %v", tc.expectErr, errs.ToAggregate())
+			}
+		})
+	}
+}
@@ -132,6 +132,19 @@ func UserCABundle(controlPlaneNamespace string) *corev1.ConfigMap {
 	}
 }
 
+// OIDCProviderCABundleLabel marks the OIDC provider CA bundles copied into the control plane
+// namespace so the ones no longer referenced can be found and deleted.
+const OIDCProviderCABundleLabel = "hypershift.openshift.io/oidc-provider-ca-bundle"
+
+func OIDCProviderCABundle(controlPlaneNamespace, providerName string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "oidc-ca-" + providerName,
+			Namespace: controlPlaneNamespace,
+		},
+	}
+}
+
 func PodMonitor(controlPlaneNamespace string) *prometheusoperatorv1.PodMonitor {
 	return &prometheusoperatorv1.PodMonitor{
 		ObjectMeta: metav1.ObjectMeta{
@@ -52,6 +52,17 @@ func Deployment(namespace string) *appsv1.Deployment {
 	}
 }
 
+// AttestationCAConfigMap contains the certificates the instance identity of
+// machines is verified with, when attestation is enabled.
+func AttestationCAConfigMap(namespace string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      ResourceName + "-attestation-ca",
+		},
+	}
+}
+
 func IgnitionCACertSecret(namespace string) *corev1.Secret {
 	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1,4 +1,9 @@
 #!/usr/bin/env bash
 set -x
+{{- if eq .IPFamily "ipv6" }}
+ip -6 addr add {{ .InternalAPIAddress }}/128 dev lo scope host nodad
+ip -6 route add {{ .InternalAPIAddress }}/128 dev lo scope link src {{ .InternalAPIAddress }}
+{{- else }}
 ip addr add {{ .InternalAPIAddress }}/32 brd {{ .InternalAPIAddress }} scope host dev lo
 ip route add {{ .InternalAPIAddress }}/32 dev lo scope link src {{ .InternalAPIAddress }}
+{{- end }}
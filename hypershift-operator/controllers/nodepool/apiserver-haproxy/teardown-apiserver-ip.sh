@@ -1,4 +1,9 @@
 #!/usr/bin/env bash
 set -x
+{{- if eq .IPFamily "ipv6" }}
+ip -6 addr delete {{ .InternalAPIAddress }}/128 dev lo
+ip -6 route del {{ .InternalAPIAddress }}/128 dev lo scope link src {{ .InternalAPIAddress }}
+{{- else }}
 ip addr delete {{ .InternalAPIAddress }}/32 dev lo
 ip route del {{ .InternalAPIAddress }}/32 dev lo scope link src {{ .InternalAPIAddress }}
+{{- end }}
@@ -6,6 +6,7 @@ import (
 	"embed"
 	"fmt"
 	"html/template"
+	"net"
 	"net/url"
 	"strconv"
 	"strings"
@@ -97,16 +98,8 @@ func (r *NodePoolReconciler) reconcileHAProxyIgnitionConfig(ctx context.Context,
 		return "", true, fmt.Errorf("release image doesn't have a %s image", haProxyRouterImageName)
 	}
 
-	apiServerInternalAddress := config.DefaultAdvertiseAddress
-	apiServerInternalPort := int32(config.DefaultAPIServerPort)
-	if hcluster.Spec.Networking.APIServer != nil {
-		if hcluster.Spec.Networking.APIServer.AdvertiseAddress != nil {
-			apiServerInternalAddress = *hcluster.Spec.Networking.APIServer.AdvertiseAddress
-		}
-		if hcluster.Spec.Networking.APIServer.Port != nil {
-			apiServerInternalPort = *hcluster.Spec.Networking.APIServer.Port
-		}
-	}
+	apiServerInternalAddress := util.GetAdvertiseAddressFromHostedCluster(hcluster, config.DefaultAdvertiseIPv4Address, config.DefaultAdvertiseIPv6Address)
+	apiServerInternalPort := util.APIPortWithDefaultFromHostedCluster(hcluster, config.DefaultAPIServerPort)
 
 	var apiserverProxy string
 	if hcluster.Spec.Configuration != nil && hcluster.Spec.Configuration.Proxy != nil && hcluster.Spec.Configuration.Proxy.HTTPSProxy != "" && util.ConnectsThroughInternetToControlplane(hcluster.Spec.Platform) {
@@ -176,6 +169,11 @@ func apiServerProxyConfig(haProxyImage, cpoImage, externalAPIAddress, internalAP
 	config := &ignitionapi.Config{}
 	config.Ignition.Version = ignitionapi.MaxVersion.String()
 
+	ipFamily := "ipv4"
+	if util.IsIPv6String(internalAPIAddress) {
+		ipFamily = "ipv6"
+	}
+
 	filesToAdd := []fileToAdd{
 		{
 			template: setupAPIServerIPScriptTemplate,
@@ -183,6 +181,7 @@ func apiServerProxyConfig(haProxyImage, cpoImage, externalAPIAddress, internalAP
 			mode:     0755,
 			params: map[string]string{
 				"InternalAPIAddress": internalAPIAddress,
+				"IPFamily":           ipFamily,
 			},
 		},
 		{
@@ -191,6 +190,7 @@ func apiServerProxyConfig(haProxyImage, cpoImage, externalAPIAddress, internalAP
 			mode:     0755,
 			params: map[string]string{
 				"InternalAPIAddress": internalAPIAddress,
+				"IPFamily":           ipFamily,
 			},
 		},
 	}
@@ -201,10 +201,9 @@ func apiServerProxyConfig(haProxyImage, cpoImage, externalAPIAddress, internalAP
 				name:     "/etc/kubernetes/apiserver-proxy-config/haproxy.cfg",
 				mode:     0644,
 				params: map[string]string{
-					"ExternalAPIAddress": externalAPIAddress,
-					"ExternalAPIPort":    strconv.FormatInt(int64(externalAPIPort), 10),
-					"InternalAPIAddress": internalAPIAddress,
-					"InternalAPIPort":    strconv.FormatInt(int64(internalAPIPort), 10),
+					// JoinHostPort brackets IPv6 addresses so haproxy can tell the port apart.
+					"ExternalAPIHostPort": net.JoinHostPort(externalAPIAddress, strconv.FormatInt(int64(externalAPIPort), 10)),
+					"InternalAPIHostPort": net.JoinHostPort(internalAPIAddress, strconv.FormatInt(int64(internalAPIPort), 10)),
 				},
 			},
 			{
@@ -215,7 +214,7 @@ func apiServerProxyConfig(haProxyImage, cpoImage, externalAPIAddress, internalAP
 		}...)
 	} else {
 		filesToAdd = append(filesToAdd, fileToAdd{
-			source: generateKubernetesDefaultProxyPod(cpoImage, fmt.Sprintf("%s:%d", internalAPIAddress, internalAPIPort), proxyAddr, fmt.Sprintf("%s:%d", externalAPIAddress, externalAPIPort)),
+			source: generateKubernetesDefaultProxyPod(cpoImage, net.JoinHostPort(internalAPIAddress, strconv.Itoa(int(internalAPIPort))), proxyAddr, net.JoinHostPort(externalAPIAddress, strconv.Itoa(int(externalAPIPort)))),
 			name:   "/etc/kubernetes/manifests/kube-apiserver-proxy.yaml",
 			mode:   0644,
 		})
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	ignitionapi "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/openshift/hypershift/api/util/ipnet"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/support/testutil"
 	mcfgv1 "github.com/openshift/hypershift/thirdparty/machineconfigoperator/pkg/apis/machineconfiguration.openshift.io/v1"
@@ -142,6 +143,18 @@ kind: Config`
 
 			expectedHAProxyConfigContent: []string{"kubeconfig-host:443"},
 		},
+		{
+			name: "ipv6 primary cluster binds to the default ipv6 advertise address",
+			hc: hc(func(hc *hyperv1.HostedCluster) {
+				hc.Spec.Platform.AWS.EndpointAccess = hyperv1.Private
+				hc.Spec.Networking.ServiceNetwork = []hyperv1.ServiceNetworkEntry{
+					{CIDR: *ipnet.MustParseCIDR("fd02::/112")},
+					{CIDR: *ipnet.MustParseCIDR("172.31.0.0/16")},
+				}
+			}),
+
+			expectedHAProxyConfigContent: []string{"bind [fd00::1]:6443"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -191,3 +204,73 @@ kind: Config`
 		})
 	}
 }
+
+func TestAPIServerHAProxyConfigIPFamily(t *testing.T) {
+	testCases := []struct {
+		name            string
+		internalAddress string
+		externalAddress string
+		expectedScript  string
+		expectedConfig  []string
+	}{
+		{
+			name:            "ipv4 address is added to the loopback device as /32",
+			internalAddress: "172.20.0.1",
+			externalAddress: "cluster.example.com",
+			expectedScript:  "ip addr add 172.20.0.1/32 brd 172.20.0.1 scope host dev lo",
+			expectedConfig:  []string{"bind 172.20.0.1:6443", "server controlplane cluster.example.com:443"},
+		},
+		{
+			name:            "ipv6 address is added to the loopback device as /128",
+			internalAddress: "fd00::1",
+			externalAddress: "cluster.example.com",
+			expectedScript:  "ip -6 addr add fd00::1/128 dev lo scope host nodad",
+			expectedConfig:  []string{"bind [fd00::1]:6443", "server controlplane cluster.example.com:443"},
+		},
+		{
+			name:            "ipv6 external address is bracketed in the haproxy backend",
+			internalAddress: "fd00::1",
+			externalAddress: "fd03::10",
+			expectedScript:  "ip -6 addr add fd00::1/128 dev lo scope host nodad",
+			expectedConfig:  []string{"bind [fd00::1]:6443", "server controlplane [fd03::10]:443"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := apiServerProxyConfig("ha-proxy-image:latest", "", tc.externalAddress, tc.internalAddress, 443, 6443, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ignitionCfg := &ignitionapi.Config{}
+			if err := yaml.Unmarshal(config, ignitionCfg); err != nil {
+				t.Fatalf("cannot unmarshal ignition config: %v", err)
+			}
+			var setupScript, haproxyConfig *dataurl.DataURL
+			for _, file := range ignitionCfg.Storage.Files {
+				switch file.Path {
+				case "/usr/local/bin/setup-apiserver-ip.sh":
+					setupScript, err = dataurl.DecodeString(*file.Contents.Source)
+				case "/etc/kubernetes/apiserver-proxy-config/haproxy.cfg":
+					haproxyConfig, err = dataurl.DecodeString(*file.Contents.Source)
+				}
+				if err != nil {
+					t.Fatalf("cannot decode dataurl: %v", err)
+				}
+			}
+			if setupScript == nil {
+				t.Fatalf("couldn't find setup-apiserver-ip.sh in ignition config %s", string(config))
+			}
+			if !strings.Contains(string(setupScript.Data), tc.expectedScript) {
+				t.Errorf("expected %s in %s", tc.expectedScript, string(setupScript.Data))
+			}
+			if haproxyConfig == nil {
+				t.Fatalf("couldn't find haproxy.cfg in ignition config %s", string(config))
+			}
+			for _, line := range tc.expectedConfig {
+				if !strings.Contains(string(haproxyConfig.Data), line) {
+					t.Errorf("expected %s in %s", line, string(haproxyConfig.Data))
+				}
+			}
+		})
+	}
+}
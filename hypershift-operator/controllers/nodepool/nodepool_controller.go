@@ -26,9 +26,11 @@ import (
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests/ignitionserver"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool/kubevirt"
+	"github.com/openshift/hypershift/ignition-server/attestation"
 	ignserver "github.com/openshift/hypershift/ignition-server/controllers"
 	"github.com/openshift/hypershift/support/globalconfig"
 	"github.com/openshift/hypershift/support/releaseinfo"
+	"github.com/openshift/hypershift/support/releaseinfo/verify"
 	"github.com/openshift/hypershift/support/supportedversion"
 	"github.com/openshift/hypershift/support/upsert"
 	supportutil "github.com/openshift/hypershift/support/util"
@@ -100,6 +102,9 @@ type NodePoolReconciler struct {
 	client.Client
 	recorder        record.EventRecorder
 	ReleaseProvider releaseinfo.Provider
+	// ReleaseVerifier verifies the signatures of the release images in NodePools
+	// that differ from the release image of their HostedCluster. Optional.
+	ReleaseVerifier verify.Verifier
 	controller      controller.Controller
 	upsert.CreateOrUpdateProvider
 	HypershiftOperatorImage string
@@ -336,7 +341,18 @@ func (r *NodePoolReconciler) reconcile(ctx context.Context, hcluster *hyperv1.Ho
 	removeStatusCondition(&nodePool.Status.Conditions, string(hyperv1.IgnitionEndpointAvailable))
 
 	// Validate and get releaseImage.
-	releaseImage, err := r.getReleaseImage(ctx, hcluster, nodePool.Status.Version, nodePool.Spec.Release.Image)
+	releaseImage, releaseImagePullSpec, err := r.getReleaseImage(ctx, hcluster, nodePool.Status.Version, nodePool.Spec.Release.Image)
+	if verify.IsUnverified(err) {
+		SetStatusCondition(&nodePool.Status.Conditions, hyperv1.NodePoolCondition{
+			Type:               hyperv1.NodePoolValidReleaseImageConditionType,
+			Status:             corev1.ConditionFalse,
+			Reason:             hyperv1.UnverifiedReleaseImageReason,
+			Message:            err.Error(),
+			ObservedGeneration: nodePool.Generation,
+		})
+		log.Error(err, "reconciliation is blocked")
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		SetStatusCondition(&nodePool.Status.Conditions, hyperv1.NodePoolCondition{
 			Type:               hyperv1.NodePoolValidReleaseImageConditionType,
@@ -450,15 +466,9 @@ func (r *NodePoolReconciler) reconcile(ctx context.Context, hcluster *hyperv1.Ho
 	}
 
 	// Validate config input.
-	// 3 generic core config resoures: fips, ssh and haproxy.
 	// TODO (alberto): consider moving the expectedCoreConfigResources check
 	// into the token Secret controller so we don't block Machine infra creation on this.
-	expectedCoreConfigResources := 3
-	if len(hcluster.Spec.ImageContentSources) > 0 {
-		// additional core config resource created when image content source specified.
-		expectedCoreConfigResources += 1
-	}
-	config, missingConfigs, err := r.getConfig(ctx, nodePool, expectedCoreConfigResources, controlPlaneNamespace, releaseImage, hcluster)
+	config, missingConfigs, err := r.getConfig(ctx, nodePool, expectedCoreConfigResources(hcluster), controlPlaneNamespace, releaseImage, hcluster)
 	if err != nil {
 		SetStatusCondition(&nodePool.Status.Conditions, hyperv1.NodePoolCondition{
 			Type:               hyperv1.NodePoolValidMachineConfigConditionType,
@@ -730,7 +740,7 @@ func (r *NodePoolReconciler) reconcile(ctx context.Context, hcluster *hyperv1.Ho
 
 	tokenSecret = TokenSecret(controlPlaneNamespace, nodePool.Name, targetConfigVersionHash)
 	if result, err := r.CreateOrUpdate(ctx, r.Client, tokenSecret, func() error {
-		return reconcileTokenSecret(tokenSecret, nodePool, compressedConfig.Bytes())
+		return reconcileTokenSecret(tokenSecret, nodePool, releaseImagePullSpec, compressedConfig.Bytes())
 	}); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile token Secret: %w", err)
 	} else {
@@ -745,7 +755,7 @@ func (r *NodePoolReconciler) reconcile(ctx context.Context, hcluster *hyperv1.Ho
 
 	userDataSecret := IgnitionUserDataSecret(controlPlaneNamespace, nodePool.GetName(), targetConfigVersionHash)
 	if result, err := r.CreateOrUpdate(ctx, r.Client, userDataSecret, func() error {
-		return reconcileUserDataSecret(userDataSecret, nodePool, caCertBytes, tokenBytes, ignEndpoint, targetConfigVersionHash, proxy)
+		return reconcileUserDataSecret(userDataSecret, nodePool, caCertBytes, tokenBytes, ignEndpoint, targetConfigVersionHash, proxy, ignitionAttestationEnabled(hcluster))
 	}); err != nil {
 		return ctrl.Result{}, err
 	} else {
@@ -1132,7 +1142,7 @@ func (r *NodePoolReconciler) deleteNodePoolSecrets(ctx context.Context, nodePool
 	return nil
 }
 
-func reconcileUserDataSecret(userDataSecret *corev1.Secret, nodePool *hyperv1.NodePool, CA, token []byte, ignEndpoint, targetConfigVersionHash string, proxy *configv1.Proxy) error {
+func reconcileUserDataSecret(userDataSecret *corev1.Secret, nodePool *hyperv1.NodePool, CA, token []byte, ignEndpoint, targetConfigVersionHash string, proxy *configv1.Proxy, attested bool) error {
 	// The token secret controller deletes expired token Secrets.
 	// When that happens the NodePool controller reconciles and create a new one.
 	// Then it reconciles the userData Secret with the new generated token.
@@ -1146,7 +1156,7 @@ func reconcileUserDataSecret(userDataSecret *corev1.Secret, nodePool *hyperv1.No
 
 	encodedCACert := base64.StdEncoding.EncodeToString(CA)
 	encodedToken := base64.StdEncoding.EncodeToString(token)
-	ignConfig := ignConfig(encodedCACert, encodedToken, ignEndpoint, targetConfigVersionHash, proxy, nodePool)
+	ignConfig := ignConfig(encodedCACert, encodedToken, ignEndpoint, targetConfigVersionHash, proxy, nodePool, attested)
 	userDataValue, err := json.Marshal(ignConfig)
 	if err != nil {
 		return fmt.Errorf("failed to marshal ignition config: %w", err)
@@ -1182,7 +1192,7 @@ func reconcileTuningConfigMap(tuningConfigMap *corev1.ConfigMap, nodePool *hyper
 	return nil
 }
 
-func reconcileTokenSecret(tokenSecret *corev1.Secret, nodePool *hyperv1.NodePool, compressedConfig []byte) error {
+func reconcileTokenSecret(tokenSecret *corev1.Secret, nodePool *hyperv1.NodePool, releaseImage string, compressedConfig []byte) error {
 	// The token secret controller updates expired token IDs for token Secrets.
 	// When that happens the NodePool controller reconciles the userData Secret with the new token ID.
 	// Therefore this secret is mutable.
@@ -1201,7 +1211,7 @@ func reconcileTokenSecret(tokenSecret *corev1.Secret, nodePool *hyperv1.NodePool
 		tokenSecret.Data = map[string][]byte{}
 		tokenSecret.Annotations[TokenSecretTokenGenerationTime] = time.Now().Format(time.RFC3339Nano)
 		tokenSecret.Data[TokenSecretTokenKey] = []byte(uuid.New().String())
-		tokenSecret.Data[TokenSecretReleaseKey] = []byte(nodePool.Spec.Release.Image)
+		tokenSecret.Data[TokenSecretReleaseKey] = []byte(releaseImage)
 		tokenSecret.Data[TokenSecretConfigKey] = compressedConfig
 	}
 	return nil
@@ -1459,7 +1469,21 @@ func setMachineDeploymentReplicas(nodePool *hyperv1.NodePool, machineDeployment
 	}
 }
 
-func ignConfig(encodedCACert, encodedToken, endpoint, targetConfigVersionHash string, proxy *configv1.Proxy, nodePool *hyperv1.NodePool) ignitionapi.Config {
+// ignitionAttestationEnabled returns whether the ignition server verifies the
+// instance identity of the machines of hcluster, as the ignition server deployment
+// of the control plane operator does.
+func ignitionAttestationEnabled(hcluster *hyperv1.HostedCluster) bool {
+	if hcluster.Annotations[hyperv1.IgnitionServerAttestationAnnotation] != "true" {
+		return false
+	}
+	return (hcluster.Spec.Platform.Type == hyperv1.AWSPlatform && hcluster.Spec.Platform.AWS != nil) ||
+		(hcluster.Spec.Platform.Type == hyperv1.AzurePlatform && hcluster.Spec.Platform.Azure != nil)
+}
+
+// ignConfig returns the pointer config of the payload served at endpoint. When
+// attested, Ignition is pointed to the attestation proxy of the instance, which adds
+// its identity to the request and forwards it to endpoint.
+func ignConfig(encodedCACert, encodedToken, endpoint, targetConfigVersionHash string, proxy *configv1.Proxy, nodePool *hyperv1.NodePool, attested bool) ignitionapi.Config {
 	cfg := ignitionapi.Config{
 		Ignition: ignitionapi.Ignition{
 			Version: "3.2.0",
@@ -1495,6 +1519,14 @@ func ignConfig(encodedCACert, encodedToken, endpoint, targetConfigVersionHash st
 			},
 		},
 	}
+	if attested {
+		source := &cfg.Ignition.Config.Merge[0]
+		source.HTTPHeaders = append(source.HTTPHeaders,
+			ignitionapi.HTTPHeader{Name: attestation.UpstreamHeader, Value: source.Source},
+			ignitionapi.HTTPHeader{Name: attestation.UpstreamCAHeader, Value: k8sutilspointer.String(encodedCACert)},
+		)
+		source.Source = k8sutilspointer.String(fmt.Sprintf("http://%s/ignition", attestation.ProxyAddress))
+	}
 	if proxy.Status.HTTPProxy != "" {
 		cfg.Ignition.Proxy.HTTPProxy = k8sutilspointer.String(proxy.Status.HTTPProxy)
 	}
@@ -1506,9 +1538,33 @@ func ignConfig(encodedCACert, encodedToken, endpoint, targetConfigVersionHash st
 			cfg.Ignition.Proxy.NoProxy = append(cfg.Ignition.Proxy.NoProxy, ignitionapi.NoProxyItem(item))
 		}
 	}
+	if attested && (proxy.Status.HTTPProxy != "" || proxy.Status.HTTPSProxy != "") {
+		// The attestation proxy runs on the instance.
+		cfg.Ignition.Proxy.NoProxy = append(cfg.Ignition.Proxy.NoProxy, "127.0.0.1")
+	}
 	return cfg
 }
 
+// expectedCoreConfigResources returns the number of core config resources of the
+// control plane of hcluster.
+func expectedCoreConfigResources(hcluster *hyperv1.HostedCluster) int {
+	// 3 generic core config resoures: fips, ssh and haproxy.
+	expected := 3
+	if len(hcluster.Spec.ImageContentSources) > 0 {
+		// additional core config resource created when image content source specified.
+		expected += 1
+	}
+	return expected
+}
+
+// Config returns the config the ignition payload of nodePool is generated from, the
+// way the NodePool controller assembles it, and whether core config resources are
+// missing. It is used to render payloads outside of the controller.
+func (r *NodePoolReconciler) Config(ctx context.Context, nodePool *hyperv1.NodePool, hcluster *hyperv1.HostedCluster, releaseImage *releaseinfo.ReleaseImage) (string, bool, error) {
+	controlPlaneNamespace := manifests.HostedControlPlaneNamespace(hcluster.Namespace, hcluster.Name).Name
+	return r.getConfig(ctx, nodePool, expectedCoreConfigResources(hcluster), controlPlaneNamespace, releaseImage, hcluster)
+}
+
 func (r *NodePoolReconciler) getConfig(ctx context.Context,
 	nodePool *hyperv1.NodePool,
 	expectedCoreConfigResources int,
@@ -1742,13 +1798,24 @@ func defaultAndValidateConfigManifest(manifest []byte) ([]byte, error) {
 	return manifest, err
 }
 
-func (r *NodePoolReconciler) getReleaseImage(ctx context.Context, hostedCluster *hyperv1.HostedCluster, currentVersion string, releaseImage string) (*releaseinfo.ReleaseImage, error) {
+// getReleaseImage validates releaseImage and returns its metadata, and the image to pull
+// it from, which is pinned to the digest verified if release images are verified.
+func (r *NodePoolReconciler) getReleaseImage(ctx context.Context, hostedCluster *hyperv1.HostedCluster, currentVersion string, releaseImage string) (*releaseinfo.ReleaseImage, string, error) {
 	pullSecret := &corev1.Secret{}
 	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: hostedCluster.Namespace, Name: hostedCluster.Spec.PullSecret.Name}, pullSecret); err != nil {
-		return nil, fmt.Errorf("cannot get pull secret %s/%s: %w", hostedCluster.Namespace, hostedCluster.Spec.PullSecret.Name, err)
+		return nil, "", fmt.Errorf("cannot get pull secret %s/%s: %w", hostedCluster.Namespace, hostedCluster.Spec.PullSecret.Name, err)
 	}
 	if _, hasKey := pullSecret.Data[corev1.DockerConfigJsonKey]; !hasKey {
-		return nil, fmt.Errorf("pull secret %s/%s missing %q key", pullSecret.Namespace, pullSecret.Name, corev1.DockerConfigJsonKey)
+		return nil, "", fmt.Errorf("pull secret %s/%s missing %q key", pullSecret.Namespace, pullSecret.Name, corev1.DockerConfigJsonKey)
+	}
+	// The release image is verified even if it is the one of the HostedCluster, to pin it
+	// to the digest verified. Verified digests are not verified again.
+	if r.ReleaseVerifier != nil {
+		d, err := r.ReleaseVerifier.Verify(ctx, releaseImage, pullSecret.Data[corev1.DockerConfigJsonKey], verify.MirrorsFromImageContentSources(hostedCluster.Spec.ImageContentSources))
+		if err != nil {
+			return nil, "", err
+		}
+		releaseImage = verify.PinnedImage(releaseImage, d)
 	}
 	ReleaseImage, err := func(ctx context.Context) (*releaseinfo.ReleaseImage, error) {
 		lookupCtx, lookupCancel := context.WithTimeout(ctx, 1*time.Minute)
@@ -1760,19 +1827,19 @@ func (r *NodePoolReconciler) getReleaseImage(ctx context.Context, hostedCluster
 		return img, nil
 	}(ctx)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	wantedVersion, err := semver.Parse(ReleaseImage.Version())
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var currentVersionParsed semver.Version
 	if currentVersion != "" {
 		currentVersionParsed, err = semver.Parse(currentVersion)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
@@ -1784,14 +1851,14 @@ func (r *NodePoolReconciler) getReleaseImage(ctx context.Context, hostedCluster
 
 	releaseInfo, err := r.ReleaseProvider.Lookup(ctx, hostedCluster.Spec.Release.Image, pullSecret.Data[corev1.DockerConfigJsonKey])
 	if err != nil {
-		return nil, fmt.Errorf("failed to lookup release image: %w", err)
+		return nil, "", fmt.Errorf("failed to lookup release image: %w", err)
 	}
 	hostedClusterVersion, err := semver.Parse(releaseInfo.Version())
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return ReleaseImage, supportedversion.IsValidReleaseVersion(&wantedVersion, &currentVersionParsed, &hostedClusterVersion, &minSupportedVersion, hostedCluster.Spec.Networking.NetworkType, hostedCluster.Spec.Platform.Type)
+	return ReleaseImage, releaseImage, supportedversion.IsValidReleaseVersion(&wantedVersion, &currentVersionParsed, &hostedClusterVersion, &minSupportedVersion, hostedCluster.Spec.Networking.NetworkType, hostedCluster.Spec.Platform.Type)
 }
 
 func isUpdatingVersion(nodePool *hyperv1.NodePool, targetVersion string) bool {
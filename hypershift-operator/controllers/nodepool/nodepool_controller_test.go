@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
 	"github.com/openshift/api/image/docker10"
 	imagev1 "github.com/openshift/api/image/v1"
 	api "github.com/openshift/hypershift/api"
@@ -17,6 +18,8 @@ import (
 	"github.com/openshift/hypershift/hypershift-operator/controllers/manifests"
 	ignserver "github.com/openshift/hypershift/ignition-server/controllers"
 	"github.com/openshift/hypershift/support/releaseinfo"
+	fakereleaseprovider "github.com/openshift/hypershift/support/releaseinfo/fake"
+	"github.com/openshift/hypershift/support/releaseinfo/verify"
 	"github.com/openshift/hypershift/support/thirdparty/library-go/pkg/image/dockerv1client"
 	"github.com/openshift/hypershift/support/upsert"
 	"github.com/openshift/hypershift/support/util/fakeimagemetadataprovider"
@@ -1903,3 +1906,92 @@ func TestTaintsToJSON(t *testing.T) {
 		})
 	}
 }
+
+type fakeReleaseVerifier struct {
+	verified []string
+	err      error
+}
+
+func (v *fakeReleaseVerifier) Verify(_ context.Context, image string, _ []byte, _ verify.Mirrors) (digest.Digest, error) {
+	v.verified = append(v.verified, image)
+	if v.err != nil {
+		return "", v.err
+	}
+	return "sha256:abc", nil
+}
+
+func TestGetReleaseImageVerification(t *testing.T) {
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "pull-secret"},
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: nil},
+	}
+	hostedCluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "example"},
+		Spec: hyperv1.HostedClusterSpec{
+			PullSecret: corev1.LocalObjectReference{Name: "pull-secret"},
+			Release:    hyperv1.Release{Image: "image-4.12.0"},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		hostedCluster    *hyperv1.HostedCluster
+		releaseImage     string
+		verifyErr        error
+		expectedVerified []string
+		expectedImage    string
+		expectedError    func(error) bool
+	}{
+		{
+			name:             "When the NodePool uses the release image of its HostedCluster it should verify it and pin it to its digest",
+			hostedCluster:    hostedCluster,
+			releaseImage:     "image-4.12.0",
+			expectedVerified: []string{"image-4.12.0"},
+			expectedImage:    "image-4.12.0@sha256:abc",
+		},
+		{
+			name:             "When the NodePool uses another release image it should verify it and pin it to its digest",
+			hostedCluster:    hostedCluster,
+			releaseImage:     "image-4.11.0",
+			expectedVerified: []string{"image-4.11.0"},
+			expectedImage:    "image-4.11.0@sha256:abc",
+		},
+		{
+			name:             "When the release image is not signed it should fail with an unverified error",
+			hostedCluster:    hostedCluster,
+			releaseImage:     "image-4.11.0",
+			verifyErr:        &verify.UnverifiedError{Image: "image-4.11.0", Digest: "sha256:abc"},
+			expectedVerified: []string{"image-4.11.0"},
+			expectedError:    verify.IsUnverified,
+		},
+		{
+			name:             "When the signature stores can't be read it should fail with a transient error",
+			hostedCluster:    hostedCluster,
+			releaseImage:     "image-4.11.0",
+			verifyErr:        &verify.TransientError{Image: "image-4.11.0", Digest: "sha256:abc"},
+			expectedVerified: []string{"image-4.11.0"},
+			expectedError:    verify.IsTransient,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			verifier := &fakeReleaseVerifier{err: tc.verifyErr}
+			r := &NodePoolReconciler{
+				Client: fake.NewClientBuilder().WithObjects(pullSecret).Build(),
+				ReleaseProvider: &fakereleaseprovider.FakeReleaseProvider{
+					ImageVersion: map[string]string{"image-4.11.0": "4.11.0", "image-4.12.0": "4.12.0", "image-4.11.0@sha256:abc": "4.11.0", "image-4.12.0@sha256:abc": "4.12.0"},
+				},
+				ReleaseVerifier: verifier,
+			}
+			_, image, err := r.getReleaseImage(context.Background(), tc.hostedCluster, "", tc.releaseImage)
+			if tc.expectedError != nil {
+				g.Expect(tc.expectedError(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(image).To(Equal(tc.expectedImage))
+			}
+			g.Expect(verifier.verified).To(Equal(tc.expectedVerified))
+		})
+	}
+}
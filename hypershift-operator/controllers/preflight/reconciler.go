@@ -0,0 +1,141 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	manifests "github.com/openshift/hypershift/hypershift-operator/controllers/manifests/preflight"
+	"github.com/openshift/hypershift/support/preflight"
+	"github.com/openshift/hypershift/support/upsert"
+)
+
+const (
+	configMapKey   = "report"
+	preflightLabel = "hypershift.openshift.io/preflight-report"
+
+	// checkInterval is the interval at which the management cluster is checked again,
+	// as most of the checked resources are not watched.
+	checkInterval = 10 * time.Minute
+)
+
+// checkOptions are the options of a HostedCluster created with the API defaults.
+var checkOptions = preflight.Options{
+	Stage:              preflight.StageCreate,
+	AvailabilityPolicy: hyperv1.SingleReplica,
+	PublishingStrategy: hyperv1.LoadBalancer,
+}
+
+// Reconciler periodically runs the preflight checks and reports the results in a ConfigMap
+// in the operator namespace.
+type Reconciler struct {
+	client.Client
+	upsert.CreateOrUpdateProvider
+	namespace string
+	run       func(ctx context.Context, opts preflight.Options) []preflight.Result
+	now       func() time.Time
+}
+
+func New(c client.Client, createOrUpdateProvider upsert.CreateOrUpdateProvider, checker *preflight.Checker, namespace string) *Reconciler {
+	return &Reconciler{
+		Client:                 c,
+		CreateOrUpdateProvider: createOrUpdateProvider,
+		namespace:              namespace,
+		run:                    checker.Run,
+		now:                    time.Now,
+	}
+}
+
+func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
+	// A channel is used to generate an initial sync event.
+	// Afterwards, the controller requeues periodically and syncs on the ConfigMap.
+	initialSync := make(chan event.GenericEvent)
+	err := ctrl.NewControllerManagedBy(mgr).
+		Named("preflight").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(r.selectReportConfigMap))).
+		Watches(&source.Channel{Source: initialSync}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %w", err)
+	}
+	go func() {
+		initialSync <- event.GenericEvent{Object: manifests.ConfigMap(r.namespace)}
+	}()
+	return nil
+}
+
+type report struct {
+	CheckedAt metav1.Time        `json:"checkedAt"`
+	Status    preflight.Status   `json:"status"`
+	Results   []preflight.Result `json:"results"`
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	cm := manifests.ConfigMap(r.namespace)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cm), cm); err == nil {
+		// Updates of the report itself trigger a reconcile, only check again once it is outdated
+		existing := &report{}
+		if err := json.Unmarshal([]byte(cm.Data[configMapKey]), existing); err == nil {
+			if age := r.now().Sub(existing.CheckedAt.Time); age >= 0 && age < checkInterval {
+				return reconcile.Result{RequeueAfter: checkInterval - age}, nil
+			}
+		}
+	}
+
+	results := r.run(ctx, checkOptions)
+	status := preflight.StatusPass
+	for _, result := range results {
+		switch result.Status {
+		case preflight.StatusFail:
+			status = preflight.StatusFail
+			log.Info("Management cluster preflight check failed", "check", result.Name, "message", result.Message, "remediation", result.Remediation)
+		case preflight.StatusWarn:
+			if status == preflight.StatusPass {
+				status = preflight.StatusWarn
+			}
+		}
+	}
+	content, err := json.Marshal(&report{
+		CheckedAt: metav1.NewTime(r.now()),
+		Status:    status,
+		Results:   results,
+	})
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("cannot marshal report: %w", err)
+	}
+
+	cm = manifests.ConfigMap(r.namespace)
+	if _, err := r.CreateOrUpdate(ctx, r, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[preflightLabel] = "true"
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[configMapKey] = string(content)
+		return nil
+	}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update preflight report configmap: %w", err)
+	}
+	return reconcile.Result{RequeueAfter: checkInterval}, nil
+}
+
+func (r *Reconciler) selectReportConfigMap(obj client.Object) bool {
+	return obj.GetNamespace() == r.namespace && obj.GetName() == manifests.ConfigMap(r.namespace).Name
+}
@@ -0,0 +1,60 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	manifests "github.com/openshift/hypershift/hypershift-operator/controllers/manifests/preflight"
+	"github.com/openshift/hypershift/support/preflight"
+	"github.com/openshift/hypershift/support/upsert"
+)
+
+func TestReconcile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := fake.NewClientBuilder().Build()
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	runs := 0
+	reconciler := &Reconciler{
+		Client:                 c,
+		CreateOrUpdateProvider: upsert.New(true),
+		namespace:              "hypershift",
+		now:                    func() time.Time { return now },
+		run: func(ctx context.Context, opts preflight.Options) []preflight.Result {
+			runs++
+			return []preflight.Result{
+				{Name: "RequiredAPIs", Status: preflight.StatusPass},
+				{Name: "EtcdStorage", Status: preflight.StatusWarn, Remediation: "use WaitForFirstConsumer"},
+			}
+		},
+	}
+
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(checkInterval))
+	cm := manifests.ConfigMap("hypershift")
+	g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+	data := &report{}
+	g.Expect(json.Unmarshal([]byte(cm.Data[configMapKey]), data)).To(Succeed())
+	g.Expect(data.Status).To(Equal(preflight.StatusWarn))
+	g.Expect(data.Results).To(HaveLen(2))
+	g.Expect(runs).To(Equal(1))
+
+	// The update of the report triggers a reconcile that must not check again
+	now = now.Add(time.Minute)
+	result, err = reconciler.Reconcile(context.Background(), reconcile.Request{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(checkInterval - time.Minute))
+	g.Expect(runs).To(Equal(1))
+
+	now = now.Add(checkInterval)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(runs).To(Equal(2))
+}
@@ -29,16 +29,20 @@ import (
 	hyperapi "github.com/openshift/hypershift/api"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	awsutil "github.com/openshift/hypershift/cmd/infra/aws/util"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/controlplanesizing"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/hostedcluster"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/platform/aws"
+	"github.com/openshift/hypershift/hypershift-operator/controllers/preflight"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/proxy"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/supportedversion"
 	"github.com/openshift/hypershift/hypershift-operator/controllers/uwmtelemetry"
 	"github.com/openshift/hypershift/pkg/version"
 	"github.com/openshift/hypershift/support/capabilities"
 	"github.com/openshift/hypershift/support/metrics"
+	supportpreflight "github.com/openshift/hypershift/support/preflight"
 	"github.com/openshift/hypershift/support/releaseinfo"
+	"github.com/openshift/hypershift/support/releaseinfo/verify"
 	"github.com/openshift/hypershift/support/upsert"
 	"github.com/openshift/hypershift/support/util"
 	hyperutil "github.com/openshift/hypershift/support/util"
@@ -90,6 +94,10 @@ type StartOptions struct {
 	OIDCStorageProviderS3Region      string
 	OIDCStorageProviderS3Credentials string
 	EnableUWMTelemetryRemoteWrite    bool
+	EnableControlPlaneSizing         bool
+	ReleaseSignatureKeysDir          string
+	ReleaseSignatureStores           []string
+	ReleaseMetadataCacheDir          string
 }
 
 func NewStartCommand() *cobra.Command {
@@ -127,6 +135,10 @@ func NewStartCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3BucketName, "oidc-storage-provider-s3-bucket-name", "", "Name of the bucket in which to store the clusters OIDC discovery information. Required for AWS guest clusters")
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3Region, "oidc-storage-provider-s3-region", opts.OIDCStorageProviderS3Region, "Region in which the OIDC bucket is located. Required for AWS guest clusters")
 	cmd.Flags().StringVar(&opts.OIDCStorageProviderS3Credentials, "oidc-storage-provider-s3-credentials", opts.OIDCStorageProviderS3Credentials, "Location of the credentials file for the OIDC bucket. Required for AWS guest clusters.")
+	cmd.Flags().BoolVar(&opts.EnableControlPlaneSizing, "enable-control-plane-sizing", opts.EnableControlPlaneSizing, "If true, enables a controller that sizes the API servers and etcd of control planes based on the load of their guest cluster")
+	cmd.Flags().StringVar(&opts.ReleaseSignatureKeysDir, "release-signature-keys-dir", opts.ReleaseSignatureKeysDir, "Directory with the GPG keyrings and cosign public keys trusted to sign release images. If set, HostedClusters are only rolled out to release images signed by a trusted key")
+	cmd.Flags().StringSliceVar(&opts.ReleaseSignatureStores, "release-signature-stores", opts.ReleaseSignatureStores, "Locations of the release image signatures, either http(s) or file URLs or local directories. Required with --release-signature-keys-dir")
+	cmd.Flags().StringVar(&opts.ReleaseMetadataCacheDir, "release-metadata-cache-dir", opts.ReleaseMetadataCacheDir, "Directory in which to persist the metadata of release images, so it is not pulled again after restarts. Metadata is only cached in memory if empty")
 	cmd.Flags().BoolVar(&opts.EnableUWMTelemetryRemoteWrite, "enable-uwm-telemetry-remote-write", opts.EnableUWMTelemetryRemoteWrite, "If true, enables a controller that ensures user workload monitoring is enabled and that it is configured to remote write telemetry metrics from control planes")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
@@ -225,15 +237,19 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 	}
 	log.Info("Using metrics set", "set", metricsSet.String())
 
+	// The release metadata cache is shared by all controllers, so each release is
+	// only pulled from the registry once.
+	releaseProvider := releaseinfo.NewCachedProvider(&releaseinfo.RegistryClientProvider{}, releaseinfo.CachedProviderOptions{
+		Dir:               opts.ReleaseMetadataCacheDir,
+		RegistryOverrides: opts.RegistryOverrides,
+	})
+
 	hostedClusterReconciler := &hostedcluster.HostedClusterReconciler{
 		Client:                        mgr.GetClient(),
 		ManagementClusterCapabilities: mgmtClusterCaps,
 		HypershiftOperatorImage:       operatorImage,
 		ReleaseProvider: &releaseinfo.RegistryMirrorProviderDecorator{
-			Delegate: &releaseinfo.CachedProvider{
-				Inner: &releaseinfo.RegistryClientProvider{},
-				Cache: map[string]*releaseinfo.ReleaseImage{},
-			},
+			Delegate:          releaseProvider,
 			RegistryOverrides: opts.RegistryOverrides,
 		},
 		EnableOCPClusterMonitoring: opts.EnableOCPClusterMonitoring,
@@ -241,6 +257,14 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 		ImageMetadataProvider:      &util.RegistryClientImageMetadataProvider{},
 		MetricsSet:                 metricsSet,
 	}
+	if opts.ReleaseSignatureKeysDir != "" {
+		releaseVerifier, err := verify.NewSignatureVerifier(opts.ReleaseSignatureKeysDir, opts.ReleaseSignatureStores)
+		if err != nil {
+			return fmt.Errorf("failed to set up release signature verification: %w", err)
+		}
+		releaseVerifier.RegistryOverrides = opts.RegistryOverrides
+		hostedClusterReconciler.ReleaseVerifier = releaseVerifier
+	}
 	if opts.OIDCStorageProviderS3BucketName != "" {
 		awsSession := awsutil.NewSession("hypershift-operator-oidc-bucket", opts.OIDCStorageProviderS3Credentials, "", "", opts.OIDCStorageProviderS3Region)
 		awsConfig := awsutil.NewConfig()
@@ -260,12 +284,10 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 	if err := (&nodepool.NodePoolReconciler{
 		Client: mgr.GetClient(),
 		ReleaseProvider: &releaseinfo.RegistryMirrorProviderDecorator{
-			Delegate: &releaseinfo.CachedProvider{
-				Inner: &releaseinfo.RegistryClientProvider{},
-				Cache: map[string]*releaseinfo.ReleaseImage{},
-			},
+			Delegate:          releaseProvider,
 			RegistryOverrides: opts.RegistryOverrides,
 		},
+		ReleaseVerifier:         hostedClusterReconciler.ReleaseVerifier,
 		CreateOrUpdateProvider:  createOrUpdate,
 		HypershiftOperatorImage: operatorImage,
 		ImageMetadataProvider:   &util.RegistryClientImageMetadataProvider{},
@@ -296,6 +318,25 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 		return fmt.Errorf("unable to create supported version controller: %w", err)
 	}
 
+	// The preflight checks read from the cache, except for pods which are not cached.
+	// Only the requests of control plane pods are counted against the capacity of
+	// nodes, so that all pods are not listed at every check.
+	preflightChecker, err := supportpreflight.NewChecker(mgr.GetClient(), mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("unable to create preflight checker: %w", err)
+	}
+	preflightChecker.Pods = mgr.GetAPIReader()
+	preflightChecker.PodSelector, err = metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: hyperutil.HCPRouteLabel, Operator: metav1.LabelSelectorOpExists}},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create preflight pod selector: %w", err)
+	}
+	if err := (preflight.New(mgr.GetClient(), createOrUpdate, preflightChecker, opts.Namespace).
+		SetupWithManager(mgr)); err != nil {
+		return fmt.Errorf("unable to create preflight controller: %w", err)
+	}
+
 	// If enabled, start controller to ensure UWM stack is enabled and configured
 	// to remote write telemetry metrics
 	if opts.EnableUWMTelemetryRemoteWrite {
@@ -308,6 +349,12 @@ func run(ctx context.Context, opts *StartOptions, log logr.Logger) error {
 		}
 	}
 
+	if opts.EnableControlPlaneSizing {
+		if err := controlplanesizing.New(mgr.GetClient()).SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to create control plane sizing controller: %w", err)
+		}
+	}
+
 	// The mgr and therefore the cache is not started yet, thus we have to construct a client that
 	// directly reads from the api.
 	apiReadingClient, err := crclient.NewDelegatingClient(crclient.NewDelegatingClientInput{
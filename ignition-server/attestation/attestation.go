@@ -0,0 +1,205 @@
+// Package attestation verifies that ignition payloads are requested by instances of
+// the cluster they belong to, in addition to the bearer token of their NodePool.
+//
+// Instances present evidence of their identity, which is specific to their
+// platform, in the headers of the payload request. Ignition can only send static
+// headers, so the evidence is added by a Proxy running on the instance, which
+// Ignition is pointed to. A Verifier checks the evidence and resolves the CAPI
+// Machine of the instance, which also allows payloads to be served only to the
+// first instance of every Machine.
+package attestation
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AttestationDocumentHeader carries the signed identity document of the
+	// instance: the base64 encoded instance identity document on AWS, and the
+	// base64 encoded PKCS#7 attested data on Azure.
+	AttestationDocumentHeader = "X-Ignition-Attestation-Document"
+	// AttestationSignatureHeader carries the base64 encoded signature of the
+	// instance identity document on AWS. The attested data of Azure is signed
+	// in the document itself.
+	AttestationSignatureHeader = "X-Ignition-Attestation-Signature"
+	// AttestationInstanceHeader carries the name of the instance on Azure, used to
+	// find its machine. It is not trusted: the VM ID of the machine is checked
+	// against the attested data.
+	AttestationInstanceHeader = "X-Ignition-Attestation-Instance"
+
+	// NoncePath is the path of the ignition server that issues the nonces
+	// instances must include in their attested data, for the token they bear.
+	NoncePath = "/attestation/nonce"
+
+	// TokenConsumedAnnotation is set on a CAPI Machine once its instance has been
+	// served a payload, when tokens are single use. Its value is the ID of the
+	// instance, which is served the payload again if it retries.
+	TokenConsumedAnnotation = "hypershift.openshift.io/ignition-token-consumed"
+)
+
+// Identity is the verified identity of the instance requesting a payload.
+type Identity struct {
+	// InstanceID is the platform specific identifier of the instance.
+	InstanceID string
+	// Machine is the CAPI Machine of the instance.
+	Machine client.ObjectKey
+}
+
+// Verifier verifies the identity evidence presented in payload requests bearing
+// token.
+type Verifier interface {
+	Verify(ctx context.Context, r *http.Request, token []byte) (*Identity, error)
+}
+
+// Options configure the Verifier of a platform.
+type Options struct {
+	// Namespace is the control plane namespace, where the machines are.
+	Namespace string
+	// InfraID is the infra ID of the cluster the instances must belong to.
+	InfraID string
+	// Region is the expected AWS region of the instances.
+	Region string
+	// AccountID is the expected AWS account of the instances.
+	AccountID string
+	// SubscriptionID is the expected Azure subscription of the instances.
+	SubscriptionID string
+	// CertificatesFile contains the PEM encoded certificates the identity documents
+	// are signed with on AWS, and the roots of their signing certificates on Azure.
+	// It is optional on Azure, where the system roots are used by default.
+	CertificatesFile string
+}
+
+// NewVerifier returns the Verifier of platform.
+func NewVerifier(platform hyperv1.PlatformType, c client.Reader, opts Options) (Verifier, error) {
+	if opts.InfraID == "" {
+		return nil, fmt.Errorf("the infra ID is required for attestation")
+	}
+	switch platform {
+	case hyperv1.AWSPlatform:
+		if opts.Region == "" || opts.AccountID == "" {
+			return nil, fmt.Errorf("the region and account ID are required for attestation on AWS")
+		}
+		certificates, err := readCertificates(opts.CertificatesFile)
+		if err != nil {
+			return nil, err
+		}
+		return &AWSVerifier{Client: c, Namespace: opts.Namespace, InfraID: opts.InfraID, Region: opts.Region, AccountID: opts.AccountID, Certificates: certificates}, nil
+	case hyperv1.AzurePlatform:
+		if opts.SubscriptionID == "" {
+			return nil, fmt.Errorf("the subscription ID is required for attestation on Azure")
+		}
+		roots, err := azureRoots(opts.CertificatesFile)
+		if err != nil {
+			return nil, err
+		}
+		vmID, err := NewAzureVMIDFunc(opts.SubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		return &AzureVerifier{Client: c, Namespace: opts.Namespace, InfraID: opts.InfraID, SubscriptionID: opts.SubscriptionID, Roots: roots, VMID: vmID, Now: time.Now}, nil
+	default:
+		return nil, fmt.Errorf("attestation is not supported on platform %s", platform)
+	}
+}
+
+// ClaimMachine records on the CAPI Machine of identity that its instance is served
+// a payload, and fails if the payload was served to another instance before. The
+// Machine is updated before the payload is served, so concurrent requests of
+// different instances can't both claim it. The same instance can claim its Machine
+// again, so it is served the payload if its first request failed.
+func ClaimMachine(ctx context.Context, c client.Client, reader client.Reader, identity *Identity) error {
+	m := &capiv1.Machine{}
+	if err := reader.Get(ctx, identity.Machine, m); err != nil {
+		return fmt.Errorf("failed to get machine %s: %w", identity.Machine, err)
+	}
+	if instanceID, consumed := m.Annotations[TokenConsumedAnnotation]; consumed {
+		if instanceID == identity.InstanceID {
+			return nil
+		}
+		return fmt.Errorf("machine %s was already served a payload", identity.Machine)
+	}
+	if m.Annotations == nil {
+		m.Annotations = map[string]string{}
+	}
+	m.Annotations[TokenConsumedAnnotation] = identity.InstanceID
+	if err := c.Update(ctx, m); err != nil {
+		return fmt.Errorf("failed to claim machine %s: %w", identity.Machine, err)
+	}
+	return nil
+}
+
+// machineOf returns the CAPI Machine owning infraMachine, after checking that it
+// belongs to the cluster with infraID.
+func machineOf(infraMachine client.Object, infraID string) (client.ObjectKey, error) {
+	if cluster := infraMachine.GetLabels()[capiv1.ClusterLabelName]; cluster != infraID {
+		return client.ObjectKey{}, fmt.Errorf("%s belongs to cluster %q instead of %q", infraMachine.GetName(), cluster, infraID)
+	}
+	for _, owner := range infraMachine.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(owner.APIVersion)
+		if err == nil && gv.Group == capiv1.GroupVersion.Group && owner.Kind == "Machine" {
+			return client.ObjectKey{Namespace: infraMachine.GetNamespace(), Name: owner.Name}, nil
+		}
+	}
+	return client.ObjectKey{}, fmt.Errorf("%s has no machine", infraMachine.GetName())
+}
+
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	if file == "" {
+		return nil, fmt.Errorf("a certificates file is required for attestation")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificates: %w", err)
+	}
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return certificates, nil
+}
+
+// azureRoots returns the roots of the certificates of Azure attested data: the
+// certificates in file if it exists, and the system roots otherwise.
+func azureRoots(file string) (*x509.CertPool, error) {
+	if file != "" {
+		if _, err := os.Stat(file); err == nil {
+			certificates, err := readCertificates(file)
+			if err != nil {
+				return nil, err
+			}
+			roots := x509.NewCertPool()
+			for _, certificate := range certificates {
+				roots.AddCert(certificate)
+			}
+			return roots, nil
+		}
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the system roots: %w", err)
+	}
+	return roots, nil
+}
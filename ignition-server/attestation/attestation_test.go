@@ -0,0 +1,346 @@
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.mozilla.org/pkcs7"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	capiaws "sigs.k8s.io/cluster-api-provider-aws/v2/api/v1beta2"
+	capiazure "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+)
+
+const (
+	namespace = "clusters-example"
+	infraID   = "example-abcde"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *rsa.PrivateKey
+}
+
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	issuer, signer := template, key
+	if parent != nil {
+		issuer, signer = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{certificate: certificate, key: key}
+}
+
+// signPKCS7 returns the DER encoded PKCS#7 SignedData of content, signed by signer
+// with SHA-256 and authenticated attributes, as Azure signs attested data.
+func signPKCS7(t *testing.T, content []byte, signer *testCertificate) []byte {
+	sd, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSigner(signer.certificate, signer.key, pkcs7.SignerInfoConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	der, err := sd.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func machineObjects(infraMachine client.Object) []client.Object {
+	infraMachine.SetNamespace(namespace)
+	infraMachine.SetLabels(map[string]string{capiv1.ClusterLabelName: infraID})
+	infraMachine.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: capiv1.GroupVersion.String(), Kind: "Machine", Name: "machine-0"}})
+	return []client.Object{
+		&capiv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "machine-0"}},
+		infraMachine,
+	}
+}
+
+var token = []byte("token")
+
+func request(headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/ignition", nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestAWSVerifier(t *testing.T) {
+	signer := newTestCertificate(t, "aws", nil)
+	other := newTestCertificate(t, "other", nil)
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(machineObjects(&capiaws.AWSMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-workers-abcde"},
+		Spec:       capiaws.AWSMachineSpec{InstanceID: pointer.String("i-0123456789")},
+	})...).Build()
+	verifier := &AWSVerifier{Client: c, Namespace: namespace, InfraID: infraID, Region: "us-east-1", AccountID: "123456789012", Certificates: []*x509.Certificate{other.certificate, signer.certificate}}
+
+	headers := func(document string, key *rsa.PrivateKey) map[string]string {
+		digest := sha256.Sum256([]byte(document))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]string{
+			AttestationDocumentHeader:  base64.StdEncoding.EncodeToString([]byte(document)),
+			AttestationSignatureHeader: base64.StdEncoding.EncodeToString(signature),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		headers       map[string]string
+		expectedError string
+	}{
+		{
+			name:    "When the document is signed for an instance of the cluster it should return its machine",
+			headers: headers(`{"instanceId":"i-0123456789","accountId":"123456789012","region":"us-east-1"}`, signer.key),
+		},
+		{
+			name:          "When the document is signed with another key it should fail",
+			headers:       headers(`{"instanceId":"i-0123456789","accountId":"123456789012","region":"us-east-1"}`, newTestCertificate(t, "attacker", nil).key),
+			expectedError: "signature is not valid",
+		},
+		{
+			name:          "When the instance is in another region it should fail",
+			headers:       headers(`{"instanceId":"i-0123456789","accountId":"123456789012","region":"eu-west-1"}`, signer.key),
+			expectedError: "instead of \"us-east-1\"",
+		},
+		{
+			name:          "When the instance is in another account it should fail",
+			headers:       headers(`{"instanceId":"i-0123456789","accountId":"210987654321","region":"us-east-1"}`, signer.key),
+			expectedError: "instead of \"123456789012\"",
+		},
+		{
+			name:          "When the instance is not a machine of the cluster it should fail",
+			headers:       headers(`{"instanceId":"i-9876543210","accountId":"123456789012","region":"us-east-1"}`, signer.key),
+			expectedError: "is not a machine of cluster",
+		},
+		{
+			name:          "When there is no document it should fail",
+			headers:       map[string]string{},
+			expectedError: "missing or invalid instance identity document",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			identity, err := verifier.Verify(context.Background(), request(tc.headers), token)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(identity).To(Equal(&Identity{InstanceID: "i-0123456789", Machine: client.ObjectKey{Namespace: namespace, Name: "machine-0"}}))
+		})
+	}
+}
+
+func TestAzureVerifier(t *testing.T) {
+	root := newTestCertificate(t, "root", nil)
+	signer := newTestCertificate(t, "metadata.azure.com", root)
+	now := time.Now()
+	roots := x509.NewCertPool()
+	roots.AddCert(root.certificate)
+	objects := append(machineObjects(&capiazure.AzureMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-workers-abcde"},
+		Spec: capiazure.AzureMachineSpec{
+			ProviderID: pointer.String("azure:///subscriptions/subscription/resourceGroups/example-abcde/providers/Microsoft.Compute/virtualMachines/example-workers-abcde"),
+		},
+	}), &capiazure.AzureMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            "example-workers-fghij",
+			Labels:          map[string]string{capiv1.ClusterLabelName: infraID},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: capiv1.GroupVersion.String(), Kind: "Machine", Name: "machine-1"}},
+		},
+	})
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(objects...).Build()
+	verifier := &AzureVerifier{
+		Client:         c,
+		Namespace:      namespace,
+		InfraID:        infraID,
+		SubscriptionID: "subscription",
+		Roots:          roots,
+		VMID: func(_ context.Context, resourceGroup, name string) (string, error) {
+			if resourceGroup == "example-abcde" && name == "example-workers-abcde" {
+				return "VM-ID", nil
+			}
+			return "", fmt.Errorf("virtual machine %s not found", name)
+		},
+		Now: func() time.Time { return now },
+	}
+
+	document := func(vmID, subscription, nonce string, createdOn time.Time) []byte {
+		data := attestedData{Nonce: nonce, VMID: vmID, SubscriptionID: subscription}
+		data.TimeStamp.CreatedOn = createdOn.Format(attestedDataTimeLayout)
+		data.TimeStamp.ExpiresOn = createdOn.Add(6 * time.Hour).Format(attestedDataTimeLayout)
+		content, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+	headers := func(message []byte, name string) map[string]string {
+		return map[string]string{
+			AttestationDocumentHeader: base64.StdEncoding.EncodeToString(message),
+			AttestationInstanceHeader: name,
+		}
+	}
+	nonce := NewNonce(token, now)
+	tampered := signPKCS7(t, document("vm-id", "subscription", nonce, now), signer)
+	tampered[len(tampered)-1] ^= 0xff
+
+	testCases := []struct {
+		name          string
+		headers       map[string]string
+		expectedError string
+	}{
+		{
+			name:    "When the attested data is signed for the VM of a machine of the cluster it should return its machine",
+			headers: headers(signPKCS7(t, document("vm-id", "SUBSCRIPTION", nonce, now), signer), "example-workers-abcde"),
+		},
+		{
+			name:          "When the attested data is signed by an untrusted certificate it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "subscription", nonce, now), newTestCertificate(t, "metadata.azure.com", nil)), "example-workers-abcde"),
+			expectedError: "signature is not valid",
+		},
+		{
+			name:          "When the signature is not valid it should fail",
+			headers:       headers(tampered, "example-workers-abcde"),
+			expectedError: "signature is not valid",
+		},
+		{
+			name:          "When the VM is in another subscription it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "other", nonce, now), signer), "example-workers-abcde"),
+			expectedError: "instead of \"subscription\"",
+		},
+		{
+			name:          "When the attested data has expired it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "subscription", nonce, now.Add(-7*time.Hour)), signer), "example-workers-abcde"),
+			expectedError: "is not valid at",
+		},
+		{
+			name:          "When the attested data has no nonce it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "subscription", "", now), signer), "example-workers-abcde"),
+			expectedError: "invalid nonce",
+		},
+		{
+			name:          "When the nonce was issued for another token it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "subscription", NewNonce([]byte("other"), now), now), signer), "example-workers-abcde"),
+			expectedError: "is not valid or has expired",
+		},
+		{
+			name:          "When another VM claims the machine it should fail",
+			headers:       headers(signPKCS7(t, document("other-vm-id", "subscription", nonce, now), signer), "example-workers-abcde"),
+			expectedError: "is not the VM of machine example-workers-abcde",
+		},
+		{
+			name:          "When the machine has no provider ID yet it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "subscription", nonce, now), signer), "example-workers-fghij"),
+			expectedError: "has no provider ID",
+		},
+		{
+			name:          "When the VM is not a machine of the cluster it should fail",
+			headers:       headers(signPKCS7(t, document("vm-id", "subscription", nonce, now), signer), "other"),
+			expectedError: "is not a machine of cluster",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			identity, err := verifier.Verify(context.Background(), request(tc.headers), token)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(identity).To(Equal(&Identity{InstanceID: "vm-id", Machine: client.ObjectKey{Namespace: namespace, Name: "machine-0"}}))
+		})
+	}
+}
+
+func TestNonce(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Now()
+	nonce := NewNonce(token, now)
+	g.Expect(nonce).To(MatchRegexp(`^[0-9]{10}$`))
+	g.Expect(VerifyNonce(token, nonce, now)).To(Succeed())
+	g.Expect(VerifyNonce(token, nonce, now.Add(nonceMaxAge*nonceWindow))).To(Succeed())
+	g.Expect(VerifyNonce(token, nonce, now.Add((nonceMaxAge+1)*nonceWindow))).To(MatchError(ContainSubstring("has expired")))
+	g.Expect(VerifyNonce([]byte("other"), nonce, now)).ToNot(Succeed())
+	g.Expect(VerifyNonce(token, "not-a-nonce", now)).To(MatchError(ContainSubstring("invalid nonce")))
+}
+
+func TestMachineOf(t *testing.T) {
+	g := NewGomegaWithT(t)
+	objects := machineObjects(&capiaws.AWSMachine{ObjectMeta: metav1.ObjectMeta{Name: "example-workers-abcde"}})
+	infraMachine := objects[1]
+
+	infraMachine.SetLabels(map[string]string{capiv1.ClusterLabelName: "other"})
+	_, err := machineOf(infraMachine, infraID)
+	g.Expect(err).To(MatchError(ContainSubstring("belongs to cluster \"other\"")))
+
+	infraMachine.SetLabels(map[string]string{capiv1.ClusterLabelName: infraID})
+	infraMachine.SetOwnerReferences(nil)
+	_, err = machineOf(infraMachine, infraID)
+	g.Expect(err).To(MatchError(ContainSubstring("has no machine")))
+}
+
+func TestClaimMachine(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(machineObjects(&capiaws.AWSMachine{})...).Build()
+	machine := client.ObjectKey{Namespace: namespace, Name: "machine-0"}
+
+	g.Expect(ClaimMachine(ctx, c, c, &Identity{InstanceID: "i-0123456789", Machine: machine})).To(Succeed())
+	claimed := &capiv1.Machine{}
+	g.Expect(c.Get(ctx, machine, claimed)).To(Succeed())
+	g.Expect(claimed.Annotations).To(HaveKeyWithValue(TokenConsumedAnnotation, "i-0123456789"))
+
+	// The instance is served the payload again when it retries.
+	g.Expect(ClaimMachine(ctx, c, c, &Identity{InstanceID: "i-0123456789", Machine: machine})).To(Succeed())
+	g.Expect(ClaimMachine(ctx, c, c, &Identity{InstanceID: "i-9876543210", Machine: machine})).To(MatchError(ContainSubstring("already served a payload")))
+	g.Expect(ClaimMachine(ctx, c, c, &Identity{InstanceID: "i-0123456789", Machine: client.ObjectKey{Namespace: namespace, Name: "missing"}})).ToNot(Succeed())
+}
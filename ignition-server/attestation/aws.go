@@ -0,0 +1,111 @@
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	capiaws "sigs.k8s.io/cluster-api-provider-aws/v2/api/v1beta2"
+	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AWSVerifier verifies EC2 instance identity documents, as returned by the
+// instance metadata service at /latest/dynamic/instance-identity/document, and their
+// RSA SHA-256 signature at /latest/dynamic/instance-identity/signature. Instance
+// identity documents can't include a nonce, so a document is bound to its instance
+// through the machine of the cluster with its instance ID.
+type AWSVerifier struct {
+	Client    client.Reader
+	Namespace string
+	InfraID   string
+	// Region is the expected region of the instances.
+	Region string
+	// AccountID is the expected account of the instances.
+	AccountID string
+	// Certificates are the AWS certificates of the region the documents are signed with.
+	Certificates []*x509.Certificate
+}
+
+var _ Verifier = (*AWSVerifier)(nil)
+
+// instanceIdentityDocument holds the fields of an EC2 instance identity document
+// used for attestation.
+type instanceIdentityDocument struct {
+	InstanceID string `json:"instanceId"`
+	AccountID  string `json:"accountId"`
+	Region     string `json:"region"`
+}
+
+func (v *AWSVerifier) Verify(ctx context.Context, r *http.Request, _ []byte) (*Identity, error) {
+	document, err := base64.StdEncoding.DecodeString(r.Header.Get(AttestationDocumentHeader))
+	if err != nil || len(document) == 0 {
+		return nil, fmt.Errorf("missing or invalid instance identity document")
+	}
+	signature, err := base64.StdEncoding.DecodeString(r.Header.Get(AttestationSignatureHeader))
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("missing or invalid instance identity signature")
+	}
+	if err := v.verifySignature(document, signature); err != nil {
+		return nil, err
+	}
+
+	var identity instanceIdentityDocument
+	if err := json.Unmarshal(document, &identity); err != nil {
+		return nil, fmt.Errorf("invalid instance identity document: %w", err)
+	}
+	if identity.InstanceID == "" {
+		return nil, fmt.Errorf("instance identity document has no instance ID")
+	}
+	if identity.AccountID != v.AccountID {
+		return nil, fmt.Errorf("instance %s is in account %q instead of %q", identity.InstanceID, identity.AccountID, v.AccountID)
+	}
+	if identity.Region != v.Region {
+		return nil, fmt.Errorf("instance %s is in region %q instead of %q", identity.InstanceID, identity.Region, v.Region)
+	}
+
+	machines := &capiaws.AWSMachineList{}
+	if err := v.Client.List(ctx, machines, client.InNamespace(v.Namespace), client.MatchingLabels{capiv1.ClusterLabelName: v.InfraID}); err != nil {
+		return nil, fmt.Errorf("failed to list AWS machines: %w", err)
+	}
+	for i := range machines.Items {
+		awsMachine := &machines.Items[i]
+		if !isAWSInstance(awsMachine, identity.InstanceID) {
+			continue
+		}
+		machine, err := machineOf(awsMachine, v.InfraID)
+		if err != nil {
+			return nil, err
+		}
+		return &Identity{InstanceID: identity.InstanceID, Machine: machine}, nil
+	}
+	return nil, fmt.Errorf("instance %s is not a machine of cluster %s", identity.InstanceID, v.InfraID)
+}
+
+func (v *AWSVerifier) verifySignature(document, signature []byte) error {
+	digest := sha256.Sum256(document)
+	for _, certificate := range v.Certificates {
+		key, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("instance identity document signature is not valid")
+}
+
+func isAWSInstance(awsMachine *capiaws.AWSMachine, instanceID string) bool {
+	if awsMachine.Spec.InstanceID != nil && *awsMachine.Spec.InstanceID == instanceID {
+		return true
+	}
+	return awsMachine.Spec.ProviderID != nil && strings.HasSuffix(*awsMachine.Spec.ProviderID, "/"+instanceID)
+}
@@ -0,0 +1,161 @@
+package attestation
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-11-01/compute"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"go.mozilla.org/pkcs7"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	capiazure "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// attestedDataTimeLayout is the layout of the timestamps of Azure attested data.
+const attestedDataTimeLayout = "01/02/06 15:04:05 -0700"
+
+// VMIDFunc returns the VM ID of the Azure virtual machine name in resourceGroup.
+type VMIDFunc func(ctx context.Context, resourceGroup, name string) (string, error)
+
+// AzureVerifier verifies the attested data of Azure instances, as returned by the
+// instance metadata service at /metadata/attested/document with a nonce issued by
+// the ignition server. The attested data is signed for the VM ID of the instance but
+// not its name, so the instance also sends its name in AttestationInstanceHeader to
+// find its AzureMachine, and the VM ID of the virtual machine of the AzureMachine is
+// checked against the attested data.
+type AzureVerifier struct {
+	Client         client.Reader
+	Namespace      string
+	InfraID        string
+	SubscriptionID string
+	// Roots are the roots of the certificates attested data is signed with.
+	Roots *x509.CertPool
+	// VMID returns the VM ID of the virtual machines of AzureMachines.
+	VMID VMIDFunc
+	Now  func() time.Time
+}
+
+var _ Verifier = (*AzureVerifier)(nil)
+
+// attestedData holds the fields of Azure attested data used for attestation.
+type attestedData struct {
+	Nonce          string `json:"nonce"`
+	VMID           string `json:"vmId"`
+	SubscriptionID string `json:"subscriptionId"`
+	TimeStamp      struct {
+		CreatedOn string `json:"createdOn"`
+		ExpiresOn string `json:"expiresOn"`
+	} `json:"timeStamp"`
+}
+
+func (v *AzureVerifier) Verify(ctx context.Context, r *http.Request, token []byte) (*Identity, error) {
+	document, err := base64.StdEncoding.DecodeString(r.Header.Get(AttestationDocumentHeader))
+	if err != nil || len(document) == 0 {
+		return nil, fmt.Errorf("missing or invalid attested data")
+	}
+	name := r.Header.Get(AttestationInstanceHeader)
+	if name == "" {
+		return nil, fmt.Errorf("missing VM name")
+	}
+	message, err := pkcs7.Parse(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attested data: %w", err)
+	}
+	now := v.Now()
+	if err := message.VerifyWithChainAtTime(v.Roots, now); err != nil {
+		return nil, fmt.Errorf("attested data signature is not valid: %w", err)
+	}
+
+	var data attestedData
+	if err := json.Unmarshal(message.Content, &data); err != nil {
+		return nil, fmt.Errorf("invalid attested data: %w", err)
+	}
+	if data.VMID == "" {
+		return nil, fmt.Errorf("attested data has no VM ID")
+	}
+	if !strings.EqualFold(data.SubscriptionID, v.SubscriptionID) {
+		return nil, fmt.Errorf("VM %s is in subscription %q instead of %q", data.VMID, data.SubscriptionID, v.SubscriptionID)
+	}
+	createdOn, err := time.Parse(attestedDataTimeLayout, data.TimeStamp.CreatedOn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attested data creation time: %w", err)
+	}
+	expiresOn, err := time.Parse(attestedDataTimeLayout, data.TimeStamp.ExpiresOn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attested data expiration time: %w", err)
+	}
+	if now.Before(createdOn.Add(-5*time.Minute)) || now.After(expiresOn) {
+		return nil, fmt.Errorf("attested data of VM %s is not valid at %s", data.VMID, now.Format(time.RFC3339))
+	}
+	// The nonce makes attested data captured from a request useless once it expires.
+	if err := VerifyNonce(token, data.Nonce, now); err != nil {
+		return nil, fmt.Errorf("attested data of VM %s: %w", data.VMID, err)
+	}
+
+	azureMachine := &capiazure.AzureMachine{}
+	if err := v.Client.Get(ctx, client.ObjectKey{Namespace: v.Namespace, Name: name}, azureMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("VM %s is not a machine of cluster %s", name, v.InfraID)
+		}
+		return nil, fmt.Errorf("failed to get Azure machine %s: %w", name, err)
+	}
+	machine, err := machineOf(azureMachine, v.InfraID)
+	if err != nil {
+		return nil, err
+	}
+	if azureMachine.Spec.ProviderID == nil {
+		return nil, fmt.Errorf("machine %s has no provider ID yet", name)
+	}
+	resource, err := azure.ParseResourceID(strings.TrimPrefix(*azureMachine.Spec.ProviderID, "azure://"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider ID of machine %s: %w", name, err)
+	}
+	if !strings.EqualFold(resource.SubscriptionID, v.SubscriptionID) {
+		return nil, fmt.Errorf("machine %s is in subscription %q instead of %q", name, resource.SubscriptionID, v.SubscriptionID)
+	}
+	vmID, err := v.VMID(ctx, resource.ResourceGroup, resource.ResourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the VM ID of machine %s: %w", name, err)
+	}
+	if !strings.EqualFold(vmID, data.VMID) {
+		return nil, fmt.Errorf("VM %s is not the VM of machine %s", data.VMID, name)
+	}
+	return &Identity{InstanceID: data.VMID, Machine: machine}, nil
+}
+
+// NewAzureVMIDFunc returns a VMIDFunc getting virtual machines of subscriptionID from
+// the Azure API, with the client credentials in the AZURE_TENANT_ID, AZURE_CLIENT_ID
+// and AZURE_CLIENT_SECRET environment variables.
+func NewAzureVMIDFunc(subscriptionID string) (VMIDFunc, error) {
+	authorizer, err := auth.ClientCredentialsConfig{
+		TenantID:     os.Getenv("AZURE_TENANT_ID"),
+		ClientID:     os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret: os.Getenv("AZURE_CLIENT_SECRET"),
+		AADEndpoint:  azure.PublicCloud.ActiveDirectoryEndpoint,
+		Resource:     azure.PublicCloud.ResourceManagerEndpoint,
+	}.Authorizer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get azure authorizer: %w", err)
+	}
+	vmClient := compute.NewVirtualMachinesClient(subscriptionID)
+	vmClient.Authorizer = authorizer
+	return func(ctx context.Context, resourceGroup, name string) (string, error) {
+		vm, err := vmClient.Get(ctx, resourceGroup, name, "")
+		if err != nil {
+			return "", err
+		}
+		if vm.VirtualMachineProperties == nil || vm.VirtualMachineProperties.VMID == nil {
+			return "", fmt.Errorf("virtual machine %s has no VM ID", name)
+		}
+		return *vm.VirtualMachineProperties.VMID, nil
+	}, nil
+}
@@ -0,0 +1,56 @@
+package attestation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// nonceWindow is the period a nonce is issued for.
+	nonceWindow = time.Minute
+	// nonceMaxAge is the number of windows a nonce is valid for after the one it is
+	// issued in.
+	nonceMaxAge = 5
+)
+
+// NewNonce returns the nonce instances bearing token include in their attested data
+// at now. Azure limits nonces to 10 digits: the first 4 identify the window the nonce
+// is issued in, and the last 6 authenticate it with the token. Nonces are derived
+// from the token rather than stored, so every replica of the ignition server
+// verifies the nonces issued by the others.
+func NewNonce(token []byte, now time.Time) string {
+	window := now.Unix() / int64(nonceWindow/time.Second)
+	return fmt.Sprintf("%04d%06d", window%10000, nonceMAC(token, window))
+}
+
+// VerifyNonce returns an error if nonce was not issued for token in the last
+// nonceMaxAge windows before now.
+func VerifyNonce(token []byte, nonce string, now time.Time) error {
+	if len(nonce) != 10 {
+		return fmt.Errorf("invalid nonce %q", nonce)
+	}
+	issued, err := strconv.ParseInt(nonce[:4], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid nonce %q", nonce)
+	}
+	current := now.Unix() / int64(nonceWindow/time.Second)
+	for window := current; window >= current-nonceMaxAge; window-- {
+		if window%10000 != issued {
+			continue
+		}
+		if fmt.Sprintf("%06d", nonceMAC(token, window)) == nonce[4:] {
+			return nil
+		}
+	}
+	return fmt.Errorf("nonce %s is not valid or has expired", nonce)
+}
+
+func nonceMAC(token []byte, window int64) uint32 {
+	mac := hmac.New(sha256.New, token)
+	fmt.Fprintf(mac, "ignition-attestation-nonce/%d", window)
+	return binary.BigEndian.Uint32(mac.Sum(nil)) % 1000000
+}
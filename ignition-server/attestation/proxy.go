@@ -0,0 +1,252 @@
+package attestation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+const (
+	// ProxyAddress is the address the Proxy listens on, on the instances.
+	ProxyAddress = "127.0.0.1:22625"
+
+	// UpstreamHeader carries the URL of the payload on the ignition server, in the
+	// requests of Ignition to the Proxy.
+	UpstreamHeader = "X-Ignition-Attestation-Upstream"
+	// UpstreamCAHeader carries the base64 encoded PEM certificates of the CA of the
+	// ignition server, in the requests of Ignition to the Proxy.
+	UpstreamCAHeader = "X-Ignition-Attestation-Upstream-CA"
+
+	// defaultMetadataURL is the URL of the instance metadata service on AWS and Azure.
+	defaultMetadataURL = "http://169.254.169.254"
+	// maxMetadataSize is the maximum size of a response of the metadata service.
+	maxMetadataSize = 64 * 1024
+)
+
+// Collector collects the evidence of the identity of the instance it runs on, as
+// the headers of a payload request.
+type Collector interface {
+	Collect(ctx context.Context, nonce string) (http.Header, error)
+}
+
+// NewCollector returns the Collector of platform, which reads the metadata service
+// at metadataURL, or the default one of the platform if empty.
+func NewCollector(platform hyperv1.PlatformType, metadataURL string) (Collector, error) {
+	if metadataURL == "" {
+		metadataURL = defaultMetadataURL
+	}
+	metadataURL = strings.TrimSuffix(metadataURL, "/")
+	// The metadata service is link local, so it is never reached through a proxy.
+	client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{}}
+	switch platform {
+	case hyperv1.AWSPlatform:
+		return &AWSCollector{MetadataURL: metadataURL, Client: client}, nil
+	case hyperv1.AzurePlatform:
+		return &AzureCollector{MetadataURL: metadataURL, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("attestation is not supported on platform %s", platform)
+	}
+}
+
+// AWSCollector collects the instance identity document of EC2 instances and its
+// signature, with IMDSv2.
+type AWSCollector struct {
+	MetadataURL string
+	Client      *http.Client
+}
+
+func (c *AWSCollector) Collect(ctx context.Context, _ string) (http.Header, error) {
+	token, err := getMetadata(ctx, c.Client, http.MethodPut, c.MetadataURL+"/latest/api/token", http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {"60"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata token: %w", err)
+	}
+	headers := http.Header{"X-Aws-Ec2-Metadata-Token": {string(token)}}
+	document, err := getMetadata(ctx, c.Client, http.MethodGet, c.MetadataURL+"/latest/dynamic/instance-identity/document", headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance identity document: %w", err)
+	}
+	signature, err := getMetadata(ctx, c.Client, http.MethodGet, c.MetadataURL+"/latest/dynamic/instance-identity/signature", headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance identity signature: %w", err)
+	}
+	evidence := http.Header{}
+	evidence.Set(AttestationDocumentHeader, base64.StdEncoding.EncodeToString(document))
+	// The signature is base64 encoded across several lines.
+	evidence.Set(AttestationSignatureHeader, strings.Join(strings.Fields(string(signature)), ""))
+	return evidence, nil
+}
+
+// AzureCollector collects the attested data of Azure instances for a nonce, and the
+// name of the instance.
+type AzureCollector struct {
+	MetadataURL string
+	Client      *http.Client
+}
+
+func (c *AzureCollector) Collect(ctx context.Context, nonce string) (http.Header, error) {
+	headers := http.Header{"Metadata": {"true"}}
+	raw, err := getMetadata(ctx, c.Client, http.MethodGet, c.MetadataURL+"/metadata/attested/document?api-version=2020-09-01&nonce="+url.QueryEscape(nonce), headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attested data: %w", err)
+	}
+	var document struct {
+		Encoding  string `json:"encoding"`
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("invalid attested data: %w", err)
+	}
+	if document.Encoding != "pkcs7" {
+		return nil, fmt.Errorf("unsupported attested data encoding %q", document.Encoding)
+	}
+	name, err := getMetadata(ctx, c.Client, http.MethodGet, c.MetadataURL+"/metadata/instance/compute/name?api-version=2021-02-01&format=text", headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get VM name: %w", err)
+	}
+	evidence := http.Header{}
+	evidence.Set(AttestationDocumentHeader, document.Signature)
+	evidence.Set(AttestationInstanceHeader, strings.TrimSpace(string(name)))
+	return evidence, nil
+}
+
+func getMetadata(ctx context.Context, client *http.Client, method, url string, headers http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return body, nil
+}
+
+// Proxy runs on instances and forwards the payload requests of Ignition to the
+// ignition server, with the evidence of the identity of the instance. Ignition only
+// sends static headers, so the pointer config of instances points Ignition to the
+// Proxy, with the URL and CA of the ignition server in UpstreamHeader and
+// UpstreamCAHeader.
+type Proxy struct {
+	Collector Collector
+	// Timeout bounds every request to the ignition server.
+	Timeout time.Duration
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upstream, err := url.Parse(r.Header.Get(UpstreamHeader))
+	if err != nil || upstream.Scheme != "https" || upstream.Host == "" {
+		http.Error(w, "Missing or invalid upstream", http.StatusBadRequest)
+		return
+	}
+	client, err := p.upstreamClient(r.Header.Get(UpstreamCAHeader))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Ignition retries the requests failing with a 5xx status, so errors reaching
+	// the ignition server or the metadata service are reported as such.
+	nonceURL := *upstream
+	nonceURL.Path, nonceURL.RawQuery = NoncePath, ""
+	nonceRequest, err := http.NewRequestWithContext(r.Context(), http.MethodGet, nonceURL.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	nonceRequest.Header.Set("Authorization", r.Header.Get("Authorization"))
+	nonceResponse, err := client.Do(nonceRequest)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get nonce: %s", err), http.StatusBadGateway)
+		return
+	}
+	nonce, err := io.ReadAll(io.LimitReader(nonceResponse.Body, maxMetadataSize))
+	nonceResponse.Body.Close()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get nonce: %s", err), http.StatusBadGateway)
+		return
+	}
+	if nonceResponse.StatusCode != http.StatusOK {
+		relay(w, nonceResponse, nonce)
+		return
+	}
+
+	evidence, err := p.Collector.Collect(r.Context(), strings.TrimSpace(string(nonce)))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to collect attestation evidence: %s", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, upstream.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	req.Header = r.Header.Clone()
+	req.Header.Del(UpstreamHeader)
+	req.Header.Del(UpstreamCAHeader)
+	for name := range evidence {
+		req.Header.Set(name, evidence.Get(name))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get payload: %s", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get payload: %s", err), http.StatusBadGateway)
+		return
+	}
+	relay(w, resp, body)
+}
+
+// upstreamClient returns a client of the ignition server trusting the base64
+// encoded PEM certificates of encodedCA.
+func (p *Proxy) upstreamClient(encodedCA string) (*http.Client, error) {
+	ca, err := base64.StdEncoding.DecodeString(encodedCA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("missing or invalid upstream CA")
+	}
+	return &http.Client{
+		Timeout: p.Timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		},
+	}, nil
+}
+
+// relay writes the response of the ignition server to w.
+func relay(w http.ResponseWriter, resp *http.Response, body []byte) {
+	for _, name := range []string{"Content-Type", "Content-Encoding", "ETag", "Retry-After"} {
+		if value := resp.Header.Get(name); value != "" {
+			w.Header().Set(name, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
@@ -0,0 +1,87 @@
+package attestation
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeCollector struct{}
+
+func (fakeCollector) Collect(_ context.Context, nonce string) (http.Header, error) {
+	return http.Header{AttestationDocumentHeader: {"document-" + nonce}}, nil
+}
+
+func TestProxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == NoncePath:
+			w.Write([]byte("0123456789"))
+		case r.Header.Get(UpstreamHeader) != "" || r.Header.Get(UpstreamCAHeader) != "":
+			w.WriteHeader(http.StatusBadRequest)
+		case r.Header.Get(AttestationDocumentHeader) != "document-0123456789":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Header().Set("ETag", `"payload"`)
+			w.Write([]byte("payload"))
+		}
+	}))
+	defer server.Close()
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	proxy := &Proxy{Collector: fakeCollector{}, Timeout: 10 * time.Second}
+
+	testCases := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "When the request has an upstream and its CA it should forward it with the evidence for the nonce",
+			headers:        map[string]string{"Authorization": "Bearer token", UpstreamHeader: server.URL + "/ignition", UpstreamCAHeader: ca},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "payload",
+		},
+		{
+			name:           "When the ignition server refuses the token it should relay its response",
+			headers:        map[string]string{"Authorization": "Bearer other", UpstreamHeader: server.URL + "/ignition", UpstreamCAHeader: ca},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "When the upstream is not https it should fail",
+			headers:        map[string]string{"Authorization": "Bearer token", UpstreamHeader: "http://example.com/ignition", UpstreamCAHeader: ca},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "When the upstream CA is missing it should fail",
+			headers:        map[string]string{"Authorization": "Bearer token", UpstreamHeader: server.URL + "/ignition"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			req := httptest.NewRequest(http.MethodGet, "http://"+ProxyAddress+"/ignition", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, req)
+			g.Expect(w.Code).To(Equal(tc.expectedStatus))
+			if tc.expectedBody != "" {
+				body, _ := io.ReadAll(w.Body)
+				g.Expect(string(body)).To(Equal(tc.expectedBody))
+				g.Expect(w.Header().Get("ETag")).To(Equal(`"payload"`))
+			}
+		})
+	}
+}
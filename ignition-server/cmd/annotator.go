@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// annotatorMaxRetries is the number of times the annotation of a token Secret is
+	// retried before giving up until its token is served again.
+	annotatorMaxRetries = 5
+)
+
+var (
+	tokenSecretAnnotationErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ign_server_token_secret_annotation_errors_total",
+	})
+)
+
+// tokenSecretAnnotator sets TokenSecretIgnitionReachedAnnotation on the token
+// Secrets of served payloads in the background, so payloads are not delayed by
+// the API server.
+type tokenSecretAnnotator struct {
+	client client.Client
+	queue  workqueue.RateLimitingInterface
+	log    logr.Logger
+}
+
+func newTokenSecretAnnotator(c client.Client, log logr.Logger) *tokenSecretAnnotator {
+	return &tokenSecretAnnotator{
+		client: c,
+		queue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "token-secret-annotator"),
+		log:    log,
+	}
+}
+
+// Add queues the annotation of the token Secret key. Keys already queued are
+// annotated once.
+func (a *tokenSecretAnnotator) Add(key client.ObjectKey) {
+	a.queue.Add(key)
+}
+
+// Start annotates the queued token Secrets until ctx is done.
+func (a *tokenSecretAnnotator) Start(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		a.queue.ShutDown()
+	}()
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		for a.processNext(ctx) {
+		}
+	}, 0)
+}
+
+func (a *tokenSecretAnnotator) processNext(ctx context.Context) bool {
+	item, shutdown := a.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer a.queue.Done(item)
+	key := item.(client.ObjectKey)
+
+	err := a.annotate(ctx, key)
+	switch {
+	case err == nil:
+		a.queue.Forget(item)
+	case a.queue.NumRequeues(item) < annotatorMaxRetries:
+		a.queue.AddRateLimited(item)
+	default:
+		tokenSecretAnnotationErrorsTotal.Inc()
+		a.log.Error(err, "Failed to annotate token secret", "secret", key.String())
+		a.queue.Forget(item)
+	}
+	return true
+}
+
+func (a *tokenSecretAnnotator) annotate(ctx context.Context, key client.ObjectKey) error {
+	tokenSecret := &corev1.Secret{}
+	if err := a.client.Get(ctx, key, tokenSecret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get token secret: %w", err)
+	}
+	if tokenSecret.Annotations[TokenSecretIgnitionReachedAnnotation] == "True" {
+		return nil
+	}
+	if tokenSecret.Annotations == nil {
+		tokenSecret.Annotations = map[string]string{}
+	}
+	tokenSecret.Annotations[TokenSecretIgnitionReachedAnnotation] = "True"
+	if err := a.client.Update(ctx, tokenSecret); err != nil {
+		return fmt.Errorf("failed to update token secret: %w", err)
+	}
+	return nil
+}
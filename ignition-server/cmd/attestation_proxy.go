@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/ignition-server/attestation"
+)
+
+type AttestationProxyOptions struct {
+	Platform    string
+	Addr        string
+	MetadataURL string
+	Timeout     time.Duration
+}
+
+// NewAttestationProxyCommand returns the command running the attestation proxy on
+// instances, before Ignition runs. See docs/content/how-to/ignition-attestation.md.
+func NewAttestationProxyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attestation-proxy",
+		Short: "Forwards the payload requests of Ignition to the ignition server with the identity evidence of the instance",
+	}
+
+	opts := AttestationProxyOptions{
+		Addr:    attestation.ProxyAddress,
+		Timeout: 30 * time.Second,
+	}
+
+	cmd.Flags().StringVar(&opts.Platform, "platform", opts.Platform, "The platform of the instance (AWS, Azure)")
+	cmd.Flags().StringVar(&opts.Addr, "addr", opts.Addr, "Listen address")
+	cmd.Flags().StringVar(&opts.MetadataURL, "metadata-url", opts.MetadataURL, "The URL of the instance metadata service (default: the one of the platform)")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "The timeout of requests to the ignition server")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-sigs
+			cancel()
+		}()
+		return opts.Run(ctx)
+	}
+
+	return cmd
+}
+
+func (o *AttestationProxyOptions) Run(ctx context.Context) error {
+	collector, err := attestation.NewCollector(hyperv1.PlatformType(o.Platform), o.MetadataURL)
+	if err != nil {
+		return err
+	}
+	server := http.Server{
+		Addr:        o.Addr,
+		Handler:     &attestation.Proxy{Collector: collector, Timeout: o.Timeout},
+		ReadTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Printf("error shutting down server: %s", err)
+		}
+	}()
+
+	log.Printf("Listening on %s", o.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
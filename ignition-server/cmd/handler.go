@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	nodepool "github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/ignition-server/attestation"
+	"github.com/openshift/hypershift/ignition-server/controllers"
+	"github.com/openshift/hypershift/support/util"
+)
+
+const (
+	limitSource = "source"
+	limitToken  = "token"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ign_server_requests_total",
+	}, []string{"code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ign_server_request_duration_seconds",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"code"})
+	rateLimitedRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ign_server_rate_limited_requests_total",
+	}, []string{"limit"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		requestsTotal,
+		requestDuration,
+		rateLimitedRequestsTotal,
+		tokenSecretAnnotationErrorsTotal,
+	)
+}
+
+// ignitionHandler serves the payloads of the payload store to the requests
+// bearing their token.
+type ignitionHandler struct {
+	namespace     string
+	payloadStore  *controllers.ExpiringCache
+	eventRecorder record.EventRecorder
+	annotator     *tokenSecretAnnotator
+	log           logr.Logger
+
+	// sourceFailures limits the requests with an invalid token or failing
+	// attestation per source IP. Sources exceeding it are refused every request.
+	// Missing and unknown tokens are not counted, since they can't be told apart
+	// from the requests of instances booting before their payload is generated.
+	sourceFailures *keyedRateLimiter
+	// trustedProxies are the networks of the proxies whose X-Forwarded-For header
+	// is trusted to hold the source IP of requests.
+	trustedProxies []*net.IPNet
+	// tokenRequests limits the requests per token.
+	tokenRequests *keyedRateLimiter
+
+	// verifier verifies the identity of instances, if set.
+	verifier        attestation.Verifier
+	singleUseTokens bool
+	client          client.Client
+	reader          client.Reader
+
+	// compressedPayloads serves payloads gzip encoded to the clients accepting
+	// it, if set.
+	compressedPayloads *compressedPayloadCache
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (h *ignitionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+	source := h.sourceIP(r)
+	result := h.serve(recorder, r, source)
+
+	code := strconv.Itoa(recorder.code)
+	duration := time.Since(start)
+	requestsTotal.WithLabelValues(code).Inc()
+	requestDuration.WithLabelValues(code).Observe(duration.Seconds())
+	h.log.Info("Request",
+		"source", source,
+		"path", r.URL.Path,
+		"userAgent", r.Header.Get("User-Agent"),
+		"nodePool", r.Header.Get("NodePool"),
+		"configHash", r.Header.Get("TargetConfigVersionHash"),
+		"code", recorder.code,
+		"result", result,
+		"latency", duration.String(),
+	)
+}
+
+// serve serves the request and returns a short description of its result.
+func (h *ignitionHandler) serve(w http.ResponseWriter, r *http.Request, source string) string {
+	tokenSecret := nodepool.TokenSecret(h.namespace,
+		util.ParseNamespacedName(r.Header.Get("NodePool")).Name,
+		r.Header.Get("TargetConfigVersionHash"))
+
+	nonceRequest := h.verifier != nil && r.URL.Path == attestation.NoncePath
+	if !ignPathPattern.MatchString(r.URL.Path) && !nonceRequest {
+		// No pattern matched; send 404 response.
+		http.NotFound(w, r)
+		return "path not found"
+	}
+
+	// Rate limited requests are refused with a 5xx status, since Ignition only
+	// retries those.
+	if !h.sourceFailures.Allowed(source) {
+		rateLimitedRequestsTotal.WithLabelValues(limitSource).Inc()
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many failed requests", http.StatusServiceUnavailable)
+		return "too many failed requests from source"
+	}
+	unauthorized := func(reason string) string {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		h.eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", reason)
+		return reason
+	}
+
+	// Authorize the request against the token
+	const bearerPrefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	n := len(bearerPrefix)
+	if len(auth) < n || auth[:n] != bearerPrefix {
+		return unauthorized("Bad header")
+	}
+	encodedToken := auth[n:]
+	decodedToken, err := base64.StdEncoding.DecodeString(encodedToken)
+	if err != nil || len(decodedToken) == 0 {
+		h.sourceFailures.Take(source)
+		return unauthorized("Token invalid")
+	}
+
+	tokenHash := sha256.Sum256(decodedToken)
+	if !h.tokenRequests.Take(hex.EncodeToString(tokenHash[:])) {
+		rateLimitedRequestsTotal.WithLabelValues(limitToken).Inc()
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many requests", http.StatusServiceUnavailable)
+		return "too many requests for token"
+	}
+
+	value, ok := h.payloadStore.Get(string(decodedToken))
+	if !ok {
+		// We return a 5xx here to give ignition the chance to backoff and retry if the machine request happens
+		// before the content is cached for this token.
+		// https://coreos.github.io/ignition/operator-notes/#http-backoff-and-retry
+		http.Error(w, "Token not found", http.StatusNetworkAuthenticationRequired)
+		h.eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Token not found in cache")
+		return "token not found"
+	}
+
+	if nonceRequest {
+		// Nonces are issued for the tokens of payloads only, so they can't be used to
+		// guess tokens.
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(attestation.NewNonce(decodedToken, time.Now())))
+		return "nonce issued"
+	}
+
+	etag := payloadETag(value.Payload)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("Content-Type", "application/json")
+
+	// HEAD requests probe that the payload of the token is served, so they neither
+	// count as the payload being reached nor require attestation.
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", strconv.Itoa(len(value.Payload)))
+		w.WriteHeader(http.StatusOK)
+		return "probed"
+	}
+
+	if h.verifier != nil {
+		identity, err := h.verifier.Verify(r.Context(), r, decodedToken)
+		if err == nil && h.singleUseTokens {
+			err = attestation.ClaimMachine(r.Context(), h.client, h.reader, identity)
+		}
+		if err != nil {
+			h.sourceFailures.Take(source)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			h.eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "AttestationFailed", err.Error())
+			return "attestation failed: " + err.Error()
+		}
+	}
+
+	result := "served"
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		result = "not modified"
+	} else {
+		body := value.Payload
+		if h.compressedPayloads != nil && acceptsGzip(r) {
+			// Payloads that fail to compress are served uncompressed.
+			if compressed, err := h.compressedPayloads.Get(etag, value.Payload); err != nil {
+				h.log.Error(err, "failed to compress payload", "tokenSecret", tokenSecret.Name)
+			} else {
+				body = compressed
+				w.Header().Set("Content-Encoding", "gzip")
+				result = "served compressed"
+			}
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+
+	h.eventRecorder.Event(tokenSecret, corev1.EventTypeNormal, "GetPayload", "")
+	getRequestsPerNodePool.WithLabelValues(r.Header.Get("NodePool")).Inc()
+
+	// Annotate tokenSecret so NodePool controller can set a conditions based on it.
+	h.annotator.Add(client.ObjectKeyFromObject(tokenSecret))
+	return result
+}
+
+// payloadETag returns the entity tag of a payload, derived from its content so it
+// stays the same when the token is rotated, and changes with the payload.
+func payloadETag(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	// The tag is weak since the payload is also served compressed.
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches returns whether the If-None-Match header value matches etag, using
+// the weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip returns whether the client of r accepts gzip encoded responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// compressedPayloadCache keeps the gzip encoding of the most recently served
+// payloads by entity tag, so they are not compressed on every request.
+type compressedPayloadCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string][]byte
+	order   []string
+}
+
+func newCompressedPayloadCache(maxEntries int) *compressedPayloadCache {
+	return &compressedPayloadCache{maxEntries: maxEntries, entries: map[string][]byte{}}
+}
+
+// Get returns the gzip encoding of payload, whose entity tag is etag. Entity tags
+// are derived from the content of payloads, so they identify their encoding too.
+func (c *compressedPayloadCache) Get(etag string, payload []byte) ([]byte, error) {
+	c.mu.Lock()
+	compressed, ok := c.entries[etag]
+	c.mu.Unlock()
+	if ok {
+		return compressed, nil
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(payload); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	compressed = buffer.Bytes()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[etag]; !exists {
+		c.entries[etag] = compressed
+		c.order = append(c.order, etag)
+		if len(c.order) > c.maxEntries {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	return compressed, nil
+}
+
+// sourceIP returns the IP address of the client of r. Requests from trusted
+// proxies are attributed to the last address of their X-Forwarded-For header
+// that is not a trusted proxy.
+func (h *ignitionHandler) sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !h.trusted(host) {
+		return host
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwarded[i])
+		if address == "" {
+			continue
+		}
+		if net.ParseIP(address) == nil {
+			// The header was not set by a trusted proxy past this point.
+			return host
+		}
+		host = address
+		if !h.trusted(address) {
+			break
+		}
+	}
+	return host
+}
+
+// trusted returns whether address is the address of a trusted proxy.
+func (h *ignitionHandler) trusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range h.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+	"github.com/openshift/hypershift/ignition-server/controllers"
+)
+
+func TestIgnitionHandler(t *testing.T) {
+	payloadStore := controllers.NewPayloadStore()
+	payloadStore.Set("token", controllers.CacheValue{Payload: []byte("payload")})
+
+	request := func(source, path, authorization string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = source + ":12345"
+		r.Header.Set("NodePool", "clusters/example")
+		r.Header.Set("TargetConfigVersionHash", "abcdef")
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		return r
+	}
+	bearer := func(token string) string {
+		return "Bearer " + base64.StdEncoding.EncodeToString([]byte(token))
+	}
+
+	testCases := []struct {
+		name         string
+		requests     []*http.Request
+		expectedCode []int
+	}{
+		{
+			name:         "When the token is valid it should serve the payload",
+			requests:     []*http.Request{request("10.0.0.1", "/ignition", bearer("token"))},
+			expectedCode: []int{http.StatusOK},
+		},
+		{
+			name:         "When the path is not the ignition path it should not be found",
+			requests:     []*http.Request{request("10.0.0.1", "/other", bearer("token"))},
+			expectedCode: []int{http.StatusNotFound},
+		},
+		{
+			name: "When a source keeps sending invalid tokens it should be refused every request",
+			requests: []*http.Request{
+				request("10.0.0.1", "/ignition", "Bearer not-base64!"),
+				request("10.0.0.1", "/ignition", "Bearer not-base64!"),
+				request("10.0.0.1", "/ignition", "Bearer not-base64!"),
+				request("10.0.0.1", "/ignition", bearer("token")),
+				request("10.0.0.2", "/ignition", bearer("token")),
+			},
+			expectedCode: []int{
+				http.StatusUnauthorized,
+				http.StatusUnauthorized,
+				http.StatusUnauthorized,
+				http.StatusServiceUnavailable,
+				http.StatusOK,
+			},
+		},
+		{
+			name: "When a source sends missing or unknown tokens it should not be refused",
+			requests: []*http.Request{
+				request("10.0.0.1", "/ignition", ""),
+				request("10.0.0.1", "/ignition", bearer("pending-0")),
+				request("10.0.0.1", "/ignition", bearer("pending-1")),
+				request("10.0.0.1", "/ignition", bearer("pending-2")),
+				request("10.0.0.1", "/ignition", bearer("token")),
+			},
+			expectedCode: []int{
+				http.StatusUnauthorized,
+				http.StatusNetworkAuthenticationRequired,
+				http.StatusNetworkAuthenticationRequired,
+				http.StatusNetworkAuthenticationRequired,
+				http.StatusOK,
+			},
+		},
+		{
+			name: "When a token is requested too often it should be refused",
+			requests: []*http.Request{
+				request("10.0.0.1", "/ignition", bearer("token")),
+				request("10.0.0.2", "/ignition", bearer("token")),
+				request("10.0.0.3", "/ignition", bearer("token")),
+			},
+			expectedCode: []int{http.StatusOK, http.StatusOK, http.StatusServiceUnavailable},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			handler := &ignitionHandler{
+				namespace:      "clusters-example",
+				payloadStore:   payloadStore,
+				eventRecorder:  record.NewFakeRecorder(10),
+				annotator:      newTokenSecretAnnotator(fake.NewClientBuilder().Build(), ctrl.Log),
+				log:            ctrl.Log,
+				sourceFailures: newKeyedRateLimiter(0.001, 3),
+				tokenRequests:  newKeyedRateLimiter(0.001, 2),
+			}
+			for i, r := range tc.requests {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				g.Expect(w.Code).To(Equal(tc.expectedCode[i]), "request %d", i)
+				if w.Code == http.StatusOK {
+					g.Expect(w.Body.String()).To(Equal("payload"))
+				}
+			}
+		})
+	}
+}
+
+func TestSourceIP(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.128.0.0/14")
+	handler := &ignitionHandler{trustedProxies: []*net.IPNet{trusted}}
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{
+			name:         "When the request is not from a trusted proxy it should ignore X-Forwarded-For",
+			remoteAddr:   "192.168.0.1:12345",
+			forwardedFor: []string{"1.2.3.4"},
+			expected:     "192.168.0.1",
+		},
+		{
+			name:         "When the request is from a trusted proxy it should be the last forwarded address",
+			remoteAddr:   "10.128.0.1:12345",
+			forwardedFor: []string{"1.2.3.4, 5.6.7.8"},
+			expected:     "5.6.7.8",
+		},
+		{
+			name:         "When the request went through several trusted proxies it should skip them",
+			remoteAddr:   "10.128.0.1:12345",
+			forwardedFor: []string{"1.2.3.4", "5.6.7.8, 10.129.0.1"},
+			expected:     "5.6.7.8",
+		},
+		{
+			name:       "When a trusted proxy does not forward the address it should be the proxy address",
+			remoteAddr: "10.128.0.1:12345",
+			expected:   "10.128.0.1",
+		},
+		{
+			name:         "When the forwarded address is invalid it should be the proxy address",
+			remoteAddr:   "10.128.0.1:12345",
+			forwardedFor: []string{"unknown"},
+			expected:     "10.128.0.1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			r := httptest.NewRequest(http.MethodGet, "/ignition", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			g.Expect(handler.sourceIP(r)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestIgnitionHandlerConditionalRequests(t *testing.T) {
+	payloadStore := controllers.NewPayloadStore()
+	payloadStore.Set("token", controllers.CacheValue{Payload: []byte("payload"), SecretName: "token-example-abcdef"})
+	etag := payloadETag([]byte("payload"))
+
+	request := func(method string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(method, "/ignition", nil)
+		r.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte("token")))
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		return r
+	}
+	gunzip := func(body []byte) string {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(decompressed)
+	}
+
+	testCases := []struct {
+		name             string
+		request          *http.Request
+		expectedCode     int
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{
+			name:         "When the request is a HEAD it should only return the headers",
+			request:      request(http.MethodHead, nil),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "When the ETag matches it should return not modified",
+			request:      request(http.MethodGet, map[string]string{"If-None-Match": `"other", ` + etag}),
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "When any ETag is accepted it should return not modified",
+			request:      request(http.MethodGet, map[string]string{"If-None-Match": "*"}),
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "When the ETag doesn't match it should serve the payload",
+			request:      request(http.MethodGet, map[string]string{"If-None-Match": `"other"`}),
+			expectedCode: http.StatusOK,
+			expectedBody: "payload",
+		},
+		{
+			name:             "When the client accepts gzip it should serve the payload compressed",
+			request:          request(http.MethodGet, map[string]string{"Accept-Encoding": "deflate, gzip;q=0.8"}),
+			expectedCode:     http.StatusOK,
+			expectedEncoding: "gzip",
+			expectedBody:     "payload",
+		},
+		{
+			name:         "When the client refuses gzip it should serve the payload uncompressed",
+			request:      request(http.MethodGet, map[string]string{"Accept-Encoding": "gzip;q=0"}),
+			expectedCode: http.StatusOK,
+			expectedBody: "payload",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			handler := &ignitionHandler{
+				namespace:          "clusters-example",
+				payloadStore:       payloadStore,
+				eventRecorder:      record.NewFakeRecorder(10),
+				annotator:          newTokenSecretAnnotator(fake.NewClientBuilder().Build(), ctrl.Log),
+				log:                ctrl.Log,
+				sourceFailures:     newKeyedRateLimiter(0, 0),
+				tokenRequests:      newKeyedRateLimiter(0, 0),
+				compressedPayloads: newCompressedPayloadCache(1),
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tc.request)
+			g.Expect(w.Code).To(Equal(tc.expectedCode))
+			g.Expect(w.Header().Get("ETag")).To(Equal(etag))
+			g.Expect(w.Header().Get("Content-Encoding")).To(Equal(tc.expectedEncoding))
+			body := w.Body.Bytes()
+			if tc.expectedEncoding == "gzip" {
+				g.Expect(gunzip(body)).To(Equal(tc.expectedBody))
+			} else {
+				g.Expect(string(body)).To(Equal(tc.expectedBody))
+			}
+			// Only served payloads count as the token being reached.
+			if tc.request.Method == http.MethodHead {
+				g.Expect(handler.annotator.queue.Len()).To(BeZero())
+			} else {
+				g.Expect(handler.annotator.queue.Len()).To(Equal(1))
+			}
+		})
+	}
+}
+
+func TestPayloadETag(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Payloads regenerated for the same token Secret, e.g. after a restart or when the
+	// release image digest changed, only share their entity tag if they are equal.
+	g.Expect(payloadETag([]byte("payload"))).To(Equal(payloadETag([]byte("payload"))))
+	g.Expect(payloadETag([]byte("payload"))).ToNot(Equal(payloadETag([]byte("other payload"))))
+}
+
+func TestCompressedPayloadCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cache := newCompressedPayloadCache(1)
+	first, err := cache.Get("a", []byte("a"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cache.Get("a", []byte("ignored"))).To(Equal(first))
+	_, err = cache.Get("b", []byte("b"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cache.entries).To(HaveLen(1))
+	g.Expect(cache.entries).To(HaveKey("b"))
+}
+
+func TestKeyedRateLimiter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Now()
+	limiter := newKeyedRateLimiter(1, 2)
+	limiter.now = func() time.Time { return now }
+
+	g.Expect(limiter.Take("a")).To(BeTrue())
+	g.Expect(limiter.Take("a")).To(BeTrue())
+	g.Expect(limiter.Allowed("a")).To(BeFalse())
+	g.Expect(limiter.Take("a")).To(BeFalse())
+	g.Expect(limiter.Take("b")).To(BeTrue())
+
+	now = now.Add(time.Second)
+	g.Expect(limiter.Allowed("a")).To(BeTrue())
+	g.Expect(limiter.Take("a")).To(BeTrue())
+	g.Expect(limiter.Take("a")).To(BeFalse())
+
+	// Only keys with full buckets are forgotten.
+	now = now.Add(time.Second)
+	limiter.Prune()
+	g.Expect(limiter.buckets).To(HaveKey("a"))
+	g.Expect(limiter.buckets).ToNot(HaveKey("b"))
+
+	disabled := newKeyedRateLimiter(0, 0)
+	g.Expect(disabled.Take("a")).To(BeTrue())
+	g.Expect(disabled.Allowed("a")).To(BeTrue())
+}
+
+func TestTokenSecretAnnotator(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tokenSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "clusters-example", Name: "token-example-abcdef"}}
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).WithObjects(tokenSecret).Build()
+	annotator := newTokenSecretAnnotator(c, ctrl.Log)
+	go annotator.Start(ctx)
+
+	annotator.Add(client.ObjectKeyFromObject(tokenSecret))
+	// Secrets that don't exist anymore are skipped.
+	annotator.Add(client.ObjectKey{Namespace: "clusters-example", Name: "missing"})
+	g.Eventually(func() string {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(tokenSecret), secret); err != nil {
+			return ""
+		}
+		return secret.Annotations[TokenSecretIgnitionReachedAnnotation]
+	}).Should(Equal("True"))
+	g.Eventually(annotator.queue.Len).Should(BeZero())
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// keyedRateLimiter is a token bucket per key, such as a source IP or token. Buckets
+// refill at rate tokens per second up to burst. A limiter with a zero rate allows
+// everything.
+type keyedRateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newKeyedRateLimiter(rate float64, burst int) *keyedRateLimiter {
+	return &keyedRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allowed returns whether key has a token left, without taking it.
+func (l *keyedRateLimiter) Allowed(key string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.refill(key).tokens >= 1
+}
+
+// Take takes a token of key and returns whether there was one.
+func (l *keyedRateLimiter) Take(key string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.refill(key)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Prune forgets the keys whose bucket is full, since they are the same as new ones.
+func (l *keyedRateLimiter) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.buckets {
+		if l.refill(key).tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *keyedRateLimiter) refill(key string) *bucket {
+	now := l.now()
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	return b
+}
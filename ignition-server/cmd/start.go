@@ -3,41 +3,57 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
-	"encoding/base64"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"regexp"
 	"syscall"
 	"time"
 
 	hyperapi "github.com/openshift/hypershift/api"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
-	nodepool "github.com/openshift/hypershift/hypershift-operator/controllers/nodepool"
+	"github.com/openshift/hypershift/ignition-server/attestation"
 	"github.com/openshift/hypershift/ignition-server/controllers"
 	"github.com/openshift/hypershift/pkg/version"
 	"github.com/openshift/hypershift/support/releaseinfo"
-	"github.com/openshift/hypershift/support/util"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap/zapcore"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
-	client "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
-const namespaceEnvVariableName = "MY_NAMESPACE"
+const (
+	namespaceEnvVariableName = "MY_NAMESPACE"
+	// tokenSecretReconcileHeadroom is the number of token Secrets reconciled in
+	// addition to the payloads being generated.
+	tokenSecretReconcileHeadroom = 4
+	// payloadStorePruneInterval is the interval at which expired payloads are
+	// deleted from a persistent payload store.
+	payloadStorePruneInterval = time.Hour
+	// rateLimiterPruneInterval is the interval at which the rate limiters forget
+	// the sources and tokens that are not limited.
+	rateLimiterPruneInterval = 10 * time.Minute
+	// compressedPayloadCacheSize is the number of compressed payloads kept.
+	compressedPayloadCacheSize = 16
+
+	PayloadStoreMemory    = "memory"
+	PayloadStoreDisk      = "disk"
+	PayloadStoreSecret    = "secret"
+	PayloadStoreConfigMap = "configmap"
+)
 
 var (
 	// We only match /ignition
 	ignPathPattern                       = regexp.MustCompile("^/ignition[^/ ]*$")
-	payloadStore                         = controllers.NewPayloadStore()
 	getRequestsPerNodePool               = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "ign_server_get_request"}, []string{"nodePool"})
 	TokenSecretIgnitionReachedAnnotation = "hypershift.openshift.io/ignition-reached"
 )
@@ -56,6 +72,41 @@ type Options struct {
 	Platform          string
 	WorkDir           string
 	MetricsAddr       string
+	// MaxConcurrentGenerations is the number of payloads generated concurrently.
+	MaxConcurrentGenerations int
+	// PayloadStore is the backend of the payload store: memory, disk, secret or configmap.
+	PayloadStore string
+	// PayloadStoreDir is the directory of the disk payload store.
+	PayloadStoreDir string
+	// PayloadStoreEncryptionKeyFile contains the key used to encrypt payloads at rest.
+	PayloadStoreEncryptionKeyFile string
+	// Attestation is the platform whose instance identity is verified before serving
+	// a payload. Attestation is disabled when empty.
+	Attestation string
+	// InfraID is the infra ID of the cluster instances must belong to.
+	InfraID                     string
+	AttestationRegion           string
+	AttestationAccountID        string
+	AttestationSubscriptionID   string
+	AttestationCertificatesFile string
+	// SingleUseTokens serves a payload only once to the CAPI Machine of an instance.
+	SingleUseTokens bool
+	// SourceFailureRate and SourceFailureBurst limit the requests with an invalid
+	// token or failing attestation per source IP. A zero rate disables the limit.
+	SourceFailureRate  float64
+	SourceFailureBurst int
+	// TrustedProxies are the CIDRs of the proxies whose X-Forwarded-For header is
+	// trusted to hold the source IP of requests.
+	TrustedProxies []string
+	// TokenRequestRate and TokenRequestBurst limit the requests per token. A zero
+	// rate disables the limit.
+	TokenRequestRate  float64
+	TokenRequestBurst int
+	// CompressPayloads serves payloads gzip encoded to the clients accepting it.
+	CompressPayloads bool
+	// ReleaseMetadataCacheDir is the directory the metadata of release images is
+	// persisted in. Metadata is only cached in memory when empty.
+	ReleaseMetadataCacheDir string
 }
 
 // This is an https server that enable us to satisfy
@@ -73,12 +124,18 @@ func NewStartCommand() *cobra.Command {
 	}
 
 	opts := Options{
-		Addr:              "0.0.0.0:9090",
-		MetricsAddr:       "0.0.0.0:8080",
-		CertFile:          "/var/run/secrets/ignition/serving-cert/tls.crt",
-		KeyFile:           "/var/run/secrets/ignition/serving-cert/tls.key",
-		WorkDir:           "/payloads",
-		RegistryOverrides: map[string]string{},
+		Addr:                     "0.0.0.0:9090",
+		MetricsAddr:              "0.0.0.0:8080",
+		CertFile:                 "/var/run/secrets/ignition/serving-cert/tls.crt",
+		KeyFile:                  "/var/run/secrets/ignition/serving-cert/tls.key",
+		WorkDir:                  "/payloads",
+		RegistryOverrides:        map[string]string{},
+		MaxConcurrentGenerations: 4,
+		PayloadStore:             PayloadStoreMemory,
+		SourceFailureBurst:       30,
+		TokenRequestRate:         5,
+		TokenRequestBurst:        20,
+		CompressPayloads:         true,
 	}
 
 	cmd.Flags().StringVar(&opts.Addr, "addr", opts.Addr, "Listen address")
@@ -88,6 +145,24 @@ func NewStartCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.Platform, "platform", "", "The cloud provider platform name")
 	cmd.Flags().StringVar(&opts.WorkDir, "work-dir", opts.WorkDir, "Directory in which to store transient working data")
 	cmd.Flags().StringVar(&opts.MetricsAddr, "metrics-addr", opts.MetricsAddr, "The address the metric endpoint binds to.")
+	cmd.Flags().StringVar(&opts.ReleaseMetadataCacheDir, "release-metadata-cache-dir", opts.ReleaseMetadataCacheDir, "Directory in which to persist the metadata of release images, so it is not pulled again after restarts. Metadata is only cached in memory if empty")
+	cmd.Flags().StringVar(&opts.PayloadStore, "payload-store", opts.PayloadStore, "Where payloads are stored (memory, disk, secret, configmap). Payloads in disk, secret and configmap stores survive restarts, and those in secret and configmap stores are shared between replicas")
+	cmd.Flags().StringVar(&opts.PayloadStoreDir, "payload-store-dir", opts.PayloadStoreDir, "Directory of the disk payload store (default: <work-dir>/payload-store)")
+	cmd.Flags().StringVar(&opts.PayloadStoreEncryptionKeyFile, "payload-store-encryption-key-file", opts.PayloadStoreEncryptionKeyFile, "File containing a 32 bytes key, raw or base64 encoded, to encrypt stored payloads with. Required by the disk and configmap payload stores")
+	cmd.Flags().StringVar(&opts.Attestation, "attestation", opts.Attestation, "Verify the identity of instances on this platform (AWS, Azure) before serving them a payload. Disabled if empty")
+	cmd.Flags().StringVar(&opts.InfraID, "infra-id", opts.InfraID, "The infra ID of the cluster instances must belong to, required by attestation")
+	cmd.Flags().StringVar(&opts.AttestationRegion, "attestation-region", opts.AttestationRegion, "The AWS region instances must be in")
+	cmd.Flags().StringVar(&opts.AttestationAccountID, "attestation-account-id", opts.AttestationAccountID, "The AWS account instances must be in")
+	cmd.Flags().StringVar(&opts.AttestationSubscriptionID, "attestation-subscription-id", opts.AttestationSubscriptionID, "The Azure subscription instances must be in")
+	cmd.Flags().StringVar(&opts.AttestationCertificatesFile, "attestation-certificates-file", opts.AttestationCertificatesFile, "File containing the PEM encoded AWS certificates of instance identity documents, or the roots of the Azure attested data certificates (default on Azure: the system roots)")
+	cmd.Flags().BoolVar(&opts.SingleUseTokens, "single-use-tokens", opts.SingleUseTokens, "Serve a payload only to the first instance of every CAPI Machine. Requires attestation")
+	cmd.Flags().Float64Var(&opts.SourceFailureRate, "source-failure-rate", opts.SourceFailureRate, "The rate per second of requests with an invalid token or failing attestation allowed per source IP. Sources exceeding it are refused every request. Requests with a missing or unknown token are not counted. 0 disables the limit")
+	cmd.Flags().IntVar(&opts.SourceFailureBurst, "source-failure-burst", opts.SourceFailureBurst, "The number of requests with an invalid token or failing attestation allowed per source IP in a burst")
+	cmd.Flags().StringSliceVar(&opts.TrustedProxies, "trusted-proxies", opts.TrustedProxies, "The CIDRs of the proxies whose X-Forwarded-For header is trusted to hold the source IP of requests. The source IP is the one of the connection when empty")
+	cmd.Flags().Float64Var(&opts.TokenRequestRate, "token-request-rate", opts.TokenRequestRate, "The rate per second of requests allowed per token. 0 disables the limit")
+	cmd.Flags().IntVar(&opts.TokenRequestBurst, "token-request-burst", opts.TokenRequestBurst, "The number of requests allowed per token in a burst")
+	cmd.Flags().BoolVar(&opts.CompressPayloads, "compress-payloads", opts.CompressPayloads, "Serve payloads gzip encoded to the clients accepting it")
+	cmd.Flags().IntVar(&opts.MaxConcurrentGenerations, "max-concurrent-payload-generations", opts.MaxConcurrentGenerations, "The number of ignition payloads generated concurrently. Identical requests in progress are always generated once.")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -110,9 +185,9 @@ func NewStartCommand() *cobra.Command {
 
 // setUpPayloadStoreReconciler sets up manager with a TokenSecretReconciler controller
 // to keep the PayloadStore up to date.
-func setUpPayloadStoreReconciler(ctx context.Context, registryOverrides map[string]string, cloudProvider hyperv1.PlatformType, cacheDir string, metricsAddr string) (ctrl.Manager, error) {
+func setUpPayloadStoreReconciler(ctx context.Context, opts Options) (ctrl.Manager, *controllers.ExpiringCache, error) {
 	if os.Getenv(namespaceEnvVariableName) == "" {
-		return nil, fmt.Errorf("environment variable %s is empty, this is not supported", namespaceEnvVariableName)
+		return nil, nil, fmt.Errorf("environment variable %s is empty, this is not supported", namespaceEnvVariableName)
 	}
 
 	restConfig := ctrl.GetConfigOrDie()
@@ -120,47 +195,122 @@ func setUpPayloadStoreReconciler(ctx context.Context, registryOverrides map[stri
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:             hyperapi.Scheme,
 		Port:               9443,
-		MetricsBindAddress: metricsAddr,
+		MetricsBindAddress: opts.MetricsAddr,
 		// LeaderElection:     opts.EnableLeaderElection,
 		Namespace: os.Getenv(namespaceEnvVariableName),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("unable to start manager: %w", err)
+		return nil, nil, fmt.Errorf("unable to start manager: %w", err)
 	}
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
-		return nil, fmt.Errorf("unable to set up health check: %w", err)
+		return nil, nil, fmt.Errorf("unable to set up health check: %w", err)
 	}
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		return nil, fmt.Errorf("unable to set up ready check: %w", err)
+		return nil, nil, fmt.Errorf("unable to set up ready check: %w", err)
 	}
 
-	imageFileCache, err := controllers.NewImageFileCache(cacheDir)
+	imageFileCache, err := controllers.NewImageFileCache(opts.WorkDir)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create image file cache: %w", err)
+		return nil, nil, fmt.Errorf("unable to create image file cache: %w", err)
+	}
+
+	payloadStore, err := newPayloadStore(opts, mgr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create payload store: %w", err)
 	}
 
 	if err = (&controllers.TokenSecretReconciler{
 		Client:       mgr.GetClient(),
 		PayloadStore: payloadStore,
+		// Reconcile more token Secrets than payloads are generated, so token rotations
+		// and cached payloads are not blocked behind generations, which wait in the
+		// IgnitionProvider queue instead.
+		MaxConcurrentReconciles: opts.MaxConcurrentGenerations + tokenSecretReconcileHeadroom,
 		IgnitionProvider: &controllers.LocalIgnitionProvider{
 			ReleaseProvider: &releaseinfo.RegistryMirrorProviderDecorator{
-				Delegate: &releaseinfo.CachedProvider{
-					Inner: &releaseinfo.RegistryClientProvider{},
-					Cache: map[string]*releaseinfo.ReleaseImage{},
-				},
-				RegistryOverrides: registryOverrides,
+				Delegate: releaseinfo.NewCachedProvider(&releaseinfo.RegistryClientProvider{}, releaseinfo.CachedProviderOptions{
+					Dir:               opts.ReleaseMetadataCacheDir,
+					RegistryOverrides: opts.RegistryOverrides,
+				}),
+				RegistryOverrides: opts.RegistryOverrides,
 			},
-			Client:         mgr.GetClient(),
-			Namespace:      os.Getenv(namespaceEnvVariableName),
-			CloudProvider:  cloudProvider,
-			WorkDir:        cacheDir,
-			ImageFileCache: imageFileCache,
+			Client:                   mgr.GetClient(),
+			Namespace:                os.Getenv(namespaceEnvVariableName),
+			CloudProvider:            hyperv1.PlatformType(opts.Platform),
+			WorkDir:                  opts.WorkDir,
+			ImageFileCache:           imageFileCache,
+			MaxConcurrentGenerations: opts.MaxConcurrentGenerations,
 		},
 	}).SetupWithManager(ctx, mgr); err != nil {
-		return nil, fmt.Errorf("unable to create controller: %w", err)
+		return nil, nil, fmt.Errorf("unable to create controller: %w", err)
 	}
 
-	return mgr, nil
+	return mgr, payloadStore, nil
+}
+
+// newPayloadStore creates the payload store with the backend selected in opts.
+func newPayloadStore(opts Options, mgr ctrl.Manager) (*controllers.ExpiringCache, error) {
+	var backend controllers.PayloadBackend
+	switch opts.PayloadStore {
+	case PayloadStoreMemory:
+		return controllers.NewPayloadStore(), nil
+	case PayloadStoreDisk:
+		dir := opts.PayloadStoreDir
+		if dir == "" {
+			dir = filepath.Join(opts.WorkDir, "payload-store")
+		}
+		diskBackend, err := controllers.NewDiskPayloadBackend(dir)
+		if err != nil {
+			return nil, err
+		}
+		backend = diskBackend
+	case PayloadStoreSecret, PayloadStoreConfigMap:
+		backend = &controllers.KubernetesPayloadBackend{
+			Client:        mgr.GetClient(),
+			Reader:        mgr.GetAPIReader(),
+			Namespace:     os.Getenv(namespaceEnvVariableName),
+			UseConfigMaps: opts.PayloadStore == PayloadStoreConfigMap,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported payload store %q", opts.PayloadStore)
+	}
+
+	switch {
+	case opts.PayloadStoreEncryptionKeyFile != "":
+		key, err := controllers.ReadEncryptionKey(opts.PayloadStoreEncryptionKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		encryptedBackend, err := controllers.NewEncryptedPayloadBackend(backend, key)
+		if err != nil {
+			return nil, err
+		}
+		backend = encryptedBackend
+	case opts.PayloadStore != PayloadStoreSecret:
+		return nil, fmt.Errorf("the %s payload store requires an encryption key", opts.PayloadStore)
+	}
+	return controllers.NewPersistentPayloadStore(backend), nil
+}
+
+// newVerifier returns the attestation Verifier selected in opts, or nil if
+// attestation is disabled.
+func newVerifier(opts Options, mgr ctrl.Manager) (attestation.Verifier, error) {
+	if opts.Attestation == "" {
+		if opts.SingleUseTokens {
+			return nil, fmt.Errorf("single use tokens require attestation")
+		}
+		return nil, nil
+	}
+	// Machines are read from the API server rather than a cache, so the server
+	// doesn't watch every machine of the cluster.
+	return attestation.NewVerifier(hyperv1.PlatformType(opts.Attestation), mgr.GetAPIReader(), attestation.Options{
+		Namespace:        os.Getenv(namespaceEnvVariableName),
+		InfraID:          opts.InfraID,
+		Region:           opts.AttestationRegion,
+		AccountID:        opts.AttestationAccountID,
+		SubscriptionID:   opts.AttestationSubscriptionID,
+		CertificatesFile: opts.AttestationCertificatesFile,
+	})
 }
 
 func run(ctx context.Context, opts Options) error {
@@ -175,7 +325,7 @@ func run(ctx context.Context, opts Options) error {
 		return fmt.Errorf("failed to load serving cert: %w", err)
 	}
 
-	mgr, err := setUpPayloadStoreReconciler(ctx, opts.RegistryOverrides, hyperv1.PlatformType(opts.Platform), opts.WorkDir, opts.MetricsAddr)
+	mgr, payloadStore, err := setUpPayloadStoreReconciler(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("error setting up manager: %w", err)
 	}
@@ -183,71 +333,49 @@ func run(ctx context.Context, opts Options) error {
 		return fmt.Errorf("failed to add certWatcher to manager: %w", err)
 	}
 	go mgr.Start(ctx)
+	go wait.UntilWithContext(ctx, payloadStore.PruneBackend, payloadStorePruneInterval)
 
 	mgr.GetLogger().Info("Using opts", "opts", fmt.Sprintf("%+v", opts))
-	eventRecorder := mgr.GetEventRecorderFor("ignition-server")
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("User Agent: %s. Requested: %s", r.Header.Get("User-Agent"), r.URL.Path)
-
-		tokenSecret := nodepool.TokenSecret(os.Getenv(namespaceEnvVariableName),
-			util.ParseNamespacedName(r.Header.Get("NodePool")).Name,
-			r.Header.Get("TargetConfigVersionHash"))
-
-		if !ignPathPattern.MatchString(r.URL.Path) {
-			// No pattern matched; send 404 response.
-			log.Printf("Path not found: %s", r.URL.Path)
-			http.NotFound(w, r)
-			return
-		}
+	verifier, err := newVerifier(opts, mgr)
+	if err != nil {
+		return fmt.Errorf("error setting up attestation: %w", err)
+	}
+	annotator := newTokenSecretAnnotator(mgr.GetClient(), ctrl.Log.WithName("token-secret-annotator"))
+	go annotator.Start(ctx)
 
-		// Authorize the request against the token
-		const bearerPrefix = "Bearer "
-		auth := r.Header.Get("Authorization")
-		n := len(bearerPrefix)
-		if len(auth) < n || auth[:n] != bearerPrefix {
-			log.Printf("Invalid Authorization header value prefix")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Bad header")
-			return
-		}
-		encodedToken := auth[n:]
-		decodedToken, err := base64.StdEncoding.DecodeString(encodedToken)
+	var trustedProxies []*net.IPNet
+	for _, cidr := range opts.TrustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
 		if err != nil {
-			log.Printf("Invalid token value")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Token invalid")
-			return
-		}
-
-		value, ok := payloadStore.Get(string(decodedToken))
-		if !ok {
-			// We return a 5xx here to give ignition the chance to backoff and retry if the machine request happens
-			// before the content is cached for this token.
-			// https://coreos.github.io/ignition/operator-notes/#http-backoff-and-retry
-			log.Printf("Token not found")
-			http.Error(w, "Token not found", http.StatusNetworkAuthenticationRequired)
-			eventRecorder.Event(tokenSecret, corev1.EventTypeWarning, "GetPayloadFailed", "Token not found in cache")
-			return
+			return fmt.Errorf("invalid trusted proxy CIDR %q: %w", cidr, err)
 		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	handler := &ignitionHandler{
+		namespace:       os.Getenv(namespaceEnvVariableName),
+		payloadStore:    payloadStore,
+		eventRecorder:   mgr.GetEventRecorderFor("ignition-server"),
+		annotator:       annotator,
+		log:             ctrl.Log.WithName("access"),
+		sourceFailures:  newKeyedRateLimiter(opts.SourceFailureRate, opts.SourceFailureBurst),
+		tokenRequests:   newKeyedRateLimiter(opts.TokenRequestRate, opts.TokenRequestBurst),
+		trustedProxies:  trustedProxies,
+		verifier:        verifier,
+		singleUseTokens: opts.SingleUseTokens,
+		client:          mgr.GetClient(),
+		reader:          mgr.GetAPIReader(),
+	}
+	if opts.CompressPayloads {
+		handler.compressedPayloads = newCompressedPayloadCache(compressedPayloadCacheSize)
+	}
+	go wait.UntilWithContext(ctx, func(context.Context) {
+		handler.sourceFailures.Prune()
+		handler.tokenRequests.Prune()
+	}, rateLimiterPruneInterval)
 
-		w.WriteHeader(http.StatusOK)
-		w.Write(value.Payload)
-
-		eventRecorder.Event(tokenSecret, corev1.EventTypeNormal, "GetPayload", "")
-		getRequestsPerNodePool.WithLabelValues(r.Header.Get("NodePool")).Inc()
-
-		// Annotate tokenSecret so NodePool controller can set a conditions based on it.
-		if err := mgr.GetClient().Get(ctx, client.ObjectKeyFromObject(tokenSecret), tokenSecret); err != nil {
-			log.Printf("Failed to get tokenSecret resource: %q: %s", client.ObjectKeyFromObject(tokenSecret).String(), err)
-		} else {
-			tokenSecret.Annotations[TokenSecretIgnitionReachedAnnotation] = "True"
-			if err := mgr.GetClient().Update(ctx, tokenSecret); err != nil {
-				log.Printf("Failed to update tokenSecret: %q: %s", tokenSecret.Name, err)
-			}
-		}
-	})
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
 	mux.HandleFunc("/healthz", func(http.ResponseWriter, *http.Request) {})
 
 	server := http.Server{
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -23,10 +24,21 @@ func init() {
 // ExpiringCache enables a cache of pairs "token: payload".
 // Any pair in the cache is expired once entry.expiry time is above the cache ttl.
 // The expiry time is renewed for an existing value on every Get operation.
-// Garbage collection of expired values happens on every Get operation.
+// Garbage collection of expired values in memory happens on every Get operation.
+//
+// If a backend is set, pairs are also written to it and pairs missing from memory
+// are read from it, so they survive restarts and are shared between replicas.
+// Only the tokens of watched token secrets, recorded by Track, are read from the
+// backend, so requests with unknown tokens never reach it.
+// Expired pairs are removed from the backend by PruneBackend, based on the expiry
+// time stored in the backend, as another replica may have renewed them.
 type ExpiringCache struct {
-	cache map[string]*entry
-	ttl   time.Duration
+	cache   map[string]*entry
+	ttl     time.Duration
+	backend PayloadBackend
+	// known maps the backend keys of the tokens that can be read from the backend
+	// to the name of their token secret.
+	known map[string]string
 	sync.RWMutex
 }
 
@@ -44,33 +56,78 @@ func (c *ExpiringCache) Get(key string) (value CacheValue, ok bool) {
 	c.garbageCollect()
 
 	c.RLock()
-	defer c.RUnlock()
-
 	result, ok := c.cache[key]
-	if !ok {
+	_, known := c.known[backendKey(key)]
+	c.RUnlock()
+	if ok {
+		return result.value, true
+	}
+	if c.backend == nil || !known {
 		return CacheValue{}, false
 	}
+	return c.load(key)
+}
 
-	return result.value, ok
+// Track records tokens as the tokens of the token secret secretName, replacing
+// those recorded before, so their payloads can be read from the backend.
+func (c *ExpiringCache) Track(secretName string, tokens ...string) {
+	c.Lock()
+	defer c.Unlock()
+	c.untrack(secretName)
+	for _, token := range tokens {
+		if token != "" {
+			c.known[backendKey(token)] = secretName
+		}
+	}
 }
 
-func (c *ExpiringCache) Set(key string, value CacheValue) {
+// Untrack forgets the tokens of the token secret secretName.
+func (c *ExpiringCache) Untrack(secretName string) {
 	c.Lock()
 	defer c.Unlock()
+	c.untrack(secretName)
+}
+
+// untrack forgets the tokens of secretName. The caller must hold the lock.
+func (c *ExpiringCache) untrack(secretName string) {
+	for id, name := range c.known {
+		if name == secretName {
+			delete(c.known, id)
+		}
+	}
+}
 
+func (c *ExpiringCache) Set(key string, value CacheValue) {
+	c.Lock()
 	// Renew expiring time everytime time we Set.
-	c.cache[key] = &entry{
+	e := &entry{
 		value:  value,
 		expiry: time.Now().Add(c.ttl),
 	}
+	c.cache[key] = e
+	c.known[backendKey(key)] = value.SecretName
 	PayloadCacheSizeTotal.Inc()
+	c.Unlock()
+
+	c.save(key, e)
 }
 
+// Delete removes key from memory and from the backend.
 func (c *ExpiringCache) Delete(key string) {
 	c.Lock()
-	defer c.Unlock()
-	delete(c.cache, key)
-	PayloadCacheSizeTotal.Dec()
+	c.evict(key)
+	delete(c.known, backendKey(key))
+	c.Unlock()
+
+	c.deleteFromBackend(key)
+}
+
+// evict removes key from memory. The caller must hold the lock.
+func (c *ExpiringCache) evict(key string) {
+	if _, ok := c.cache[key]; ok {
+		delete(c.cache, key)
+		PayloadCacheSizeTotal.Dec()
+	}
 }
 
 func (c *ExpiringCache) Keys() []string {
@@ -84,10 +141,120 @@ func (c *ExpiringCache) Keys() []string {
 	return keys
 }
 
+// garbageCollect removes the expired values from memory. They are left in the
+// backend for PruneBackend, so Get does not wait for it.
 func (c *ExpiringCache) garbageCollect() {
+	c.RLock()
+	var expired []string
 	for key, entry := range c.cache {
 		if time.Now().After(entry.expiry) {
-			c.Delete(key)
+			expired = append(expired, key)
+		}
+	}
+	c.RUnlock()
+	if len(expired) == 0 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	for _, key := range expired {
+		// The value may have been renewed since.
+		if entry, ok := c.cache[key]; ok && time.Now().After(entry.expiry) {
+			c.evict(key)
+		}
+	}
+}
+
+// load reads the value of key from the backend and caches it in memory until the
+// expiry time it was stored with.
+func (c *ExpiringCache) load(key string) (CacheValue, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	data, ok, err := c.backend.Load(ctx, backendKey(key))
+	if err != nil {
+		backendLog.Error(err, "failed to load payload")
+		PayloadBackendErrorsTotal.Inc()
+		return CacheValue{}, false
+	}
+	if !ok {
+		return CacheValue{}, false
+	}
+	e, err := decodeEntry(data)
+	if err != nil {
+		backendLog.Error(err, "failed to decode payload")
+		PayloadBackendErrorsTotal.Inc()
+		return CacheValue{}, false
+	}
+	if time.Now().After(e.expiry) {
+		return CacheValue{}, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if existing, ok := c.cache[key]; ok {
+		return existing.value, true
+	}
+	c.cache[key] = e
+	PayloadCacheSizeTotal.Inc()
+	return e.value, true
+}
+
+// save writes e to the backend. Failures are only logged because the value is still
+// served from memory.
+func (c *ExpiringCache) save(key string, e *entry) {
+	if c.backend == nil {
+		return
+	}
+	data, err := encodeEntry(e)
+	if err != nil {
+		backendLog.Error(err, "failed to encode payload")
+		PayloadBackendErrorsTotal.Inc()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := c.backend.Save(ctx, backendKey(key), data); err != nil {
+		backendLog.Error(err, "failed to save payload")
+		PayloadBackendErrorsTotal.Inc()
+	}
+}
+
+func (c *ExpiringCache) deleteFromBackend(key string) {
+	if c.backend == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := c.backend.Delete(ctx, backendKey(key)); err != nil {
+		backendLog.Error(err, "failed to delete payload")
+		PayloadBackendErrorsTotal.Inc()
+	}
+}
+
+// PruneBackend deletes the expired values from the backend, including those of
+// tokens this replica never loaded.
+func (c *ExpiringCache) PruneBackend(ctx context.Context) {
+	if c.backend == nil {
+		return
+	}
+	ids, err := c.backend.Keys(ctx)
+	if err != nil {
+		backendLog.Error(err, "failed to list payloads")
+		PayloadBackendErrorsTotal.Inc()
+		return
+	}
+	for _, id := range ids {
+		data, ok, err := c.backend.Load(ctx, id)
+		if err != nil || !ok {
+			continue
+		}
+		if e, err := decodeEntry(data); err == nil && time.Now().Before(e.expiry) {
+			continue
+		}
+		if err := c.backend.Delete(ctx, id); err != nil {
+			backendLog.Error(err, "failed to delete expired payload", "id", id)
+			PayloadBackendErrorsTotal.Inc()
 		}
 	}
 }
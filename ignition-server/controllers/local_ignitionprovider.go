@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -17,6 +19,7 @@ import (
 	"time"
 
 	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/go-logr/logr"
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 	"github.com/openshift/hypershift/control-plane-operator/controllers/hostedcontrolplane/manifests"
 	"github.com/openshift/hypershift/support/certs"
@@ -39,11 +42,9 @@ import (
 // image because the effort of managing the cache is not yet justified by any
 // performance measurements.
 //
-// Currently, all GetPayload executions are performed serially, enforced by a
-// mutex. Enabling concurrent executions requires more work because of the of
-// MCS, which is an HTTP server process, implying work to allocate
-// non-conflicting ports. This effort is not yet justified by any performance
-// measurements.
+// Up to MaxConcurrentGenerations GetPayload executions run concurrently, each
+// with its own working directory and MCS ports. Executions for the same release
+// image and config while one is in progress share its result.
 type LocalIgnitionProvider struct {
 	Client          client.Client
 	ReleaseProvider releaseinfo.Provider
@@ -61,15 +62,26 @@ type LocalIgnitionProvider struct {
 
 	ImageFileCache *imageFileCache
 
-	lock sync.Mutex
+	// MaxConcurrentGenerations is the number of payloads generated concurrently.
+	// Defaults to 1.
+	MaxConcurrentGenerations int
+
+	generatorOnce sync.Once
+	generator     *payloadGenerator
 }
 
 var _ IgnitionProvider = (*LocalIgnitionProvider)(nil)
 
 func (p *LocalIgnitionProvider) GetPayload(ctx context.Context, releaseImage string, customConfig string) ([]byte, error) {
-	p.lock.Lock()
-	defer p.lock.Unlock()
+	p.generatorOnce.Do(func() {
+		p.generator = newPayloadGenerator(p.MaxConcurrentGenerations, p.generatePayload)
+	})
+	return p.generator.Generate(ctx, releaseImage, customConfig)
+}
 
+// generatePayload extracts the MCO binaries of releaseImage and runs them to render
+// the payload for customConfig.
+func (p *LocalIgnitionProvider) generatePayload(ctx context.Context, releaseImage string, customConfig string) ([]byte, error) {
 	log := ctrl.Log.WithName("get-payload")
 
 	// Fetch the pull secret contents
@@ -422,63 +434,20 @@ func (p *LocalIgnitionProvider) GetPayload(ctx context.Context, releaseImage str
 			return nil, fmt.Errorf("failed to generate certificates: %w", err)
 		}
 
-		// Spin up the MCS process and ensure it's signaled to terminate when
-		// the function returns
-		mcsCtx, cancel := context.WithCancel(ctx)
-		defer cancel()
-		cmd := exec.CommandContext(mcsCtx, filepath.Join(binDir, "machine-config-server"), "bootstrap",
-			fmt.Sprintf("--server-basedir=%s", mcsBaseDir),
-			fmt.Sprintf("--bootstrap-kubeconfig=%s/kubeconfig", mcsBaseDir),
-			fmt.Sprintf("--cert=%s/tls.crt", mcsBaseDir),
-			fmt.Sprintf("--key=%s/tls.key", mcsBaseDir),
-			"--secure-port=22623",
-			"--insecure-port=22624",
-		)
-		go func() {
-			out, err := cmd.CombinedOutput()
-			log.Info("machine-config-server process exited", "output", string(out), "error", err)
-		}()
-
-		// Try connecting to the server until we get a response or the context is
-		// closed
-		httpclient := &http.Client{
-			Timeout: 5 * time.Second,
-		}
+		// The ports are only free when they are picked, so another process can
+		// bind them before the MCS does. Start the MCS again on new ports when
+		// it exits before serving the payload.
 		var payload []byte
-		err = wait.PollUntilWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
-			req, err := http.NewRequestWithContext(ctx, "GET", "http://localhost:22624/config/master", nil)
-			if err != nil {
-				return false, fmt.Errorf("error building http request: %w", err)
-			}
-			// We pass expected Headers to return the right config version.
-			// https://www.iana.org/assignments/media-types/application/vnd.coreos.ignition+json
-			// https://github.com/coreos/ignition/blob/0cbe33fee45d012515479a88f0fe94ef58d5102b/internal/resource/url.go#L61-L64
-			// https://github.com/openshift/machine-config-operator/blob/9c6c2bfd7ed498bfbc296d530d1839bd6a177b0b/pkg/server/api.go#L269
-			req.Header.Add("Accept", "application/vnd.coreos.ignition+json;version=3.2.0, */*;q=0.1")
-			res, err := httpclient.Do(req)
-			if err != nil {
-				log.Error(err, "mcs request failed")
-				return false, nil
-			}
-			if res.StatusCode != http.StatusOK {
-				log.Error(err, "mcs returned unexpected response code", "code", res.StatusCode)
-				return false, nil
-			}
-
-			defer func() {
-				if err := res.Body.Close(); err != nil {
-					log.Error(err, "failed to close mcs response body")
-				}
-			}()
-			p, err := io.ReadAll(res.Body)
-			if err != nil {
-				log.Error(err, "failed to read mcs response body")
-				return false, nil
+		for attempt := 1; ; attempt++ {
+			payload, err = runMachineConfigServer(ctx, log, binDir, mcsBaseDir)
+			if err == nil || !errors.Is(err, errMachineConfigServerExited) || attempt == mcsStartAttempts {
+				break
 			}
-			payload = p
+			log.Info("machine-config-server exited before serving the payload, retrying on new ports", "attempt", attempt)
+		}
+		if err == nil {
 			log.Info("got mcs payload", "time", time.Since(start).Round(time.Second).String())
-			return true, nil
-		})
+		}
 		return payload, err
 	}()
 	if err != nil {
@@ -488,6 +457,102 @@ func (p *LocalIgnitionProvider) GetPayload(ctx context.Context, releaseImage str
 	return payload, nil
 }
 
+// mcsStartAttempts is how many times the MCS is started before giving up when
+// it exits before serving the payload.
+const mcsStartAttempts = 3
+
+var errMachineConfigServerExited = errors.New("machine-config-server exited")
+
+// runMachineConfigServer runs the MCS on free ports until it serves the payload
+// or the context is closed. It returns errMachineConfigServerExited when the MCS
+// exits first, e.g. because one of its ports was taken in the meantime.
+func runMachineConfigServer(ctx context.Context, log logr.Logger, binDir, mcsBaseDir string) ([]byte, error) {
+	// Spin up the MCS process on free ports, so that it doesn't conflict
+	// with concurrent executions, and ensure it's signaled to terminate when
+	// the function returns
+	ports, err := freePorts(2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate mcs ports: %w", err)
+	}
+	securePort, insecurePort := ports[0], ports[1]
+	mcsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(mcsCtx, filepath.Join(binDir, "machine-config-server"), "bootstrap",
+		fmt.Sprintf("--server-basedir=%s", mcsBaseDir),
+		fmt.Sprintf("--bootstrap-kubeconfig=%s/kubeconfig", mcsBaseDir),
+		fmt.Sprintf("--cert=%s/tls.crt", mcsBaseDir),
+		fmt.Sprintf("--key=%s/tls.key", mcsBaseDir),
+		fmt.Sprintf("--secure-port=%d", securePort),
+		fmt.Sprintf("--insecure-port=%d", insecurePort),
+	)
+	exited := make(chan error, 1)
+	go func() {
+		out, err := cmd.CombinedOutput()
+		log.Info("machine-config-server process exited", "output", string(out), "error", err)
+		exited <- err
+	}()
+
+	// Try connecting to the server until we get a response, the server exits
+	// or the context is closed
+	httpclient := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	var payload []byte
+	err = wait.PollUntilWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
+		select {
+		case err := <-exited:
+			return false, fmt.Errorf("%w: %v", errMachineConfigServerExited, err)
+		default:
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("http://localhost:%d/config/master", insecurePort), nil)
+		if err != nil {
+			return false, fmt.Errorf("error building http request: %w", err)
+		}
+		// We pass expected Headers to return the right config version.
+		// https://www.iana.org/assignments/media-types/application/vnd.coreos.ignition+json
+		// https://github.com/coreos/ignition/blob/0cbe33fee45d012515479a88f0fe94ef58d5102b/internal/resource/url.go#L61-L64
+		// https://github.com/openshift/machine-config-operator/blob/9c6c2bfd7ed498bfbc296d530d1839bd6a177b0b/pkg/server/api.go#L269
+		req.Header.Add("Accept", "application/vnd.coreos.ignition+json;version=3.2.0, */*;q=0.1")
+		res, err := httpclient.Do(req)
+		if err != nil {
+			log.Error(err, "mcs request failed")
+			return false, nil
+		}
+		if res.StatusCode != http.StatusOK {
+			log.Error(err, "mcs returned unexpected response code", "code", res.StatusCode)
+			return false, nil
+		}
+
+		defer func() {
+			if err := res.Body.Close(); err != nil {
+				log.Error(err, "failed to close mcs response body")
+			}
+		}()
+		p, err := io.ReadAll(res.Body)
+		if err != nil {
+			log.Error(err, "failed to read mcs response body")
+			return false, nil
+		}
+		payload = p
+		return true, nil
+	})
+	return payload, err
+}
+
+// freePorts returns n distinct ports that are free on the loopback interface.
+func freePorts(n int) ([]int, error) {
+	var ports []int
+	for i := 0; i < n; i++ {
+		listener, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+		defer listener.Close()
+		ports = append(ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+	return ports, nil
+}
+
 // copyFile copies a file named src to dst, preserving attributes.
 func copyFile(src, dst string) error {
 	srcfd, err := os.Open(src)
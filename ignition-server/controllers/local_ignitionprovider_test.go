@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
 )
 
@@ -198,3 +203,14 @@ func TestFindMatchingManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestRunMachineConfigServerExits(t *testing.T) {
+	g := NewGomegaWithT(t)
+	binDir := t.TempDir()
+	// Fail like the MCS does when one of its ports is already in use.
+	err := os.WriteFile(filepath.Join(binDir, "machine-config-server"), []byte("#!/bin/sh\necho 'bind: address already in use'\nexit 1\n"), 0755)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = runMachineConfigServer(context.Background(), logr.Discard(), binDir, t.TempDir())
+	g.Expect(errors.Is(err, errMachineConfigServerExited)).To(BeTrue(), "unexpected error: %v", err)
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// backendTimeout bounds every operation of the payload store on its backend.
+	backendTimeout = 30 * time.Second
+)
+
+var (
+	backendLog = ctrl.Log.WithName("payload-backend")
+
+	PayloadBackendErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ign_server_payload_backend_errors_total",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		PayloadBackendErrorsTotal,
+	)
+}
+
+// PayloadBackend persists the entries of the payload store. Entries are identified
+// by a hash of their token, so tokens are never written to the backend.
+type PayloadBackend interface {
+	// Load returns the data stored for id and whether it exists.
+	Load(ctx context.Context, id string) ([]byte, bool, error)
+	// Save stores data for id, replacing any previous data.
+	Save(ctx context.Context, id string, data []byte) error
+	// Delete removes the data stored for id, if any.
+	Delete(ctx context.Context, id string) error
+	// Keys returns the ids of all stored data.
+	Keys(ctx context.Context) ([]string, error)
+}
+
+// backendKey returns the id of the entry of token in a PayloadBackend.
+func backendKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:24])
+}
+
+// storedEntry is the serialized form of an entry in a PayloadBackend.
+type storedEntry struct {
+	Payload    []byte    `json:"payload"`
+	SecretName string    `json:"secretName"`
+	Expiry     time.Time `json:"expiry"`
+}
+
+func encodeEntry(e *entry) ([]byte, error) {
+	return json.Marshal(storedEntry{Payload: e.value.Payload, SecretName: e.value.SecretName, Expiry: e.expiry})
+}
+
+func decodeEntry(data []byte) (*entry, error) {
+	var stored storedEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return &entry{value: CacheValue{Payload: stored.Payload, SecretName: stored.SecretName}, expiry: stored.Expiry}, nil
+}
+
+// DiskPayloadBackend stores every entry in a file of Dir.
+type DiskPayloadBackend struct {
+	Dir string
+}
+
+var _ PayloadBackend = (*DiskPayloadBackend)(nil)
+
+func NewDiskPayloadBackend(dir string) (*DiskPayloadBackend, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create payload store directory: %w", err)
+	}
+	return &DiskPayloadBackend{Dir: dir}, nil
+}
+
+func (b *DiskPayloadBackend) Load(_ context.Context, id string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(b.Dir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Save writes data to a temporary file that is renamed to the entry file, so
+// concurrent readers never see a partial entry.
+func (b *DiskPayloadBackend) Save(_ context.Context, id string, data []byte) error {
+	file, err := os.CreateTemp(b.Dir, ".tmp-"+id+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filepath.Join(b.Dir, id))
+}
+
+func (b *DiskPayloadBackend) Delete(_ context.Context, id string) error {
+	if err := os.Remove(filepath.Join(b.Dir, id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (b *DiskPayloadBackend) Keys(_ context.Context) ([]string, error) {
+	files, err := os.ReadDir(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		ids = append(ids, file.Name())
+	}
+	return ids, nil
+}
+
+// encryptedPayloadBackend encrypts the data of a delegate PayloadBackend with
+// AES-GCM. The id of an entry is authenticated with its data, so the data of an
+// entry can't be swapped for another.
+type encryptedPayloadBackend struct {
+	delegate PayloadBackend
+	aead     cipher.AEAD
+}
+
+var _ PayloadBackend = (*encryptedPayloadBackend)(nil)
+
+// NewEncryptedPayloadBackend returns a PayloadBackend that encrypts the data stored
+// in delegate with key, which must be 32 bytes long.
+func NewEncryptedPayloadBackend(delegate PayloadBackend, key []byte) (PayloadBackend, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes long, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedPayloadBackend{delegate: delegate, aead: aead}, nil
+}
+
+func (b *encryptedPayloadBackend) Load(ctx context.Context, id string) ([]byte, bool, error) {
+	data, ok, err := b.delegate.Load(ctx, id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	nonceSize := b.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, false, fmt.Errorf("encrypted payload %s is too short", id)
+	}
+	plaintext, err := b.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(id))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decrypt payload %s: %w", id, err)
+	}
+	return plaintext, true, nil
+}
+
+func (b *encryptedPayloadBackend) Save(ctx context.Context, id string, data []byte) error {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return b.delegate.Save(ctx, id, b.aead.Seal(nonce, nonce, data, []byte(id)))
+}
+
+func (b *encryptedPayloadBackend) Delete(ctx context.Context, id string) error {
+	return b.delegate.Delete(ctx, id)
+}
+
+func (b *encryptedPayloadBackend) Keys(ctx context.Context) ([]string, error) {
+	return b.delegate.Keys(ctx)
+}
+
+// ReadEncryptionKey reads a 32 bytes key from file, either raw or base64 encoded.
+func ReadEncryptionKey(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key: %w", err)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key is neither 32 bytes nor base64 encoded: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PayloadStoreLabel is set on the Secrets and ConfigMaps of the payload store.
+	PayloadStoreLabel = "hypershift.openshift.io/ignition-payload-store"
+	// payloadIDLabel is the id of the entry a chunk belongs to.
+	payloadIDLabel = "hypershift.openshift.io/ignition-payload-id"
+	// payloadChunkLabel is the index of a chunk in its entry.
+	payloadChunkLabel = "hypershift.openshift.io/ignition-payload-chunk"
+	// payloadChunksAnnotation is the number of chunks of an entry, set on its first chunk.
+	payloadChunksAnnotation = "hypershift.openshift.io/ignition-payload-chunks"
+	// payloadChecksumAnnotation is the SHA-256 of the data of an entry, set on its first chunk.
+	payloadChecksumAnnotation = "hypershift.openshift.io/ignition-payload-checksum"
+
+	payloadChunkKey = "chunk"
+	// payloadChunkSize keeps every chunk well below the 1MiB size limit of Secrets
+	// and ConfigMaps.
+	payloadChunkSize = 768 * 1024
+)
+
+// KubernetesPayloadBackend stores every entry in one or more Secrets, or ConfigMaps,
+// of Namespace. Payloads can be larger than an object can hold, so their data is
+// split in chunks. The first chunk is written last and carries a checksum of the
+// data, so readers never use an entry that is being written.
+type KubernetesPayloadBackend struct {
+	Client client.Client
+	// Reader reads the chunks. It should not be backed by a cache, so entries
+	// written by other replicas are found right away.
+	Reader    client.Reader
+	Namespace string
+	// UseConfigMaps stores the chunks in ConfigMaps instead of Secrets. Payloads
+	// contain credentials, so ConfigMaps should only be used with encryption.
+	UseConfigMaps bool
+}
+
+var _ PayloadBackend = (*KubernetesPayloadBackend)(nil)
+
+func (b *KubernetesPayloadBackend) Load(ctx context.Context, id string) ([]byte, bool, error) {
+	head, data, err := b.getChunk(ctx, id, 0)
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	chunks, err := strconv.Atoi(head.GetAnnotations()[payloadChunksAnnotation])
+	if err != nil || chunks < 1 {
+		return nil, false, fmt.Errorf("payload %s has an invalid number of chunks", id)
+	}
+	buffer := bytes.NewBuffer(data)
+	for i := 1; i < chunks; i++ {
+		_, data, err := b.getChunk(ctx, id, i)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get chunk %d of payload %s: %w", i, id, err)
+		}
+		buffer.Write(data)
+	}
+	if payloadChecksum(buffer.Bytes()) != head.GetAnnotations()[payloadChecksumAnnotation] {
+		return nil, false, fmt.Errorf("payload %s does not match its checksum, it is being written", id)
+	}
+	return buffer.Bytes(), true, nil
+}
+
+func (b *KubernetesPayloadBackend) Save(ctx context.Context, id string, data []byte) error {
+	var chunks [][]byte
+	for len(data) > payloadChunkSize {
+		chunks = append(chunks, data[:payloadChunkSize])
+		data = data[payloadChunkSize:]
+	}
+	chunks = append(chunks, data)
+
+	for i := len(chunks) - 1; i >= 0; i-- {
+		var annotations map[string]string
+		if i == 0 {
+			annotations = map[string]string{
+				payloadChunksAnnotation:   strconv.Itoa(len(chunks)),
+				payloadChecksumAnnotation: payloadChecksum(bytes.Join(chunks, nil)),
+			}
+		}
+		if err := b.putChunk(ctx, id, i, annotations, chunks[i]); err != nil {
+			return fmt.Errorf("failed to write chunk %d of payload %s: %w", i, id, err)
+		}
+	}
+
+	// Remove the chunks left over from larger data previously stored for id.
+	objects, err := b.list(ctx, client.MatchingLabels{PayloadStoreLabel: "true", payloadIDLabel: id})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if index, err := strconv.Atoi(obj.GetLabels()[payloadChunkLabel]); err == nil && index < len(chunks) {
+			continue
+		}
+		if err := b.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *KubernetesPayloadBackend) Delete(ctx context.Context, id string) error {
+	objects, err := b.list(ctx, client.MatchingLabels{PayloadStoreLabel: "true", payloadIDLabel: id})
+	if err != nil {
+		return err
+	}
+	// Delete the first chunk first, so readers don't use a partially deleted entry.
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].GetLabels()[payloadChunkLabel] < objects[j].GetLabels()[payloadChunkLabel]
+	})
+	for _, obj := range objects {
+		if err := b.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *KubernetesPayloadBackend) Keys(ctx context.Context) ([]string, error) {
+	objects, err := b.list(ctx, client.MatchingLabels{PayloadStoreLabel: "true", payloadChunkLabel: "0"})
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, obj := range objects {
+		ids = append(ids, obj.GetLabels()[payloadIDLabel])
+	}
+	return ids, nil
+}
+
+func (b *KubernetesPayloadBackend) chunkName(id string, index int) string {
+	return fmt.Sprintf("ignition-payload-%s-%d", id, index)
+}
+
+func (b *KubernetesPayloadBackend) newObject(id string, index int) client.Object {
+	meta := metav1.ObjectMeta{Namespace: b.Namespace, Name: b.chunkName(id, index)}
+	if b.UseConfigMaps {
+		return &corev1.ConfigMap{ObjectMeta: meta}
+	}
+	return &corev1.Secret{ObjectMeta: meta}
+}
+
+func (b *KubernetesPayloadBackend) getChunk(ctx context.Context, id string, index int) (client.Object, []byte, error) {
+	obj := b.newObject(id, index)
+	if err := b.Reader.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return nil, nil, err
+	}
+	return obj, chunkData(obj), nil
+}
+
+func (b *KubernetesPayloadBackend) putChunk(ctx context.Context, id string, index int, annotations map[string]string, data []byte) error {
+	obj := b.newObject(id, index)
+	setChunk := func(obj client.Object) {
+		obj.SetLabels(map[string]string{
+			PayloadStoreLabel: "true",
+			payloadIDLabel:    id,
+			payloadChunkLabel: strconv.Itoa(index),
+		})
+		obj.SetAnnotations(annotations)
+		switch obj := obj.(type) {
+		case *corev1.Secret:
+			obj.Type = corev1.SecretTypeOpaque
+			obj.Data = map[string][]byte{payloadChunkKey: data}
+		case *corev1.ConfigMap:
+			obj.BinaryData = map[string][]byte{payloadChunkKey: data}
+		}
+	}
+	setChunk(obj)
+	err := b.Client.Create(ctx, obj)
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing := b.newObject(id, index)
+	if err := b.Reader.Get(ctx, client.ObjectKeyFromObject(existing), existing); err != nil {
+		return err
+	}
+	setChunk(existing)
+	return b.Client.Update(ctx, existing)
+}
+
+func (b *KubernetesPayloadBackend) list(ctx context.Context, selector client.MatchingLabels) ([]client.Object, error) {
+	var objects []client.Object
+	if b.UseConfigMaps {
+		list := &corev1.ConfigMapList{}
+		if err := b.Reader.List(ctx, list, client.InNamespace(b.Namespace), selector); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+		return objects, nil
+	}
+	list := &corev1.SecretList{}
+	if err := b.Reader.List(ctx, list, client.InNamespace(b.Namespace), selector); err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		objects = append(objects, &list.Items[i])
+	}
+	return objects, nil
+}
+
+func chunkData(obj client.Object) []byte {
+	switch obj := obj.(type) {
+	case *corev1.Secret:
+		return obj.Data[payloadChunkKey]
+	case *corev1.ConfigMap:
+		return obj.BinaryData[payloadChunkKey]
+	}
+	return nil
+}
+
+func payloadChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperapi "github.com/openshift/hypershift/api"
+)
+
+func TestPayloadBackends(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	largePayload := make([]byte, 2*payloadChunkSize+1024)
+	if _, err := rand.Read(largePayload); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		backend func(g *WithT) PayloadBackend
+	}{
+		{
+			name: "When using the disk backend it should store payloads in files",
+			backend: func(g *WithT) PayloadBackend {
+				backend, err := NewDiskPayloadBackend(filepath.Join(t.TempDir(), "store"))
+				g.Expect(err).ToNot(HaveOccurred())
+				return backend
+			},
+		},
+		{
+			name: "When using the encrypted disk backend it should store payloads in files",
+			backend: func(g *WithT) PayloadBackend {
+				disk, err := NewDiskPayloadBackend(t.TempDir())
+				g.Expect(err).ToNot(HaveOccurred())
+				backend, err := NewEncryptedPayloadBackend(disk, key)
+				g.Expect(err).ToNot(HaveOccurred())
+				return backend
+			},
+		},
+		{
+			name: "When using the secret backend it should store payloads in chunked secrets",
+			backend: func(g *WithT) PayloadBackend {
+				c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).Build()
+				return &KubernetesPayloadBackend{Client: c, Reader: c, Namespace: "clusters-example"}
+			},
+		},
+		{
+			name: "When using the configmap backend it should store payloads in chunked configmaps",
+			backend: func(g *WithT) PayloadBackend {
+				c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).Build()
+				backend, err := NewEncryptedPayloadBackend(&KubernetesPayloadBackend{Client: c, Reader: c, Namespace: "clusters-example", UseConfigMaps: true}, key)
+				g.Expect(err).ToNot(HaveOccurred())
+				return backend
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			ctx := context.Background()
+			backend := tc.backend(g)
+
+			_, ok, err := backend.Load(ctx, "missing")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeFalse())
+
+			g.Expect(backend.Save(ctx, "large", largePayload)).To(Succeed())
+			g.Expect(backend.Save(ctx, "small", []byte("payload"))).To(Succeed())
+			data, ok, err := backend.Load(ctx, "large")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeTrue())
+			g.Expect(bytes.Equal(data, largePayload)).To(BeTrue())
+			g.Expect(backend.Keys(ctx)).To(ConsistOf("large", "small"))
+
+			// Replacing a large payload with a smaller one leaves no chunks behind.
+			g.Expect(backend.Save(ctx, "large", []byte("replaced"))).To(Succeed())
+			data, ok, err = backend.Load(ctx, "large")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeTrue())
+			g.Expect(string(data)).To(Equal("replaced"))
+
+			g.Expect(backend.Delete(ctx, "large")).To(Succeed())
+			g.Expect(backend.Delete(ctx, "missing")).To(Succeed())
+			_, ok, err = backend.Load(ctx, "large")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeFalse())
+			g.Expect(backend.Keys(ctx)).To(ConsistOf("small"))
+		})
+	}
+}
+
+func TestKubernetesPayloadBackendChunks(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(hyperapi.Scheme).Build()
+	backend := &KubernetesPayloadBackend{Client: c, Reader: c, Namespace: "clusters-example"}
+
+	g.Expect(backend.Save(ctx, "id", make([]byte, 2*payloadChunkSize+1))).To(Succeed())
+	secrets := &corev1.SecretList{}
+	g.Expect(c.List(ctx, secrets, client.MatchingLabels{PayloadStoreLabel: "true"})).To(Succeed())
+	g.Expect(secrets.Items).To(HaveLen(3))
+	for _, secret := range secrets.Items {
+		g.Expect(len(secret.Data[payloadChunkKey])).To(BeNumerically("<=", payloadChunkSize))
+	}
+
+	// An entry whose first chunk doesn't match the other chunks is being written.
+	chunk := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "clusters-example", Name: "ignition-payload-id-2"}, chunk)).To(Succeed())
+	chunk.Data[payloadChunkKey] = []byte("other")
+	g.Expect(c.Update(ctx, chunk)).To(Succeed())
+	_, _, err := backend.Load(ctx, "id")
+	g.Expect(err).To(MatchError(ContainSubstring("does not match its checksum")))
+}
+
+func TestEncryptedPayloadBackend(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	g.Expect(err).ToNot(HaveOccurred())
+	dir := t.TempDir()
+	disk, err := NewDiskPayloadBackend(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	backend, err := NewEncryptedPayloadBackend(disk, key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(backend.Save(ctx, "a", []byte("secret payload"))).To(Succeed())
+	raw, err := os.ReadFile(filepath.Join(dir, "a"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(raw)).ToNot(ContainSubstring("secret payload"))
+
+	// The data of an entry can't be used as the data of another one.
+	g.Expect(os.WriteFile(filepath.Join(dir, "b"), raw, 0600)).To(Succeed())
+	_, _, err = backend.Load(ctx, "b")
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = NewEncryptedPayloadBackend(disk, []byte("short"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestPersistentPayloadStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	backend, err := NewDiskPayloadBackend(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// A replica, or a restarted one, serves the payloads stored by another.
+	first := NewPersistentPayloadStore(backend)
+	second := NewPersistentPayloadStore(backend)
+	first.Set("token", CacheValue{Payload: []byte("payload"), SecretName: "token-secret"})
+	_, ok := second.Get("token")
+	g.Expect(ok).To(BeFalse(), "tokens of unknown secrets must not be read from the backend")
+	second.Track("token-secret", "token")
+	value, ok := second.Get("token")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(value).To(Equal(CacheValue{Payload: []byte("payload"), SecretName: "token-secret"}))
+	g.Expect(second.Keys()).To(ConsistOf("token"))
+
+	// Tokens are not written to the backend.
+	ids, err := backend.Keys(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ids).To(ConsistOf(backendKey("token")))
+
+	first.Delete("token")
+	restarted := NewPersistentPayloadStore(backend)
+	restarted.Track("token-secret", "token")
+	_, ok = restarted.Get("token")
+	g.Expect(ok).To(BeFalse())
+
+	// Untracked tokens are no longer read from the backend.
+	first.Set("untracked", CacheValue{Payload: []byte("payload"), SecretName: "untracked-secret"})
+	restarted.Track("untracked-secret", "untracked")
+	restarted.Untrack("untracked-secret")
+	_, ok = restarted.Get("untracked")
+	g.Expect(ok).To(BeFalse())
+	first.Delete("untracked")
+
+	// Payloads expired in memory are left in the backend, where another replica
+	// may have renewed them.
+	first.Set("renewed", CacheValue{Payload: []byte("payload")})
+	first.Lock()
+	first.cache["renewed"].expiry = time.Now().Add(-time.Minute)
+	first.Unlock()
+	second.Set("renewed", CacheValue{Payload: []byte("payload")})
+	_, ok = first.Get("other")
+	g.Expect(ok).To(BeFalse())
+	g.Expect(first.Keys()).ToNot(ContainElement("renewed"))
+	first.PruneBackend(ctx)
+	_, ok = first.Get("renewed")
+	g.Expect(ok).To(BeTrue())
+	first.Delete("renewed")
+
+	// Expired payloads are neither served nor kept.
+	data, err := encodeEntry(&entry{value: CacheValue{Payload: []byte("payload")}, expiry: time.Now().Add(-time.Minute)})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(backend.Save(ctx, backendKey("expired"), data)).To(Succeed())
+	second.Track("expired-secret", "expired")
+	_, ok = second.Get("expired")
+	g.Expect(ok).To(BeFalse())
+	second.PruneBackend(ctx)
+	ids, err = backend.Keys(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ids).To(BeEmpty())
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	PayloadGenerationQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ign_server_payload_generation_queue_depth",
+	})
+
+	PayloadGenerationsInProgress = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ign_server_payload_generations_in_progress",
+	})
+
+	PayloadGenerationCoalescedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ign_server_payload_generation_coalesced_total",
+	})
+
+	PayloadRenderSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ign_server_payload_render_seconds",
+		Buckets: []float64{5, 15, 30, 60, 120, 300, 600},
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		PayloadGenerationQueueDepth,
+		PayloadGenerationsInProgress,
+		PayloadGenerationCoalescedTotal,
+		PayloadRenderSeconds,
+	)
+}
+
+type generateFunc func(ctx context.Context, releaseImage, customConfig string) ([]byte, error)
+
+// payloadGenerator runs payload generations concurrently on a bounded number of
+// workers. Requests for the same release image and config while a generation for
+// them is in progress wait for and share its result instead of rendering again.
+type payloadGenerator struct {
+	generate generateFunc
+	// workers holds a token for every generation being rendered.
+	workers chan struct{}
+
+	lock     sync.Mutex
+	inflight map[string]*payloadGeneration
+}
+
+type payloadGeneration struct {
+	done    chan struct{}
+	payload []byte
+	err     error
+}
+
+func newPayloadGenerator(workers int, generate generateFunc) *payloadGenerator {
+	if workers < 1 {
+		workers = 1
+	}
+	return &payloadGenerator{
+		generate: generate,
+		workers:  make(chan struct{}, workers),
+		inflight: map[string]*payloadGeneration{},
+	}
+}
+
+// Generate returns the payload for releaseImage and customConfig. The generation
+// runs with the context of the first request, so if that is cancelled all requests
+// coalesced into it fail and are expected to be retried.
+func (g *payloadGenerator) Generate(ctx context.Context, releaseImage, customConfig string) ([]byte, error) {
+	key := payloadKey(releaseImage, customConfig)
+
+	g.lock.Lock()
+	if generation, ok := g.inflight[key]; ok {
+		g.lock.Unlock()
+		PayloadGenerationCoalescedTotal.Inc()
+		select {
+		case <-generation.done:
+			return generation.payload, generation.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	generation := &payloadGeneration{done: make(chan struct{})}
+	g.inflight[key] = generation
+	g.lock.Unlock()
+
+	generation.payload, generation.err = g.render(ctx, releaseImage, customConfig)
+
+	g.lock.Lock()
+	delete(g.inflight, key)
+	g.lock.Unlock()
+	close(generation.done)
+
+	return generation.payload, generation.err
+}
+
+// render waits for a free worker and renders the payload.
+func (g *payloadGenerator) render(ctx context.Context, releaseImage, customConfig string) ([]byte, error) {
+	PayloadGenerationQueueDepth.Inc()
+	select {
+	case g.workers <- struct{}{}:
+		PayloadGenerationQueueDepth.Dec()
+	case <-ctx.Done():
+		PayloadGenerationQueueDepth.Dec()
+		return nil, ctx.Err()
+	}
+	defer func() { <-g.workers }()
+
+	PayloadGenerationsInProgress.Inc()
+	defer PayloadGenerationsInProgress.Dec()
+	start := time.Now()
+	payload, err := g.generate(ctx, releaseImage, customConfig)
+	PayloadRenderSeconds.Observe(time.Since(start).Seconds())
+	return payload, err
+}
+
+// payloadKey identifies the payload of a release image and config.
+func payloadKey(releaseImage, customConfig string) string {
+	configHash := sha256.Sum256([]byte(customConfig))
+	return releaseImage + "/" + hex.EncodeToString(configHash[:])
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(g *WithT, counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	g.Expect(counter.Write(metric)).To(Succeed())
+	return metric.GetCounter().GetValue()
+}
+
+func TestPayloadGeneratorCoalescesIdenticalRequests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var generations int32
+	release := make(chan struct{})
+	coalesced := counterValue(g, PayloadGenerationCoalescedTotal)
+	generator := newPayloadGenerator(4, func(ctx context.Context, releaseImage, customConfig string) ([]byte, error) {
+		atomic.AddInt32(&generations, 1)
+		<-release
+		return []byte(releaseImage + ":" + customConfig), nil
+	})
+
+	requests := []struct{ releaseImage, config string }{
+		{"release:1", "config-a"},
+		{"release:1", "config-a"},
+		{"release:1", "config-a"},
+		{"release:1", "config-b"},
+		{"release:2", "config-a"},
+	}
+	payloads := make([][]byte, len(requests))
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, releaseImage, config string) {
+			defer wg.Done()
+			payload, err := generator.Generate(context.Background(), releaseImage, config)
+			g.Expect(err).ToNot(HaveOccurred())
+			payloads[i] = payload
+		}(i, request.releaseImage, request.config)
+	}
+
+	g.Eventually(func() float64 { return counterValue(g, PayloadGenerationCoalescedTotal) }).Should(Equal(coalesced + 2))
+	g.Expect(atomic.LoadInt32(&generations)).To(BeNumerically("<=", 3))
+	close(release)
+	wg.Wait()
+
+	g.Expect(atomic.LoadInt32(&generations)).To(Equal(int32(3)))
+	for i, request := range requests {
+		g.Expect(string(payloads[i])).To(Equal(request.releaseImage + ":" + request.config))
+	}
+	g.Expect(generator.inflight).To(BeEmpty())
+}
+
+func TestPayloadGeneratorBoundsConcurrency(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var running, maxRunning int32
+	generator := newPayloadGenerator(2, func(ctx context.Context, releaseImage, customConfig string) ([]byte, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return []byte(customConfig), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := generator.Generate(context.Background(), "release:1", fmt.Sprintf("config-%d", i))
+			g.Expect(err).ToNot(HaveOccurred())
+		}(i)
+	}
+	wg.Wait()
+
+	g.Expect(atomic.LoadInt32(&maxRunning)).To(Equal(int32(2)))
+}
+
+func TestPayloadGeneratorReturnsErrorToCoalescedRequests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	release := make(chan struct{})
+	var generations int32
+	coalesced := counterValue(g, PayloadGenerationCoalescedTotal)
+	generator := newPayloadGenerator(1, func(ctx context.Context, releaseImage, customConfig string) ([]byte, error) {
+		atomic.AddInt32(&generations, 1)
+		<-release
+		return nil, fmt.Errorf("failed to extract binaries")
+	})
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := generator.Generate(context.Background(), "release:1", "config")
+			errs <- err
+		}()
+	}
+	g.Eventually(func() float64 { return counterValue(g, PayloadGenerationCoalescedTotal) }).Should(Equal(coalesced + 1))
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		g.Expect(<-errs).To(MatchError("failed to extract binaries"))
+	}
+
+	// A failed generation is not remembered, so the next request renders again.
+	_, err := generator.Generate(context.Background(), "release:1", "config")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(atomic.LoadInt32(&generations)).To(Equal(int32(2)))
+}
@@ -17,6 +17,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -67,11 +68,20 @@ func init() {
 func NewPayloadStore() *ExpiringCache {
 	return &ExpiringCache{
 		cache:   make(map[string]*entry),
+		known:   make(map[string]string),
 		ttl:     ttl,
 		RWMutex: sync.RWMutex{},
 	}
 }
 
+// NewPersistentPayloadStore returns a payload store that persists its entries in
+// backend, so they survive restarts and are shared between replicas.
+func NewPersistentPayloadStore(backend PayloadBackend) *ExpiringCache {
+	store := NewPayloadStore()
+	store.backend = backend
+	return store
+}
+
 // IgnitionProvider can build ignition payload contents
 // for a given release image.
 type IgnitionProvider interface {
@@ -98,6 +108,9 @@ type TokenSecretReconciler struct {
 	client.Client
 	IgnitionProvider IgnitionProvider
 	PayloadStore     *ExpiringCache
+	// MaxConcurrentReconciles is the number of token Secrets reconciled, and so of
+	// payloads requested from the IgnitionProvider, concurrently. Defaults to 1.
+	MaxConcurrentReconciles int
 }
 
 func tokenSecretAnnotationPredicate(ctx context.Context) predicate.Predicate {
@@ -121,6 +134,7 @@ func (r *TokenSecretReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 	log.Info("SetupWithManager", "ns", os.Getenv("MY_NAMESPACE"))
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Secret{}).WithEventFilter(tokenSecretAnnotationPredicate(ctx)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -161,6 +175,7 @@ func (r *TokenSecretReconciler) processExpiredToken(ctx context.Context, tokenSe
 	if currentToken, ok := tokenSecret.Data[TokenSecretTokenKey]; ok {
 		r.PayloadStore.Delete(string(currentToken))
 	}
+	r.PayloadStore.Untrack(tokenSecret.Name)
 	if err := r.Client.Delete(ctx, tokenSecret); err != nil && !apierrors.IsNotFound(err) {
 		return err
 	}
@@ -202,6 +217,7 @@ func (r *TokenSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				r.PayloadStore.Delete(k)
 			}
 		}
+		r.PayloadStore.Untrack(req.Name)
 		return ctrl.Result{}, nil
 	}
 
@@ -219,7 +235,10 @@ func (r *TokenSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Let the payloads of the secret tokens be read from the backend, e.g. when they
+	// were generated by another replica.
 	token := string(tokenSecret.Data[TokenSecretTokenKey])
+	r.PayloadStore.Track(tokenSecret.Name, token, string(tokenSecret.Data[TokenSecretOldTokenKey]))
 	if value, ok := r.PayloadStore.Get(token); ok {
 		log.Info("Payload found in cache")
 
@@ -24,6 +24,7 @@ func main() {
 
 	root.AddCommand(cmd.NewStartCommand())
 	root.AddCommand(cmd.NewRunLocalIgnitionProviderCommand())
+	root.AddCommand(cmd.NewAttestationProxyCommand())
 
 	if err := root.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
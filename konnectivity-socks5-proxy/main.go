@@ -10,15 +10,18 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	socks5 "github.com/armon/go-socks5"
 	"github.com/openshift/hypershift/pkg/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/net/proxy"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -28,6 +31,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// egressRoutesReloadInterval is the interval in which the egress routes file is checked for changes.
+const egressRoutesReloadInterval = 30 * time.Second
+
 func NewStartCommand() *cobra.Command {
 	l := log.Log.WithName("konnectivity-socks5-proxy")
 	log.SetLogger(zap.New(zap.UseDevMode(true), zap.JSONEncoder(func(o *zapcore.EncoderConfig) {
@@ -52,12 +58,16 @@ func NewStartCommand() *cobra.Command {
 	var clientKeyPath string
 	var connectDirectlyToCloudAPIs bool
 	var resolveFromGuestClusterDNS bool
+	var egressRoutesFile string
+	var metricsAddr string
 
 	cmd.Flags().StringVar(&proxyHostname, "konnectivity-hostname", "konnectivity-server-local", "The hostname of the konnectivity service.")
 	cmd.Flags().IntVar(&proxyPort, "konnectivity-port", 8090, "The konnectivity port that socks5 proxy should connect to.")
 	cmd.Flags().IntVar(&servingPort, "serving-port", 8090, "The port that socks5 proxy should serve on.")
 	cmd.Flags().BoolVar(&connectDirectlyToCloudAPIs, "connect-directly-to-cloud-apis", false, "If true, traffic destined for AWS or Azure APIs should be sent there directly rather than going through konnectivity. If enabled, proxy env vars from the mgmt cluster must be propagated to this container")
 	cmd.Flags().BoolVar(&resolveFromGuestClusterDNS, "resolve-from-guest-cluster-dns", false, "If DNS resolving should use the guest clusters cluster-dns")
+	cmd.Flags().StringVar(&egressRoutesFile, "egress-routes-file", "", "Path to a file with the control plane egress routes. The file is reloaded periodically. If empty, no egress routes are used.")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "The address the metrics endpoint binds to. If empty, metrics are not served.")
 
 	cmd.Flags().StringVar(&caCertPath, "ca-cert-path", "/etc/konnectivity-proxy-tls/ca.crt", "The path to the konnectivity client's ca-cert.")
 	cmd.Flags().StringVar(&clientCertPath, "tls-cert-path", "/etc/konnectivity-proxy-tls/tls.crt", "The path to the konnectivity client's tls certificate.")
@@ -70,18 +80,38 @@ func NewStartCommand() *cobra.Command {
 			panic(err)
 		}
 
-		dialFunc := dialFunc(caCertPath, clientCertPath, clientKeyPath, proxyHostname, proxyPort, connectDirectlyToCloudAPIs)
+		var router *egressRouter
+		if egressRoutesFile != "" {
+			router = newEgressRouter(egressRoutesFile, l)
+			if err := router.load(); err != nil {
+				l.Error(err, "failed to load egress routes")
+			}
+			go router.run(cmd.Context(), egressRoutesReloadInterval)
+		}
+
+		if metricsAddr != "" {
+			go func() {
+				mux := http.NewServeMux()
+				mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+				if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+					l.Error(err, "metrics server failed")
+				}
+			}()
+		}
+
+		konnectivityDial := konnectivityDialFunc(caCertPath, clientCertPath, clientKeyPath, proxyHostname, proxyPort)
 		conf := &socks5.Config{
-			Dial: dialFunc,
+			Dial: routingDialFunc(router, konnectivityDial, connectDirectlyToCloudAPIs),
 			Resolver: proxyResolver{
 				client:                  client,
 				resolveFromGuestCluster: resolveFromGuestClusterDNS,
 				guestClusterResolver: &guestClusterResolver{
 					log:                  l,
 					client:               client,
-					konnektivityDialFunc: dialFunc,
+					konnektivityDialFunc: konnectivityDial,
 				},
-				log: l,
+				router: router,
+				log:    l,
 			},
 		}
 		server, err := socks5.New(conf)
@@ -97,11 +127,9 @@ func NewStartCommand() *cobra.Command {
 	return cmd
 }
 
-func dialFunc(caCertPath string, clientCertPath string, clientKeyPath string, proxyHostname string, proxyPort int, connectDirectlyToCloudApis bool) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+// konnectivityDialFunc returns a dial function that tunnels connections through the konnectivity server.
+func konnectivityDialFunc(caCertPath string, clientCertPath string, clientKeyPath string, proxyHostname string, proxyPort int) dialer {
 	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
-		if connectDirectlyToCloudApis && isCloudAPI(strings.Split(addr, ":")[0]) {
-			return dialDirect(ctx, network, addr)
-		}
 		caCert := caCertPath
 		tlsConfig, err := util.GetClientTLSConfig(caCert, clientCertPath, clientKeyPath, proxyHostname, nil)
 		if err != nil {
@@ -194,7 +222,7 @@ func (gr *guestClusterResolver) resolve(ctx context.Context, name string) (net.I
 }
 
 // proxyResolver tries to resolve addresses using the following steps in order:
-// 1. Not at all for cloud provider apis, as we do not want to tunnel them through Konnektivity
+// 1. Not at all for cloud provider apis and names matched by an egress route, as the route target resolves them
 // 2. If the address is a valid Kubernetes service and that service exists in the guest cluster, it's clusterIP is returned
 // 2. If --resolve-from-guest-cluster-dns is set, it uses the guest clusters dns. If that fails, an error is returned
 // 4. Lastly, golangs default resolver is used
@@ -202,12 +230,13 @@ type proxyResolver struct {
 	client                  client.Client
 	resolveFromGuestCluster bool
 	guestClusterResolver    *guestClusterResolver
+	router                  *egressRouter
 	log                     logr.Logger
 }
 
 func (d proxyResolver) Resolve(ctx context.Context, name string) (context.Context, net.IP, error) {
 	// Preserve the host so we can recognize it
-	if isCloudAPI(name) {
+	if isCloudAPI(name) || d.router.preservesHostname(name) {
 		return ctx, nil, nil
 	}
 	l := d.log.WithValues("name", name)
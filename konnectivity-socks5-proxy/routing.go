@@ -0,0 +1,262 @@
+package konnectivitysocks5proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+const (
+	// defaultRouteName is used in metrics for connections that do not match any route.
+	defaultRouteName = "default"
+
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+var (
+	connectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "konnectivity_socks5_proxy_connections_total",
+		Help: "Number of connections established by the konnectivity socks5 proxy by egress route, target and result.",
+	}, []string{"route", "target", "result"})
+
+	dialDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "konnectivity_socks5_proxy_dial_duration_seconds",
+		Help:    "Time taken to establish connections by egress route and target.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "target"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(connectionsTotal, dialDuration)
+}
+
+// egressRoute is the parsed form of a hyperv1.EgressRoute.
+type egressRoute struct {
+	name     string
+	target   hyperv1.EgressRouteTarget
+	cidrs    []*net.IPNet
+	domains  []string
+	proxyURL *url.URL
+}
+
+// matchesHost returns true if host, which is either an IP address or a host name,
+// is matched by the route.
+func (r *egressRoute) matchesHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		for _, cidr := range r.cidrs {
+			if cidr.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return r.matchesDomain(host)
+}
+
+func (r *egressRoute) matchesDomain(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range r.domains {
+		if strings.HasPrefix(domain, "*.") {
+			if strings.HasSuffix(host, domain[1:]) {
+				return true
+			}
+			continue
+		}
+		if host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEgressRoutes(data []byte) ([]egressRoute, error) {
+	spec := hyperv1.ControlPlaneEgressSpec{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse egress routes: %w", err)
+		}
+	}
+	routes := make([]egressRoute, 0, len(spec.Routes))
+	for _, route := range spec.Routes {
+		parsed := egressRoute{name: route.Name, target: route.Target}
+		for _, cidr := range route.CIDRs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: invalid CIDR %q: %w", route.Name, cidr, err)
+			}
+			parsed.cidrs = append(parsed.cidrs, ipNet)
+		}
+		for _, domain := range route.Domains {
+			parsed.domains = append(parsed.domains, strings.ToLower(strings.TrimSuffix(domain, ".")))
+		}
+		switch route.Target {
+		case hyperv1.DirectEgressRouteTarget, hyperv1.KonnectivityEgressRouteTarget:
+		case hyperv1.ProxyEgressRouteTarget:
+			proxyURL, err := url.Parse(route.ProxyURL)
+			if err != nil || proxyURL.Host == "" {
+				return nil, fmt.Errorf("route %s: invalid proxy URL %q", route.Name, route.ProxyURL)
+			}
+			parsed.proxyURL = proxyURL
+		default:
+			return nil, fmt.Errorf("route %s: unsupported target %q", route.Name, route.Target)
+		}
+		routes = append(routes, parsed)
+	}
+	return routes, nil
+}
+
+// egressRouter holds the egress routing table and keeps it in sync with the
+// routes file that is mounted from the konnectivity-egress-routes ConfigMap.
+type egressRouter struct {
+	path string
+	log  logr.Logger
+
+	lock    sync.RWMutex
+	content []byte
+	routes  []egressRoute
+}
+
+func newEgressRouter(path string, log logr.Logger) *egressRouter {
+	return &egressRouter{path: path, log: log}
+}
+
+// load reads the routes file and replaces the routing table if its content changed.
+// A missing file results in an empty routing table. If the file can not be parsed,
+// the previous routing table is kept.
+func (r *egressRouter) load() error {
+	if r == nil || r.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read egress routes file %s: %w", r.path, err)
+	}
+	r.lock.RLock()
+	unchanged := r.content != nil && bytes.Equal(r.content, data)
+	r.lock.RUnlock()
+	if unchanged {
+		return nil
+	}
+	routes, err := parseEgressRoutes(data)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.content = append([]byte{}, data...)
+	r.routes = routes
+	r.lock.Unlock()
+	r.log.Info("Loaded egress routes", "count", len(routes))
+	return nil
+}
+
+// run periodically reloads the routes file until the context is done.
+func (r *egressRouter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.load(); err != nil {
+				r.log.Error(err, "failed to reload egress routes, keeping previous routes")
+			}
+		}
+	}
+}
+
+// match returns the first route matching host or nil if no route matches.
+func (r *egressRouter) match(host string) *egressRoute {
+	if r == nil {
+		return nil
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for i := range r.routes {
+		if r.routes[i].matchesHost(host) {
+			route := r.routes[i]
+			return &route
+		}
+	}
+	return nil
+}
+
+// preservesHostname returns true if connections to host should be dialed by name
+// rather than being resolved by the proxy, so that the route target is responsible
+// for name resolution.
+func (r *egressRouter) preservesHostname(host string) bool {
+	if net.ParseIP(host) != nil {
+		return false
+	}
+	return r.match(host) != nil
+}
+
+type dialer func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+// routingDialFunc returns a dial function that establishes connections according to the
+// egress routing table. Connections not matching any route go directly to cloud APIs when
+// connectDirectlyToCloudAPIs is set and through konnectivity otherwise.
+func routingDialFunc(router *egressRouter, konnectivityDial dialer, connectDirectlyToCloudAPIs bool) dialer {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+
+		routeName := defaultRouteName
+		target := hyperv1.KonnectivityEgressRouteTarget
+		dial := konnectivityDial
+		if route := router.match(host); route != nil {
+			routeName = route.name
+			target = route.target
+			switch route.target {
+			case hyperv1.DirectEgressRouteTarget:
+				dial = dialDirect
+			case hyperv1.ProxyEgressRouteTarget:
+				dial = dialThroughProxy(route.proxyURL)
+			}
+		} else if connectDirectlyToCloudAPIs && isCloudAPI(host) {
+			target = hyperv1.DirectEgressRouteTarget
+			dial = dialDirect
+		}
+
+		start := time.Now()
+		conn, err := dial(ctx, network, addr)
+		dialDuration.WithLabelValues(routeName, string(target)).Observe(time.Since(start).Seconds())
+		result := resultSuccess
+		if err != nil {
+			result = resultError
+		}
+		connectionsTotal.WithLabelValues(routeName, string(target), result).Inc()
+		return conn, err
+	}
+}
+
+// dialThroughProxy returns a dial function that connects through the given HTTP proxy.
+func dialThroughProxy(proxyURL *url.URL) dialer {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		forward := &net.Dialer{}
+		d := &httpProxyDialer{
+			proxyURL: proxyURL,
+			forwardDial: func(network, addr string) (net.Conn, error) {
+				return forward.DialContext(ctx, network, addr)
+			},
+		}
+		return d.Dial(network, addr)
+	}
+}
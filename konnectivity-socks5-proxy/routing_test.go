@@ -0,0 +1,137 @@
+package konnectivitysocks5proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+const testRoutes = `{"routes":[
+	{"name":"registry","domains":["*.quay.io","registry.example.com"],"target":"Direct"},
+	{"name":"corp","cidrs":["10.10.0.0/16","fd03::/64"],"target":"Proxy","proxyURL":"http://proxy.example.com:3128"},
+	{"name":"guest","cidrs":["10.10.1.0/24"],"target":"Konnectivity"}
+]}`
+
+func TestEgressRouterMatch(t *testing.T) {
+	testCases := []struct {
+		name          string
+		host          string
+		expectedRoute string
+	}{
+		{name: "exact domain", host: "registry.example.com", expectedRoute: "registry"},
+		{name: "exact domain with trailing dot and upper case", host: "Registry.Example.com.", expectedRoute: "registry"},
+		{name: "wildcard subdomain", host: "cdn.quay.io", expectedRoute: "registry"},
+		{name: "wildcard does not match apex", host: "quay.io"},
+		{name: "IPv4 CIDR, first matching route wins", host: "10.10.1.5", expectedRoute: "corp"},
+		{name: "IPv6 CIDR", host: "fd03::10", expectedRoute: "corp"},
+		{name: "unmatched IP", host: "192.168.1.1"},
+		{name: "unmatched domain", host: "example.org"},
+	}
+
+	router := newEgressRouter(writeRoutesFile(t, testRoutes), log.Log)
+	if err := router.load(); err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			route := router.match(tc.host)
+			if tc.expectedRoute == "" {
+				g.Expect(route).To(BeNil())
+				return
+			}
+			g.Expect(route).ToNot(BeNil())
+			g.Expect(route.name).To(Equal(tc.expectedRoute))
+		})
+	}
+}
+
+func TestEgressRouterLoad(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := writeRoutesFile(t, testRoutes)
+	router := newEgressRouter(path, log.Log)
+	g.Expect(router.load()).To(Succeed())
+	g.Expect(router.match("cdn.quay.io")).ToNot(BeNil())
+
+	// An invalid file keeps the previous routes
+	g.Expect(os.WriteFile(path, []byte(`{"routes":[{"name":"bad","cidrs":["10.0.0.0"],"target":"Direct"}]}`), 0644)).To(Succeed())
+	g.Expect(router.load()).ToNot(Succeed())
+	g.Expect(router.match("cdn.quay.io")).ToNot(BeNil())
+
+	// A removed file results in an empty routing table
+	g.Expect(os.Remove(path)).To(Succeed())
+	g.Expect(router.load()).To(Succeed())
+	g.Expect(router.match("cdn.quay.io")).To(BeNil())
+}
+
+func TestRoutingDialFunc(t *testing.T) {
+	g := NewGomegaWithT(t)
+	router := newEgressRouter(writeRoutesFile(t, `{"routes":[{"name":"guest","cidrs":["10.10.0.0/16"],"target":"Konnectivity"}]}`), log.Log)
+	g.Expect(router.load()).To(Succeed())
+
+	errKonnectivity := errors.New("konnectivity")
+	var dialed []string
+	konnectivityDial := func(_ context.Context, _ string, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return nil, errKonnectivity
+	}
+
+	dial := routingDialFunc(router, konnectivityDial, false)
+	_, err := dial(context.Background(), "tcp", "10.10.0.1:443")
+	g.Expect(err).To(MatchError(errKonnectivity))
+	_, err = dial(context.Background(), "tcp", "example.org:443")
+	g.Expect(err).To(MatchError(errKonnectivity))
+	g.Expect(dialed).To(Equal([]string{"10.10.0.1:443", "example.org:443"}))
+
+	g.Expect(router.preservesHostname("example.org")).To(BeFalse())
+	g.Expect(router.preservesHostname("10.10.0.1")).To(BeFalse())
+	var nilRouter *egressRouter
+	g.Expect(nilRouter.match("10.10.0.1")).To(BeNil())
+}
+
+func TestParseEgressRoutes(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		expectErr bool
+	}{
+		{name: "empty file", data: ""},
+		{name: "no routes", data: `{}`},
+		{name: "valid routes", data: testRoutes},
+		{name: "invalid json", data: `{`, expectErr: true},
+		{name: "proxy route without proxy URL", data: `{"routes":[{"name":"corp","cidrs":["10.0.0.0/8"],"target":"Proxy"}]}`, expectErr: true},
+		{name: "unknown target", data: `{"routes":[{"name":"corp","cidrs":["10.0.0.0/8"],"target":"Tunnel"}]}`, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			routes, err := parseEgressRoutes([]byte(tc.data))
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, route := range routes {
+				if route.target == hyperv1.ProxyEgressRouteTarget {
+					g.Expect(route.proxyURL).ToNot(BeNil())
+				}
+			}
+		})
+	}
+}
+
+func writeRoutesFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write routes file: %v", err)
+	}
+	return path
+}
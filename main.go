@@ -27,9 +27,15 @@ import (
 
 	"github.com/openshift/hypershift/cmd/consolelogs"
 	createcmd "github.com/openshift/hypershift/cmd/create"
+	describecmd "github.com/openshift/hypershift/cmd/describe"
 	destroycmd "github.com/openshift/hypershift/cmd/destroy"
 	dumpcmd "github.com/openshift/hypershift/cmd/dump"
+	getcmd "github.com/openshift/hypershift/cmd/get"
 	installcmd "github.com/openshift/hypershift/cmd/install"
+	nodepoolcmd "github.com/openshift/hypershift/cmd/nodepool"
+	preflightcmd "github.com/openshift/hypershift/cmd/preflight"
+	rendercmd "github.com/openshift/hypershift/cmd/render"
+	upgradecmd "github.com/openshift/hypershift/cmd/upgrade"
 	cliversion "github.com/openshift/hypershift/cmd/version"
 	"github.com/openshift/hypershift/pkg/version"
 )
@@ -53,10 +59,17 @@ func main() {
 	defer cancel()
 
 	cmd.AddCommand(installcmd.NewCommand())
+	cmd.AddCommand(installcmd.NewUninstallCommand())
+	cmd.AddCommand(preflightcmd.NewCommand())
 	cmd.AddCommand(createcmd.NewCommand())
 	cmd.AddCommand(destroycmd.NewCommand())
 	cmd.AddCommand(dumpcmd.NewCommand())
+	cmd.AddCommand(getcmd.NewCommand())
+	cmd.AddCommand(describecmd.NewCommand())
+	cmd.AddCommand(upgradecmd.NewCommand())
+	cmd.AddCommand(nodepoolcmd.NewCommand())
 	cmd.AddCommand(consolelogs.NewCommand())
+	cmd.AddCommand(rendercmd.NewCommand())
 	cmd.AddCommand(cliversion.NewVersionCommand())
 
 	sigs := make(chan os.Signal, 1)
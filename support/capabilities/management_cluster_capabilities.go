@@ -69,8 +69,8 @@ func (m *ManagementClusterCapabilities) Has(capabilities ...CapabilityType) bool
 	return true
 }
 
-// isAPIResourceRegistered determines if a specified API resource is registered on the cluster
-func isAPIResourceRegistered(client discovery.ServerResourcesInterface, groupVersion schema.GroupVersion, resourceName string) (bool, error) {
+// IsAPIResourceRegistered determines if a specified API resource is registered on the cluster
+func IsAPIResourceRegistered(client discovery.ServerResourcesInterface, groupVersion schema.GroupVersion, resourceName string) (bool, error) {
 	apis, err := client.ServerResourcesForGroupVersion(groupVersion.String())
 	if err != nil && !errors.IsNotFound(err) {
 		return false, err
@@ -91,7 +91,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	discoveredCapabilities := map[CapabilityType]struct{}{}
 
 	// check for route capability
-	hasRouteCap, err := isAPIResourceRegistered(client, routev1.GroupVersion, "routes")
+	hasRouteCap, err := IsAPIResourceRegistered(client, routev1.GroupVersion, "routes")
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +100,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	}
 
 	// check for scc capability
-	hasSccCap, err := isAPIResourceRegistered(client, securityv1.GroupVersion, "securitycontextconstraints")
+	hasSccCap, err := IsAPIResourceRegistered(client, securityv1.GroupVersion, "securitycontextconstraints")
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +109,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	}
 
 	// check for infrastructure capability
-	hasInfraCap, err := isAPIResourceRegistered(client, configv1.GroupVersion, "infrastructures")
+	hasInfraCap, err := IsAPIResourceRegistered(client, configv1.GroupVersion, "infrastructures")
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +118,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	}
 
 	// check for ingress capability
-	hasIngressCap, err := isAPIResourceRegistered(client, configv1.GroupVersion, "ingresses")
+	hasIngressCap, err := IsAPIResourceRegistered(client, configv1.GroupVersion, "ingresses")
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +127,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	}
 
 	// check for proxy capability
-	hasProxyCap, err := isAPIResourceRegistered(client, configv1.GroupVersion, "proxies")
+	hasProxyCap, err := IsAPIResourceRegistered(client, configv1.GroupVersion, "proxies")
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +136,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	}
 
 	// check for dns capability
-	hasDNSCap, err := isAPIResourceRegistered(client, configv1.GroupVersion, "dnses")
+	hasDNSCap, err := IsAPIResourceRegistered(client, configv1.GroupVersion, "dnses")
 	if err != nil {
 		return nil, err
 	}
@@ -145,7 +145,7 @@ func DetectManagementClusterCapabilities(client discovery.ServerResourcesInterfa
 	}
 
 	// check for networks capability
-	hasNetworksCap, err := isAPIResourceRegistered(client, configv1.GroupVersion, "networks")
+	hasNetworksCap, err := IsAPIResourceRegistered(client, configv1.GroupVersion, "networks")
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +129,7 @@ func TestIsAPIResourceRegistered(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := isAPIResourceRegistered(tc.client, tc.groupVersion, tc.resourceName)
+			got, err := IsAPIResourceRegistered(tc.client, tc.groupVersion, tc.resourceName)
 			g := NewGomegaWithT(t)
 			g.Expect(got).To(Equal(tc.isRegistered))
 			if tc.shouldError {
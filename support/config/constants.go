@@ -15,7 +15,8 @@ const (
 
 	DefaultServiceAccountIssuer  = "https://kubernetes.default.svc"
 	DefaultImageRegistryHostname = "image-registry.openshift-image-registry.svc:5000"
-	DefaultAdvertiseAddress      = "172.20.0.1"
+	DefaultAdvertiseIPv4Address  = "172.20.0.1"
+	DefaultAdvertiseIPv6Address  = "fd00::1"
 	DefaultEtcdURL               = "https://etcd-client:2379"
 	DefaultAPIServerPort         = 6443
 	DefaultServiceNodePortRange  = "30000-32767"
@@ -25,4 +26,11 @@ const (
 	RecommendedRetryPeriod       = "26s"
 
 	DefaultIngressDomainEnvVar = "DEFAULT_INGRESS_DOMAIN"
+
+	// ReleaseMetadataCacheDir is the directory control plane components persist the
+	// metadata of release images in, so it is not pulled again when they restart.
+	ReleaseMetadataCacheDir = "/var/cache/release-metadata"
+	// ReleaseMetadataCacheDirEnvVar sets the release metadata cache directory of the
+	// control plane operator, which ignores it if it doesn't persist release metadata.
+	ReleaseMetadataCacheDirEnvVar = "RELEASE_METADATA_CACHE_DIR"
 )
@@ -19,6 +19,11 @@ const (
 	// ManagedByLabel can be used to filter deployments.
 	ManagedByLabel = "hypershift.openshift.io/managed-by"
 
+	// InvalidComponentOverridesAnnotation is set on Deployments and StatefulSets to the resource
+	// overrides of spec.controlPlaneComponents that were skipped because their requests exceed
+	// the limits of the component.
+	InvalidComponentOverridesAnnotation = "hypershift.openshift.io/invalid-component-overrides"
+
 	// There are used by NodeAffinity to prefer/tolerate Nodes.
 	controlPlaneLabelTolerationKey = "hypershift.openshift.io/control-plane"
 	clusterLabelTolerationKey      = "hypershift.openshift.io/cluster"
@@ -45,17 +50,72 @@ type DeploymentConfig struct {
 	Resources                 ResourcesSpec         `json:"resources"`
 	DebugDeployments          sets.String           `json:"debugDeployments"`
 	ResourceRequestOverrides  ResourceOverrides     `json:"resourceRequestOverrides"`
+	SizeOverrides             ComponentOverrides    `json:"sizeOverrides"`
+	ComponentOverrides        ComponentOverrides    `json:"componentOverrides"`
+
+	// defaultResources are the resources of the containers whose resources were replaced
+	// with the deployed ones by SetContainerResourcesIfPresent.
+	defaultResources ResourcesSpec
 }
 
 func (c *DeploymentConfig) SetContainerResourcesIfPresent(container *corev1.Container) {
 	resources := container.Resources
 	if len(resources.Requests) > 0 || len(resources.Limits) > 0 {
 		if c.Resources != nil {
+			if _, recorded := c.defaultResources[container.Name]; !recorded {
+				if c.defaultResources == nil {
+					c.defaultResources = ResourcesSpec{}
+				}
+				c.defaultResources[container.Name] = c.Resources[container.Name]
+			}
 			c.Resources[container.Name] = resources
 		}
 	}
 }
 
+// renderedDefaults returns a copy of podSpec with the default resources, the size tier and the
+// resource request override annotations applied. Resources preserved from the deployed pod spec
+// are left out, so the component overrides are validated against the defaults and not against
+// the result of earlier overrides.
+func (c *DeploymentConfig) renderedDefaults(name string, podSpec *corev1.PodSpec) *corev1.PodSpec {
+	defaults := podSpec.DeepCopy()
+	resources := ResourcesSpec{}
+	for container, res := range c.Resources {
+		resources[container] = *res.DeepCopy()
+	}
+	for container, res := range c.defaultResources {
+		resources[container] = *res.DeepCopy()
+	}
+	resources.ApplyTo(defaults)
+	c.SizeOverrides.ApplyTo(name, defaults)
+	c.ResourceRequestOverrides.ApplyRequestsTo(name, defaults)
+	return defaults
+}
+
+// applyComponentOverrides applies the overrides of spec.controlPlaneComponents to the pod spec of
+// the component name. Resource overrides apply to the default resources of their container and
+// those whose requests exceed the limits of the component are skipped, so they don't make the API
+// server reject the component, and recorded in the InvalidComponentOverridesAnnotation of objectMeta.
+func (c *DeploymentConfig) applyComponentOverrides(name string, objectMeta *metav1.ObjectMeta, podSpec *corev1.PodSpec, defaults *corev1.PodSpec) {
+	overrides, err := c.ComponentOverrides.Valid(name, defaults)
+	for _, containerOverride := range overrides[name].Containers {
+		if len(containerOverride.Resources.Requests) == 0 && len(containerOverride.Resources.Limits) == 0 {
+			continue
+		}
+		resetResources(containerOverride.Name, podSpec.InitContainers, defaults.InitContainers)
+		resetResources(containerOverride.Name, podSpec.Containers, defaults.Containers)
+	}
+	overrides.ApplyTo(name, podSpec)
+	if err != nil {
+		if objectMeta.Annotations == nil {
+			objectMeta.Annotations = map[string]string{}
+		}
+		objectMeta.Annotations[InvalidComponentOverridesAnnotation] = err.Error()
+	} else {
+		delete(objectMeta.Annotations, InvalidComponentOverridesAnnotation)
+	}
+}
+
 func (c *DeploymentConfig) SetRestartAnnotation(objectMetadata metav1.ObjectMeta) {
 	if _, ok := objectMetadata.Annotations[hyperv1.RestartDateAnnotation]; ok {
 		if c.AdditionalAnnotations == nil {
@@ -75,6 +135,10 @@ func (c *DeploymentConfig) SetReleaseImageAnnotation(releaseImage string) {
 func (c *DeploymentConfig) ApplyTo(deployment *appsv1.Deployment) {
 	if c.DebugDeployments != nil && c.DebugDeployments.Has(deployment.Name) {
 		deployment.Spec.Replicas = pointer.Int32(0)
+	} else if replicas := c.ComponentOverrides.Replicas(deployment.Name); replicas != nil {
+		deployment.Spec.Replicas = pointer.Int32(*replicas)
+	} else if replicas := c.SizeOverrides.Replicas(deployment.Name); replicas != nil {
+		deployment.Spec.Replicas = pointer.Int32(*replicas)
 	} else {
 		deployment.Spec.Replicas = pointer.Int32Ptr(int32(c.Replicas))
 	}
@@ -105,16 +169,35 @@ func (c *DeploymentConfig) ApplyTo(deployment *appsv1.Deployment) {
 	}
 	deployment.Labels[ManagedByLabel] = "control-plane-operator"
 
+	defaults := c.renderedDefaults(deployment.Name, &deployment.Spec.Template.Spec)
 	c.Scheduling.ApplyTo(&deployment.Spec.Template.Spec)
 	c.AdditionalLabels.ApplyTo(&deployment.Spec.Template.ObjectMeta)
 	c.SecurityContexts.ApplyTo(&deployment.Spec.Template.Spec)
 	c.LivenessProbes.ApplyTo(&deployment.Spec.Template.Spec)
 	c.ReadinessProbes.ApplyTo(&deployment.Spec.Template.Spec)
 	c.Resources.ApplyTo(&deployment.Spec.Template.Spec)
+	// The size tier replaces the defaults, the resource request override annotations take
+	// precedence over the size tier and the overrides in the spec over both.
+	c.SizeOverrides.ApplyTo(deployment.Name, &deployment.Spec.Template.Spec)
 	c.ResourceRequestOverrides.ApplyRequestsTo(deployment.Name, &deployment.Spec.Template.Spec)
+	c.applyComponentOverrides(deployment.Name, &deployment.ObjectMeta, &deployment.Spec.Template.Spec, defaults)
 	c.AdditionalAnnotations.ApplyTo(&deployment.Spec.Template.ObjectMeta)
 }
 
+// resetResources sets the resources of the container name in containers to those it has in defaults.
+func resetResources(name string, containers, defaults []corev1.Container) {
+	for i := range containers {
+		if containers[i].Name != name {
+			continue
+		}
+		for _, container := range defaults {
+			if container.Name == name {
+				containers[i].Resources = *container.Resources.DeepCopy()
+			}
+		}
+	}
+}
+
 func (c *DeploymentConfig) ApplyToDaemonSet(daemonset *appsv1.DaemonSet) {
 	// replicas is not used for DaemonSets
 	c.Scheduling.ApplyTo(&daemonset.Spec.Template.Spec)
@@ -129,13 +212,25 @@ func (c *DeploymentConfig) ApplyToDaemonSet(daemonset *appsv1.DaemonSet) {
 
 func (c *DeploymentConfig) ApplyToStatefulSet(sts *appsv1.StatefulSet) {
 	sts.Spec.Replicas = pointer.Int32Ptr(int32(c.Replicas))
+
+	// set managed-by label
+	if sts.Labels == nil {
+		sts.Labels = map[string]string{}
+	}
+	sts.Labels[ManagedByLabel] = "control-plane-operator"
+
+	defaults := c.renderedDefaults(sts.Name, &sts.Spec.Template.Spec)
 	c.Scheduling.ApplyTo(&sts.Spec.Template.Spec)
 	c.AdditionalLabels.ApplyTo(&sts.Spec.Template.ObjectMeta)
 	c.SecurityContexts.ApplyTo(&sts.Spec.Template.Spec)
 	c.LivenessProbes.ApplyTo(&sts.Spec.Template.Spec)
 	c.ReadinessProbes.ApplyTo(&sts.Spec.Template.Spec)
 	c.Resources.ApplyTo(&sts.Spec.Template.Spec)
+	// The size tier replaces the defaults, the resource request override annotations take
+	// precedence over the size tier and the overrides in the spec over both.
+	c.SizeOverrides.ApplyTo(sts.Name, &sts.Spec.Template.Spec)
 	c.ResourceRequestOverrides.ApplyRequestsTo(sts.Name, &sts.Spec.Template.Spec)
+	c.applyComponentOverrides(sts.Name, &sts.ObjectMeta, &sts.Spec.Template.Spec, defaults)
 	c.AdditionalAnnotations.ApplyTo(&sts.Spec.Template.ObjectMeta)
 }
 
@@ -289,6 +384,8 @@ func (c *DeploymentConfig) SetDefaults(hcp *hyperv1.HostedControlPlane, multiZon
 	c.DebugDeployments = debugDeployments(hcp)
 
 	c.ResourceRequestOverrides = resourceRequestOverrides(hcp)
+	c.SizeOverrides = sizeOverrides(hcp)
+	c.ComponentOverrides = componentOverrides(hcp)
 
 	c.setLocation(hcp, multiZoneSpreadLabels)
 	// TODO (alberto): make this private, atm is needed for the konnectivity agent daemonset.
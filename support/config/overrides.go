@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ComponentOverrides are the overrides of spec.controlPlaneComponents keyed by
+// the name of the Deployment or StatefulSet of the component.
+type ComponentOverrides map[string]hyperv1.ControlPlaneComponentOverride
+
+func componentOverrides(hcp *hyperv1.HostedControlPlane) ComponentOverrides {
+	if len(hcp.Spec.ControlPlaneComponents) == 0 {
+		return nil
+	}
+	result := ComponentOverrides{}
+	for _, override := range hcp.Spec.ControlPlaneComponents {
+		result[override.Name] = override
+	}
+	return result
+}
+
+// Replicas returns the replicas override of the component name, if any.
+func (o ComponentOverrides) Replicas(name string) *int32 {
+	if override, exists := o[name]; exists {
+		return override.Replicas
+	}
+	return nil
+}
+
+// ApplyTo applies the overrides of the component name to its pod spec.
+func (o ComponentOverrides) ApplyTo(name string, podSpec *corev1.PodSpec) {
+	override, exists := o[name]
+	if !exists {
+		return
+	}
+	if override.PriorityClassName != "" {
+		podSpec.PriorityClassName = override.PriorityClassName
+	}
+	if len(override.Tolerations) > 0 {
+		// The tolerations of the pod spec may share their backing array with the
+		// scheduling config of other components, so they are copied before appending.
+		tolerations := make([]corev1.Toleration, 0, len(podSpec.Tolerations)+len(override.Tolerations))
+		tolerations = append(tolerations, podSpec.Tolerations...)
+		podSpec.Tolerations = append(tolerations, override.Tolerations...)
+	}
+	for _, containerOverride := range override.Containers {
+		for i := range podSpec.InitContainers {
+			if podSpec.InitContainers[i].Name == containerOverride.Name {
+				applyContainerOverride(containerOverride, &podSpec.InitContainers[i])
+			}
+		}
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == containerOverride.Name {
+				applyContainerOverride(containerOverride, &podSpec.Containers[i])
+			}
+		}
+	}
+}
+
+func applyContainerOverride(override hyperv1.ControlPlaneContainerOverride, container *corev1.Container) {
+	if override.Image != "" {
+		container.Image = override.Image
+	}
+	if len(override.Resources.Requests) > 0 && container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	for name, value := range override.Resources.Requests {
+		container.Resources.Requests[name] = value
+	}
+	if len(override.Resources.Limits) > 0 && container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+	for name, value := range override.Resources.Limits {
+		container.Resources.Limits[name] = value
+	}
+	for _, env := range override.Env {
+		replaced := false
+		for i := range container.Env {
+			if container.Env[i].Name == env.Name {
+				container.Env[i] = env
+				replaced = true
+			}
+		}
+		if !replaced {
+			container.Env = append(container.Env, env)
+		}
+	}
+}
+
+// ValidateComponentOverrides returns an error for every override that references
+// a component or container that is not in components, which maps the names of
+// the control plane components to the names of their containers.
+func ValidateComponentOverrides(overrides []hyperv1.ControlPlaneComponentOverride, components map[string]sets.String) error {
+	var errs []error
+	for _, override := range overrides {
+		containers, exists := components[override.Name]
+		if !exists {
+			errs = append(errs, fmt.Errorf("component %s does not exist", override.Name))
+			continue
+		}
+		for _, container := range override.Containers {
+			if !containers.Has(container.Name) {
+				errs = append(errs, fmt.Errorf("component %s has no container %s, valid containers are %v", override.Name, container.Name, containers.List()))
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return utilerrors.NewAggregate(errs)
+}
+
+// ValidateComponentOverrideResources returns an error for every container whose
+// requests exceed its limits once the resource overrides are applied to the
+// resources it has in podSpecs, which maps the names of the control plane
+// components to their pod specs rendered without the overrides.
+func ValidateComponentOverrideResources(overrides []hyperv1.ControlPlaneComponentOverride, podSpecs map[string]*corev1.PodSpec) error {
+	var errs []error
+	for _, override := range overrides {
+		podSpec, exists := podSpecs[override.Name]
+		if !exists {
+			continue
+		}
+		for _, containerOverride := range override.Containers {
+			if err := validateContainerOverrideResources(override.Name, containerOverride, podSpec); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return utilerrors.NewAggregate(errs)
+}
+
+// Valid returns the overrides without the resource overrides of the containers of
+// the component name whose requests would exceed their limits in defaults, the pod
+// spec of the component rendered without the overrides. The skipped resource
+// overrides are returned as an error, the rest of the overrides is kept.
+func (o ComponentOverrides) Valid(name string, defaults *corev1.PodSpec) (ComponentOverrides, error) {
+	override, exists := o[name]
+	if !exists {
+		return o, nil
+	}
+	var errs []error
+	containers := make([]hyperv1.ControlPlaneContainerOverride, 0, len(override.Containers))
+	for _, containerOverride := range override.Containers {
+		if err := validateContainerOverrideResources(name, containerOverride, defaults); err != nil {
+			errs = append(errs, err)
+			containerOverride.Resources = corev1.ResourceRequirements{}
+		}
+		containers = append(containers, containerOverride)
+	}
+	if len(errs) == 0 {
+		return o, nil
+	}
+	override.Containers = containers
+	result := make(ComponentOverrides, len(o))
+	for k, v := range o {
+		result[k] = v
+	}
+	result[name] = override
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return result, utilerrors.NewAggregate(errs)
+}
+
+// validateContainerOverrideResources returns an error if the resource overrides make
+// the requests of the overridden container in podSpec exceed its limits.
+func validateContainerOverrideResources(component string, override hyperv1.ControlPlaneContainerOverride, podSpec *corev1.PodSpec) error {
+	if len(override.Resources.Requests) == 0 && len(override.Resources.Limits) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, container := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+		if container.Name != override.Name {
+			continue
+		}
+		effective := container.DeepCopy()
+		applyContainerOverride(override, effective)
+		for name, limit := range effective.Resources.Limits {
+			if request, exists := effective.Resources.Requests[name]; exists && request.Cmp(limit) > 0 {
+				errs = append(errs, fmt.Errorf("component %s container %s: %s request %s exceeds its limit %s", component, container.Name, name, request.String(), limit.String()))
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return utilerrors.NewAggregate(errs)
+}
+
+// PodSpecContainers returns the names of the containers and init containers of podSpec.
+func PodSpecContainers(podSpec *corev1.PodSpec) sets.String {
+	containers := sets.NewString()
+	for _, container := range podSpec.InitContainers {
+		containers.Insert(container.Name)
+	}
+	for _, container := range podSpec.Containers {
+		containers.Insert(container.Name)
+	}
+	return containers
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+)
+
+func TestComponentOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hcp := &hyperv1.HostedControlPlane{}
+	hcp.Namespace = "clusters-example"
+	hcp.Annotations = map[string]string{
+		hyperv1.ResourceRequestOverrideAnnotationPrefix + "/kube-apiserver.kube-apiserver": "cpu=500m,memory=1Gi",
+	}
+	hcp.Spec.ControlPlaneComponents = []hyperv1.ControlPlaneComponentOverride{
+		{
+			Name:              "kube-apiserver",
+			Replicas:          pointer.Int32(2),
+			PriorityClassName: "custom",
+			Tolerations:       []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			Containers: []hyperv1.ControlPlaneContainerOverride{
+				{
+					Name:  "kube-apiserver",
+					Image: "example.com/kube-apiserver:custom",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+					},
+					Env: []corev1.EnvVar{{Name: "GOGC", Value: "50"}, {Name: "LOG_LEVEL", Value: "4"}},
+				},
+				{
+					Name:  "init-bootstrap",
+					Image: "example.com/init:custom",
+				},
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init-bootstrap", Image: "init"}},
+					Containers: []corev1.Container{
+						{
+							Name:  "kube-apiserver",
+							Image: "kube-apiserver",
+							Env:   []corev1.EnvVar{{Name: "GOGC", Value: "100"}},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+							},
+						},
+						{Name: "audit-logs", Image: "audit-logs"},
+					},
+				},
+			},
+		},
+	}
+
+	cfg := &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, nil)
+	cfg.ApplyTo(deployment)
+
+	g.Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+	podSpec := deployment.Spec.Template.Spec
+	g.Expect(podSpec.PriorityClassName).To(Equal("custom"))
+	g.Expect(podSpec.Tolerations).To(ContainElement(corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpExists}))
+	g.Expect(podSpec.Tolerations).To(HaveLen(3))
+	g.Expect(podSpec.InitContainers[0].Image).To(Equal("example.com/init:custom"))
+
+	container := podSpec.Containers[0]
+	g.Expect(container.Image).To(Equal("example.com/kube-apiserver:custom"))
+	// The override takes precedence over the annotation, which takes precedence over the default.
+	g.Expect(container.Resources.Requests).To(Equal(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("2Gi"),
+	}))
+	g.Expect(container.Resources.Limits).To(Equal(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}))
+	g.Expect(container.Env).To(Equal([]corev1.EnvVar{{Name: "GOGC", Value: "50"}, {Name: "LOG_LEVEL", Value: "4"}}))
+	g.Expect(podSpec.Containers[1].Image).To(Equal("audit-logs"))
+
+	// Overrides of other components are left alone.
+	other := deployment.DeepCopy()
+	other.Name = "kube-controller-manager"
+	other.Spec.Template.Spec = corev1.PodSpec{Containers: []corev1.Container{{Name: "kube-apiserver", Image: "kube-apiserver"}}}
+	cfg.ApplyTo(other)
+	g.Expect(*other.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(other.Spec.Template.Spec.Containers[0].Image).To(Equal("kube-apiserver"))
+}
+
+func TestComponentOverridesTolerations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hcp := &hyperv1.HostedControlPlane{}
+	hcp.Namespace = "clusters-example"
+	hcp.Spec.ControlPlaneComponents = []hyperv1.ControlPlaneComponentOverride{{
+		Name:        "kube-apiserver",
+		Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+	}}
+	cfg := &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, nil)
+	// Leave room in the backing array of the shared tolerations, so appending in place would
+	// be visible to other components.
+	cfg.Scheduling.Tolerations = append(make([]corev1.Toleration, 0, 10), cfg.Scheduling.Tolerations...)
+	defaultTolerations := len(cfg.Scheduling.Tolerations)
+
+	newDeployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: name}},
+			}}},
+		}
+	}
+	kas := newDeployment("kube-apiserver")
+	cfg.ApplyTo(kas)
+	kcm := newDeployment("kube-controller-manager")
+	cfg.ApplyTo(kcm)
+
+	g.Expect(kas.Spec.Template.Spec.Tolerations).To(HaveLen(defaultTolerations + 1))
+	g.Expect(kcm.Spec.Template.Spec.Tolerations).To(HaveLen(defaultTolerations))
+	g.Expect(cfg.Scheduling.Tolerations[:cap(cfg.Scheduling.Tolerations)][defaultTolerations]).To(Equal(corev1.Toleration{}))
+}
+
+func TestComponentOverridesResources(t *testing.T) {
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "kube-apiserver", Image: "kube-apiserver"}},
+					},
+				},
+			},
+		}
+	}
+	override := func(resources corev1.ResourceRequirements) []hyperv1.ControlPlaneComponentOverride {
+		return []hyperv1.ControlPlaneComponentOverride{{
+			Name: "kube-apiserver",
+			Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name:      "kube-apiserver",
+				Image:     "example.com/kube-apiserver:custom",
+				Resources: resources,
+			}},
+		}}
+	}
+
+	testCases := []struct {
+		name               string
+		size               hyperv1.ControlPlaneSizeTier
+		overrides          []hyperv1.ControlPlaneComponentOverride
+		deployedResources  *corev1.ResourceRequirements
+		expectedResources  corev1.ResourceRequirements
+		expectedAnnotation string
+	}{
+		{
+			name:      "When the request override is below the limit of the size tier it should be applied",
+			size:      hyperv1.SmallControlPlaneSize,
+			overrides: override(corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}}),
+			expectedResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+			},
+		},
+		{
+			name:      "When the request override exceeds the limit of the size tier it should be skipped and recorded",
+			size:      hyperv1.SmallControlPlaneSize,
+			overrides: override(corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")}}),
+			expectedResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+			},
+			expectedAnnotation: "component kube-apiserver container kube-apiserver: memory request 8Gi exceeds its limit 4Gi",
+		},
+		{
+			name:      "When the deployed container has a limit left over from a removed override it should validate against the defaults",
+			overrides: override(corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}}),
+			deployedResources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			expectedResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			hcp := &hyperv1.HostedControlPlane{}
+			hcp.Namespace = "clusters-example"
+			hcp.Spec.ControlPlaneSize = tc.size
+			hcp.Spec.ControlPlaneComponents = tc.overrides
+
+			deployment := newDeployment()
+			cfg := &DeploymentConfig{Resources: ResourcesSpec{}}
+			cfg.SetDefaults(hcp, nil, pointer.Int(1))
+			if tc.deployedResources != nil {
+				cfg.SetContainerResourcesIfPresent(&corev1.Container{Name: "kube-apiserver", Resources: *tc.deployedResources})
+			}
+			cfg.ApplyTo(deployment)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			g.Expect(container.Image).To(Equal("example.com/kube-apiserver:custom"))
+			g.Expect(container.Resources).To(Equal(tc.expectedResources))
+			if tc.expectedAnnotation == "" {
+				g.Expect(deployment.Annotations).ToNot(HaveKey(InvalidComponentOverridesAnnotation))
+			} else {
+				g.Expect(deployment.Annotations).To(HaveKeyWithValue(InvalidComponentOverridesAnnotation, tc.expectedAnnotation))
+			}
+		})
+	}
+}
+
+func TestValidateComponentOverrides(t *testing.T) {
+	components := map[string]sets.String{
+		"kube-apiserver": sets.NewString("kube-apiserver", "audit-logs"),
+		"etcd":           sets.NewString("etcd"),
+	}
+	testCases := []struct {
+		name          string
+		overrides     []hyperv1.ControlPlaneComponentOverride
+		expectedError string
+	}{
+		{
+			name: "When the overrides reference existing components and containers it should succeed",
+			overrides: []hyperv1.ControlPlaneComponentOverride{
+				{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{Name: "audit-logs"}}},
+				{Name: "etcd"},
+			},
+		},
+		{
+			name:          "When an override references an unknown component it should fail",
+			overrides:     []hyperv1.ControlPlaneComponentOverride{{Name: "kube-api-server"}},
+			expectedError: "component kube-api-server does not exist",
+		},
+		{
+			name: "When an override references an unknown container it should fail",
+			overrides: []hyperv1.ControlPlaneComponentOverride{
+				{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{Name: "kas"}}},
+			},
+			expectedError: "component kube-apiserver has no container kas, valid containers are [audit-logs kube-apiserver]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := ValidateComponentOverrides(tc.overrides, components)
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.expectedError))
+		})
+	}
+}
+
+func TestValidateComponentOverrideResources(t *testing.T) {
+	podSpecs := map[string]*corev1.PodSpec{
+		"kube-apiserver": {
+			Containers: []corev1.Container{{
+				Name: "kube-apiserver",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				},
+			}},
+		},
+	}
+	testCases := []struct {
+		name          string
+		overrides     []hyperv1.ControlPlaneComponentOverride
+		expectedError string
+	}{
+		{
+			name: "When the limit override is above the default request it should succeed",
+			overrides: []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name:      "kube-apiserver",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}},
+			}}}},
+		},
+		{
+			name: "When the limit override is below the default request it should fail",
+			overrides: []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name:      "kube-apiserver",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			}}}},
+			expectedError: "component kube-apiserver container kube-apiserver: memory request 2Gi exceeds its limit 1Gi",
+		},
+		{
+			name: "When the request override lowers the request below the limit override it should succeed",
+			overrides: []hyperv1.ControlPlaneComponentOverride{{Name: "kube-apiserver", Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name: "kube-apiserver",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			}}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := ValidateComponentOverrideResources(tc.overrides, podSpecs)
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.expectedError))
+		})
+	}
+}
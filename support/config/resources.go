@@ -19,19 +19,27 @@ func (s ResourcesSpec) ApplyTo(podSpec *corev1.PodSpec) {
 	}
 }
 
+// ApplyRequestsOverrideTo overrides the requests of the containers in podSpec. Limits
+// below an overridden request, like the memory limits of the size tiers, are raised to
+// the request so the pod spec remains valid.
 func (s ResourcesSpec) ApplyRequestsOverrideTo(podSpec *corev1.PodSpec) {
 	for i, c := range podSpec.InitContainers {
 		if res, ok := s[c.Name]; ok {
-			for name, value := range res.Requests {
-				podSpec.InitContainers[i].Resources.Requests[name] = value
-			}
+			applyRequestsOverride(res.Requests, &podSpec.InitContainers[i].Resources)
 		}
 	}
 	for i, c := range podSpec.Containers {
 		if res, ok := s[c.Name]; ok {
-			for name, value := range res.Requests {
-				podSpec.Containers[i].Resources.Requests[name] = value
-			}
+			applyRequestsOverride(res.Requests, &podSpec.Containers[i].Resources)
+		}
+	}
+}
+
+func applyRequestsOverride(requests corev1.ResourceList, resources *corev1.ResourceRequirements) {
+	for name, value := range requests {
+		resources.Requests[name] = value
+		if limit, exists := resources.Limits[name]; exists && limit.Cmp(value) < 0 {
+			resources.Limits[name] = value
 		}
 	}
 }
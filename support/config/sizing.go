@@ -0,0 +1,111 @@
+package config
+
+import (
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+)
+
+// componentSize is the size of the main container of a control plane component in a size tier.
+type componentSize struct {
+	container string
+	cpu       string
+	memory    string
+	// memoryLimit is the memory limit of the container. Empty leaves the container
+	// without a limit.
+	memoryLimit string
+	// minReplicas is the minimum number of replicas of the component, regardless of the
+	// availability policy. Zero leaves the replicas alone.
+	minReplicas int32
+}
+
+// controlPlaneSizes are the sizes of the API servers and etcd in each size tier, keyed
+// by the name of their Deployment or StatefulSet. CPU is not limited to avoid throttling
+// the API servers under bursts of requests. The memory of etcd is not limited either, as
+// it grows with the size of its database, which does not shrink when the load does, and
+// an OOM kill of etcd makes the whole control plane unavailable.
+var controlPlaneSizes = map[hyperv1.ControlPlaneSizeTier]map[string]componentSize{
+	hyperv1.SmallControlPlaneSize: {
+		"kube-apiserver":      {container: "kube-apiserver", cpu: "250m", memory: "1Gi", memoryLimit: "4Gi"},
+		"etcd":                {container: "etcd", cpu: "200m", memory: "400Mi"},
+		"openshift-apiserver": {container: "openshift-apiserver", cpu: "50m", memory: "150Mi", memoryLimit: "1Gi"},
+		"oauth-openshift":     {container: "oauth-server", cpu: "20m", memory: "30Mi", memoryLimit: "256Mi"},
+	},
+	hyperv1.MediumControlPlaneSize: {
+		"kube-apiserver":      {container: "kube-apiserver", cpu: "1", memory: "4Gi", memoryLimit: "10Gi"},
+		"etcd":                {container: "etcd", cpu: "500m", memory: "2Gi"},
+		"openshift-apiserver": {container: "openshift-apiserver", cpu: "200m", memory: "400Mi", memoryLimit: "3Gi"},
+		"oauth-openshift":     {container: "oauth-server", cpu: "50m", memory: "80Mi", memoryLimit: "512Mi"},
+	},
+	hyperv1.LargeControlPlaneSize: {
+		"kube-apiserver":      {container: "kube-apiserver", cpu: "2", memory: "8Gi", memoryLimit: "16Gi", minReplicas: 2},
+		"etcd":                {container: "etcd", cpu: "1", memory: "4Gi"},
+		"openshift-apiserver": {container: "openshift-apiserver", cpu: "500m", memory: "1Gi", memoryLimit: "4Gi", minReplicas: 2},
+		"oauth-openshift":     {container: "oauth-server", cpu: "100m", memory: "150Mi", memoryLimit: "1Gi", minReplicas: 2},
+	},
+	hyperv1.XLargeControlPlaneSize: {
+		"kube-apiserver":      {container: "kube-apiserver", cpu: "4", memory: "16Gi", memoryLimit: "32Gi", minReplicas: 3},
+		"etcd":                {container: "etcd", cpu: "2", memory: "8Gi"},
+		"openshift-apiserver": {container: "openshift-apiserver", cpu: "1", memory: "2Gi", memoryLimit: "8Gi", minReplicas: 3},
+		"oauth-openshift":     {container: "oauth-server", cpu: "200m", memory: "300Mi", memoryLimit: "1Gi", minReplicas: 3},
+	},
+}
+
+// sizeOverrides returns the overrides of the size tier of the control plane. Replicas
+// are only raised above the number derived from the availability policy.
+func sizeOverrides(hcp *hyperv1.HostedControlPlane) ComponentOverrides {
+	sizes, exists := controlPlaneSizes[hcp.Spec.ControlPlaneSize]
+	if !exists {
+		return nil
+	}
+	defaultReplicas := int32(1)
+	if hcp.Spec.ControllerAvailabilityPolicy == hyperv1.HighlyAvailable {
+		defaultReplicas = 3
+	}
+	result := ComponentOverrides{}
+	for name, size := range sizes {
+		resources := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(size.cpu),
+				corev1.ResourceMemory: resource.MustParse(size.memory),
+			},
+		}
+		if size.memoryLimit != "" {
+			resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(size.memoryLimit)}
+		}
+		override := hyperv1.ControlPlaneComponentOverride{
+			Name: name,
+			Containers: []hyperv1.ControlPlaneContainerOverride{{
+				Name:      size.container,
+				Resources: resources,
+			}},
+		}
+		if size.minReplicas > defaultReplicas {
+			override.Replicas = pointer.Int32(size.minReplicas)
+		}
+		result[name] = override
+	}
+	return result
+}
+
+// SizedComponentPodSpecs returns the pod specs of the components sized by the size tier of
+// hcp with the resources of the tier and the resource request override annotations of hcp
+// applied. They are the known default resources the component overrides can be validated
+// against before the control plane operator renders the components.
+func SizedComponentPodSpecs(hcp *hyperv1.HostedControlPlane) map[string]*corev1.PodSpec {
+	sizes, exists := controlPlaneSizes[hcp.Spec.ControlPlaneSize]
+	if !exists {
+		return nil
+	}
+	overrides := sizeOverrides(hcp)
+	requestOverrides := resourceRequestOverrides(hcp)
+	result := map[string]*corev1.PodSpec{}
+	for name, size := range sizes {
+		podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: size.container}}}
+		overrides.ApplyTo(name, podSpec)
+		requestOverrides.ApplyRequestsTo(name, podSpec)
+		result[name] = podSpec
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSizeOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "kube-apiserver"}},
+					},
+				},
+			},
+		}
+	}
+	hcp := &hyperv1.HostedControlPlane{}
+	hcp.Namespace = "clusters-example"
+	hcp.Spec.ControllerAvailabilityPolicy = hyperv1.SingleReplica
+	hcp.Spec.ControlPlaneSize = hyperv1.LargeControlPlaneSize
+
+	deployment := newDeployment()
+	cfg := &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, pointer.Int(1))
+	cfg.ApplyTo(deployment)
+	g.Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Requests).To(Equal(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("8Gi"),
+	}))
+
+	// Overrides of the user take precedence over the size tier.
+	hcp.Spec.ControlPlaneComponents = []hyperv1.ControlPlaneComponentOverride{{
+		Name:     "kube-apiserver",
+		Replicas: pointer.Int32(1),
+		Containers: []hyperv1.ControlPlaneContainerOverride{{
+			Name:      "kube-apiserver",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}},
+		}},
+	}}
+	deployment = newDeployment()
+	cfg = &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, pointer.Int(1))
+	cfg.ApplyTo(deployment)
+	g.Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Requests).To(Equal(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("3"),
+		corev1.ResourceMemory: resource.MustParse("8Gi"),
+	}))
+
+	// Resource request override annotations take precedence over the size tier.
+	hcp.Spec.ControlPlaneComponents = nil
+	hcp.Annotations = map[string]string{
+		hyperv1.ResourceRequestOverrideAnnotationPrefix + "/kube-apiserver.kube-apiserver": "memory=12Gi",
+	}
+	deployment = newDeployment()
+	cfg = &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, pointer.Int(1))
+	cfg.ApplyTo(deployment)
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Requests).To(Equal(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("12Gi"),
+	}))
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Limits).To(Equal(corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}))
+
+	// A resource request override annotation above the memory limit of the size tier raises the limit.
+	hcp.Annotations = map[string]string{
+		hyperv1.ResourceRequestOverrideAnnotationPrefix + "/kube-apiserver.kube-apiserver": "memory=20Gi",
+	}
+	deployment = newDeployment()
+	cfg = &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, pointer.Int(1))
+	cfg.ApplyTo(deployment)
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Requests).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("20Gi")))
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Limits).To(Equal(corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("20Gi"),
+	}))
+	hcp.Annotations = nil
+
+	// The memory of etcd is not limited.
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd"},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "etcd"}},
+				},
+			},
+		},
+	}
+	cfg.ApplyToStatefulSet(sts)
+	g.Expect(sts.Spec.Template.Spec.Containers[0].Resources.Requests).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("4Gi")))
+	g.Expect(sts.Spec.Template.Spec.Containers[0].Resources.Limits).To(BeEmpty())
+
+	// Clusters without a size tier keep the defaults.
+	hcp.Spec.ControlPlaneSize = ""
+	hcp.Spec.ControlPlaneComponents = nil
+	deployment = newDeployment()
+	cfg = &DeploymentConfig{}
+	cfg.SetDefaults(hcp, nil, pointer.Int(1))
+	cfg.ApplyTo(deployment)
+	g.Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Requests).To(BeEmpty())
+}
@@ -381,3 +381,18 @@ func RegistryOperatorRelabelConfigs(set MetricsSet) []*prometheusoperatorv1.Rela
 	}
 	return nil
 }
+
+func KonnectivityRelabelConfigs(set MetricsSet) []*prometheusoperatorv1.RelabelConfig {
+	switch set {
+	case MetricsSetTelemetry:
+		return []*prometheusoperatorv1.RelabelConfig{
+			{
+				Action:       "keep",
+				Regex:        "(konnectivity_network_proxy_server_ready_backend_connections|konnectivity_network_proxy_server_established_connections|konnectivity_network_proxy_agent_open_endpoint_connections)",
+				SourceLabels: []prometheusoperatorv1.LabelName{"__name__"},
+			},
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,503 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/capabilities"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusPass Status = "Pass"
+	StatusWarn Status = "Warn"
+	StatusFail Status = "Fail"
+)
+
+// Stage is the point in time at which the checks are run.
+type Stage string
+
+const (
+	// StageInstall checks a management cluster before the HyperShift operator is installed.
+	StageInstall Stage = "install"
+	// StageCreate checks a management cluster before a HostedCluster is created.
+	StageCreate Stage = "create"
+)
+
+// Result is the outcome of a check. Remediation describes how to resolve a warning or
+// failure and is empty for passed checks.
+type Result struct {
+	Name        string `json:"name"`
+	Status      Status `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Options describe the HostedCluster the management cluster is checked for. Empty fields
+// skip the checks that depend on them.
+type Options struct {
+	Stage              Stage
+	AvailabilityPolicy hyperv1.AvailabilityPolicy
+	PublishingStrategy hyperv1.PublishingStrategyType
+	// EtcdStorageClass is the storage class of the etcd volumes. The default storage
+	// class is checked if empty.
+	EtcdStorageClass  string
+	ExternalDNSDomain string
+}
+
+// OptionsForHostedCluster returns the options to check the management cluster for hc.
+func OptionsForHostedCluster(hc *hyperv1.HostedCluster, externalDNSDomain string) Options {
+	opts := Options{
+		Stage:              StageCreate,
+		AvailabilityPolicy: hc.Spec.ControllerAvailabilityPolicy,
+		ExternalDNSDomain:  externalDNSDomain,
+	}
+	for _, service := range hc.Spec.Services {
+		if service.Service == hyperv1.APIServer {
+			opts.PublishingStrategy = service.Type
+		}
+	}
+	if etcd := hc.Spec.Etcd.Managed; etcd != nil && etcd.Storage.PersistentVolume != nil && etcd.Storage.PersistentVolume.StorageClassName != nil {
+		opts.EtcdStorageClass = *etcd.Storage.PersistentVolume.StorageClassName
+	}
+	return opts
+}
+
+// Resolver looks up the name servers of a domain. It is satisfied by net.Resolver.
+type Resolver interface {
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+}
+
+// Checker runs the preflight checks against a management cluster. Checks that can't read
+// what they check warn instead of failing, so only definite problems fail.
+type Checker struct {
+	Client    client.Reader
+	Discovery discovery.ServerResourcesInterface
+	Resolver  Resolver
+	// Pods reads the pods whose requests are counted against the capacity of nodes,
+	// Client if nil.
+	Pods client.Reader
+	// PodSelector selects the pods whose requests are counted against the capacity of
+	// nodes, all of them if nil.
+	PodSelector labels.Selector
+}
+
+// NewChecker returns a checker that reads from c, discovers APIs with config and resolves
+// names with the default resolver.
+func NewChecker(c client.Reader, config *rest.Config) (*Checker, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	return &Checker{Client: c, Discovery: discoveryClient, Resolver: net.DefaultResolver}, nil
+}
+
+// Run runs every check that applies to opts and returns the results in a stable order.
+func (c *Checker) Run(ctx context.Context, opts Options) []Result {
+	results := []Result{
+		c.checkRequiredAPIs(opts),
+		c.checkEtcdStorage(ctx, opts),
+	}
+	if opts.PublishingStrategy != "" {
+		results = append(results, c.checkPublishingStrategy(ctx, opts))
+	}
+	if opts.ExternalDNSDomain != "" {
+		results = append(results, c.checkExternalDNSDomain(ctx, opts))
+	}
+	if opts.AvailabilityPolicy != "" {
+		results = append(results, c.checkNodeCapacity(ctx, opts))
+	}
+	return results
+}
+
+// Failed returns true if any of results failed.
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+type requiredAPI struct {
+	groupVersion schema.GroupVersion
+	resource     string
+	remediation  string
+}
+
+var monitoringGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1"}
+
+var requiredAPIs = []requiredAPI{
+	{
+		groupVersion: monitoringGroupVersion,
+		resource:     "servicemonitors",
+		remediation:  "Install the Prometheus operator CRDs, e.g. with the cluster monitoring stack or the prometheus-operator bundle.",
+	},
+	{
+		groupVersion: monitoringGroupVersion,
+		resource:     "prometheusrules",
+		remediation:  "Install the Prometheus operator CRDs, e.g. with the cluster monitoring stack or the prometheus-operator bundle.",
+	},
+}
+
+var hyperShiftAPIs = []requiredAPI{
+	{
+		groupVersion: hyperv1.GroupVersion,
+		resource:     "hostedclusters",
+		remediation:  "Install HyperShift with `hypershift install`.",
+	},
+	{
+		groupVersion: hyperv1.GroupVersion,
+		resource:     "nodepools",
+		remediation:  "Install HyperShift with `hypershift install`.",
+	},
+}
+
+func (c *Checker) checkRequiredAPIs(opts Options) Result {
+	const name = "RequiredAPIs"
+	apis := requiredAPIs
+	if opts.Stage == StageCreate {
+		apis = append(append([]requiredAPI{}, requiredAPIs...), hyperShiftAPIs...)
+	}
+	var missing []string
+	var remediation []string
+	for _, api := range apis {
+		registered, err := capabilities.IsAPIResourceRegistered(c.Discovery, api.groupVersion, api.resource)
+		if err != nil {
+			return errorResult(name, fmt.Errorf("failed to discover %s: %w", api.groupVersion, err))
+		}
+		if !registered {
+			missing = append(missing, api.resource+"."+api.groupVersion.String())
+			if !contains(remediation, api.remediation) {
+				remediation = append(remediation, api.remediation)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return Result{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("Required APIs are not available: %s", strings.Join(missing, ", ")),
+			Remediation: strings.Join(remediation, " "),
+		}
+	}
+	return Result{Name: name, Status: StatusPass, Message: "All required APIs are available"}
+}
+
+func (c *Checker) checkEtcdStorage(ctx context.Context, opts Options) Result {
+	const name = "EtcdStorage"
+	var storageClass *storagev1.StorageClass
+	if opts.EtcdStorageClass != "" {
+		storageClass = &storagev1.StorageClass{}
+		if err := c.Client.Get(ctx, client.ObjectKey{Name: opts.EtcdStorageClass}, storageClass); err != nil {
+			if apierrors.IsNotFound(err) {
+				return Result{
+					Name:        name,
+					Status:      StatusFail,
+					Message:     fmt.Sprintf("Storage class %q for etcd does not exist", opts.EtcdStorageClass),
+					Remediation: "Create the storage class or choose an existing one with --etcd-storage-class.",
+				}
+			}
+			return errorResult(name, fmt.Errorf("failed to get storage class %s: %w", opts.EtcdStorageClass, err))
+		}
+	} else {
+		storageClasses := &storagev1.StorageClassList{}
+		if err := c.Client.List(ctx, storageClasses); err != nil {
+			return errorResult(name, fmt.Errorf("failed to list storage classes: %w", err))
+		}
+		var defaults []string
+		for i := range storageClasses.Items {
+			if isDefaultStorageClass(&storageClasses.Items[i]) {
+				defaults = append(defaults, storageClasses.Items[i].Name)
+				if storageClass == nil {
+					storageClass = &storageClasses.Items[i]
+				}
+			}
+		}
+		if storageClass == nil {
+			return Result{
+				Name:        name,
+				Status:      StatusFail,
+				Message:     "There is no default storage class for the etcd volumes",
+				Remediation: fmt.Sprintf("Annotate a storage class with %s=true or choose one with --etcd-storage-class.", defaultStorageClassAnnotation),
+			}
+		}
+		if len(defaults) > 1 {
+			sort.Strings(defaults)
+			return Result{
+				Name:        name,
+				Status:      StatusWarn,
+				Message:     fmt.Sprintf("Multiple storage classes are marked as default: %s", strings.Join(defaults, ", ")),
+				Remediation: "Mark a single storage class as default or choose one with --etcd-storage-class.",
+			}
+		}
+	}
+
+	if storageClass.VolumeBindingMode == nil || *storageClass.VolumeBindingMode != storagev1.VolumeBindingWaitForFirstConsumer {
+		return Result{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("Storage class %q binds volumes immediately, so etcd volumes may be provisioned in a zone the etcd pods cannot be scheduled to", storageClass.Name),
+			Remediation: "Use a storage class with volumeBindingMode WaitForFirstConsumer for etcd.",
+		}
+	}
+	return Result{Name: name, Status: StatusPass, Message: fmt.Sprintf("Storage class %q binds volumes when etcd is scheduled", storageClass.Name)}
+}
+
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+func isDefaultStorageClass(sc *storagev1.StorageClass) bool {
+	return sc.Annotations[defaultStorageClassAnnotation] == "true" || sc.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true"
+}
+
+func (c *Checker) checkPublishingStrategy(ctx context.Context, opts Options) Result {
+	const name = "PublishingStrategy"
+	switch opts.PublishingStrategy {
+	case hyperv1.LoadBalancer:
+		services := &corev1.ServiceList{}
+		if err := c.Client.List(ctx, services); err != nil {
+			return errorResult(name, fmt.Errorf("failed to list services: %w", err))
+		}
+		var pending []string
+		for _, svc := range services.Items {
+			if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				continue
+			}
+			if len(svc.Status.LoadBalancer.Ingress) > 0 {
+				return Result{Name: name, Status: StatusPass, Message: fmt.Sprintf("Load balancers are provisioned, e.g. for service %s/%s", svc.Namespace, svc.Name)}
+			}
+			pending = append(pending, svc.Namespace+"/"+svc.Name)
+		}
+		// Load balancers may still be provisioning, so pending ones are no definite problem
+		if len(pending) > 0 {
+			sort.Strings(pending)
+			return Result{
+				Name:        name,
+				Status:      StatusWarn,
+				Message:     fmt.Sprintf("No load balancer has been provisioned for services of type LoadBalancer: %s", strings.Join(pending, ", ")),
+				Remediation: "Install a load balancer provider such as MetalLB or use the NodePort or Route publishing strategy.",
+			}
+		}
+		return Result{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     "There are no services of type LoadBalancer, so load balancer support could not be verified",
+			Remediation: "Make sure the management cluster can provision load balancers, e.g. with a cloud provider or MetalLB.",
+		}
+	case hyperv1.Route:
+		registered, err := capabilities.IsAPIResourceRegistered(c.Discovery, routev1.GroupVersion, "routes")
+		if err != nil {
+			return errorResult(name, fmt.Errorf("failed to discover %s: %w", routev1.GroupVersion, err))
+		}
+		if !registered {
+			return Result{
+				Name:        name,
+				Status:      StatusFail,
+				Message:     "The Route publishing strategy requires the route.openshift.io API",
+				Remediation: "Use an OpenShift management cluster or the LoadBalancer or NodePort publishing strategy.",
+			}
+		}
+		return Result{Name: name, Status: StatusPass, Message: "Routes are supported"}
+	default:
+		return Result{Name: name, Status: StatusPass, Message: fmt.Sprintf("The %s publishing strategy has no management cluster requirements", opts.PublishingStrategy)}
+	}
+}
+
+// checkExternalDNSDomain verifies that the domain or one of its parent zones is delegated,
+// so that the records created by external-dns can be resolved.
+func (c *Checker) checkExternalDNSDomain(ctx context.Context, opts Options) Result {
+	const name = "ExternalDNSDomain"
+	domain := strings.TrimSuffix(opts.ExternalDNSDomain, ".")
+	domainLabels := strings.Split(domain, ".")
+	var lookupErr error
+	// The top level domain is always resolvable and says nothing about the zone
+	for i := 0; i < len(domainLabels)-1; i++ {
+		zone := strings.Join(domainLabels[i:], ".")
+		servers, err := c.Resolver.LookupNS(ctx, zone)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+				lookupErr = err
+			}
+			continue
+		}
+		if len(servers) == 0 {
+			continue
+		}
+		if zone == domain {
+			return Result{Name: name, Status: StatusPass, Message: fmt.Sprintf("Domain %s is delegated to %s", domain, servers[0].Host)}
+		}
+		return Result{Name: name, Status: StatusPass, Message: fmt.Sprintf("Domain %s resolves through zone %s served by %s", domain, zone, servers[0].Host)}
+	}
+	if lookupErr != nil {
+		return errorResult(name, fmt.Errorf("failed to look up the name servers of %s: %w", domain, lookupErr))
+	}
+	return Result{
+		Name:        name,
+		Status:      StatusFail,
+		Message:     fmt.Sprintf("No name servers were found for %s or its parent domains", domain),
+		Remediation: "Create a public DNS zone for the domain and delegate it from its parent domain.",
+	}
+}
+
+// controlPlaneReplicaRequests approximates the requests of one replica of every control
+// plane component.
+var controlPlaneReplicaRequests = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("2"),
+	corev1.ResourceMemory: resource.MustParse("6Gi"),
+}
+
+// tolerationKeys are the taints that control plane pods tolerate, see support/config.
+var tolerationKeys = []string{"hypershift.openshift.io/control-plane", "hypershift.openshift.io/cluster"}
+
+func (c *Checker) checkNodeCapacity(ctx context.Context, opts Options) Result {
+	const name = "NodeCapacity"
+	replicas := 1
+	if opts.AvailabilityPolicy == hyperv1.HighlyAvailable {
+		replicas = 3
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := c.Client.List(ctx, nodes); err != nil {
+		return errorResult(name, fmt.Errorf("failed to list nodes: %w", err))
+	}
+	podReader := c.Pods
+	if podReader == nil {
+		podReader = c.Client
+	}
+	var listOpts []client.ListOption
+	if c.PodSelector != nil {
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: c.PodSelector})
+	}
+	pods := &corev1.PodList{}
+	if err := podReader.List(ctx, pods, listOpts...); err != nil {
+		return errorResult(name, fmt.Errorf("failed to list pods: %w", err))
+	}
+	requested := map[string]corev1.ResourceList{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		nodeRequests := requested[pod.Spec.NodeName]
+		if nodeRequests == nil {
+			nodeRequests = corev1.ResourceList{}
+			requested[pod.Spec.NodeName] = nodeRequests
+		}
+		for _, container := range pod.Spec.Containers {
+			for resourceName, quantity := range container.Resources.Requests {
+				total := nodeRequests[resourceName]
+				total.Add(quantity)
+				nodeRequests[resourceName] = total
+			}
+		}
+	}
+
+	schedulable := 0
+	free := corev1.ResourceList{}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !isSchedulable(node) {
+			continue
+		}
+		schedulable++
+		for resourceName := range controlPlaneReplicaRequests {
+			available := node.Status.Allocatable[resourceName]
+			used := requested[node.Name][resourceName]
+			available.Sub(used)
+			if available.Sign() < 0 {
+				continue
+			}
+			total := free[resourceName]
+			total.Add(available)
+			free[resourceName] = total
+		}
+	}
+
+	if schedulable < replicas {
+		return Result{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("The %s availability policy spreads control plane replicas across %d nodes, but only %d nodes are schedulable", opts.AvailabilityPolicy, replicas, schedulable),
+			Remediation: "Add schedulable nodes to the management cluster or use the SingleReplica availability policy.",
+		}
+	}
+	var insufficient []string
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		perReplica := controlPlaneReplicaRequests[resourceName]
+		needed := resource.NewMilliQuantity(perReplica.MilliValue()*int64(replicas), perReplica.Format)
+		available := free[resourceName]
+		if available.Cmp(*needed) < 0 {
+			insufficient = append(insufficient, fmt.Sprintf("%s: %s free, %s needed", resourceName, available.String(), needed.String()))
+		}
+	}
+	if len(insufficient) > 0 {
+		return Result{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("Schedulable nodes may not have enough free capacity for a %s control plane (%s)", opts.AvailabilityPolicy, strings.Join(insufficient, "; ")),
+			Remediation: "Add nodes or enable the cluster autoscaler on the management cluster.",
+		}
+	}
+	return Result{Name: name, Status: StatusPass, Message: fmt.Sprintf("%d schedulable nodes have enough free capacity for a %s control plane", schedulable, opts.AvailabilityPolicy)}
+}
+
+func isSchedulable(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	if !ready {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule || contains(tolerationKeys, taint.Key) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// errorResult is the result of a check that could not read what it checks. It only
+// warns, as the check says nothing about the management cluster then.
+func errorResult(name string, err error) Result {
+	return Result{
+		Name:        name,
+		Status:      StatusWarn,
+		Message:     fmt.Sprintf("Could not be checked: %v", err),
+		Remediation: "Make sure the management cluster is reachable and the current user can read the checked resources.",
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/api"
+)
+
+func TestRun(t *testing.T) {
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	immediate := storagev1.VolumeBindingImmediate
+	defaultStorageClass := &storagev1.StorageClass{
+		ObjectMeta:        metav1.ObjectMeta{Name: "gp3", Annotations: map[string]string{defaultStorageClassAnnotation: "true"}},
+		VolumeBindingMode: &waitForFirstConsumer,
+	}
+	immediateStorageClass := &storagev1.StorageClass{
+		ObjectMeta:        metav1.ObjectMeta{Name: "local"},
+		VolumeBindingMode: &immediate,
+	}
+	provisionedService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "router"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{Hostname: "router.elb.example.com"}},
+		}},
+	}
+	pendingService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "router"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	monitoringAPIs := metav1.APIResourceList{
+		GroupVersion: monitoringGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "servicemonitors"}, {Name: "prometheusrules"}},
+	}
+	hyperShiftAPIs := metav1.APIResourceList{
+		GroupVersion: hyperv1.GroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "hostedclusters"}, {Name: "nodepools"}},
+	}
+	routeAPIs := metav1.APIResourceList{
+		GroupVersion: "route.openshift.io/v1",
+		APIResources: []metav1.APIResource{{Name: "routes"}},
+	}
+
+	testCases := []struct {
+		name      string
+		opts      Options
+		objects   []client.Object
+		resources []metav1.APIResourceList
+		zones     map[string]string
+		expected  map[string]Status
+	}{
+		{
+			name:      "When the cluster meets all requirements it should pass",
+			opts:      Options{Stage: StageCreate, AvailabilityPolicy: hyperv1.HighlyAvailable, PublishingStrategy: hyperv1.LoadBalancer, ExternalDNSDomain: "hc.example.com"},
+			objects:   append([]client.Object{defaultStorageClass, provisionedService}, nodes(3, "4", "16Gi")...),
+			resources: []metav1.APIResourceList{monitoringAPIs, hyperShiftAPIs},
+			zones:     map[string]string{"hc.example.com": "ns1.example.net."},
+			expected: map[string]Status{
+				"RequiredAPIs":       StatusPass,
+				"EtcdStorage":        StatusPass,
+				"PublishingStrategy": StatusPass,
+				"ExternalDNSDomain":  StatusPass,
+				"NodeCapacity":       StatusPass,
+			},
+		},
+		{
+			name:      "When HyperShift is not installed before install it should pass the API check",
+			opts:      Options{Stage: StageInstall},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass},
+		},
+		{
+			name:      "When HyperShift is not installed before create it should fail the API check",
+			opts:      Options{Stage: StageCreate},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusFail, "EtcdStorage": StatusPass},
+		},
+		{
+			name:     "When there is no default storage class it should fail",
+			opts:     Options{Stage: StageInstall},
+			objects:  []client.Object{immediateStorageClass},
+			expected: map[string]Status{"RequiredAPIs": StatusFail, "EtcdStorage": StatusFail},
+		},
+		{
+			name:      "When the etcd storage class binds immediately it should warn",
+			opts:      Options{Stage: StageInstall, EtcdStorageClass: "local"},
+			objects:   []client.Object{defaultStorageClass, immediateStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusWarn},
+		},
+		{
+			name:      "When load balancers are not provisioned yet it should warn",
+			opts:      Options{Stage: StageInstall, PublishingStrategy: hyperv1.LoadBalancer},
+			objects:   []client.Object{defaultStorageClass, pendingService},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "PublishingStrategy": StatusWarn},
+		},
+		{
+			name:      "When there are no load balancer services it should warn",
+			opts:      Options{Stage: StageInstall, PublishingStrategy: hyperv1.LoadBalancer},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "PublishingStrategy": StatusWarn},
+		},
+		{
+			name:      "When routes are used without the route API it should fail",
+			opts:      Options{Stage: StageInstall, PublishingStrategy: hyperv1.Route},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "PublishingStrategy": StatusFail},
+		},
+		{
+			name:      "When routes are used with the route API it should pass",
+			opts:      Options{Stage: StageInstall, PublishingStrategy: hyperv1.Route},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs, routeAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "PublishingStrategy": StatusPass},
+		},
+		{
+			name:      "When only a parent zone of the external DNS domain resolves it should pass",
+			opts:      Options{Stage: StageInstall, ExternalDNSDomain: "hc.example.com"},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			zones:     map[string]string{"example.com": "ns1.example.net."},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "ExternalDNSDomain": StatusPass},
+		},
+		{
+			name:      "When the external DNS domain does not resolve it should fail",
+			opts:      Options{Stage: StageInstall, ExternalDNSDomain: "hc.example.com"},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			zones:     map[string]string{"com": "a.gtld-servers.net."},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "ExternalDNSDomain": StatusFail},
+		},
+		{
+			name:      "When name servers can't be looked up it should warn",
+			opts:      Options{Stage: StageInstall, ExternalDNSDomain: "hc.example.com"},
+			objects:   []client.Object{defaultStorageClass},
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			zones:     map[string]string{"example.com": "timeout"},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "ExternalDNSDomain": StatusWarn},
+		},
+		{
+			name:      "When there are fewer schedulable nodes than HA replicas it should fail",
+			opts:      Options{Stage: StageInstall, AvailabilityPolicy: hyperv1.HighlyAvailable},
+			objects:   append([]client.Object{defaultStorageClass, unschedulable(nodes(1, "4", "16Gi")[0])}, nodes(2, "4", "16Gi")...),
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "NodeCapacity": StatusFail},
+		},
+		{
+			name: "When nodes are full it should warn",
+			opts: Options{Stage: StageInstall, AvailabilityPolicy: hyperv1.SingleReplica},
+			objects: append([]client.Object{defaultStorageClass, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "big"},
+				Spec: corev1.PodSpec{NodeName: "node-0", Containers: []corev1.Container{{
+					Name:      "big",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}},
+				}}},
+			}}, nodes(1, "4", "16Gi")...),
+			resources: []metav1.APIResourceList{monitoringAPIs},
+			expected:  map[string]Status{"RequiredAPIs": StatusPass, "EtcdStorage": StatusPass, "NodeCapacity": StatusWarn},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			checker := &Checker{
+				Client:    fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(tc.objects...).Build(),
+				Discovery: fakeDiscovery(tc.resources),
+				Resolver:  fakeResolver(tc.zones),
+			}
+			results := checker.Run(context.Background(), tc.opts)
+			actual := map[string]Status{}
+			for _, result := range results {
+				actual[result.Name] = result.Status
+				if result.Status != StatusPass {
+					g.Expect(result.Remediation).NotTo(BeEmpty(), "%s has no remediation", result.Name)
+				}
+			}
+			g.Expect(actual).To(Equal(tc.expected))
+			g.Expect(Failed(results)).To(Equal(containsStatus(tc.expected, StatusFail)))
+		})
+	}
+}
+
+func TestOptionsForHostedCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hc := &hyperv1.HostedCluster{Spec: hyperv1.HostedClusterSpec{
+		ControllerAvailabilityPolicy: hyperv1.HighlyAvailable,
+		Services: []hyperv1.ServicePublishingStrategyMapping{
+			{Service: hyperv1.OAuthServer, ServicePublishingStrategy: hyperv1.ServicePublishingStrategy{Type: hyperv1.Route}},
+			{Service: hyperv1.APIServer, ServicePublishingStrategy: hyperv1.ServicePublishingStrategy{Type: hyperv1.LoadBalancer}},
+		},
+		Etcd: hyperv1.EtcdSpec{Managed: &hyperv1.ManagedEtcdSpec{Storage: hyperv1.ManagedEtcdStorageSpec{
+			PersistentVolume: &hyperv1.PersistentVolumeEtcdStorageSpec{StorageClassName: pointer.String("gp3")},
+		}}},
+	}}
+	g.Expect(OptionsForHostedCluster(hc, "hc.example.com")).To(Equal(Options{
+		Stage:              StageCreate,
+		AvailabilityPolicy: hyperv1.HighlyAvailable,
+		PublishingStrategy: hyperv1.LoadBalancer,
+		EtcdStorageClass:   "gp3",
+		ExternalDNSDomain:  "hc.example.com",
+	}))
+}
+
+func nodes(count int, cpu, memory string) []client.Object {
+	var result []client.Object
+	names := []string{"node-0", "node-1", "node-2", "node-3"}
+	for i := 0; i < count; i++ {
+		result = append(result, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: names[i]},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+				{Key: "hypershift.openshift.io/control-plane", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			}},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		})
+	}
+	return result
+}
+
+func unschedulable(obj client.Object) client.Object {
+	node := obj.(*corev1.Node)
+	node.Name = "cordoned"
+	node.Spec.Unschedulable = true
+	return node
+}
+
+func containsStatus(statuses map[string]Status, status Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeDiscovery []metav1.APIResourceList
+
+func (f fakeDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for i := range f {
+		if f[i].GroupVersion == groupVersion {
+			return &f[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f fakeDiscovery) ServerResources() ([]*metav1.APIResourceList, error) {
+	panic("not implemented")
+}
+
+func (f fakeDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	panic("not implemented")
+}
+
+func (f fakeDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	panic("not implemented")
+}
+
+func (f fakeDiscovery) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	panic("not implemented")
+}
+
+// fakeResolver maps zones to their name server.
+type fakeResolver map[string]string
+
+func (f fakeResolver) LookupNS(_ context.Context, name string) ([]*net.NS, error) {
+	host, exists := f[name]
+	if !exists {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	if host == "timeout" {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+	}
+	return []*net.NS{{Host: host}}, nil
+}
+
+// forbiddenReader fails every read like a user without permissions.
+type forbiddenReader struct{}
+
+func (forbiddenReader) Get(_ context.Context, key client.ObjectKey, _ client.Object) error {
+	return apierrors.NewForbidden(schema.GroupResource{}, key.Name, errors.New("forbidden"))
+}
+
+func (forbiddenReader) List(context.Context, client.ObjectList, ...client.ListOption) error {
+	return apierrors.NewForbidden(schema.GroupResource{}, "", errors.New("forbidden"))
+}
+
+func TestRunWithoutPermissions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	checker := &Checker{Client: forbiddenReader{}, Discovery: fakeDiscovery(nil), Resolver: fakeResolver(nil)}
+	results := checker.Run(context.Background(), Options{Stage: StageInstall, AvailabilityPolicy: hyperv1.SingleReplica, PublishingStrategy: hyperv1.LoadBalancer, EtcdStorageClass: "gp3"})
+	for _, result := range results {
+		if result.Name != "RequiredAPIs" {
+			g.Expect(result.Status).To(Equal(StatusWarn), "%s should warn", result.Name)
+		}
+	}
+}
+
+func TestNodeCapacityPodSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pod := func(name string, podLabels map[string]string) client.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: podLabels},
+			Spec: corev1.PodSpec{NodeName: "node-0", Containers: []corev1.Container{{
+				Name:      name,
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}},
+			}}},
+		}
+	}
+	objects := append([]client.Object{pod("other", nil)}, nodes(1, "4", "16Gi")...)
+	checker := &Checker{
+		Client:      fake.NewClientBuilder().WithScheme(api.Scheme).WithObjects(objects...).Build(),
+		PodSelector: labels.SelectorFromSet(labels.Set{"hypershift.openshift.io/hosted-control-plane": "true"}),
+	}
+	g.Expect(checker.checkNodeCapacity(context.Background(), Options{AvailabilityPolicy: hyperv1.SingleReplica}).Status).To(Equal(StatusPass))
+	checker.PodSelector = nil
+	g.Expect(checker.checkNodeCapacity(context.Background(), Options{AvailabilityPolicy: hyperv1.SingleReplica}).Status).To(Equal(StatusWarn))
+}
@@ -2,48 +2,347 @@ package releaseinfo
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/cache"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/openshift/hypershift/support/releaseinfo/registryclient"
+	"github.com/openshift/hypershift/support/thirdparty/library-go/pkg/image/reference"
+)
+
+const (
+	DefaultCacheMaxEntries = 64
+	DefaultCacheTTL        = 24 * time.Hour
+	DefaultCacheTagTTL     = 5 * time.Minute
 )
 
+var (
+	releaseCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hypershift_release_metadata_cache_requests_total",
+		Help: "Number of release metadata lookups by result: hit, miss, or shared when waiting for a concurrent lookup of the same release",
+	}, []string{"result"})
+	releaseLookupDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hypershift_release_metadata_lookup_duration_seconds",
+		Help:    "Time taken to look up release metadata that is not cached",
+		Buckets: prometheus.ExponentialBuckets(0.25, 2, 10),
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		releaseCacheRequests,
+		releaseLookupDuration,
+	)
+}
+
 var _ Provider = (*CachedProvider)(nil)
 
-// CachedProvider maintains a simple cache of release image info and only queries
-// the embedded provider when there is no cache hit.
+// CachedProviderOptions configures a CachedProvider.
+type CachedProviderOptions struct {
+	// MaxEntries is the maximum number of releases kept in memory and on disk.
+	MaxEntries int
+	// TTL is the time the metadata of a release is cached for.
+	TTL time.Duration
+	// TagTTL is the time the digest of a release referred to by tag is cached for.
+	TagTTL time.Duration
+	// Dir is the directory the metadata of releases is persisted in, so it survives
+	// restarts. Metadata is only kept in memory if empty.
+	Dir string
+	// RegistryOverrides maps registries to the mirrors release digests are resolved
+	// through before the original registry is tried.
+	RegistryOverrides map[string]string
+}
+
+// CachedProvider maintains a size-bounded cache of release image info by image digest
+// and only queries the embedded provider when there is no cache hit. Concurrent lookups
+// of the same release with the same pull secret are deduplicated, so a lookup failing
+// with the pull secret of a caller does not fail the lookups of others.
 type CachedProvider struct {
-	Cache map[string]*ReleaseImage
 	Inner Provider
+
+	options CachedProviderOptions
+	// releases caches release images by digest.
+	releases *cache.LRUExpireCache
+	// digests caches the digests of releases referred to by tag.
+	digests *cache.LRUExpireCache
+	// knownDigests keeps the last resolved digests of releases referred to by tag for
+	// TTL, to serve when the registry and its mirrors cannot be reached.
+	knownDigests *cache.LRUExpireCache
+
 	mu    sync.Mutex
+	calls map[lookupKey]*lookupCall
+
+	resolveDigest func(ctx context.Context, image string, pullSecret []byte) (digest.Digest, error)
+	now           func() time.Time
+}
+
+// lookupKey identifies the lookups of a release that can share their result.
+type lookupKey struct {
+	digest     digest.Digest
+	pullSecret [sha256.Size]byte
+}
+
+// lookupCall is a lookup of the embedded provider in progress.
+type lookupCall struct {
+	done         chan struct{}
+	releaseImage *ReleaseImage
+	err          error
+}
+
+// NewCachedProvider returns a CachedProvider for inner. Zero options are defaulted.
+func NewCachedProvider(inner Provider, options CachedProviderOptions) *CachedProvider {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = DefaultCacheMaxEntries
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultCacheTTL
+	}
+	if options.TagTTL <= 0 {
+		options.TagTTL = DefaultCacheTagTTL
+	}
+	return &CachedProvider{
+		Inner:         inner,
+		options:       options,
+		releases:      cache.NewLRUExpireCache(options.MaxEntries),
+		digests:       cache.NewLRUExpireCache(options.MaxEntries),
+		knownDigests:  cache.NewLRUExpireCache(options.MaxEntries),
+		calls:         map[lookupKey]*lookupCall{},
+		resolveDigest: registryclient.GetDigest,
+		now:           time.Now,
+	}
+}
+
+func (p *CachedProvider) Lookup(ctx context.Context, image string, pullSecret []byte) (*ReleaseImage, error) {
+	d, err := p.digest(ctx, image, pullSecret)
+	if err != nil {
+		return nil, err
+	}
+	if entry, ok := p.releases.Get(d); ok {
+		releaseCacheRequests.WithLabelValues("hit").Inc()
+		return copyReleaseImage(entry.(*ReleaseImage)), nil
+	}
+	if entry := p.load(ctx, d); entry != nil {
+		releaseCacheRequests.WithLabelValues("hit").Inc()
+		return copyReleaseImage(entry), nil
+	}
+
+	key := lookupKey{digest: d, pullSecret: sha256.Sum256(pullSecret)}
+	p.mu.Lock()
+	if call, exists := p.calls[key]; exists {
+		p.mu.Unlock()
+		releaseCacheRequests.WithLabelValues("shared").Inc()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		return copyReleaseImage(call.releaseImage), nil
+	}
+	call := &lookupCall{done: make(chan struct{})}
+	p.calls[key] = call
+	p.mu.Unlock()
+
+	releaseCacheRequests.WithLabelValues("miss").Inc()
+	call.releaseImage, call.err = p.lookup(ctx, image, d, pullSecret)
 
-	once sync.Once
-}
-
-func (p *CachedProvider) Lookup(ctx context.Context, image string, pullSecret []byte) (releaseImage *ReleaseImage, err error) {
-	// Purge the cache every once in a while as a simple leak mitigation
-	p.once.Do(func() {
-		go func() {
-			t := time.NewTicker(30 * time.Minute)
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-t.C:
-					p.mu.Lock()
-					p.Cache = map[string]*ReleaseImage{}
-					p.mu.Unlock()
-				}
-			}
-		}()
-	})
 	p.mu.Lock()
-	defer p.mu.Unlock()
-	if entry, ok := p.Cache[image]; ok {
-		return entry, nil
+	delete(p.calls, key)
+	p.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		return nil, call.err
+	}
+	return copyReleaseImage(call.releaseImage), nil
+}
+
+// digest returns the digest of image. Images referred to by tag are resolved through
+// the registry overrides first, then in the original registry, and the result is cached
+// for TagTTL. The last resolved digest is returned if resolution fails.
+func (p *CachedProvider) digest(ctx context.Context, image string, pullSecret []byte) (digest.Digest, error) {
+	if d, ok := p.digests.Get(image); ok {
+		return d.(digest.Digest), nil
+	}
+	var errs []error
+	for _, candidate := range append(overriddenImages(image, p.options.RegistryOverrides), image) {
+		d, err := p.resolveDigest(ctx, candidate, pullSecret)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", candidate, err))
+			continue
+		}
+		p.digests.Add(image, d, p.options.TagTTL)
+		p.knownDigests.Add(image, d, p.options.TTL)
+		return d, nil
 	}
-	entry, err := p.Inner.Lookup(ctx, image, pullSecret)
+	err := utilerrors.NewAggregate(errs)
+	if d, ok := p.knownDigests.Get(image); ok {
+		ctrl.LoggerFrom(ctx).Info("Failed to resolve the digest of release image, using the last known digest", "image", image, "digest", d, "error", err.Error())
+		return d.(digest.Digest), nil
+	}
+	return "", fmt.Errorf("failed to resolve the digest of release image %s: %w", image, err)
+}
+
+// overriddenImages returns image with each matching registry override applied.
+func overriddenImages(image string, overrides map[string]string) []string {
+	var images []string
+	for source, mirror := range overrides {
+		if strings.HasPrefix(image, source) {
+			images = append(images, strings.Replace(image, source, mirror, 1))
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// lookup looks up the release image with the embedded provider and caches it. The
+// image is pinned to digest d, so the cached metadata matches d if its tag moved.
+func (p *CachedProvider) lookup(ctx context.Context, image string, d digest.Digest, pullSecret []byte) (*ReleaseImage, error) {
+	if ref, err := reference.Parse(image); err == nil {
+		ref.Tag = ""
+		ref.ID = d.String()
+		image = ref.Exact()
+	}
+	start := p.now()
+	releaseImage, err := p.Inner.Lookup(ctx, image, pullSecret)
+	releaseLookupDuration.Observe(p.now().Sub(start).Seconds())
 	if err != nil {
 		return nil, err
 	}
-	p.Cache[image] = entry
-	return entry, nil
+	p.releases.Add(d, releaseImage, p.options.TTL)
+	p.store(ctx, d, releaseImage)
+	return releaseImage, nil
+}
+
+// persistedReleaseImage is a release image persisted on disk.
+type persistedReleaseImage struct {
+	Expires      time.Time     `json:"expires"`
+	ReleaseImage *ReleaseImage `json:"releaseImage"`
+}
+
+func (p *CachedProvider) path(d digest.Digest) string {
+	return filepath.Join(p.options.Dir, fmt.Sprintf("%s-%s.json", d.Algorithm(), d.Encoded()))
+}
+
+// load returns the release image with digest d persisted on disk and caches it in
+// memory, or nil if there is none.
+func (p *CachedProvider) load(ctx context.Context, d digest.Digest) *ReleaseImage {
+	if p.options.Dir == "" {
+		return nil
+	}
+	path := p.path(d)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to read cached release metadata", "path", path)
+		}
+		return nil
+	}
+	var persisted persistedReleaseImage
+	if err := json.Unmarshal(raw, &persisted); err != nil || persisted.ReleaseImage == nil || persisted.ReleaseImage.ImageStream == nil {
+		ctrl.LoggerFrom(ctx).Info("Removing invalid cached release metadata", "path", path)
+		_ = os.Remove(path)
+		return nil
+	}
+	ttl := persisted.Expires.Sub(p.now())
+	if ttl <= 0 {
+		_ = os.Remove(path)
+		return nil
+	}
+	p.releases.Add(d, persisted.ReleaseImage, ttl)
+	return persisted.ReleaseImage
+}
+
+// store persists the release image with digest d on disk, and removes the least
+// recently stored release images beyond MaxEntries. Failures are only logged, as the
+// release image is cached in memory.
+func (p *CachedProvider) store(ctx context.Context, d digest.Digest, releaseImage *ReleaseImage) {
+	if p.options.Dir == "" {
+		return
+	}
+	log := ctrl.LoggerFrom(ctx)
+	raw, err := json.Marshal(persistedReleaseImage{Expires: p.now().Add(p.options.TTL), ReleaseImage: releaseImage})
+	if err != nil {
+		log.Error(err, "failed to serialize release metadata", "digest", d)
+		return
+	}
+	if err := os.MkdirAll(p.options.Dir, 0755); err != nil {
+		log.Error(err, "failed to create release metadata cache directory", "path", p.options.Dir)
+		return
+	}
+	// Write to a temporary file first, so concurrent readers never see partial files.
+	f, err := os.CreateTemp(p.options.Dir, ".tmp-")
+	if err != nil {
+		log.Error(err, "failed to persist release metadata", "digest", d)
+		return
+	}
+	_, err = f.Write(raw)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// The modification time orders the persisted releases for removal.
+		now := p.now()
+		err = os.Chtimes(f.Name(), now, now)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p.path(d))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		log.Error(err, "failed to persist release metadata", "digest", d)
+		return
+	}
+
+	entries, err := os.ReadDir(p.options.Dir)
+	if err != nil {
+		log.Error(err, "failed to list persisted release metadata", "path", p.options.Dir)
+		return
+	}
+	type persistedFile struct {
+		name    string
+		modTime time.Time
+	}
+	var files []persistedFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, persistedFile{name: entry.Name(), modTime: info.ModTime()})
+	}
+	if len(files) <= p.options.MaxEntries {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files[:len(files)-p.options.MaxEntries] {
+		_ = os.Remove(filepath.Join(p.options.Dir, file.name))
+	}
+}
+
+// copyReleaseImage returns a copy of a cached release image that callers can modify,
+// as decorators like StaticProviderDecorator add tags to the release images they get.
+func copyReleaseImage(releaseImage *ReleaseImage) *ReleaseImage {
+	return &ReleaseImage{
+		ImageStream:    releaseImage.ImageStream.DeepCopy(),
+		StreamMetadata: releaseImage.StreamMetadata,
+	}
 }
@@ -0,0 +1,233 @@
+package releaseinfo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	imageapi "github.com/openshift/api/image/v1"
+)
+
+// countingProvider returns an empty release image and counts the lookups per image.
+type countingProvider struct {
+	mu      sync.Mutex
+	lookups map[string]int
+	// release blocks lookups until closed, if set.
+	release chan struct{}
+}
+
+func (p *countingProvider) Lookup(_ context.Context, image string, _ []byte) (*ReleaseImage, error) {
+	if p.release != nil {
+		<-p.release
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lookups[image]++
+	return &ReleaseImage{ImageStream: &imageapi.ImageStream{Spec: imageapi.ImageStreamSpec{Tags: []imageapi.TagReference{{Name: "cli"}}}}}, nil
+}
+
+func (p *countingProvider) total() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	total := 0
+	for _, count := range p.lookups {
+		total += count
+	}
+	return total
+}
+
+var (
+	digestA = digest.FromString("a")
+	digestB = digest.FromString("b")
+)
+
+func newTestCachedProvider(inner Provider, options CachedProviderOptions, now *time.Time) *CachedProvider {
+	p := NewCachedProvider(inner, options)
+	p.resolveDigest = func(_ context.Context, image string, _ []byte) (digest.Digest, error) {
+		switch image {
+		case "quay.io/ocp/release:a", "mirror.example.com/ocp/release:a":
+			return digestA, nil
+		default:
+			return digestB, nil
+		}
+	}
+	p.now = func() time.Time { return *now }
+	return p
+}
+
+func TestCachedProvider(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	now := time.Now()
+	inner := &countingProvider{lookups: map[string]int{}}
+	p := newTestCachedProvider(inner, CachedProviderOptions{MaxEntries: 1}, &now)
+
+	releaseImage, err := p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	// Lookups are pinned to the digest.
+	g.Expect(inner.lookups).To(Equal(map[string]int{"quay.io/ocp/release@" + digestA.String(): 1}))
+
+	// Callers can modify the release images they get without changing the cache.
+	releaseImage.Spec.Tags = append(releaseImage.Spec.Tags, imageapi.TagReference{Name: "extra"})
+	releaseImage, err = p.Lookup(ctx, "mirror.example.com/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(releaseImage.Spec.Tags).To(HaveLen(1))
+	g.Expect(inner.total()).To(Equal(1))
+
+	// The least recently used release is evicted.
+	_, err = p.Lookup(ctx, "quay.io/ocp/release:b", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inner.total()).To(Equal(3))
+}
+
+func TestCachedProviderDeduplicatesConcurrentLookups(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Now()
+	inner := &countingProvider{lookups: map[string]int{}, release: make(chan struct{})}
+	p := newTestCachedProvider(inner, CachedProviderOptions{}, &now)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Lookup(context.Background(), "quay.io/ocp/release:a", nil)
+			errs <- err
+		}()
+	}
+	// Wait until a lookup is in progress before letting it finish.
+	g.Eventually(func() int {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.calls)
+	}).Should(Equal(1))
+	close(inner.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(inner.total()).To(Equal(1))
+}
+
+// pullSecretProvider fails the lookups with other pull secrets than the valid one,
+// after blocking them until release is closed.
+type pullSecretProvider struct {
+	valid   string
+	release chan struct{}
+}
+
+func (p *pullSecretProvider) Lookup(_ context.Context, _ string, pullSecret []byte) (*ReleaseImage, error) {
+	<-p.release
+	if string(pullSecret) != p.valid {
+		return nil, errors.New("unauthorized")
+	}
+	return &ReleaseImage{ImageStream: &imageapi.ImageStream{}}, nil
+}
+
+func TestCachedProviderDoesNotShareLookupsBetweenPullSecrets(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Now()
+	inner := &pullSecretProvider{valid: "valid", release: make(chan struct{})}
+	p := newTestCachedProvider(inner, CachedProviderOptions{}, &now)
+	inFlight := func() int {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.calls)
+	}
+
+	invalid := make(chan error, 1)
+	go func() {
+		_, err := p.Lookup(context.Background(), "quay.io/ocp/release:a", []byte("invalid"))
+		invalid <- err
+	}()
+	g.Eventually(inFlight).Should(Equal(1))
+	valid := make(chan error, 1)
+	go func() {
+		_, err := p.Lookup(context.Background(), "quay.io/ocp/release:a", []byte("valid"))
+		valid <- err
+	}()
+	g.Eventually(inFlight).Should(Equal(2))
+	close(inner.release)
+	g.Expect(<-invalid).To(HaveOccurred())
+	g.Expect(<-valid).ToNot(HaveOccurred())
+}
+
+func TestCachedProviderPersistence(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Now()
+	inner := &countingProvider{lookups: map[string]int{}}
+
+	p := newTestCachedProvider(inner, CachedProviderOptions{Dir: dir, TTL: time.Hour, MaxEntries: 1}, &now)
+	_, err := p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// A new provider, as after a restart, reads the persisted release.
+	p = newTestCachedProvider(inner, CachedProviderOptions{Dir: dir, TTL: time.Hour, MaxEntries: 1}, &now)
+	releaseImage, err := p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(releaseImage.Spec.Tags).To(Equal([]imageapi.TagReference{{Name: "cli"}}))
+	g.Expect(inner.total()).To(Equal(1))
+
+	// Expired releases are looked up again.
+	now = now.Add(2 * time.Hour)
+	p = newTestCachedProvider(inner, CachedProviderOptions{Dir: dir, TTL: time.Hour, MaxEntries: 1}, &now)
+	_, err = p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inner.total()).To(Equal(2))
+
+	// Persisted releases are bounded by MaxEntries.
+	now = now.Add(time.Minute)
+	_, err = p.Lookup(ctx, "quay.io/ocp/release:b", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(p.path(digestA)).ToNot(BeAnExistingFile())
+	g.Expect(p.path(digestB)).To(BeAnExistingFile())
+}
+
+func TestCachedProviderDigestResolution(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	inner := &countingProvider{lookups: map[string]int{}}
+	p := NewCachedProvider(inner, CachedProviderOptions{
+		TagTTL:            time.Nanosecond,
+		RegistryOverrides: map[string]string{"quay.io/ocp": "mirror.example.com/ocp"},
+	})
+	var resolved []string
+	reachable := map[string]bool{"mirror.example.com/ocp/release:a": true}
+	p.resolveDigest = func(_ context.Context, image string, _ []byte) (digest.Digest, error) {
+		resolved = append(resolved, image)
+		if !reachable[image] {
+			return "", errors.New("unreachable")
+		}
+		return digestA, nil
+	}
+
+	// Digests are resolved through the mirror first.
+	_, err := p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(resolved).To(Equal([]string{"mirror.example.com/ocp/release:a"}))
+	g.Expect(inner.lookups).To(Equal(map[string]int{"quay.io/ocp/release@" + digestA.String(): 1}))
+
+	// The last known digest is served when neither the mirror nor the original
+	// registry can be reached.
+	time.Sleep(time.Millisecond)
+	reachable = map[string]bool{}
+	resolved = nil
+	_, err = p.Lookup(ctx, "quay.io/ocp/release:a", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(resolved).To(Equal([]string{"mirror.example.com/ocp/release:a", "quay.io/ocp/release:a"}))
+	g.Expect(inner.total()).To(Equal(1))
+
+	// Releases that were never resolved fail.
+	_, err = p.Lookup(ctx, "quay.io/ocp/release:b", nil)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to resolve the digest of release image quay.io/ocp/release:b")))
+}
@@ -310,6 +310,27 @@ func GetManifest(ctx context.Context, imageRef string, pullSecret []byte) (distr
 	return digestsManifest, nil
 }
 
+// GetDigest gets the digest of the manifest an image refers to. For manifest listed images, this is the
+// digest of the manifest list. Images referred to by digest are not looked up in the registry.
+func GetDigest(ctx context.Context, imageRef string, pullSecret []byte) (digest.Digest, error) {
+	ref, err := reference.Parse(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse image reference %q: %w", imageRef, err)
+	}
+	if len(ref.ID) > 0 {
+		return digest.Parse(ref.ID)
+	}
+	repo, _, err := GetRepoSetup(ctx, imageRef, pullSecret)
+	if err != nil {
+		return "", err
+	}
+	desc, err := repo.Tags(ctx).Get(ctx, ref.Tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tag %s of %s: %w", ref.Tag, imageRef, err)
+	}
+	return desc.Digest, nil
+}
+
 // IsMultiArchManifestList determines whether an image is a manifest listed image and contains manifests the following processor architectures: amd64, arm64, s390x, ppc64le
 func IsMultiArchManifestList(ctx context.Context, imageRef string, pullSecret []byte) (bool, error) {
 	srcManifest, err := GetManifest(ctx, imageRef, pullSecret)
@@ -0,0 +1,98 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSignatureSize is the maximum size of a file read from a signature store.
+const maxSignatureSize = 1024 * 1024
+
+// Store is a location of release signatures. The signatures of a release are stored by
+// its digest, in the GPG signature store layout used by OpenShift:
+//
+//	<algorithm>=<hex>/signature-<n>  GPG signatures, numbered from 1
+//	<algorithm>=<hex>/cosign.bundle  cosign signatures
+type Store interface {
+	// Get returns the content of the file at path in the store, or nil if it does not exist.
+	Get(ctx context.Context, path string) ([]byte, error)
+	String() string
+}
+
+// NewStore returns the store at location, either an http(s) or file URL, or a local
+// directory for disconnected environments.
+func NewStore(location string) (Store, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature store %q: %w", location, err)
+	}
+	switch u.Scheme {
+	case "":
+		return dirStore(location), nil
+	case "file":
+		return dirStore(u.Path), nil
+	case "http", "https":
+		return &httpStore{
+			base:   strings.TrimSuffix(u.String(), "/"),
+			client: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid signature store %q: unsupported scheme %s", location, u.Scheme)
+	}
+}
+
+// dirStore is a store in a local directory.
+type dirStore string
+
+func (s dirStore) Get(_ context.Context, path string) ([]byte, error) {
+	f, err := os.Open(filepath.Join(string(s), filepath.FromSlash(path)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxSignatureSize))
+}
+
+func (s dirStore) String() string {
+	return string(s)
+}
+
+// httpStore is a store served over http(s).
+type httpStore struct {
+	base   string
+	client *http.Client
+}
+
+func (s *httpStore) Get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.base+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize))
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to get %s: unexpected status %s", req.URL, resp.Status)
+	}
+}
+
+func (s *httpStore) String() string {
+	return s.base
+}
@@ -0,0 +1,476 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/opencontainers/go-digest"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+	"github.com/openshift/hypershift/support/releaseinfo/registryclient"
+)
+
+const (
+	// maxGPGSignatures is the maximum number of GPG signatures read from a store for a release.
+	maxGPGSignatures = 16
+
+	// cosignBundleFile is the file of a store with the cosign signatures of a release, in the
+	// format written by `cosign download signature`.
+	cosignBundleFile = "cosign.bundle"
+
+	atomicSignatureType = "atomic container signature"
+	cosignSignatureType = "cosign container image signature"
+)
+
+// Verifier verifies that a release image is signed by a trusted key.
+type Verifier interface {
+	// Verify verifies image, whose digest is resolved through mirrors first, and returns
+	// the digest verified. Callers pull image by that digest, see PinnedImage, so the
+	// image pulled is the one verified even if its tag is moved afterwards.
+	Verify(ctx context.Context, image string, pullSecret []byte, mirrors Mirrors) (digest.Digest, error)
+}
+
+// PinnedImage returns image pinned to digest d, keeping its tag so the release it refers to
+// is still recognizable, e.g. quay.io/openshift-release-dev/ocp-release:4.12.0-x86_64@sha256:...
+// Images already pulled by digest are returned unchanged.
+func PinnedImage(image string, d digest.Digest) string {
+	if strings.Contains(image, "@") {
+		return image
+	}
+	return image + "@" + d.String()
+}
+
+// IsPinnedImage returns true if pinned is image pinned to a digest by PinnedImage.
+func IsPinnedImage(pinned, image string) bool {
+	if strings.Contains(image, "@") {
+		return pinned == image
+	}
+	d := strings.TrimPrefix(pinned, image+"@")
+	return d != pinned && digest.Digest(d).Validate() == nil
+}
+
+// Mirrors maps the source repositories of images to the repositories they are mirrored
+// to, as in the imageContentSources of HostedClusters.
+type Mirrors map[string][]string
+
+// MirrorsFromImageContentSources returns the mirrors of sources.
+func MirrorsFromImageContentSources(sources []hyperv1.ImageContentSource) Mirrors {
+	mirrors := Mirrors{}
+	for _, source := range sources {
+		mirrors[source.Source] = append(mirrors[source.Source], source.Mirrors...)
+	}
+	return mirrors
+}
+
+// UnverifiedError is returned when a release image has no valid signature of a trusted key.
+type UnverifiedError struct {
+	Image  string
+	Digest digest.Digest
+	Errs   []error
+}
+
+func (e *UnverifiedError) Error() string {
+	msg := fmt.Sprintf("release image %s (%s) is not signed by a trusted key", e.Image, e.Digest)
+	if len(e.Errs) > 0 {
+		msg += ": " + utilerrors.NewAggregate(e.Errs).Error()
+	}
+	return msg
+}
+
+// IsUnverified returns true if err is or wraps an UnverifiedError.
+func IsUnverified(err error) bool {
+	var unverified *UnverifiedError
+	return errors.As(err, &unverified)
+}
+
+// TransientError is returned when a release image has no valid signature of a trusted key
+// in the stores that could be read, but other stores could not be read, e.g. because of
+// network or server errors. The release image may be verified once they can be read again.
+type TransientError struct {
+	Image  string
+	Digest digest.Digest
+	Errs   []error
+}
+
+func (e *TransientError) Error() string {
+	return fmt.Sprintf("signatures of release image %s (%s) could not be read: %s", e.Image, e.Digest, utilerrors.NewAggregate(e.Errs))
+}
+
+// IsTransient returns true if err is or wraps a TransientError.
+func IsTransient(err error) bool {
+	var transient *TransientError
+	return errors.As(err, &transient)
+}
+
+var _ Verifier = (*SignatureVerifier)(nil)
+
+// SignatureVerifier verifies the GPG signatures and cosign signatures of release images
+// against trusted keys. The signatures are read from stores by the digest of the release
+// image, so a release is trusted regardless of the registry it is mirrored to.
+type SignatureVerifier struct {
+	// RegistryOverrides maps source registries to the registries release images are
+	// pulled from instead, like the registry overrides of the release provider.
+	RegistryOverrides map[string]string
+
+	keyring    openpgp.EntityList
+	cosignKeys []crypto.PublicKey
+	stores     []Store
+
+	// verified are the digests verified before. Trusted keys don't change while the
+	// verifier runs, so they are not verified again.
+	verifiedLock sync.Mutex
+	verified     map[digest.Digest]struct{}
+
+	resolveDigest func(ctx context.Context, image string, pullSecret []byte) (digest.Digest, error)
+	now           func() time.Time
+}
+
+// maxVerifiedDigests is the maximum number of verified digests remembered.
+const maxVerifiedDigests = 256
+
+// NewSignatureVerifier returns a SignatureVerifier trusting the keys in keysDir, which
+// reads signatures from the stores at storeLocations.
+func NewSignatureVerifier(keysDir string, storeLocations []string) (*SignatureVerifier, error) {
+	keyring, cosignKeys, err := LoadKeys(keysDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyring) == 0 && len(cosignKeys) == 0 {
+		return nil, fmt.Errorf("no trusted keys found in %s", keysDir)
+	}
+	if len(storeLocations) == 0 {
+		return nil, fmt.Errorf("at least one signature store is required")
+	}
+	var stores []Store
+	for _, location := range storeLocations {
+		store, err := NewStore(location)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+	return &SignatureVerifier{
+		keyring:       keyring,
+		cosignKeys:    cosignKeys,
+		stores:        stores,
+		resolveDigest: registryclient.GetDigest,
+		now:           time.Now,
+	}, nil
+}
+
+// LoadKeys loads the trusted keys from the files in dir. Files may contain PEM encoded
+// public keys for cosign signatures, or armored or binary GPG keyrings.
+func LoadKeys(dir string) (openpgp.EntityList, []crypto.PublicKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read trusted keys: %w", err)
+	}
+	var keyring openpgp.EntityList
+	var cosignKeys []crypto.PublicKey
+	for _, entry := range entries {
+		// Skip the hidden files and directories of ConfigMap and Secret volumes.
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read trusted key %s: %w", path, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read trusted key %s: %w", path, err)
+		}
+		entities, cosignKey, err := parseKey(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse trusted key %s: %w", path, err)
+		}
+		keyring = append(keyring, entities...)
+		if cosignKey != nil {
+			cosignKeys = append(cosignKeys, cosignKey)
+		}
+	}
+	return keyring, cosignKeys, nil
+}
+
+func parseKey(data []byte) (openpgp.EntityList, crypto.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil && block.Type == "PUBLIC KEY" {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+			return nil, key, nil
+		default:
+			return nil, nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+	var keyring openpgp.EntityList
+	var err error
+	if bytes.Contains(data, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	return keyring, nil, err
+}
+
+// Verify returns nil if any store has a valid signature of a trusted key for the digest
+// of image, and returns that digest. It returns a TransientError if no store that could be
+// read has one but some stores could not be read, and an UnverifiedError otherwise.
+func (v *SignatureVerifier) Verify(ctx context.Context, image string, pullSecret []byte, mirrors Mirrors) (digest.Digest, error) {
+	d, err := v.digestOf(ctx, image, pullSecret, mirrors)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the digest of release image %s: %w", image, err)
+	}
+	v.verifiedLock.Lock()
+	_, verified := v.verified[d]
+	v.verifiedLock.Unlock()
+	if verified {
+		return d, nil
+	}
+
+	var errs, readErrs []error
+	for _, store := range v.stores {
+		readErr, err := v.verifyFromStore(ctx, store, d)
+		if err == nil {
+			v.verifiedLock.Lock()
+			defer v.verifiedLock.Unlock()
+			if v.verified == nil || len(v.verified) >= maxVerifiedDigests {
+				v.verified = map[digest.Digest]struct{}{}
+			}
+			v.verified[d] = struct{}{}
+			return d, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", store, err))
+		if readErr {
+			readErrs = append(readErrs, fmt.Errorf("%s: %w", store, err))
+		}
+	}
+	if len(readErrs) > 0 {
+		return "", &TransientError{Image: image, Digest: d, Errs: readErrs}
+	}
+	return "", &UnverifiedError{Image: image, Digest: d, Errs: errs}
+}
+
+// digestOf resolves the digest of image from the repositories it is mirrored to first,
+// as release images may only be pulled from their mirrors in disconnected environments.
+func (v *SignatureVerifier) digestOf(ctx context.Context, image string, pullSecret []byte, mirrors Mirrors) (digest.Digest, error) {
+	var errs []error
+	for _, candidate := range append(v.mirrorsOf(image, mirrors), image) {
+		d, err := v.resolveDigest(ctx, candidate, pullSecret)
+		if err == nil {
+			return d, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", utilerrors.NewAggregate(errs)
+}
+
+// mirrorsOf returns the references of image in its mirrors, those of the registry overrides
+// first. Registry overrides apply to the images of a registry or of a repository prefix,
+// and mirrors to the images of a repository.
+func (v *SignatureVerifier) mirrorsOf(image string, mirrors Mirrors) []string {
+	var result []string
+	for _, source := range sortedKeys(v.RegistryOverrides) {
+		if rest, ok := trimRepositoryPrefix(image, source, "/:@"); ok {
+			result = append(result, v.RegistryOverrides[source]+rest)
+		}
+	}
+	for _, source := range sortedKeys(mirrors) {
+		if rest, ok := trimRepositoryPrefix(image, source, ":@"); ok {
+			for _, mirror := range mirrors[source] {
+				result = append(result, mirror+rest)
+			}
+		}
+	}
+	return result
+}
+
+// trimRepositoryPrefix returns the rest of image after prefix, if prefix is followed by one
+// of separators in image, so prefix is a whole registry or repository name.
+func trimRepositoryPrefix(image, prefix, separators string) (string, bool) {
+	rest := strings.TrimPrefix(image, prefix)
+	if rest == image || rest == "" || !strings.ContainsRune(separators, rune(rest[0])) {
+		return "", false
+	}
+	return rest, true
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// verifyFromStore verifies the signatures of d in store. It also returns whether reading
+// the store failed.
+func (v *SignatureVerifier) verifyFromStore(ctx context.Context, store Store, d digest.Digest) (bool, error) {
+	dir := fmt.Sprintf("%s=%s", d.Algorithm(), d.Encoded())
+	var errs []error
+	readErr := false
+	if len(v.keyring) > 0 {
+		for i := 1; i <= maxGPGSignatures; i++ {
+			name := fmt.Sprintf("signature-%d", i)
+			data, err := store.Get(ctx, dir+"/"+name)
+			if err != nil {
+				errs = append(errs, err)
+				readErr = true
+				break
+			}
+			if data == nil {
+				break
+			}
+			if err := v.verifyGPGSignature(data, d); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			return false, nil
+		}
+	}
+	if len(v.cosignKeys) > 0 {
+		data, err := store.Get(ctx, dir+"/"+cosignBundleFile)
+		if err != nil {
+			errs = append(errs, err)
+			readErr = true
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for i := 1; data != nil; i++ {
+			var bundle cosignBundle
+			if err := decoder.Decode(&bundle); err != nil {
+				if err != io.EOF {
+					errs = append(errs, fmt.Errorf("%s: failed to decode signature %d: %w", cosignBundleFile, i, err))
+				}
+				break
+			}
+			if err := v.verifyCosignSignature(bundle, d); err != nil {
+				errs = append(errs, fmt.Errorf("%s: signature %d: %w", cosignBundleFile, i, err))
+				continue
+			}
+			return false, nil
+		}
+	}
+	if len(errs) == 0 {
+		return false, fmt.Errorf("no signatures found")
+	}
+	return readErr, utilerrors.NewAggregate(errs)
+}
+
+// verifyGPGSignature verifies an OpenPGP signed message containing an atomic container
+// signature, the format of OpenShift release signatures.
+func (v *SignatureVerifier) verifyGPGSignature(data []byte, d digest.Digest) error {
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), v.keyring, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	if !md.IsSigned {
+		return fmt.Errorf("message is not signed")
+	}
+	// The signature is only checked once the whole body was read.
+	content, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	if md.SignatureError != nil {
+		return fmt.Errorf("invalid signature: %w", md.SignatureError)
+	}
+	if md.SignedBy == nil {
+		return fmt.Errorf("signed by untrusted key %X", md.SignedByKeyId)
+	}
+	if md.Signature != nil && md.Signature.SigLifetimeSecs != nil {
+		expiry := md.Signature.CreationTime.Add(time.Duration(*md.Signature.SigLifetimeSecs) * time.Second)
+		if v.now().After(expiry) {
+			return fmt.Errorf("signature expired on %s", expiry)
+		}
+	}
+	return verifyPayload(content, atomicSignatureType, d)
+}
+
+// cosignBundle is a cosign signature as written by `cosign download signature`.
+type cosignBundle struct {
+	Base64Signature string `json:"Base64Signature"`
+	Payload         []byte `json:"Payload"`
+}
+
+func (v *SignatureVerifier) verifyCosignSignature(bundle cosignBundle, d digest.Digest) error {
+	signature, err := base64.StdEncoding.DecodeString(bundle.Base64Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	verified := false
+	for _, key := range v.cosignKeys {
+		if verifySignature(key, bundle.Payload, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return fmt.Errorf("not signed by a trusted key")
+	}
+	return verifyPayload(bundle.Payload, cosignSignatureType, d)
+}
+
+func verifySignature(key crypto.PublicKey, payload, signature []byte) bool {
+	sum := sha256.Sum256(payload)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, sum[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, signature)
+	}
+	return false
+}
+
+// signaturePayload is the signed payload of container image signatures. The identity of
+// the image is not verified, as release images are mirrored to other registries.
+type signaturePayload struct {
+	Critical struct {
+		Type  string `json:"type"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+func verifyPayload(content []byte, signatureType string, d digest.Digest) error {
+	var payload signaturePayload
+	if err := json.Unmarshal(content, &payload); err != nil {
+		return fmt.Errorf("failed to decode signed payload: %w", err)
+	}
+	if payload.Critical.Type != signatureType {
+		return fmt.Errorf("signed payload has type %q, expected %q", payload.Critical.Type, signatureType)
+	}
+	if payload.Critical.Image.DockerManifestDigest != d.String() {
+		return fmt.Errorf("signed payload is for digest %s", payload.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
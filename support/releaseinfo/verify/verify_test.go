@@ -0,0 +1,359 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+)
+
+const releaseDigest = digest.Digest("sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+
+func payload(signatureType string, d digest.Digest) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"quay.io/openshift-release-dev/ocp-release:4.12.0-x86_64"},"image":{"docker-manifest-digest":%q},"type":%q},"optional":{}}`, d, signatureType))
+}
+
+func gpgSign(t *testing.T, signer *openpgp.Entity, content []byte) []byte {
+	out := &bytes.Buffer{}
+	w, err := openpgp.Sign(out, signer, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func cosignSign(t *testing.T, key *ecdsa.PrivateKey, content []byte) []byte {
+	sum := sha256.Sum256(content)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := json.Marshal(cosignBundle{Base64Signature: base64.StdEncoding.EncodeToString(signature), Payload: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(bundle, '\n')
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	trusted, err := openpgp.NewEntity("trusted", "", "trusted@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrusted, err := openpgp.NewEntity("untrusted", "", "untrusted@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustedCosignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrustedCosignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Trusted keys are loaded from a directory, as mounted from a ConfigMap.
+	keysDir := t.TempDir()
+	armored := &bytes.Buffer{}
+	w, err := armor.Encode(armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := trusted.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	writeFile(t, filepath.Join(keysDir, "release.gpg"), armored.Bytes())
+	der, err := x509.MarshalPKIXPublicKey(&trustedCosignKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(keysDir, "cosign.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	keyring, cosignKeys, err := LoadKeys(keysDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatureDir := fmt.Sprintf("sha256=%s", releaseDigest.Encoded())
+	testCases := []struct {
+		name          string
+		files         map[string][]byte
+		expectedError string
+	}{
+		{
+			name: "When a GPG signature of a trusted key exists it should succeed",
+			files: map[string][]byte{
+				"signature-1": gpgSign(t, untrusted, payload(atomicSignatureType, releaseDigest)),
+				"signature-2": gpgSign(t, trusted, payload(atomicSignatureType, releaseDigest)),
+			},
+		},
+		{
+			name: "When a cosign signature of a trusted key exists it should succeed",
+			files: map[string][]byte{
+				cosignBundleFile: append(
+					cosignSign(t, untrustedCosignKey, payload(cosignSignatureType, releaseDigest)),
+					cosignSign(t, trustedCosignKey, payload(cosignSignatureType, releaseDigest))...),
+			},
+		},
+		{
+			name: "When only keys that are not trusted signed the release it should fail",
+			files: map[string][]byte{
+				"signature-1":    gpgSign(t, untrusted, payload(atomicSignatureType, releaseDigest)),
+				cosignBundleFile: cosignSign(t, untrustedCosignKey, payload(cosignSignatureType, releaseDigest)),
+			},
+			expectedError: "signature-1: signed by untrusted key",
+		},
+		{
+			name: "When the signature is for another digest it should fail",
+			files: map[string][]byte{
+				"signature-1": gpgSign(t, trusted, payload(atomicSignatureType, digest.FromString("other"))),
+			},
+			expectedError: "signed payload is for digest " + digest.FromString("other").String(),
+		},
+		{
+			name: "When the signed payload has another type it should fail",
+			files: map[string][]byte{
+				cosignBundleFile: cosignSign(t, trustedCosignKey, payload(atomicSignatureType, releaseDigest)),
+			},
+			expectedError: `signed payload has type "atomic container signature"`,
+		},
+		{
+			name:          "When the release has no signatures it should fail",
+			expectedError: "no signatures found",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			storeDir := t.TempDir()
+			for name, content := range tc.files {
+				writeFile(t, filepath.Join(storeDir, signatureDir, name), content)
+			}
+			v := &SignatureVerifier{
+				keyring:    keyring,
+				cosignKeys: cosignKeys,
+				stores:     []Store{dirStore(storeDir)},
+				resolveDigest: func(context.Context, string, []byte) (digest.Digest, error) {
+					return releaseDigest, nil
+				},
+				now: time.Now,
+			}
+			d, err := v.Verify(context.Background(), "registry.example.com/ocp-release:4.12.0", nil, nil)
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(d).To(Equal(releaseDigest))
+				return
+			}
+			g.Expect(IsUnverified(err)).To(BeTrue())
+			g.Expect(err.Error()).To(ContainSubstring(tc.expectedError))
+		})
+	}
+}
+
+// failingStore is a store that can't be read.
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, error) {
+	return nil, fmt.Errorf("unexpected status 503")
+}
+
+func (failingStore) String() string {
+	return "failing"
+}
+
+func TestSignatureVerifierStoreErrors(t *testing.T) {
+	untrusted, err := openpgp.NewEntity("untrusted", "", "untrusted@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := openpgp.NewEntity("trusted", "", "trusted@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeDir := t.TempDir()
+	writeFile(t, filepath.Join(storeDir, fmt.Sprintf("sha256=%s", releaseDigest.Encoded()), "signature-1"), gpgSign(t, untrusted, payload(atomicSignatureType, releaseDigest)))
+	resolveDigest := func(context.Context, string, []byte) (digest.Digest, error) {
+		return releaseDigest, nil
+	}
+
+	testCases := []struct {
+		name              string
+		stores            []Store
+		expectedTransient bool
+	}{
+		{
+			name:              "When a store can't be read it should return a transient error",
+			stores:            []Store{dirStore(storeDir), failingStore{}},
+			expectedTransient: true,
+		},
+		{
+			name:   "When all stores were read it should return an unverified error",
+			stores: []Store{dirStore(storeDir)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			v := &SignatureVerifier{keyring: openpgp.EntityList{trusted}, stores: tc.stores, resolveDigest: resolveDigest, now: time.Now}
+			_, err := v.Verify(context.Background(), "registry.example.com/ocp-release:4.12.0", nil, nil)
+			g.Expect(IsTransient(err)).To(Equal(tc.expectedTransient))
+			g.Expect(IsUnverified(err)).To(Equal(!tc.expectedTransient))
+		})
+	}
+}
+
+func TestDigestOf(t *testing.T) {
+	testCases := []struct {
+		name              string
+		image             string
+		registryOverrides map[string]string
+		mirrors           Mirrors
+		available         string
+		expectedResolved  []string
+	}{
+		{
+			name:             "When the image is not mirrored it should resolve it from its registry",
+			image:            "quay.io/openshift-release-dev/ocp-release:4.12.0",
+			available:        "quay.io/openshift-release-dev/ocp-release:4.12.0",
+			expectedResolved: []string{"quay.io/openshift-release-dev/ocp-release:4.12.0"},
+		},
+		{
+			name:              "When the image is mirrored it should resolve it from its mirrors first",
+			image:             "quay.io/openshift-release-dev/ocp-release:4.12.0",
+			registryOverrides: map[string]string{"quay.io": "override.example.com"},
+			mirrors:           Mirrors{"quay.io/openshift-release-dev/ocp-release": {"mirror.example.com/ocp/release"}},
+			available:         "mirror.example.com/ocp/release:4.12.0",
+			expectedResolved:  []string{"override.example.com/openshift-release-dev/ocp-release:4.12.0", "mirror.example.com/ocp/release:4.12.0"},
+		},
+		{
+			name:             "When the mirror is for another repository it should not resolve the image from it",
+			image:            "quay.io/openshift-release-dev/ocp-release-nightly:4.12.0",
+			mirrors:          Mirrors{"quay.io/openshift-release-dev/ocp-release": {"mirror.example.com/ocp/release"}},
+			available:        "quay.io/openshift-release-dev/ocp-release-nightly:4.12.0",
+			expectedResolved: []string{"quay.io/openshift-release-dev/ocp-release-nightly:4.12.0"},
+		},
+		{
+			name:              "When the registry override is for another registry it should not resolve the image from it",
+			image:             "quay.io.example.com/openshift-release-dev/ocp-release:4.12.0",
+			registryOverrides: map[string]string{"quay.io": "override.example.com", "example.com": "other.example.com"},
+			available:         "quay.io.example.com/openshift-release-dev/ocp-release:4.12.0",
+			expectedResolved:  []string{"quay.io.example.com/openshift-release-dev/ocp-release:4.12.0"},
+		},
+		{
+			name:              "When the registry override is for a repository prefix it should resolve the image from it",
+			image:             "quay.io/openshift-release-dev/ocp-release:4.12.0",
+			registryOverrides: map[string]string{"quay.io/openshift-release-dev": "override.example.com/ocp", "quay.io/openshift-release": "other.example.com"},
+			available:         "override.example.com/ocp/ocp-release:4.12.0",
+			expectedResolved:  []string{"override.example.com/ocp/ocp-release:4.12.0"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			var resolved []string
+			v := &SignatureVerifier{
+				RegistryOverrides: tc.registryOverrides,
+				resolveDigest: func(_ context.Context, image string, _ []byte) (digest.Digest, error) {
+					resolved = append(resolved, image)
+					if image != tc.available {
+						return "", fmt.Errorf("%s not found", image)
+					}
+					return releaseDigest, nil
+				},
+			}
+			d, err := v.digestOf(context.Background(), tc.image, nil, tc.mirrors)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(d).To(Equal(releaseDigest))
+			g.Expect(resolved).To(Equal(tc.expectedResolved))
+		})
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pinned := PinnedImage("quay.io/openshift-release-dev/ocp-release:4.12.0", releaseDigest)
+	g.Expect(pinned).To(Equal("quay.io/openshift-release-dev/ocp-release:4.12.0@" + releaseDigest.String()))
+	g.Expect(IsPinnedImage(pinned, "quay.io/openshift-release-dev/ocp-release:4.12.0")).To(BeTrue())
+	g.Expect(IsPinnedImage(pinned, "quay.io/openshift-release-dev/ocp-release:4.12.1")).To(BeFalse())
+	g.Expect(IsPinnedImage("quay.io/openshift-release-dev/ocp-release:4.12.0", "quay.io/openshift-release-dev/ocp-release:4.12.0")).To(BeFalse())
+
+	byDigest := "quay.io/openshift-release-dev/ocp-release@" + releaseDigest.String()
+	g.Expect(PinnedImage(byDigest, releaseDigest)).To(Equal(byDigest))
+	g.Expect(IsPinnedImage(byDigest, byDigest)).To(BeTrue())
+}
+
+func TestHTTPStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/signatures/sha256=abc/signature-1":
+			w.Write([]byte("signature"))
+		case "/signatures/sha256=abc/signature-2":
+			http.NotFound(w, r)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewStore(server.URL + "/signatures/")
+	g.Expect(err).ToNot(HaveOccurred())
+	content, err := store.Get(context.Background(), "sha256=abc/signature-1")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(content).To(Equal([]byte("signature")))
+	content, err = store.Get(context.Background(), "sha256=abc/signature-2")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(content).To(BeNil())
+	_, err = store.Get(context.Background(), "sha256=abc/signature-3")
+	g.Expect(err).To(MatchError(ContainSubstring("unexpected status 500")))
+}
+
+func TestParseKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	keyring, cosignKey, err := parseKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(keyring).To(BeEmpty())
+	g.Expect(cosignKey).To(Equal(crypto.PublicKey(&key.PublicKey)))
+
+	_, _, err = parseKey([]byte("not a key"))
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,17 @@
+package util
+
+import (
+	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
+)
+
+// IsOIDCAuthenticationHCP returns true if the API server of the hosted control plane
+// validates tokens of external OIDC issuers instead of using the OpenShift OAuth stack.
+func IsOIDCAuthenticationHCP(hcp *hyperv1.HostedControlPlane) bool {
+	return hcp.Spec.Authentication != nil && hcp.Spec.Authentication.Type == hyperv1.OIDCAuthenticationType
+}
+
+// IsOIDCAuthenticationHC returns true if the hosted cluster uses external OIDC issuers
+// instead of the OpenShift OAuth stack.
+func IsOIDCAuthenticationHC(hc *hyperv1.HostedCluster) bool {
+	return hc.Spec.Authentication != nil && hc.Spec.Authentication.Type == hyperv1.OIDCAuthenticationType
+}
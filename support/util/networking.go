@@ -1,6 +1,8 @@
 package util
 
 import (
+	"net"
+
 	hyperv1 "github.com/openshift/hypershift/api/v1beta1"
 )
 
@@ -80,11 +82,46 @@ func AdvertiseAddress(hcp *hyperv1.HostedControlPlane) *string {
 	return nil
 }
 
-func AdvertiseAddressWithDefault(hcp *hyperv1.HostedControlPlane, defaultValue string) string {
+// GetAdvertiseAddress returns the configured advertise address of the HostedControlPlane or,
+// if none is set, the default address matching the primary IP family of its service network.
+func GetAdvertiseAddress(hcp *hyperv1.HostedControlPlane, ipv4DefaultAddress, ipv6DefaultAddress string) string {
 	if address := AdvertiseAddress(hcp); address != nil {
 		return *address
 	}
-	return defaultValue
+	if hcp != nil && IsIPv6Primary(hcp.Spec.Networking.ServiceNetwork) {
+		return ipv6DefaultAddress
+	}
+	return ipv4DefaultAddress
+}
+
+// GetAdvertiseAddressFromHostedCluster is the HostedCluster equivalent of GetAdvertiseAddress.
+func GetAdvertiseAddressFromHostedCluster(hc *hyperv1.HostedCluster, ipv4DefaultAddress, ipv6DefaultAddress string) string {
+	if hc.Spec.Networking.APIServer != nil && hc.Spec.Networking.APIServer.AdvertiseAddress != nil {
+		return *hc.Spec.Networking.APIServer.AdvertiseAddress
+	}
+	if IsIPv6Primary(hc.Spec.Networking.ServiceNetwork) {
+		return ipv6DefaultAddress
+	}
+	return ipv4DefaultAddress
+}
+
+// IsIPv6Primary returns true if the first entry of the service network is an IPv6 CIDR.
+// The first entry determines the primary IP family of single and dual-stack clusters.
+func IsIPv6Primary(serviceNetwork []hyperv1.ServiceNetworkEntry) bool {
+	if len(serviceNetwork) == 0 {
+		return false
+	}
+	return IsIPv6(serviceNetwork[0].CIDR.IP)
+}
+
+// IsIPv6 returns true if ip is a valid IPv6 address that is not an IPv4-mapped address.
+func IsIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil && ip.To16() != nil
+}
+
+// IsIPv6String returns true if address parses as an IPv6 address.
+func IsIPv6String(address string) bool {
+	return IsIPv6(net.ParseIP(address))
 }
 
 func AllowedCIDRBlocks(hcp *hyperv1.HostedControlPlane) []hyperv1.CIDRBlock {
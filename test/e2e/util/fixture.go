@@ -191,6 +191,9 @@ func createClusterOpts(ctx context.Context, client crclient.Client, hc *hyperv1.
 	opts.Namespace = hc.Namespace
 	opts.Name = hc.Name
 	opts.NonePlatform.ExposeThroughLoadBalancer = true
+	// The management cluster of the tests is known to host control planes, and the
+	// checks would run again for every test cluster.
+	opts.SkipPreflight = true
 
 	switch hc.Spec.Platform.Type {
 	case hyperv1.AWSPlatform: